@@ -1,14 +1,17 @@
 package main
 
 import (
+	"context"
 	"log"
 	"log/slog"
 	"net/http"
 	"os"
+	"time"
 
 	"go.temporal.io/sdk/client"
 
 	"github.com/ansg191/job-temporal/internal/database"
+	"github.com/ansg191/job-temporal/internal/github"
 	"github.com/ansg191/job-temporal/internal/webhook"
 )
 
@@ -42,10 +45,27 @@ func main() {
 	defer db.Close()
 
 	handler := webhook.NewHandler(tc, db, webhookSecret)
+	if github.AppConfigured() {
+		if err := syncInstallations(db); err != nil {
+			log.Fatalf("failed to sync github app installations: %v", err)
+		}
+		handler.RequireInstalledRepos()
+	} else {
+		slog.Warn("no GitHub App credentials, accepting webhooks from every repository")
+	}
+
+	// Deliveries are stored as they arrive and signalled
+	// from the inbox in the background.
+	go webhook.NewDispatcher(handler).Run(context.Background())
 
 	mux := http.NewServeMux()
 	mux.Handle("/webhook", handler)
 	mux.HandleFunc("/health", healthHandler)
+	if adminToken := os.Getenv("WEBHOOK_ADMIN_TOKEN"); adminToken != "" {
+		mux.Handle("/admin/webhooks/", webhook.NewAdminHandler(handler, adminToken))
+	} else {
+		slog.Info("WEBHOOK_ADMIN_TOKEN not set, webhook admin endpoint disabled")
+	}
 
 	slog.Info("starting server", "addr", ":8080")
 	if err := http.ListenAndServe(":8080", mux); err != nil {
@@ -53,6 +73,32 @@ func main() {
 	}
 }
 
+// syncInstallations records the repositories of every
+// installation of the app, which installation events keep up
+// to date while the server runs.
+func syncInstallations(db database.Database) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	installations, err := github.ListInstallationRepos(ctx)
+	if err != nil {
+		return err
+	}
+	repos := make(map[int64][]database.InstallationRepo, len(installations))
+	count := 0
+	for id, installed := range installations {
+		for _, r := range installed {
+			repos[id] = append(repos[id], database.InstallationRepo{Owner: r.Owner, Repo: r.Repo})
+		}
+		count += len(installed)
+	}
+	if err = db.ReplaceInstallations(ctx, repos); err != nil {
+		return err
+	}
+	slog.Info("synced github app installations", "installations", len(repos), "repos", count)
+	return nil
+}
+
 func healthHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write([]byte("ok"))
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+
+	"github.com/ansg191/job-temporal/internal/github"
+	"github.com/ansg191/job-temporal/internal/workflows"
+)
+
+const (
+	cleanupScheduleID         = "job-cleanup"
+	defaultCleanupSchedule    = "0 4 * * *"
+	defaultCleanupRetention   = 30 * 24 * time.Hour
+	cleanupWorkflowRunTimeout = time.Hour
+)
+
+// cleanupConfigFromEnv reads the cleanup schedule's settings.
+// It returns false if CLEANUP_REPOS is unset, which disables
+// the schedule.
+func cleanupConfigFromEnv() (string, workflows.CleanupWorkflowRequest, bool, error) {
+	var req workflows.CleanupWorkflowRequest
+	for _, name := range strings.Split(os.Getenv("CLEANUP_REPOS"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		owner, repo, ok := strings.Cut(name, "/")
+		if !ok || owner == "" || repo == "" {
+			return "", req, false, fmt.Errorf("invalid CLEANUP_REPOS entry %q, want owner/repo", name)
+		}
+		req.Repos = append(req.Repos, github.ClientOptions{Owner: owner, Repo: repo})
+	}
+	if len(req.Repos) == 0 {
+		return "", req, false, nil
+	}
+
+	cron := os.Getenv("CLEANUP_SCHEDULE")
+	if cron == "" {
+		cron = defaultCleanupSchedule
+	}
+
+	req.Retention = defaultCleanupRetention
+	if v := os.Getenv("CLEANUP_RETENTION"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			slog.Warn("invalid CLEANUP_RETENTION, ignoring", "value", v)
+		} else {
+			req.Retention = d
+		}
+	}
+
+	// Default to a dry run so the first reports can be checked
+	// before anything is deleted.
+	req.DryRun = true
+	if v := os.Getenv("CLEANUP_DRY_RUN"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			slog.Warn("invalid CLEANUP_DRY_RUN, ignoring", "value", v)
+		} else {
+			req.DryRun = b
+		}
+	}
+	return cron, req, true, nil
+}
+
+// ensureCleanupSchedule creates the cleanup schedule, or
+// updates it to the current settings.
+func ensureCleanupSchedule(ctx context.Context, c client.Client) error {
+	cron, req, ok, err := cleanupConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		slog.Info("CLEANUP_REPOS not set, cleanup schedule disabled")
+		return nil
+	}
+
+	spec := client.ScheduleSpec{CronExpressions: []string{cron}}
+	action := &client.ScheduleWorkflowAction{
+		ID:                 cleanupScheduleID,
+		Workflow:           workflows.CleanupWorkflow,
+		Args:               []any{req},
+		TaskQueue:          "my-task-queue",
+		WorkflowRunTimeout: cleanupWorkflowRunTimeout,
+	}
+
+	_, err = c.ScheduleClient().Create(ctx, client.ScheduleOptions{
+		ID:     cleanupScheduleID,
+		Spec:   spec,
+		Action: action,
+	})
+	if errors.Is(err, temporal.ErrScheduleAlreadyRunning) {
+		handle := c.ScheduleClient().GetHandle(ctx, cleanupScheduleID)
+		err = handle.Update(ctx, client.ScheduleUpdateOptions{
+			DoUpdate: func(input client.ScheduleUpdateInput) (*client.ScheduleUpdate, error) {
+				schedule := input.Description.Schedule
+				schedule.Spec = &spec
+				schedule.Action = action
+				return &client.ScheduleUpdate{Schedule: &schedule}, nil
+			},
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to ensure cleanup schedule: %w", err)
+	}
+	slog.Info("Cleanup schedule ensured", "cron", cron, "repos", len(req.Repos),
+		"retention", req.Retention, "dry_run", req.DryRun)
+	return nil
+}
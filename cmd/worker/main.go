@@ -8,6 +8,9 @@ import (
 
 	"github.com/ansg191/job-temporal/internal/activities"
 	"github.com/ansg191/job-temporal/internal/database"
+	"github.com/ansg191/job-temporal/internal/forge"
+	"github.com/ansg191/job-temporal/internal/git"
+	"github.com/ansg191/job-temporal/internal/github"
 	"github.com/ansg191/job-temporal/internal/workflows"
 	"github.com/ansg191/job-temporal/internal/workflows/agents"
 
@@ -19,10 +22,25 @@ func main() {
 	if err := database.EnsureMigrations(); err != nil {
 		log.Fatalln("Unable to ensure database migrations", err)
 	}
+	if forge.Kind() == "github" {
+		mode, err := github.ValidateAuth()
+		if err != nil {
+			log.Fatalln("Invalid GitHub authentication", err)
+		}
+		slog.Info("GitHub authentication configured", "mode", mode)
+	}
+	if format, err := git.ValidateSigning(context.Background()); err != nil {
+		log.Fatalln("Unable to sign commits", err)
+	} else if format != "" {
+		slog.Info("Commit signing configured", "format", format)
+	}
 	if err := activities.CheckR2ReadWrite(context.Background()); err != nil {
 		log.Fatalln("Unable to verify R2 bucket read/write access", err)
 	}
 	slog.Info("R2 bucket read/write check succeeded")
+	if err := activities.SeedTypstPackages(context.Background()); err != nil {
+		log.Fatalln("Unable to seed typst package cache", err)
+	}
 
 	temporalAddress := os.Getenv("TEMPORAL_ADDRESS")
 	if temporalAddress == "" {
@@ -37,10 +55,15 @@ func main() {
 	}
 	defer c.Close()
 
+	if err = ensureCleanupSchedule(context.Background(), c); err != nil {
+		log.Fatalln("Unable to set up cleanup schedule", err)
+	}
+
 	w := worker.New(c, "my-task-queue", worker.Options{})
 
 	w.RegisterWorkflow(workflows.JobWorkflow)
 	w.RegisterWorkflow(workflows.BuilderWorkflow)
+	w.RegisterWorkflow(workflows.CleanupWorkflow)
 	w.RegisterWorkflow(agents.BranchNameAgent)
 	w.RegisterWorkflow(agents.BuilderAgent)
 	w.RegisterWorkflow(agents.PullRequestAgent)
@@ -49,25 +72,47 @@ func main() {
 	w.RegisterWorkflow(agents.ReviewPDFLayoutWorkflow)
 	w.RegisterWorkflow(agents.ReviewLetterContentWorkflow)
 	w.RegisterWorkflow(agents.OracleWorkflow)
+	w.RegisterWorkflow(agents.MergeConflictAgent)
 	w.RegisterActivity(activities.Greet)
 	w.RegisterActivity(activities.CreateConversation)
 	w.RegisterActivity(activities.CallAI)
+	w.RegisterActivity(activities.ListFiles)
 	w.RegisterActivity(activities.ReadFile)
 	w.RegisterActivity(activities.EditFile)
+	w.RegisterActivity(activities.EditFiles)
 	w.RegisterActivity(activities.EditLine)
+	w.RegisterActivity(activities.BranchHistory)
+	w.RegisterActivity(activities.RegroupCommits)
+	w.RegisterActivity(activities.CheckMerge)
+	w.RegisterActivity(activities.ResolveMerge)
+	w.RegisterActivity(activities.ApplyMerge)
+	w.RegisterActivity(activities.DeleteMergeScratch)
 	w.RegisterActivity(activities.Build)
 	w.RegisterActivity(activities.RenderLayoutReviewPages)
 	w.RegisterActivity(activities.RenderOraclePages)
 	w.RegisterActivity(activities.ListLabels)
 	w.RegisterActivity(activities.ReadLetterContent)
 	w.RegisterActivity(activities.BuildFinalPDF)
+	w.RegisterActivity(activities.ATSCheck)
+	w.RegisterActivity(activities.Lint)
+	w.RegisterActivity(activities.KeywordCoverage)
+	w.RegisterActivity(activities.FinalizePDF)
 	w.RegisterActivity(activities.UploadPDF)
+	w.RegisterActivity(activities.StartBuildCheck)
+	w.RegisterActivity(activities.CompleteBuildCheck)
+	w.RegisterActivity(activities.UploadExports)
+	w.RegisterActivity(activities.VisualDiff)
+	w.RegisterActivity(activities.CommentOnPullRequest)
+	w.RegisterActivity(activities.ReplyToReviewComment)
+	w.RegisterActivity(activities.ResolveReviewThread)
+	w.RegisterActivity(activities.SuggestChanges)
 	w.RegisterActivity(activities.DeletePDFByURL)
 	w.RegisterActivity(activities.ListBranches)
 	w.RegisterActivity(activities.CreateBranch)
 	w.RegisterActivity(activities.GetBranchHeadSHA)
 	w.RegisterActivity(activities.CreatePullRequest)
 	w.RegisterActivity(activities.GetPullRequestBody)
+	w.RegisterActivity(activities.GetPullRequestLabels)
 	w.RegisterActivity(activities.UpdatePullRequestBody)
 	w.RegisterActivity(activities.ProtectBranch)
 	w.RegisterActivity(activities.ListGithubTools)
@@ -80,6 +125,8 @@ func main() {
 	w.RegisterActivity(activities.SaveAgentMemory)
 	w.RegisterActivity(activities.ListAgentMemories)
 	w.RegisterActivity(activities.DeleteAgentMemory)
+	w.RegisterActivity(activities.PlanCleanup)
+	w.RegisterActivity(activities.ApplyCleanupAction)
 
 	err = w.Run(worker.InterruptCh())
 	if err != nil {
@@ -0,0 +1,398 @@
+package activities
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"go.temporal.io/sdk/temporal"
+
+	"github.com/ansg191/job-temporal/internal/github"
+	"github.com/ansg191/job-temporal/internal/pdftext"
+)
+
+const ErrTypeATSExtractFailed = "ATSExtractFailed"
+
+const (
+	// atsColumnJumpLines is how many lines text must move
+	// back up the page, while also moving sideways, to count
+	// as switching columns.
+	atsColumnJumpLines = 3.0
+	// atsColumnJumpWidth is the fraction of the page width
+	// the sideways move must cover.
+	atsColumnJumpWidth = 0.15
+	// atsInterleavedJumps is the number of column switches
+	// on a page beyond which columns are interleaved rather
+	// than extracted as separate blocks.
+	atsInterleavedJumps = 3
+	// atsMinImageCoverage ignores images smaller than this
+	// fraction of the page, such as inline icons.
+	atsMinImageCoverage = 0.02
+	// atsImageOnlyCoverage and atsImageOnlyChars flag pages
+	// that are mostly image with little extractable text.
+	atsImageOnlyCoverage = 0.25
+	atsImageOnlyChars    = 200
+	// atsManyLostChars escalates lost characters to high.
+	atsManyLostChars = 5
+	atsSnippetLen    = 60
+)
+
+var (
+	atsEmailRe = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	atsPhoneRe = regexp.MustCompile(`\+?\(?\d[\d\s().-]{7,}\d`)
+	atsNameRe  = regexp.MustCompile(`^\p{L}[\p{L}'’.-]*(?:\s+\p{L}[\p{L}'’.-]*){1,4}$`)
+)
+
+type ATSCheckRequest struct {
+	github.ClientOptions
+	Branch  string `json:"branch"`
+	Builder string `json:"builder"`
+	File    string `json:"file"`
+}
+
+// ATSCheckOutput reports how an applicant tracking system
+// would read the built PDF. Issues use the same severity
+// model as layout review.
+type ATSCheckOutput struct {
+	Summary string                 `json:"summary"`
+	Pages   int                    `json:"pages"`
+	Name    string                 `json:"name"`
+	Email   string                 `json:"email"`
+	Phone   string                 `json:"phone"`
+	Issues  []ReviewPDFLayoutIssue `json:"issues"`
+}
+
+// ATSCheck builds the final PDF and checks that its text
+// extracts cleanly. It returns ATSCheckOutput as JSON.
+func ATSCheck(ctx context.Context, req ATSCheckRequest) (string, error) {
+	content, err := BuildFinalPDF(ctx, BuildFinalPDFRequest{
+		ClientOptions: req.ClientOptions,
+		Branch:        req.Branch,
+		Builder:       req.Builder,
+		File:          req.File,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	pages, err := pdftext.Extract(bytes.NewReader(content))
+	if err != nil {
+		return "", temporal.NewNonRetryableApplicationError(
+			"failed to extract pdf text",
+			ErrTypeATSExtractFailed,
+			err,
+		)
+	}
+
+	output := analyzeATSText(pages)
+	data, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal ats check output: %w", err)
+	}
+	return string(data), nil
+}
+
+func analyzeATSText(pages []pdftext.Page) ATSCheckOutput {
+	output := ATSCheckOutput{
+		Pages:  len(pages),
+		Issues: []ReviewPDFLayoutIssue{},
+	}
+	for _, page := range pages {
+		output.Issues = append(output.Issues, checkATSReadingOrder(page)...)
+		output.Issues = append(output.Issues, checkATSCharacters(page)...)
+		output.Issues = append(output.Issues, checkATSImages(page)...)
+	}
+	output.Issues = append(output.Issues, checkATSContact(pages, &output)...)
+	output.Summary = summarizeATSCheck(output)
+	return output
+}
+
+// checkATSReadingOrder looks for the text flow jumping back
+// up the page into another column. A single jump means the
+// columns extract as separate blocks; many mean they
+// interleave line by line.
+func checkATSReadingOrder(page pdftext.Page) []ReviewPDFLayoutIssue {
+	if page.Width <= 0 {
+		return nil
+	}
+	jumps := 0
+	var first string
+	for i := 1; i < len(page.Runs); i++ {
+		prev, cur := page.Runs[i-1], page.Runs[i]
+		lineHeight := math.Max(math.Max(prev.FontSize, cur.FontSize), 1)
+		if cur.Y-prev.Y > atsColumnJumpLines*lineHeight &&
+			math.Abs(cur.X-prev.X) > atsColumnJumpWidth*page.Width {
+			jumps++
+			if first == "" {
+				first = snippet(cur.Text)
+			}
+		}
+	}
+
+	switch {
+	case jumps == 0:
+		return nil
+	case jumps >= atsInterleavedJumps:
+		return []ReviewPDFLayoutIssue{{
+			Page:      page.Number,
+			IssueType: "reading_order",
+			Severity:  "high",
+			Evidence:  fmt.Sprintf("text switches between columns %d times in extraction order, so columns interleave (first switch at %q)", jumps, first),
+			FixHint:   "Use a single-column layout, or keep each section's text contiguous instead of placing columns side by side",
+		}}
+	default:
+		return []ReviewPDFLayoutIssue{{
+			Page:      page.Number,
+			IssueType: "multi_column",
+			Severity:  "medium",
+			Evidence:  fmt.Sprintf("a side column extracts as a separate block out of visual order, starting at %q", first),
+			FixHint:   "Move sidebar content (skills, contact) into the main single-column flow so it is read in order",
+		}}
+	}
+}
+
+// checkATSCharacters reports glyphs that extract as nothing,
+// replacement characters, ligatures or private-use icons.
+func checkATSCharacters(page pdftext.Page) []ReviewPDFLayoutIssue {
+	lost := 0
+	lostFonts := map[string]bool{}
+	var ligatures, icons []string
+	for _, run := range page.Runs {
+		if run.Unmapped > 0 {
+			lost += run.Unmapped
+			lostFonts[run.Font] = true
+		}
+		for _, r := range run.Text {
+			switch {
+			case r == unicode.ReplacementChar || (unicode.IsControl(r) && r != '\t' && r != '\n'):
+				lost++
+				lostFonts[run.Font] = true
+			case r >= 0xFB00 && r <= 0xFB06:
+				ligatures = append(ligatures, snippet(run.Text))
+			case unicode.In(r, unicode.Co):
+				icons = append(icons, fmt.Sprintf("U+%04X in %s", r, run.Font))
+			}
+		}
+	}
+
+	var issues []ReviewPDFLayoutIssue
+	if lost > 0 {
+		severity := "medium"
+		if lost >= atsManyLostChars {
+			severity = "high"
+		}
+		issues = append(issues, ReviewPDFLayoutIssue{
+			Page:      page.Number,
+			IssueType: "lost_characters",
+			Severity:  severity,
+			Evidence:  fmt.Sprintf("%d glyph(s) have no Unicode mapping and extract as nothing or garbage (fonts: %s)", lost, joinKeys(lostFonts)),
+			FixHint:   "Use a font with a complete Unicode mapping for body text, or replace the affected symbols with plain text",
+		})
+	}
+	if len(ligatures) > 0 {
+		issues = append(issues, ReviewPDFLayoutIssue{
+			Page:      page.Number,
+			IssueType: "ligature",
+			Severity:  "medium",
+			Evidence:  fmt.Sprintf("%d ligature character(s) extract as single code points, e.g. %q, so keyword matching can miss those words", len(ligatures), ligatures[0]),
+			FixHint:   "Disable ligatures (typst: #set text(ligatures: false); LaTeX: \\usepackage{microtype} with \\DisableLigatures{encoding = *, family = *})",
+		})
+	}
+	if len(icons) > 0 {
+		issues = append(issues, ReviewPDFLayoutIssue{
+			Page:      page.Number,
+			IssueType: "icon_glyph",
+			Severity:  "medium",
+			Evidence:  fmt.Sprintf("%d icon glyph(s) extract as private-use characters (%s)", len(icons), icons[0]),
+			FixHint:   "Remove icon glyphs or pair them with plain text labels; keep contact details as plain text",
+		})
+	}
+	return issues
+}
+
+// checkATSImages reports images large enough to hold text,
+// which an ATS cannot read.
+func checkATSImages(page pdftext.Page) []ReviewPDFLayoutIssue {
+	pageArea := page.Width * page.Height
+	if pageArea <= 0 {
+		return nil
+	}
+
+	coverage := 0.0
+	count := 0
+	for _, img := range page.Images {
+		c := img.Width * img.Height / pageArea
+		if c < atsMinImageCoverage {
+			continue
+		}
+		coverage += c
+		count++
+	}
+	if count == 0 {
+		return nil
+	}
+
+	chars := len([]rune(strings.TrimSpace(page.Text())))
+	if coverage >= atsImageOnlyCoverage && chars < atsImageOnlyChars {
+		return []ReviewPDFLayoutIssue{{
+			Page:      page.Number,
+			IssueType: "text_in_image",
+			Severity:  "high",
+			Evidence:  fmt.Sprintf("images cover %.0f%% of the page but only %d characters of text extract", coverage*100, chars),
+			FixHint:   "Replace images of text with real text",
+		}}
+	}
+	return []ReviewPDFLayoutIssue{{
+		Page:      page.Number,
+		IssueType: "text_in_image",
+		Severity:  "low",
+		Evidence:  fmt.Sprintf("%d image(s) cover %.0f%% of the page; any text inside them is invisible to an ATS", count, coverage*100),
+		FixHint:   "Make sure no required information exists only inside an image",
+	}}
+}
+
+// checkATSContact checks that the name, taken as the largest
+// text on the first page, and the email and phone extract.
+// It records what it found in output.
+func checkATSContact(pages []pdftext.Page, output *ATSCheckOutput) []ReviewPDFLayoutIssue {
+	if len(pages) == 0 {
+		return []ReviewPDFLayoutIssue{{
+			Page:      1,
+			IssueType: "missing_name",
+			Severity:  "high",
+			Evidence:  "the document has no pages",
+			FixHint:   "Make sure the document builds with content",
+		}}
+	}
+
+	var texts []string
+	for _, page := range pages {
+		texts = append(texts, page.Text())
+	}
+	all := strings.Join(texts, "\n")
+
+	output.Name = extractATSName(pages[0])
+	output.Email = atsEmailRe.FindString(all)
+	output.Phone = findATSPhone(all)
+
+	var issues []ReviewPDFLayoutIssue
+	if !atsNameRe.MatchString(output.Name) {
+		evidence := "no text extracts from the first page"
+		if output.Name != "" {
+			evidence = fmt.Sprintf("the largest text on the first page extracts as %q, which does not read as a name", output.Name)
+		}
+		issues = append(issues, ReviewPDFLayoutIssue{
+			Page:      1,
+			IssueType: "missing_name",
+			Severity:  "high",
+			Evidence:  evidence,
+			FixHint:   "Put the full name as plain text in the largest font at the top of the first page",
+		})
+	}
+	if output.Email == "" {
+		issues = append(issues, ReviewPDFLayoutIssue{
+			Page:      1,
+			IssueType: "missing_contact",
+			Severity:  "high",
+			Evidence:  "no email address extracts from the text",
+			FixHint:   "Write the email address as plain text, not only as a link target or icon",
+		})
+	}
+	if output.Phone == "" {
+		issues = append(issues, ReviewPDFLayoutIssue{
+			Page:      1,
+			IssueType: "missing_contact",
+			Severity:  "medium",
+			Evidence:  "no phone number extracts from the text",
+			FixHint:   "Write the phone number as plain text with digits, not only as an icon or image",
+		})
+	}
+	return issues
+}
+
+// extractATSName joins the consecutive runs on the baseline
+// of the first run in the page's largest font size.
+func extractATSName(page pdftext.Page) string {
+	start := -1
+	for i, run := range page.Runs {
+		if strings.TrimSpace(run.Text) == "" {
+			continue
+		}
+		if start < 0 || run.FontSize > page.Runs[start].FontSize {
+			start = i
+		}
+	}
+	if start < 0 {
+		return ""
+	}
+
+	line := pdftext.Page{Runs: []pdftext.Run{page.Runs[start]}}
+	for _, run := range page.Runs[start+1:] {
+		first := page.Runs[start]
+		if math.Abs(run.Y-first.Y) > 0.5*first.FontSize || math.Abs(run.FontSize-first.FontSize) > 0.5 {
+			break
+		}
+		line.Runs = append(line.Runs, run)
+	}
+	return strings.Join(strings.Fields(line.Text()), " ")
+}
+
+func findATSPhone(text string) string {
+	for _, m := range atsPhoneRe.FindAllString(text, -1) {
+		digits := 0
+		for _, r := range m {
+			if unicode.IsDigit(r) {
+				digits++
+			}
+		}
+		if digits >= 10 && digits <= 15 {
+			return strings.TrimSpace(m)
+		}
+	}
+	return ""
+}
+
+func summarizeATSCheck(output ATSCheckOutput) string {
+	counts := map[string]int{}
+	for _, issue := range output.Issues {
+		counts[issue.Severity]++
+	}
+	found := func(v string) string {
+		if v == "" {
+			return "not found"
+		}
+		return fmt.Sprintf("%q", v)
+	}
+	return fmt.Sprintf(
+		"%d page(s), %d issue(s) (%d high, %d medium, %d low); name %s, email %s, phone %s",
+		output.Pages, len(output.Issues), counts["high"], counts["medium"], counts["low"],
+		found(output.Name), found(output.Email), found(output.Phone),
+	)
+}
+
+func snippet(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	r := []rune(s)
+	if len(r) > atsSnippetLen {
+		return string(r[:atsSnippetLen]) + "…"
+	}
+	return s
+}
+
+func joinKeys(m map[string]bool) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		if k == "" {
+			k = "unknown"
+		}
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return strings.Join(keys, ", ")
+}
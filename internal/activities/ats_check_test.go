@@ -0,0 +1,146 @@
+package activities
+
+import (
+	"testing"
+
+	"github.com/ansg191/job-temporal/internal/pdftext"
+)
+
+func atsRun(x, y, size float64, text string) pdftext.Run {
+	return pdftext.Run{X: x, Y: y, EndX: x + float64(len(text))*size/2, FontSize: size, Font: "Body", Text: text}
+}
+
+func atsIssueTypes(issues []ReviewPDFLayoutIssue) map[string]string {
+	ret := make(map[string]string, len(issues))
+	for _, issue := range issues {
+		// Keep the first, most severe, issue of each type.
+		if _, ok := ret[issue.IssueType]; !ok {
+			ret[issue.IssueType] = issue.Severity
+		}
+	}
+	return ret
+}
+
+func TestAnalyzeATSText(t *testing.T) {
+	t.Parallel()
+
+	header := []pdftext.Run{
+		atsRun(72, 720, 24, "Jane"),
+		atsRun(140, 720, 24, "Doe"),
+		atsRun(72, 700, 10, "jane@example.com | +1 (555) 123-4567"),
+	}
+
+	tests := []struct {
+		name      string
+		page      pdftext.Page
+		wantTypes map[string]string
+		wantName  string
+	}{
+		{
+			name: "clean single column",
+			page: pdftext.Page{Runs: append(header[:3:3],
+				atsRun(72, 680, 10, "Experience"),
+				atsRun(72, 666, 10, "Built things"),
+			)},
+			wantTypes: map[string]string{},
+			wantName:  "Jane Doe",
+		},
+		{
+			name: "sidebar block",
+			page: pdftext.Page{Runs: append(header[:3:3],
+				atsRun(72, 600, 10, "Main column"),
+				atsRun(72, 300, 10, "Main column end"),
+				atsRun(450, 650, 10, "Skills"),
+				atsRun(450, 630, 10, "Go"),
+			)},
+			wantTypes: map[string]string{"multi_column": "medium"},
+			wantName:  "Jane Doe",
+		},
+		{
+			name: "interleaved columns",
+			page: pdftext.Page{Runs: append(header[:3:3],
+				atsRun(72, 600, 10, "left 1"),
+				atsRun(72, 500, 10, "left 2"),
+				atsRun(450, 600, 10, "right 1"),
+				atsRun(72, 400, 10, "left 3"),
+				atsRun(450, 500, 10, "right 2"),
+				atsRun(72, 300, 10, "left 4"),
+				atsRun(450, 400, 10, "right 3"),
+			)},
+			wantTypes: map[string]string{"reading_order": "high"},
+			wantName:  "Jane Doe",
+		},
+		{
+			name: "ligatures and icons",
+			page: pdftext.Page{Runs: append(header[:3:3],
+				atsRun(72, 680, 10, "Of\ufb01ce \uf0e0 work"),
+			)},
+			wantTypes: map[string]string{"ligature": "medium", "icon_glyph": "medium"},
+			wantName:  "Jane Doe",
+		},
+		{
+			name: "garbled name and missing contact",
+			page: pdftext.Page{Runs: []pdftext.Run{
+				{X: 72, Y: 720, FontSize: 24, Font: "Fancy", Text: "J\ufffde", Unmapped: 6},
+				atsRun(72, 700, 10, "Experience"),
+			}},
+			wantTypes: map[string]string{
+				"lost_characters": "high",
+				"missing_name":    "high",
+				"missing_contact": "high",
+			},
+			wantName: "J\ufffde",
+		},
+		{
+			name: "image of text",
+			page: pdftext.Page{
+				Runs:   header,
+				Images: []pdftext.Image{{X: 0, Y: 0, Width: 612, Height: 600}},
+			},
+			wantTypes: map[string]string{"text_in_image": "high"},
+			wantName:  "Jane Doe",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			page := tt.page
+			page.Number, page.Width, page.Height = 1, 612, 792
+			output := analyzeATSText([]pdftext.Page{page})
+
+			got := atsIssueTypes(output.Issues)
+			if len(got) != len(tt.wantTypes) {
+				t.Errorf("issues = %+v, want types %v", output.Issues, tt.wantTypes)
+			}
+			for typ, severity := range tt.wantTypes {
+				if got[typ] != severity {
+					t.Errorf("issue %q severity = %q, want %q (issues: %+v)", typ, got[typ], severity, output.Issues)
+				}
+			}
+			if output.Name != tt.wantName {
+				t.Errorf("Name = %q, want %q", output.Name, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestFindATSPhone(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"call +1 (555) 123-4567 today", "+1 (555) 123-4567"},
+		{"555.123.4567", "555.123.4567"},
+		{"2019 - 2023", ""},
+		{"no phone", ""},
+	}
+	for _, tt := range tests {
+		if got := findATSPhone(tt.text); got != tt.want {
+			t.Errorf("findATSPhone(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
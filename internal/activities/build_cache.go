@@ -0,0 +1,116 @@
+package activities
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/ansg191/job-temporal/internal/buildcache"
+	"github.com/ansg191/job-temporal/internal/builder"
+	"github.com/ansg191/job-temporal/internal/git"
+	"github.com/ansg191/job-temporal/internal/github"
+)
+
+const (
+	defaultBuildCacheMaxBytes = 1 << 30 // 1 GiB
+	buildCacheObjectPrefix    = "build-cache"
+)
+
+var (
+	buildCacheOnce sync.Once
+	buildCache     *buildcache.Cache
+)
+
+// getBuildCache returns the worker's build cache, or nil if
+// it is disabled. It is configured from the environment:
+//
+//   - BUILD_CACHE_DIR: local directory, "off" to disable
+//     (default: $TMPDIR/job-temporal-build-cache)
+//   - BUILD_CACHE_MAX_BYTES: local size limit (default 1 GiB)
+//   - BUILD_CACHE_BUCKET: optional bucket at AWS_ENDPOINT_URL
+//     shared between workers
+func getBuildCache(ctx context.Context) *buildcache.Cache {
+	buildCacheOnce.Do(func() {
+		dir := os.Getenv("BUILD_CACHE_DIR")
+		if dir == "off" {
+			return
+		}
+		if dir == "" {
+			dir = filepath.Join(os.TempDir(), "job-temporal-build-cache")
+		}
+
+		maxBytes := int64(defaultBuildCacheMaxBytes)
+		if v := os.Getenv("BUILD_CACHE_MAX_BYTES"); v != "" {
+			parsed, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				slog.WarnContext(ctx, "invalid BUILD_CACHE_MAX_BYTES, using default", "value", v, "error", err)
+			} else {
+				maxBytes = parsed
+			}
+		}
+
+		disk, err := buildcache.NewDiskStore(dir, maxBytes)
+		if err != nil {
+			slog.WarnContext(ctx, "build cache disabled", "dir", dir, "error", err)
+			return
+		}
+		stores := []buildcache.Store{disk}
+
+		if bucket := os.Getenv("BUILD_CACHE_BUCKET"); bucket != "" {
+			s3Client, err := newR2S3Client(ctx, os.Getenv("AWS_ENDPOINT_URL"))
+			if err != nil {
+				slog.WarnContext(ctx, "build cache object storage disabled", "bucket", bucket, "error", err)
+			} else {
+				stores = append(stores, buildcache.NewS3Store(s3Client, bucket, buildCacheObjectPrefix))
+			}
+		}
+
+		buildCache = buildcache.New(stores...)
+	})
+	return buildCache
+}
+
+// newBuildCacheKey returns the cache key for building file
+// from the repository in clientOpts. Commit is filled in by
+// the caller once it is known.
+func newBuildCacheKey(clientOpts github.ClientOptions, builderName, file string) buildcache.Key {
+	return buildcache.Key{
+		Repo:     clientOpts.Owner + "/" + clientOpts.Repo,
+		RootFile: file,
+		Builder:  builderName,
+	}
+}
+
+// cachedBuild builds the checked out commit of repo with b,
+// or restores the output from the cache if the same commit
+// was already built with the same options.
+func cachedBuild(
+	ctx context.Context,
+	key buildcache.Key,
+	b builder.Builder,
+	repo *git.Repository,
+	outputPath string,
+) (*builder.BuildResult, error) {
+	commit, err := repo.GetHeadCommit(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "build cache skipped, unknown commit", "error", err)
+	}
+	key.Commit = commit
+
+	cache := getBuildCache(ctx)
+	if result, ok := cache.Get(ctx, key, outputPath); ok {
+		return result, nil
+	}
+
+	result, err := b.Build(ctx, repo.Path(), outputPath)
+	if err != nil {
+		return nil, err
+	}
+	if err = cache.Put(ctx, key, outputPath, result); err != nil {
+		slog.WarnContext(ctx, "build cache write failed", "error", err)
+	}
+	return result, nil
+}
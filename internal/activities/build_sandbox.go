@@ -0,0 +1,164 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ansg191/job-temporal/internal/builder"
+)
+
+const (
+	defaultBuildTimeout = 2 * time.Minute
+	defaultBuildCPUTime = time.Minute
+)
+
+type buildSandboxConfig struct {
+	cpuTime      time.Duration
+	memoryBytes  int64
+	timeout      time.Duration
+	packageLock  string
+	packageCache string
+	offline      bool
+	marginPt     float64
+	lineHeightPt float64
+	measureFill  bool
+}
+
+// getBuildSandboxConfig reads the compiler limits and typst
+// package settings from the environment:
+//
+//   - BUILD_TIMEOUT: wall-clock limit per compile (default 2m)
+//   - BUILD_CPU_TIME: CPU time limit per compile (default 1m)
+//   - BUILD_MEMORY_BYTES: address space limit (default none)
+//   - TYPST_PACKAGE_LOCK: lockfile of packages to vendor
+//   - TYPST_PACKAGE_CACHE: vendored package cache (default:
+//     $TMPDIR/job-temporal-typst-packages when a lockfile is
+//     set, otherwise typst's own cache)
+//   - TYPST_OFFLINE: "true" to never download packages
+//   - TYPST_PAGE_MARGIN_PT: top and bottom page margin of
+//     the templates, in points (default 72)
+//   - TYPST_LINE_HEIGHT_PT: body line height of the
+//     templates, in points (default 14)
+//   - TYPST_MEASURE_FILL: "true" to report page fill on
+//     every build, not only over the page limit
+var getBuildSandboxConfig = sync.OnceValue(func() buildSandboxConfig {
+	cfg := buildSandboxConfig{
+		timeout:      envDuration("BUILD_TIMEOUT", defaultBuildTimeout),
+		cpuTime:      envDuration("BUILD_CPU_TIME", defaultBuildCPUTime),
+		packageLock:  os.Getenv("TYPST_PACKAGE_LOCK"),
+		packageCache: os.Getenv("TYPST_PACKAGE_CACHE"),
+		marginPt:     envPoints("TYPST_PAGE_MARGIN_PT"),
+		lineHeightPt: envPoints("TYPST_LINE_HEIGHT_PT"),
+	}
+	if v := os.Getenv("BUILD_MEMORY_BYTES"); v != "" {
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			slog.Warn("invalid BUILD_MEMORY_BYTES, ignoring", "value", v, "error", err)
+		} else {
+			cfg.memoryBytes = parsed
+		}
+	}
+	if v := os.Getenv("TYPST_OFFLINE"); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			slog.Warn("invalid TYPST_OFFLINE, ignoring", "value", v, "error", err)
+		} else {
+			cfg.offline = parsed
+		}
+	}
+	if v := os.Getenv("TYPST_MEASURE_FILL"); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			slog.Warn("invalid TYPST_MEASURE_FILL, ignoring", "value", v, "error", err)
+		} else {
+			cfg.measureFill = parsed
+		}
+	}
+	if cfg.packageCache == "" && cfg.packageLock != "" {
+		cfg.packageCache = filepath.Join(os.TempDir(), "job-temporal-typst-packages")
+	}
+	return cfg
+})
+
+// envPoints reads a positive length in points from name. It
+// returns 0, keeping the builder's default, when name is
+// unset or invalid.
+func envPoints(name string) float64 {
+	v := os.Getenv(name)
+	if v == "" {
+		return 0
+	}
+	parsed, err := strconv.ParseFloat(v, 64)
+	if err != nil || parsed <= 0 {
+		slog.Warn("invalid "+name+", using default", "value", v, "error", err)
+		return 0
+	}
+	return parsed
+}
+
+func envDuration(name string, def time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	parsed, err := time.ParseDuration(v)
+	if err != nil {
+		slog.Warn("invalid "+name+", using default", "value", v, "error", err)
+		return def
+	}
+	return parsed
+}
+
+// newSandboxedBuilder creates a builder bounded by the
+// worker's compiler limits and package settings.
+func newSandboxedBuilder(builderName string, opts ...func(builder.Builder)) (builder.Builder, error) {
+	return builder.NewBuilder(builderName, append(opts, buildSandboxOptions(builderName)...)...)
+}
+
+func buildSandboxOptions(builderName string) []func(builder.Builder) {
+	cfg := getBuildSandboxConfig()
+	opts := []func(builder.Builder){
+		builder.WithTimeout(cfg.timeout),
+		builder.WithCPUTimeLimit(cfg.cpuTime),
+		builder.WithMemoryLimit(cfg.memoryBytes),
+	}
+	if builderName == "typst" {
+		opts = append(opts,
+			builder.WithTypstPackageCache(cfg.packageCache),
+			builder.WithTypstOffline(cfg.offline),
+			builder.WithTypstMeasureFill(cfg.measureFill),
+		)
+		if cfg.marginPt > 0 {
+			opts = append(opts, builder.WithTypstPageMargin(cfg.marginPt))
+		}
+		if cfg.lineHeightPt > 0 {
+			opts = append(opts, builder.WithTypstLineHeight(cfg.lineHeightPt))
+		}
+	}
+	return opts
+}
+
+// SeedTypstPackages vendors the packages in TYPST_PACKAGE_LOCK
+// into the worker's package cache. It does nothing when no
+// lockfile is configured.
+func SeedTypstPackages(ctx context.Context) error {
+	cfg := getBuildSandboxConfig()
+	if cfg.packageLock == "" {
+		return nil
+	}
+	specs, err := builder.ReadPackageLock(cfg.packageLock)
+	if err != nil {
+		return err
+	}
+	if err = builder.SeedPackageCache(ctx, nil, "", cfg.packageCache, specs); err != nil {
+		return fmt.Errorf("seed typst package cache: %w", err)
+	}
+	slog.InfoContext(ctx, "Typst package cache seeded", "packages", len(specs), "dir", cfg.packageCache)
+	return nil
+}
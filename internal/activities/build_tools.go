@@ -2,11 +2,15 @@ package activities
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
 	"os"
 	"path"
+	"slices"
 	"strings"
 
 	"github.com/ansg191/job-temporal/internal/builder"
+	"github.com/ansg191/job-temporal/internal/forge"
 	"github.com/ansg191/job-temporal/internal/git"
 	"github.com/ansg191/job-temporal/internal/github"
 )
@@ -28,16 +32,77 @@ func Build(ctx context.Context, req BuildRequest) (string, error) {
 	}
 	defer os.Remove(tmpFile.Name())
 
-	result, err := runBuild(ctx, req.ClientOptions, req.Branch, req.Builder, req.File, tmpFile.Name())
+	result, err := runBuild(ctx, req.ClientOptions, req.Branch, req.Builder, req.File, "", tmpFile.Name())
 	if err != nil {
 		return "", err
 	}
+	reportBuildCheck(ctx, req, result)
 
+	report := strings.Join(result.Report(), "\n\n")
 	if result.Success {
-		return "Success", nil
+		if report == "" {
+			return "Success", nil
+		}
+		return "Success\n\n" + report, nil
 	}
 
-	return "Builder returned errors:\n" + strings.Join(result.Errors, "\n"), nil
+	return "Builder returned errors:\n" + report, nil
+}
+
+// reportBuildCheck records the build of a branch with an
+// open PR as a completed check run on its head, so the PR
+// shows the agent's builds as well as its artifacts. The
+// agent gets its report either way, so failures are logged.
+func reportBuildCheck(ctx context.Context, req BuildRequest, result *builder.BuildResult) {
+	client, err := forge.New(req.ClientOptions)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to create forge client for build check", "error", err)
+		return
+	}
+	prs, err := client.ListPullRequests(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to list pull requests for build check", "error", err)
+		return
+	}
+	if !slices.ContainsFunc(prs, func(pr github.PullRequestSummary) bool {
+		return pr.Open && pr.Head == req.Branch
+	}) {
+		return
+	}
+	sha, err := client.GetBranchHeadSHA(ctx, req.Branch)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to resolve branch head for build check", "branch", req.Branch, "error", err)
+		return
+	}
+	if _, err = client.CreateCheckRun(ctx, toolBuildCheckRun(req, sha, result)); err != nil {
+		slog.WarnContext(ctx, "failed to create build check", "branch", req.Branch, "error", err)
+	}
+}
+
+// toolBuildCheckRun renders the completed check run of a
+// build without an artifact.
+func toolBuildCheckRun(req BuildRequest, sha string, result *builder.BuildResult) github.CheckRun {
+	check := BuildCheck{Name: buildCheckName(req.File), HeadSHA: sha}
+	if !result.Success {
+		run := buildCheckRun(CompleteBuildCheckRequest{
+			Check:       check,
+			Branch:      req.Branch,
+			File:        req.File,
+			Report:      result.Report(),
+			Diagnostics: result.Diagnostics,
+		})
+		run.HeadSHA = sha
+		return run
+	}
+	return github.CheckRun{
+		Name:       check.Name,
+		HeadSHA:    sha,
+		ExternalID: req.Branch,
+		Status:     github.CheckStatusCompleted,
+		Conclusion: github.CheckConclusionSuccess,
+		Title:      "Built " + req.File,
+		Summary:    fmt.Sprintf("Built `%s` from `%s`.", req.File, sha),
+	}
 }
 
 func runBuild(
@@ -46,9 +111,10 @@ func runBuild(
 	branch string,
 	builderName string,
 	file string,
+	pdfStandard string,
 	outputPath string,
 ) (*builder.BuildResult, error) {
-	client, err := github.NewClient(clientOpts)
+	client, err := forge.New(clientOpts)
 	if err != nil {
 		return nil, err
 	}
@@ -58,7 +124,22 @@ func runBuild(
 		return nil, err
 	}
 
-	repo, err := git.NewGitRepo(ctx, repoRemote)
+	pageLimit := pageLimitForBuildFile(file)
+	key := newBuildCacheKey(clientOpts, builderName, file)
+	key.Format = "pdf"
+	key.PageLimit = pageLimit
+	key.PDFStandard = pdfStandard
+
+	// Resolve the branch head first so a cache hit skips the
+	// clone entirely.
+	if key.Commit, err = git.RemoteBranchHead(ctx, repoRemote, branch); err != nil {
+		slog.WarnContext(ctx, "failed to resolve branch head for build cache", "branch", branch, "error", err)
+	}
+	if result, ok := getBuildCache(ctx).Get(ctx, key, outputPath); ok {
+		return result, nil
+	}
+
+	repo, err := git.Open(ctx, repoRemote)
 	if err != nil {
 		return nil, err
 	}
@@ -69,22 +150,22 @@ func runBuild(
 	}
 
 	rootFile := path.Join(repo.Path(), file)
-	pageLimit := pageLimitForBuildFile(file)
-	b, err := builder.NewBuilder(
+	b, err := newSandboxedBuilder(
 		builderName,
-		builder.WithTypstRootFile(rootFile),
+		builder.WithRootFile(rootFile),
 		builder.WithPageLimit(pageLimit),
+		builder.WithPDFStandard(pdfStandard),
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	return b.Build(ctx, repo.Path(), outputPath)
+	return cachedBuild(ctx, key, b, repo, outputPath)
 }
 
 func pageLimitForBuildFile(file string) int {
 	switch file {
-	case "resume.typ":
+	case "resume.typ", "resume.tex":
 		// Allow resume generation/review passes to overshoot by one page.
 		return 2
 	default:
@@ -0,0 +1,167 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"go.temporal.io/sdk/temporal"
+
+	"github.com/ansg191/job-temporal/internal/builder"
+	"github.com/ansg191/job-temporal/internal/forge"
+	"github.com/ansg191/job-temporal/internal/github"
+)
+
+// BuildCheck is the check run reporting a build of a branch.
+// A zero ID means the forge does not show checks.
+type BuildCheck struct {
+	ID      int64  `json:"id"`
+	Name    string `json:"name"`
+	HeadSHA string `json:"head_sha"`
+}
+
+type StartBuildCheckRequest struct {
+	github.ClientOptions
+	Branch string `json:"branch"`
+	File   string `json:"file"`
+}
+
+// StartBuildCheck reports a build of File as in progress on
+// the branch's head commit.
+func StartBuildCheck(ctx context.Context, req StartBuildCheckRequest) (*BuildCheck, error) {
+	client, err := forge.New(req.ClientOptions)
+	if err != nil {
+		return nil, temporal.NewNonRetryableApplicationError(
+			"failed to create forge client",
+			"ForgeClientError",
+			err,
+		)
+	}
+
+	sha, err := client.GetBranchHeadSHA(ctx, req.Branch)
+	if err != nil {
+		return nil, err
+	}
+	check := &BuildCheck{Name: buildCheckName(req.File), HeadSHA: sha}
+	check.ID, err = client.CreateCheckRun(ctx, github.CheckRun{
+		Name:       check.Name,
+		HeadSHA:    sha,
+		ExternalID: req.Branch,
+		Status:     github.CheckStatusInProgress,
+		Title:      "Building " + req.File,
+		Summary:    fmt.Sprintf("Building `%s` from `%s`.", req.File, req.Branch),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return check, nil
+}
+
+type CompleteBuildCheckRequest struct {
+	github.ClientOptions
+	Check  BuildCheck `json:"check"`
+	Branch string     `json:"branch"`
+	File   string     `json:"file"`
+	// ArtifactURL is the uploaded PDF of a successful build.
+	ArtifactURL string `json:"artifact_url,omitempty"`
+	// Report and Diagnostics describe a failed build, as in
+	// the details of an ErrTypeBuildFailed error.
+	Report      []string             `json:"report,omitempty"`
+	Diagnostics []builder.Diagnostic `json:"diagnostics,omitempty"`
+	// Error describes a failure other than the build itself,
+	// such as the upload.
+	Error string `json:"error,omitempty"`
+}
+
+// CompleteBuildCheck concludes a build's check run: success
+// with a link to the artifact, or failure with the build's
+// diagnostics annotated on the source lines they refer to.
+func CompleteBuildCheck(ctx context.Context, req CompleteBuildCheckRequest) error {
+	if req.Check.ID == 0 {
+		return nil
+	}
+	client, err := forge.New(req.ClientOptions)
+	if err != nil {
+		return temporal.NewNonRetryableApplicationError(
+			"failed to create forge client",
+			"ForgeClientError",
+			err,
+		)
+	}
+	return client.UpdateCheckRun(ctx, req.Check.ID, buildCheckRun(req))
+}
+
+func buildCheckName(file string) string {
+	return "build: " + file
+}
+
+// buildCheckRun renders the completed check run of a build.
+func buildCheckRun(req CompleteBuildCheckRequest) github.CheckRun {
+	run := github.CheckRun{
+		Name:       req.Check.Name,
+		ExternalID: req.Branch,
+		Status:     github.CheckStatusCompleted,
+	}
+	if req.ArtifactURL != "" && req.Error == "" && len(req.Report) == 0 {
+		run.Conclusion = github.CheckConclusionSuccess
+		run.DetailsURL = req.ArtifactURL
+		run.Title = "Built " + req.File
+		run.Summary = fmt.Sprintf("[Download the PDF](%s) built from `%s`.", req.ArtifactURL, req.Check.HeadSHA)
+		return run
+	}
+
+	run.Conclusion = github.CheckConclusionFailure
+	run.Title = "Failed to build " + req.File
+	switch {
+	case len(req.Report) > 0:
+		// Only the summary line of the first problem; the
+		// rest is in the text and annotations.
+		summary, _, _ := strings.Cut(req.Report[0], "\n")
+		run.Summary = "`" + summary + "`"
+		if n := len(req.Report); n > 1 {
+			run.Summary += fmt.Sprintf(" and %d more", n-1)
+		}
+		run.Text = "```text\n" + strings.Join(req.Report, "\n\n") + "\n```"
+	case req.Error != "":
+		run.Summary = req.Error
+	default:
+		run.Summary = "The build failed."
+	}
+	run.Annotations = checkAnnotations(req.Diagnostics)
+	return run
+}
+
+// checkAnnotations converts diagnostics to annotations on
+// the repository's files. Diagnostics without a line, or in
+// files outside the repository such as packages, are left
+// to the check's text.
+func checkAnnotations(diags []builder.Diagnostic) []github.CheckAnnotation {
+	var ret []github.CheckAnnotation
+	for _, d := range diags {
+		file := path.Clean(strings.TrimPrefix(d.File, "/"))
+		if d.File == "" || d.Line <= 0 || strings.HasPrefix(d.File, "@") ||
+			file == ".." || strings.HasPrefix(file, "../") {
+			continue
+		}
+		level := "failure"
+		if d.Severity == builder.SeverityWarning {
+			level = "warning"
+		}
+		message := d.Message
+		for _, hint := range d.Hints {
+			message += "\nhint: " + hint
+		}
+		ret = append(ret, github.CheckAnnotation{
+			Path:        file,
+			StartLine:   d.Line,
+			EndLine:     d.Line,
+			StartColumn: d.Column,
+			Level:       level,
+			Title:       string(d.Severity),
+			Message:     message,
+			RawDetails:  d.Excerpt,
+		})
+	}
+	return ret
+}
@@ -0,0 +1,150 @@
+package activities
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ansg191/job-temporal/internal/builder"
+	"github.com/ansg191/job-temporal/internal/github"
+)
+
+func TestCheckAnnotations(t *testing.T) {
+	t.Parallel()
+
+	diags := []builder.Diagnostic{
+		{
+			File:     "jobs.typ",
+			Line:     12,
+			Column:   5,
+			Severity: builder.SeverityError,
+			Message:  "unknown variable: foo",
+			Hints:    []string{"did you mean bar?"},
+			Excerpt:  "> 12 | #foo",
+		},
+		{File: "/person.typ", Line: 3, Severity: builder.SeverityWarning, Message: "unused"},
+		{File: "@preview/pkg:0.1.0/lib.typ", Line: 1, Severity: builder.SeverityError, Message: "in package"},
+		{File: "../outside.typ", Line: 1, Severity: builder.SeverityError, Message: "outside"},
+		{File: "resume.typ", Severity: builder.SeverityError, Message: "page limit exceeded"},
+	}
+
+	got := checkAnnotations(diags)
+	want := []github.CheckAnnotation{
+		{
+			Path:        "jobs.typ",
+			StartLine:   12,
+			EndLine:     12,
+			StartColumn: 5,
+			Level:       "failure",
+			Title:       "error",
+			Message:     "unknown variable: foo\nhint: did you mean bar?",
+			RawDetails:  "> 12 | #foo",
+		},
+		{Path: "person.typ", StartLine: 3, EndLine: 3, Level: "warning", Title: "warning", Message: "unused"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("checkAnnotations() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("annotation %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestBuildCheckRun(t *testing.T) {
+	t.Parallel()
+
+	check := BuildCheck{ID: 1, Name: buildCheckName("resume.typ"), HeadSHA: "abc123"}
+
+	tests := []struct {
+		name           string
+		req            CompleteBuildCheckRequest
+		wantConclusion string
+		wantSummary    string
+		wantText       bool
+	}{
+		{
+			name:           "success links artifact",
+			req:            CompleteBuildCheckRequest{Check: check, File: "resume.typ", ArtifactURL: "https://example.com/r.pdf"},
+			wantConclusion: github.CheckConclusionSuccess,
+			wantSummary:    "https://example.com/r.pdf",
+		},
+		{
+			name: "build failure summarises report",
+			req: CompleteBuildCheckRequest{
+				Check:  check,
+				File:   "resume.typ",
+				Report: []string{"jobs.typ:12:5: error: unknown variable: foo\n  hint: x", "pages: 2 (limit 1)"},
+			},
+			wantConclusion: github.CheckConclusionFailure,
+			wantSummary:    "`jobs.typ:12:5: error: unknown variable: foo` and 1 more",
+			wantText:       true,
+		},
+		{
+			name:           "upload failure",
+			req:            CompleteBuildCheckRequest{Check: check, File: "resume.typ", Error: "upload failed"},
+			wantConclusion: github.CheckConclusionFailure,
+			wantSummary:    "upload failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			run := buildCheckRun(tt.req)
+			if run.Status != github.CheckStatusCompleted || run.Conclusion != tt.wantConclusion {
+				t.Errorf("status %q conclusion %q, want completed %q", run.Status, run.Conclusion, tt.wantConclusion)
+			}
+			if !strings.Contains(run.Summary, tt.wantSummary) {
+				t.Errorf("summary %q does not contain %q", run.Summary, tt.wantSummary)
+			}
+			if (run.Text != "") != tt.wantText {
+				t.Errorf("text %q, want text %v", run.Text, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestToolBuildCheckRun(t *testing.T) {
+	t.Parallel()
+
+	req := BuildRequest{Branch: "feature", File: "resume.typ"}
+
+	tests := []struct {
+		name           string
+		result         *builder.BuildResult
+		wantConclusion string
+		wantSummary    string
+	}{
+		{
+			name:           "success",
+			result:         &builder.BuildResult{Success: true},
+			wantConclusion: github.CheckConclusionSuccess,
+			wantSummary:    "abc123",
+		},
+		{
+			name:           "failure",
+			result:         &builder.BuildResult{Errors: []string{"resume.typ:1:1: error: boom"}},
+			wantConclusion: github.CheckConclusionFailure,
+			wantSummary:    "boom",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			run := toolBuildCheckRun(req, "abc123", tt.result)
+			if run.HeadSHA != "abc123" || run.Name != buildCheckName("resume.typ") {
+				t.Errorf("run on %q named %q, want abc123 and %q", run.HeadSHA, run.Name, buildCheckName("resume.typ"))
+			}
+			if run.Status != github.CheckStatusCompleted || run.Conclusion != tt.wantConclusion {
+				t.Errorf("status %q conclusion %q, want completed %q", run.Status, run.Conclusion, tt.wantConclusion)
+			}
+			if !strings.Contains(run.Summary, tt.wantSummary) {
+				t.Errorf("summary %q does not contain %q", run.Summary, tt.wantSummary)
+			}
+		})
+	}
+}
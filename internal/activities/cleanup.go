@@ -0,0 +1,336 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	gh "github.com/google/go-github/v81/github"
+	"go.temporal.io/sdk/temporal"
+
+	"github.com/ansg191/job-temporal/internal/database"
+	"github.com/ansg191/job-temporal/internal/forge"
+	"github.com/ansg191/job-temporal/internal/github"
+)
+
+// Cleanup action kinds, in the order a plan applies them.
+const (
+	CleanupClosePR        = "close_pr"
+	CleanupFinishWorkflow = "finish_workflow"
+	CleanupDeleteBranch   = "delete_branch"
+	CleanupArchiveBranch  = "archive_branch"
+)
+
+// archiveTagPrefix namespaces the tags final branches are
+// archived as.
+const archiveTagPrefix = "archive/"
+
+// CleanupAction is one step of a cleanup plan.
+type CleanupAction struct {
+	Kind   string `json:"kind"`
+	PR     int    `json:"pr,omitempty"`
+	Branch string `json:"branch,omitempty"`
+	// Tag and SHA are where an archived branch is kept.
+	Tag string `json:"tag,omitempty"`
+	SHA string `json:"sha,omitempty"`
+	// WorkflowID is the review agent of a finished branch.
+	WorkflowID string `json:"workflow_id,omitempty"`
+	Reason     string `json:"reason"`
+}
+
+func (a CleanupAction) String() string {
+	switch a.Kind {
+	case CleanupClosePR:
+		return fmt.Sprintf("close PR #%d (%s)", a.PR, a.Reason)
+	case CleanupFinishWorkflow:
+		return fmt.Sprintf("finish review workflow %s of %s", a.WorkflowID, a.Branch)
+	case CleanupDeleteBranch:
+		return fmt.Sprintf("delete branch %s (%s)", a.Branch, a.Reason)
+	case CleanupArchiveBranch:
+		return fmt.Sprintf("archive branch %s as tag %s at %s (%s)", a.Branch, a.Tag, a.SHA, a.Reason)
+	default:
+		return a.Kind
+	}
+}
+
+type CleanupPlan struct {
+	Repo    github.ClientOptions `json:"repo"`
+	Actions []CleanupAction      `json:"actions"`
+}
+
+// Report lists the plan's actions, one per line.
+func (p *CleanupPlan) Report() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s/%s: ", p.Repo.Owner, p.Repo.Repo)
+	if len(p.Actions) == 0 {
+		sb.WriteString("nothing to clean up")
+		return sb.String()
+	}
+	fmt.Fprintf(&sb, "%d actions", len(p.Actions))
+	for _, a := range p.Actions {
+		sb.WriteString("\n  - ")
+		sb.WriteString(a.String())
+	}
+	return sb.String()
+}
+
+type PlanCleanupRequest struct {
+	github.ClientOptions
+	// Retention is how long a job's PRs and branches are kept
+	// after their last activity.
+	Retention time.Duration `json:"retention"`
+	// Now is the time retention is measured back from.
+	Now time.Time `json:"now"`
+}
+
+// PlanCleanup works out which of the repository's job PRs
+// to close and which of their branches to delete or archive.
+// Only PRs carrying a purpose label, and their head and base
+// branches, are considered; nothing is changed.
+func PlanCleanup(ctx context.Context, req PlanCleanupRequest) (*CleanupPlan, error) {
+	client, err := forge.New(req.ClientOptions)
+	if err != nil {
+		return nil, temporal.NewNonRetryableApplicationError(
+			"failed to create forge client",
+			"ForgeClientError",
+			err,
+		)
+	}
+
+	prs, err := client.ListPullRequests(ctx)
+	if err != nil {
+		return nil, err
+	}
+	branches, err := client.ListBranches(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defaultBranch, err := client.DefaultBranch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	branches = slices.DeleteFunc(branches, func(b string) bool { return b == defaultBranch })
+
+	db, err := database.NewPostgresDatabase()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	plan := &CleanupPlan{Repo: req.ClientOptions}
+	for _, action := range planCleanup(prs, branches, req.Now.Add(-req.Retention)) {
+		switch action.Kind {
+		case CleanupArchiveBranch:
+			if action.SHA, err = client.GetBranchHeadSHA(ctx, action.Branch); err != nil {
+				return nil, err
+			}
+		case CleanupDeleteBranch:
+			workflowID, err := db.GetBranchWorkflowId(ctx, req.Owner, req.Repo, action.Branch)
+			if err == nil {
+				plan.Actions = append(plan.Actions, CleanupAction{
+					Kind:       CleanupFinishWorkflow,
+					Branch:     action.Branch,
+					WorkflowID: workflowID,
+					Reason:     "branch deleted",
+				})
+			} else if !errors.Is(err, database.ErrNotFound) {
+				return nil, err
+			}
+		}
+		plan.Actions = append(plan.Actions, action)
+	}
+	return plan, nil
+}
+
+// planCleanup plans the cleanup of job PRs, those with a
+// purpose label, last active before cutoff:
+//
+//   - open PRs are closed as abandoned
+//   - head branches of merged PRs, and of PRs closed before
+//     cutoff, are deleted
+//   - a final branch, the base of job PRs, is archived as a
+//     tag once all its PRs are closed and were last active
+//     before cutoff
+//
+// Only the given branches are deleted or archived.
+func planCleanup(prs []github.PullRequestSummary, branches []string, cutoff time.Time) []CleanupAction {
+	prs = slices.Clone(prs)
+	slices.SortFunc(prs, func(a, b github.PullRequestSummary) int { return a.Number - b.Number })
+
+	var (
+		closes, deletes, archives []CleanupAction
+		planned                   = make(map[string]bool)
+		finals                    []string
+		finalActive               = make(map[string]bool)
+	)
+	for _, pr := range prs {
+		if !slices.ContainsFunc(pr.Labels, func(l string) bool {
+			return slices.Contains(github.SupportedPRPurposeLabels, l)
+		}) {
+			continue
+		}
+		if !slices.Contains(finals, pr.Base) {
+			finals = append(finals, pr.Base)
+		}
+		stale := pr.UpdatedAt.Before(cutoff)
+
+		var reason string
+		switch {
+		case pr.Open && stale:
+			closes = append(closes, CleanupAction{
+				Kind:   CleanupClosePR,
+				PR:     pr.Number,
+				Branch: pr.Head,
+				Reason: "no activity since " + pr.UpdatedAt.Format(time.DateOnly),
+			})
+			reason = fmt.Sprintf("PR #%d abandoned", pr.Number)
+		case pr.Open:
+			finalActive[pr.Base] = true
+			continue
+		case pr.Merged:
+			reason = fmt.Sprintf("PR #%d merged", pr.Number)
+		case stale:
+			reason = fmt.Sprintf("PR #%d closed", pr.Number)
+		default:
+			// Recently closed PRs may still be reopened.
+			finalActive[pr.Base] = true
+			continue
+		}
+		if !stale {
+			finalActive[pr.Base] = true
+		}
+		if slices.Contains(branches, pr.Head) && !planned[pr.Head] {
+			planned[pr.Head] = true
+			deletes = append(deletes, CleanupAction{Kind: CleanupDeleteBranch, Branch: pr.Head, Reason: reason})
+		}
+	}
+
+	for _, final := range finals {
+		if finalActive[final] || !slices.Contains(branches, final) {
+			continue
+		}
+		archives = append(archives, CleanupAction{
+			Kind:   CleanupArchiveBranch,
+			Branch: final,
+			Tag:    archiveTagPrefix + final,
+			Reason: "all PRs closed",
+		})
+	}
+
+	// A final branch is never also a feature branch, but a
+	// branch being archived must not be deleted untagged.
+	deletes = slices.DeleteFunc(deletes, func(a CleanupAction) bool {
+		return slices.Contains(finals, a.Branch)
+	})
+	return slices.Concat(closes, deletes, archives)
+}
+
+type ApplyCleanupActionRequest struct {
+	github.ClientOptions
+	Action CleanupAction `json:"action"`
+}
+
+// ApplyCleanupAction carries out one action of a cleanup
+// plan. Actions already carried out are not an error, so a
+// plan can be retried.
+func ApplyCleanupAction(ctx context.Context, req ApplyCleanupActionRequest) error {
+	if req.Action.Kind == CleanupFinishWorkflow {
+		return FinishReview(ctx, req.Action.WorkflowID)
+	}
+
+	client, err := forge.New(req.ClientOptions)
+	if err != nil {
+		return temporal.NewNonRetryableApplicationError(
+			"failed to create forge client",
+			"ForgeClientError",
+			err,
+		)
+	}
+
+	a := req.Action
+	switch a.Kind {
+	case CleanupClosePR:
+		return client.ClosePullRequest(ctx, a.PR)
+	case CleanupDeleteBranch:
+		return ignoreAlreadyDone(client.DeleteBranch(ctx, a.Branch))
+	case CleanupArchiveBranch:
+		return archiveBranch(ctx, client, a)
+	default:
+		return temporal.NewNonRetryableApplicationError(
+			"unknown cleanup action "+a.Kind,
+			"InvalidCleanupAction",
+			nil,
+		)
+	}
+}
+
+// branchArchiver is the part of a forge archiving a branch
+// needs.
+type branchArchiver interface {
+	CreateTag(ctx context.Context, tag, sha string) error
+	GetTagSHA(ctx context.Context, tag string) (string, error)
+	GetBranchHeadSHA(ctx context.Context, branch string) (string, error)
+	DeleteBranch(ctx context.Context, branch string) error
+}
+
+// archiveBranch tags the branch at the planned commit, then
+// deletes it. The branch is kept if the tag ends up
+// anywhere else, or if commits were pushed to it since the
+// plan was made, so nothing is lost untagged.
+func archiveBranch(ctx context.Context, client branchArchiver, a CleanupAction) error {
+	if err := client.CreateTag(ctx, a.Tag, a.SHA); err != nil {
+		// A failed create may have been an earlier attempt's
+		// tag, or a different commit's.
+		tagSHA, tagErr := client.GetTagSHA(ctx, a.Tag)
+		if tagErr != nil {
+			return fmt.Errorf("failed to create tag %s: %w", a.Tag, err)
+		}
+		if tagSHA != a.SHA {
+			return temporal.NewNonRetryableApplicationError(
+				fmt.Sprintf("tag %s already points at %s, not %s; keeping branch %s", a.Tag, tagSHA, a.SHA, a.Branch),
+				"ArchiveTagConflict",
+				nil,
+			)
+		}
+	}
+
+	head, err := client.GetBranchHeadSHA(ctx, a.Branch)
+	if isNotFound(err) {
+		// Deleted by an earlier attempt.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if head != a.SHA {
+		return temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("branch %s moved from %s to %s since it was planned; keeping it", a.Branch, a.SHA, head),
+			"ArchiveBranchMoved",
+			nil,
+		)
+	}
+	return ignoreAlreadyDone(client.DeleteBranch(ctx, a.Branch))
+}
+
+// ignoreAlreadyDone drops the errors returned for deleting
+// a branch that does not exist, as when retrying an action.
+func ignoreAlreadyDone(err error) error {
+	var ghErr *gh.ErrorResponse
+	if isNotFound(err) || errors.As(err, &ghErr) && ghErr.Response != nil &&
+		ghErr.Response.StatusCode == http.StatusUnprocessableEntity {
+		return nil
+	}
+	return err
+}
+
+// isNotFound reports whether err is the forge's answer for
+// a missing branch or tag.
+func isNotFound(err error) bool {
+	var ghErr *gh.ErrorResponse
+	return errors.Is(err, forge.ErrNotFound) || errors.As(err, &ghErr) && ghErr.Response != nil &&
+		ghErr.Response.StatusCode == http.StatusNotFound
+}
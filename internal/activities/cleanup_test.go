@@ -0,0 +1,182 @@
+package activities
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ansg191/job-temporal/internal/forge"
+	"github.com/ansg191/job-temporal/internal/github"
+)
+
+func TestPlanCleanup(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	cutoff := now.Add(-30 * 24 * time.Hour)
+	old := cutoff.Add(-time.Hour)
+	recent := cutoff.Add(time.Hour)
+	pr := func(number int, head, base string, open, merged bool, updated time.Time) github.PullRequestSummary {
+		return github.PullRequestSummary{
+			Number:    number,
+			Head:      head,
+			Base:      base,
+			Labels:    []string{"resume"},
+			Open:      open,
+			Merged:    merged,
+			UpdatedAt: updated,
+		}
+	}
+
+	tests := []struct {
+		name     string
+		prs      []github.PullRequestSummary
+		branches []string
+		want     []string
+	}{
+		{
+			name:     "nothing stale",
+			prs:      []github.PullRequestSummary{pr(1, "acme/resume", "acme", true, false, recent)},
+			branches: []string{"acme", "acme/resume"},
+			want:     nil,
+		},
+		{
+			name: "abandoned job",
+			prs: []github.PullRequestSummary{
+				pr(2, "acme/letter", "acme", true, false, old),
+				pr(1, "acme/resume", "acme", true, false, old),
+			},
+			branches: []string{"acme", "acme/resume", "acme/letter"},
+			want: []string{
+				"close_pr #1", "close_pr #2",
+				"delete_branch acme/resume", "delete_branch acme/letter",
+				"archive_branch acme",
+			},
+		},
+		{
+			name: "merged branch deleted while final still active",
+			prs: []github.PullRequestSummary{
+				pr(1, "acme/resume", "acme", false, true, recent),
+				pr(2, "acme/letter", "acme", true, false, recent),
+			},
+			branches: []string{"acme", "acme/resume", "acme/letter"},
+			want:     []string{"delete_branch acme/resume"},
+		},
+		{
+			name:     "recently closed PR kept",
+			prs:      []github.PullRequestSummary{pr(1, "acme/resume", "acme", false, false, recent)},
+			branches: []string{"acme", "acme/resume"},
+			want:     nil,
+		},
+		{
+			name:     "stale closed PR with deleted head",
+			prs:      []github.PullRequestSummary{pr(1, "acme/resume", "acme", false, false, old)},
+			branches: []string{"acme"},
+			want:     []string{"archive_branch acme"},
+		},
+		{
+			name: "unlabelled PR ignored",
+			prs: []github.PullRequestSummary{{
+				Number: 1, Head: "feature", Base: "main", Open: true, UpdatedAt: old,
+			}},
+			branches: []string{"feature"},
+			want:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got []string
+			for _, a := range planCleanup(tt.prs, tt.branches, cutoff) {
+				if a.Kind == CleanupClosePR {
+					got = append(got, a.Kind+" #"+strconv.Itoa(a.PR))
+				} else {
+					got = append(got, a.Kind+" "+a.Branch)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("planCleanup() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeArchiver is a forge of branch and tag heads.
+type fakeArchiver struct {
+	branches, tags map[string]string
+}
+
+func (f *fakeArchiver) CreateTag(_ context.Context, tag, sha string) error {
+	if _, ok := f.tags[tag]; ok {
+		return forge.ErrAlreadyExists
+	}
+	f.tags[tag] = sha
+	return nil
+}
+
+func (f *fakeArchiver) GetTagSHA(_ context.Context, tag string) (string, error) {
+	if sha, ok := f.tags[tag]; ok {
+		return sha, nil
+	}
+	return "", forge.ErrNotFound
+}
+
+func (f *fakeArchiver) GetBranchHeadSHA(_ context.Context, branch string) (string, error) {
+	if sha, ok := f.branches[branch]; ok {
+		return sha, nil
+	}
+	return "", forge.ErrNotFound
+}
+
+func (f *fakeArchiver) DeleteBranch(_ context.Context, branch string) error {
+	delete(f.branches, branch)
+	return nil
+}
+
+func TestArchiveBranch(t *testing.T) {
+	t.Parallel()
+
+	action := CleanupAction{Kind: CleanupArchiveBranch, Branch: "final", Tag: "archive/final", SHA: "aaa"}
+	tests := []struct {
+		name         string
+		branches     map[string]string
+		tags         map[string]string
+		errExpected  bool
+		wantBranch   bool
+		wantTagAtSHA bool
+	}{
+		{name: "archives", branches: map[string]string{"final": "aaa"}, wantTagAtSHA: true},
+		{name: "retry after tagging", branches: map[string]string{"final": "aaa"}, tags: map[string]string{"archive/final": "aaa"}, wantTagAtSHA: true},
+		{name: "retry after deleting", tags: map[string]string{"archive/final": "aaa"}, wantTagAtSHA: true},
+		{name: "tag at another commit", branches: map[string]string{"final": "aaa"}, tags: map[string]string{"archive/final": "bbb"}, errExpected: true, wantBranch: true},
+		{name: "pushed since planning", branches: map[string]string{"final": "ccc"}, errExpected: true, wantBranch: true, wantTagAtSHA: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			f := &fakeArchiver{branches: maps.Clone(tt.branches), tags: maps.Clone(tt.tags)}
+			if f.branches == nil {
+				f.branches = map[string]string{}
+			}
+			if f.tags == nil {
+				f.tags = map[string]string{}
+			}
+
+			err := archiveBranch(context.Background(), f, action)
+			if (err != nil) != tt.errExpected {
+				t.Fatalf("archiveBranch() error = %v, errExpected %v", err, tt.errExpected)
+			}
+			if _, ok := f.branches["final"]; ok != tt.wantBranch {
+				t.Errorf("branch kept = %v, want %v", ok, tt.wantBranch)
+			}
+			if got := f.tags["archive/final"] == "aaa"; got != tt.wantTagAtSHA {
+				t.Errorf("tag at planned commit = %v, want %v", got, tt.wantTagAtSHA)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package activities
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"go.temporal.io/sdk/temporal"
+
+	"github.com/ansg191/job-temporal/internal/export"
+	"github.com/ansg191/job-temporal/internal/github"
+	"github.com/ansg191/job-temporal/internal/pdftext"
+)
+
+const ErrTypeExportFailed = "ExportFailed"
+
+type UploadExportsRequest struct {
+	github.ClientOptions
+	Branch  string   `json:"branch"`
+	Builder string   `json:"builder"`
+	File    string   `json:"file"`
+	Formats []string `json:"formats"`
+	// PDFURL is the uploaded PDF artifact. Exports are stored
+	// beside it under the same name.
+	PDFURL string `json:"pdf_url"`
+	// PDFStandard is the artifact's conformance, so the
+	// exports come from the same (cached) build.
+	PDFStandard string `json:"pdf_standard,omitempty"`
+}
+
+type ExportArtifact struct {
+	Format string `json:"format"`
+	Label  string `json:"label"`
+	URL    string `json:"url"`
+}
+
+// UploadExports converts the PDF built from the branch into
+// each requested format and uploads it next to the PDF
+// artifact, so "…/Jane-Doe-Acme-Resume.pdf" gains
+// "…/Jane-Doe-Acme-Resume.docx".
+func UploadExports(ctx context.Context, req UploadExportsRequest) ([]ExportArtifact, error) {
+	formats, err := export.ParseFormats(req.Formats)
+	if err != nil {
+		return nil, temporal.NewNonRetryableApplicationError(err.Error(), ErrTypeExportFailed, err)
+	}
+	if len(formats) == 0 {
+		return nil, nil
+	}
+
+	r2cfg, err := loadR2Config()
+	if err != nil {
+		return nil, err
+	}
+	pdfKey, err := objectKeyFromPublicURL(req.PDFURL)
+	if err != nil {
+		return nil, temporal.NewNonRetryableApplicationError("invalid pdf artifact url", ErrTypeExportFailed, err)
+	}
+
+	content, err := BuildFinalPDF(ctx, BuildFinalPDFRequest{
+		ClientOptions: req.ClientOptions,
+		Branch:        req.Branch,
+		Builder:       req.Builder,
+		File:          req.File,
+		PDFStandard:   req.PDFStandard,
+	})
+	if err != nil {
+		return nil, err
+	}
+	pages, err := pdftext.Extract(bytes.NewReader(content))
+	if err != nil {
+		return nil, temporal.NewNonRetryableApplicationError("failed to extract pdf text", ErrTypeExportFailed, err)
+	}
+	doc := export.FromPages(pages)
+
+	baseURL := strings.TrimRight(r2cfg.PublicBaseURL, "/")
+	ret := make([]ExportArtifact, 0, len(formats))
+	for _, format := range formats {
+		data, err := export.Render(doc, format)
+		if err != nil {
+			return nil, temporal.NewNonRetryableApplicationError(
+				fmt.Sprintf("failed to render %s export", format),
+				ErrTypeExportFailed,
+				err,
+			)
+		}
+
+		key := exportObjectKey(pdfKey, format)
+		if err = putR2Object(ctx, r2cfg, key, data, format.ContentType(), contentDisposition(path.Base(key))); err != nil {
+			return nil, fmt.Errorf("failed to upload %s export: %w", format, err)
+		}
+		ret = append(ret, ExportArtifact{
+			Format: string(format),
+			Label:  format.Label(),
+			URL:    baseURL + "/" + key,
+		})
+	}
+	return ret, nil
+}
+
+// exportObjectKey swaps the PDF key's extension for the
+// export format's.
+func exportObjectKey(pdfKey string, format export.Format) string {
+	return strings.TrimSuffix(pdfKey, path.Ext(pdfKey)) + "." + string(format)
+}
@@ -0,0 +1,25 @@
+package activities
+
+import (
+	"testing"
+
+	"github.com/ansg191/job-temporal/internal/export"
+)
+
+func TestExportObjectKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		key    string
+		format export.Format
+		want   string
+	}{
+		{"0b7e/Jane-Doe-Acme-Resume.pdf", export.FormatDOCX, "0b7e/Jane-Doe-Acme-Resume.docx"},
+		{"0b7e.pdf", export.FormatText, "0b7e.txt"},
+	}
+	for _, tt := range tests {
+		if got := exportObjectKey(tt.key, tt.format); got != tt.want {
+			t.Errorf("exportObjectKey(%q, %q) = %q, want %q", tt.key, tt.format, got, tt.want)
+		}
+	}
+}
@@ -2,82 +2,189 @@ package activities
 
 import (
 	"context"
+	"fmt"
+	"path"
 	"slices"
+	"strings"
 
+	"github.com/ansg191/job-temporal/internal/forge"
 	"github.com/ansg191/job-temporal/internal/git"
+	"github.com/ansg191/job-temporal/internal/github"
 )
 
+// FileToolOutput is the result of a file tool. Head is the
+// branch head the result reflects, so the workflow can pass
+// it back as the base commit of later edits.
+type FileToolOutput struct {
+	Result string `json:"result"`
+	Head   string `json:"head"`
+}
+
+type ListFilesRequest struct {
+	github.ClientOptions
+	Branch string `json:"branch"`
+	// AllowList and EditAllowList are the read and edit
+	// allowlists of the other file tools.
+	AllowList     []string `json:"allowList"`
+	EditAllowList []string `json:"editAllowList"`
+}
+
+// ListFiles lists the files on the branch the file tools may
+// read, marking the ones they may not edit.
+func ListFiles(ctx context.Context, req ListFilesRequest) (*FileToolOutput, error) {
+	repo, head, err := openFileToolRepo(ctx, req.ClientOptions, req.Branch)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.Close()
+
+	files, err := repo.ListFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for _, f := range files {
+		if !fileAllowed(req.AllowList, f) {
+			continue
+		}
+		if !fileAllowed(req.EditAllowList, f) {
+			f += " (read-only)"
+		}
+		lines = append(lines, f)
+	}
+	if len(lines) == 0 {
+		return &FileToolOutput{Result: "No files available", Head: head}, nil
+	}
+	return &FileToolOutput{Result: strings.Join(lines, "\n"), Head: head}, nil
+}
+
 type ReadFileRequest struct {
-	Path       string   `json:"path"`
-	AllowList  []string `json:"allowList"`
-	RepoRemote string   `json:"repoRemote"`
-	Branch     string   `json:"branch"`
+	github.ClientOptions
+	Path      string   `json:"path"`
+	AllowList []string `json:"allowList"`
+	Branch    string   `json:"branch"`
 }
 
-func ReadFile(ctx context.Context, req ReadFileRequest) (string, error) {
+func ReadFile(ctx context.Context, req ReadFileRequest) (*FileToolOutput, error) {
+	req.Path = cleanToolPath(req.Path)
+
 	// Check that file is in the allowlist
-	if !slices.Contains(req.AllowList, req.Path) {
-		return "File not allowed to be read", nil
+	if !fileAllowed(req.AllowList, req.Path) {
+		return &FileToolOutput{Result: "File not allowed to be read"}, nil
 	}
 
-	// Clone the repository
-	repo, err := git.NewGitRepo(ctx, req.RepoRemote)
+	repo, head, err := openFileToolRepo(ctx, req.ClientOptions, req.Branch)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer repo.Close()
 
-	// Checkout the branch
-	if err = repo.SetBranch(ctx, req.Branch); err != nil {
-		return "", err
-	}
-
 	// Read the file
 	content, err := repo.GetFile(ctx, req.Path)
 	if err != nil {
-		return "", err
+		return &FileToolOutput{Result: err.Error(), Head: head}, nil
 	}
 
-	return content, nil
+	return &FileToolOutput{Result: content, Head: head}, nil
 }
 
 type EditFileRequest struct {
 	ReadFileRequest
 	Patch   string
 	Message string
+	// BaseCommit is the head the edit was written against. If
+	// empty, the current head is assumed.
+	BaseCommit string `json:"baseCommit,omitempty"`
 }
 
-func EditFile(ctx context.Context, req EditFileRequest) (string, error) {
-	// Check that file is in the allowlist
-	if !slices.Contains(req.AllowList, req.Path) {
-		return "File not allowed to be edited", nil
+func EditFile(ctx context.Context, req EditFileRequest) (*FileToolOutput, error) {
+	// Check that file, and every file the patch touches, is
+	// in the allowlist
+	if !fileAllowed(req.AllowList, req.Path) {
+		return &FileToolOutput{Result: "File not allowed to be edited"}, nil
+	}
+	for _, f := range git.PatchFiles(req.Patch) {
+		if !fileAllowed(req.AllowList, f) {
+			return &FileToolOutput{Result: fmt.Sprintf("Patch touches %s, which is not allowed to be edited", f)}, nil
+		}
 	}
 
-	// Clone the repository
-	repo, err := git.NewGitRepo(ctx, req.RepoRemote)
+	repo, head, err := openFileToolRepo(ctx, req.ClientOptions, req.Branch)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer repo.Close()
 
-	// Checkout the branch
-	if err = repo.SetBranch(ctx, req.Branch); err != nil {
-		return "", err
+	if req.BaseCommit != "" {
+		head = req.BaseCommit
 	}
 
-	// Get HEAD commit
-	head, err := repo.GetHeadCommit(ctx)
+	// Edit the file
+	newHead, err := repo.EditFile(ctx, head, req.Patch, req.Message)
 	if err != nil {
-		return "", err
+		return &FileToolOutput{Result: err.Error()}, nil
 	}
 
-	// Edit the file
-	_, err = repo.EditFile(ctx, head, req.Patch, req.Message)
+	return &FileToolOutput{Result: "Success, committed " + newHead, Head: newHead}, nil
+}
+
+// FileEdit is one patch of an EditFiles request.
+type FileEdit struct {
+	Path  string `json:"path"`
+	Patch string `json:"patch"`
+}
+
+type EditFilesRequest struct {
+	github.ClientOptions
+	AllowList []string   `json:"allowList"`
+	Branch    string     `json:"branch"`
+	Edits     []FileEdit `json:"edits"`
+	Message   string     `json:"message"`
+	// BaseCommit is the head the edits were written against.
+	// If empty, the current head is assumed.
+	BaseCommit string `json:"baseCommit,omitempty"`
+}
+
+// EditFiles applies several patches as one commit, so
+// related changes across files land, and trigger a rebuild,
+// together. Nothing is committed unless every patch applies.
+func EditFiles(ctx context.Context, req EditFilesRequest) (*FileToolOutput, error) {
+	if len(req.Edits) == 0 {
+		return &FileToolOutput{Result: "No edits given"}, nil
+	}
+
+	// Check that every file, and every file the patches
+	// touch, is in the allowlist
+	patches := make([]string, len(req.Edits))
+	for i, edit := range req.Edits {
+		if !fileAllowed(req.AllowList, edit.Path) {
+			return &FileToolOutput{Result: fmt.Sprintf("File %s not allowed to be edited", edit.Path)}, nil
+		}
+		for _, f := range git.PatchFiles(edit.Patch) {
+			if !fileAllowed(req.AllowList, f) {
+				return &FileToolOutput{Result: fmt.Sprintf("Patch touches %s, which is not allowed to be edited", f)}, nil
+			}
+		}
+		patches[i] = edit.Patch
+	}
+
+	repo, head, err := openFileToolRepo(ctx, req.ClientOptions, req.Branch)
 	if err != nil {
-		return err.Error(), nil
+		return nil, err
+	}
+	defer repo.Close()
+
+	if req.BaseCommit != "" {
+		head = req.BaseCommit
 	}
 
-	return "Success", nil
+	newHead, err := repo.EditFiles(ctx, head, patches, req.Message)
+	if err != nil {
+		return &FileToolOutput{Result: err.Error()}, nil
+	}
+
+	return &FileToolOutput{Result: "Success, committed " + newHead, Head: newHead}, nil
 }
 
 type EditLineRequest struct {
@@ -86,37 +193,92 @@ type EditLineRequest struct {
 	EndLine    int
 	NewContent string
 	Message    string
+	// BaseCommit is the head the line numbers refer to. If
+	// empty, the current head is assumed.
+	BaseCommit string `json:"baseCommit,omitempty"`
 }
 
-func EditLine(ctx context.Context, req EditLineRequest) (string, error) {
+func EditLine(ctx context.Context, req EditLineRequest) (*FileToolOutput, error) {
+	req.Path = cleanToolPath(req.Path)
+
 	// Check that file is in the allowlist
-	if !slices.Contains(req.AllowList, req.Path) {
-		return "File not allowed to be edited", nil
+	if !fileAllowed(req.AllowList, req.Path) {
+		return &FileToolOutput{Result: "File not allowed to be edited"}, nil
 	}
 
-	// Clone the repository
-	repo, err := git.NewGitRepo(ctx, req.RepoRemote)
+	repo, head, err := openFileToolRepo(ctx, req.ClientOptions, req.Branch)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer repo.Close()
 
-	// Checkout the branch
-	if err = repo.SetBranch(ctx, req.Branch); err != nil {
-		return "", err
+	if req.BaseCommit != "" {
+		head = req.BaseCommit
 	}
 
-	// Get HEAD commit
+	// Edit the file by lines
+	newHead, err := repo.EditFileByLines(ctx, head, req.Path, req.StartLine, req.EndLine, req.NewContent, req.Message)
+	if err != nil {
+		return &FileToolOutput{Result: err.Error()}, nil
+	}
+
+	return &FileToolOutput{Result: "Success, committed " + newHead, Head: newHead}, nil
+}
+
+// openFileToolRepo checks out branch and returns its head
+// commit.
+func openFileToolRepo(ctx context.Context, opts github.ClientOptions, branch string) (*git.Repository, string, error) {
+	repo, err := openBranch(ctx, opts, branch)
+	if err != nil {
+		return nil, "", err
+	}
 	head, err := repo.GetHeadCommit(ctx)
 	if err != nil {
-		return "", err
+		_ = repo.Close()
+		return nil, "", err
 	}
+	return repo, head, nil
+}
 
-	// Edit the file by lines
-	_, err = repo.EditFileByLines(ctx, head, req.Path, req.StartLine, req.EndLine, req.NewContent, req.Message)
+// openBranch checks out branch from the repository's forge.
+func openBranch(ctx context.Context, opts github.ClientOptions, branch string) (*git.Repository, error) {
+	client, err := forge.New(opts)
+	if err != nil {
+		return nil, err
+	}
+	remote, err := client.GetAuthenticatedRemoteURL(ctx)
 	if err != nil {
-		return err.Error(), nil
+		return nil, err
 	}
 
-	return "Success", nil
+	repo, err := git.Open(ctx, remote)
+	if err != nil {
+		return nil, err
+	}
+	if err = repo.SetBranch(ctx, branch); err != nil {
+		_ = repo.Close()
+		return nil, err
+	}
+	return repo, nil
+}
+
+// cleanToolPath normalizes a path given to a file tool, so
+// the path checked against the allowlist is the one read or
+// edited.
+func cleanToolPath(p string) string {
+	return path.Clean(strings.TrimSpace(p))
+}
+
+// fileAllowed reports whether p, a path relative to the
+// repository root, matches an allowlist entry. Entries are
+// exact paths or path.Match patterns such as "*.typ".
+func fileAllowed(allowList []string, p string) bool {
+	p = cleanToolPath(p)
+	if p == "." || path.IsAbs(p) || p == ".." || strings.HasPrefix(p, "../") {
+		return false
+	}
+	return slices.ContainsFunc(allowList, func(pattern string) bool {
+		ok, err := path.Match(pattern, p)
+		return err == nil && ok
+	})
 }
@@ -0,0 +1,55 @@
+package activities
+
+import "testing"
+
+func TestFileAllowed(t *testing.T) {
+	t.Parallel()
+
+	allowList := []string{"*.typ", "assets/logo.svg"}
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: "jobs.typ", want: true},
+		{path: "./jobs.typ", want: true},
+		{path: " jobs.typ ", want: true},
+		{path: "assets/logo.svg", want: true},
+		{path: "sub/jobs.typ", want: false},
+		{path: "../jobs.typ", want: false},
+		{path: "/jobs.typ", want: false},
+		{path: "notes.md", want: false},
+		{path: "", want: false},
+		{path: ".", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			t.Parallel()
+			if got := fileAllowed(allowList, tt.path); got != tt.want {
+				t.Errorf("fileAllowed(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCleanToolPath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "jobs.typ", want: "jobs.typ"},
+		{path: " jobs.typ ", want: "jobs.typ"},
+		{path: "./assets//logo.svg", want: "assets/logo.svg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			t.Parallel()
+			if got := cleanToolPath(tt.path); got != tt.want {
+				t.Errorf("cleanToolPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
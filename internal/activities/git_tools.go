@@ -9,6 +9,7 @@ import (
 	gh "github.com/google/go-github/v81/github"
 	"go.temporal.io/sdk/temporal"
 
+	"github.com/ansg191/job-temporal/internal/forge"
 	"github.com/ansg191/job-temporal/internal/github"
 )
 
@@ -17,11 +18,11 @@ type ListBranchesRequest struct {
 }
 
 func ListBranches(ctx context.Context, req ListBranchesRequest) (string, error) {
-	client, err := github.NewClient(req.ClientOptions)
+	client, err := forge.New(req.ClientOptions)
 	if err != nil {
 		return "", temporal.NewNonRetryableApplicationError(
-			"failed to create github client",
-			"GithubClientError",
+			"failed to create forge client",
+			"ForgeClientError",
 			err,
 		)
 	}
@@ -39,11 +40,11 @@ type CreateBranchRequest struct {
 }
 
 func CreateBranch(ctx context.Context, req CreateBranchRequest) error {
-	client, err := github.NewClient(req.ClientOptions)
+	client, err := forge.New(req.ClientOptions)
 	if err != nil {
 		return temporal.NewNonRetryableApplicationError(
-			"failed to create github client",
-			"GithubClientError",
+			"failed to create forge client",
+			"ForgeClientError",
 			err,
 		)
 	}
@@ -51,7 +52,7 @@ func CreateBranch(ctx context.Context, req CreateBranchRequest) error {
 	err = client.CreateBranch(ctx, req.Branch)
 	if err != nil {
 		var ghErr *gh.ErrorResponse
-		if errors.As(err, &ghErr) && ghErr.Response != nil &&
+		if errors.Is(err, forge.ErrAlreadyExists) || errors.As(err, &ghErr) && ghErr.Response != nil &&
 			ghErr.Response.StatusCode == http.StatusUnprocessableEntity {
 			return temporal.NewNonRetryableApplicationError(
 				err.Error(),
@@ -74,11 +75,11 @@ type CreatePullRequestRequest struct {
 }
 
 func CreatePullRequest(ctx context.Context, req CreatePullRequestRequest) (int, error) {
-	client, err := github.NewClient(req.ClientOptions)
+	client, err := forge.New(req.ClientOptions)
 	if err != nil {
 		return 0, temporal.NewNonRetryableApplicationError(
-			"failed to create github client",
-			"GithubClientError",
+			"failed to create forge client",
+			"ForgeClientError",
 			err,
 		)
 	}
@@ -92,11 +93,11 @@ type GetBranchHeadSHARequest struct {
 }
 
 func GetBranchHeadSHA(ctx context.Context, req GetBranchHeadSHARequest) (string, error) {
-	client, err := github.NewClient(req.ClientOptions)
+	client, err := forge.New(req.ClientOptions)
 	if err != nil {
 		return "", temporal.NewNonRetryableApplicationError(
-			"failed to create github client",
-			"GithubClientError",
+			"failed to create forge client",
+			"ForgeClientError",
 			err,
 		)
 	}
@@ -110,11 +111,11 @@ type GetPullRequestBodyRequest struct {
 }
 
 func GetPullRequestBody(ctx context.Context, req GetPullRequestBodyRequest) (string, error) {
-	client, err := github.NewClient(req.ClientOptions)
+	client, err := forge.New(req.ClientOptions)
 	if err != nil {
 		return "", temporal.NewNonRetryableApplicationError(
-			"failed to create github client",
-			"GithubClientError",
+			"failed to create forge client",
+			"ForgeClientError",
 			err,
 		)
 	}
@@ -122,6 +123,24 @@ func GetPullRequestBody(ctx context.Context, req GetPullRequestBodyRequest) (str
 	return client.GetPullRequestBody(ctx, req.PRNumber)
 }
 
+type GetPullRequestLabelsRequest struct {
+	github.ClientOptions
+	PRNumber int
+}
+
+func GetPullRequestLabels(ctx context.Context, req GetPullRequestLabelsRequest) ([]string, error) {
+	client, err := forge.New(req.ClientOptions)
+	if err != nil {
+		return nil, temporal.NewNonRetryableApplicationError(
+			"failed to create forge client",
+			"ForgeClientError",
+			err,
+		)
+	}
+
+	return client.GetPullRequestLabels(ctx, req.PRNumber)
+}
+
 type UpdatePullRequestBodyRequest struct {
 	github.ClientOptions
 	PRNumber int
@@ -129,11 +148,11 @@ type UpdatePullRequestBodyRequest struct {
 }
 
 func UpdatePullRequestBody(ctx context.Context, req UpdatePullRequestBodyRequest) error {
-	client, err := github.NewClient(req.ClientOptions)
+	client, err := forge.New(req.ClientOptions)
 	if err != nil {
 		return temporal.NewNonRetryableApplicationError(
-			"failed to create github client",
-			"GithubClientError",
+			"failed to create forge client",
+			"ForgeClientError",
 			err,
 		)
 	}
@@ -141,17 +160,99 @@ func UpdatePullRequestBody(ctx context.Context, req UpdatePullRequestBodyRequest
 	return client.UpdatePullRequestBody(ctx, req.PRNumber, req.Body)
 }
 
+type CommentOnPullRequestRequest struct {
+	github.ClientOptions
+	PRNumber int
+	Body     string
+}
+
+func CommentOnPullRequest(ctx context.Context, req CommentOnPullRequestRequest) error {
+	client, err := forge.New(req.ClientOptions)
+	if err != nil {
+		return temporal.NewNonRetryableApplicationError(
+			"failed to create forge client",
+			"ForgeClientError",
+			err,
+		)
+	}
+
+	return client.CommentOnPullRequest(ctx, req.PRNumber, req.Body)
+}
+
+type ReplyToReviewCommentRequest struct {
+	github.ClientOptions
+	PRNumber  int
+	CommentID int64
+	Body      string
+}
+
+// ReplyToReviewComment replies in a review comment's thread.
+func ReplyToReviewComment(ctx context.Context, req ReplyToReviewCommentRequest) error {
+	client, err := forge.New(req.ClientOptions)
+	if err != nil {
+		return temporal.NewNonRetryableApplicationError(
+			"failed to create forge client",
+			"ForgeClientError",
+			err,
+		)
+	}
+
+	return client.ReplyToReviewComment(ctx, req.PRNumber, req.CommentID, req.Body)
+}
+
+type ResolveReviewThreadRequest struct {
+	github.ClientOptions
+	PRNumber  int
+	CommentID int64
+}
+
+// ResolveReviewThread resolves the review thread containing
+// a review comment.
+func ResolveReviewThread(ctx context.Context, req ResolveReviewThreadRequest) error {
+	client, err := forge.New(req.ClientOptions)
+	if err != nil {
+		return temporal.NewNonRetryableApplicationError(
+			"failed to create forge client",
+			"ForgeClientError",
+			err,
+		)
+	}
+
+	return client.ResolveReviewThread(ctx, req.PRNumber, req.CommentID)
+}
+
+type SuggestChangesRequest struct {
+	github.ClientOptions
+	PRNumber    int
+	Body        string
+	Suggestions []github.ReviewSuggestion
+}
+
+// SuggestChanges posts suggested edits to a PR as a review.
+func SuggestChanges(ctx context.Context, req SuggestChangesRequest) error {
+	client, err := forge.New(req.ClientOptions)
+	if err != nil {
+		return temporal.NewNonRetryableApplicationError(
+			"failed to create forge client",
+			"ForgeClientError",
+			err,
+		)
+	}
+
+	return client.SuggestChanges(ctx, req.PRNumber, req.Body, req.Suggestions)
+}
+
 type ProtectBranchRequest struct {
 	github.ClientOptions
 	Branch string
 }
 
 func ProtectBranch(ctx context.Context, req ProtectBranchRequest) error {
-	client, err := github.NewClient(req.ClientOptions)
+	client, err := forge.New(req.ClientOptions)
 	if err != nil {
 		return temporal.NewNonRetryableApplicationError(
-			"failed to create github client",
-			"GithubClientError",
+			"failed to create forge client",
+			"ForgeClientError",
 			err,
 		)
 	}
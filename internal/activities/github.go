@@ -5,7 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"log/slog"
+	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -21,7 +25,15 @@ const (
 	githubToolRetryMaxBackoff  = 8 * time.Second
 )
 
+// ListGithubTools lists the tools of the hosted GitHub MCP.
+// The agents read and edit files with native tools, so the
+// MCP is optional: unless GITHUB_MCP_ENABLED is true no
+// tools are listed and it is never contacted.
 func ListGithubTools(ctx context.Context) ([]llm.ToolDefinition, error) {
+	if !githubMCPEnabled() {
+		return nil, nil
+	}
+
 	var aiTools []llm.ToolDefinition
 	err := retryGithubRateLimit(ctx, func() error {
 		var err error
@@ -34,6 +46,19 @@ func ListGithubTools(ctx context.Context) ([]llm.ToolDefinition, error) {
 	return aiTools, nil
 }
 
+var githubMCPEnabled = sync.OnceValue(func() bool {
+	v := os.Getenv("GITHUB_MCP_ENABLED")
+	if v == "" {
+		return false
+	}
+	enabled, err := strconv.ParseBool(v)
+	if err != nil {
+		slog.Warn("invalid GITHUB_MCP_ENABLED, ignoring", "value", v, "error", err)
+		return false
+	}
+	return enabled
+})
+
 func CallGithubTool(ctx context.Context, call llm.ToolCall) (string, error) {
 	log.Println(call.Name, call.Arguments)
 
@@ -45,6 +70,11 @@ func CallGithubTool(ctx context.Context, call llm.ToolCall) (string, error) {
 			err,
 		)
 	}
+	// Comments, reviews and PRs the agent posts through the
+	// MCP are marked like those it posts natively.
+	if body, ok := args["body"].(string); ok && body != "" {
+		args["body"] = github.MarkAgentBody(body)
+	}
 
 	var res *mcp.CallToolResult
 	err = retryGithubRateLimit(ctx, func() error {
@@ -0,0 +1,137 @@
+package activities
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"go.temporal.io/sdk/temporal"
+
+	"github.com/ansg191/job-temporal/internal/forge"
+	"github.com/ansg191/job-temporal/internal/github"
+	"github.com/ansg191/job-temporal/internal/keywords"
+	"github.com/ansg191/job-temporal/internal/pdftext"
+)
+
+type KeywordCoverageRequest struct {
+	github.ClientOptions
+	Branch string `json:"branch"`
+	// Target and Purpose are the base and purpose label of
+	// Branch's PR. The other document is built from the open
+	// PR into Target with the other purpose, or from Target
+	// once that PR is merged. Without a Target both are built
+	// from Branch.
+	Target     string `json:"target,omitempty"`
+	Purpose    string `json:"purpose,omitempty"`
+	Builder    string `json:"builder"`
+	Job        string `json:"job"`
+	ResumeFile string `json:"resume_file"`
+	LetterFile string `json:"letter_file"`
+}
+
+// KeywordCoverage compares the skills and keywords in the job
+// description with the text of the resume and cover letter
+// built for the job. It returns the coverage table as
+// markdown.
+func KeywordCoverage(ctx context.Context, req KeywordCoverageRequest) (string, error) {
+	kws := keywords.Extract(req.Job)
+
+	resumeBranch, letterBranch := req.Branch, req.Branch
+	if req.Target != "" {
+		sibling, err := siblingBranch(ctx, req)
+		if err != nil {
+			return "", err
+		}
+		if req.Purpose == purposeLabelCoverLetter {
+			resumeBranch = sibling
+		} else {
+			letterBranch = sibling
+		}
+	}
+
+	resume, err := builtPDFText(ctx, req, resumeBranch, req.ResumeFile)
+	if err != nil {
+		return "", err
+	}
+	// The letter only adds the "letter only" column, so a
+	// broken letter should not hide resume coverage.
+	letter, err := builtPDFText(ctx, req, letterBranch, req.LetterFile)
+	if err != nil {
+		slog.WarnContext(ctx, "keyword coverage without cover letter", "file", req.LetterFile, "error", err)
+		letter = ""
+	}
+
+	return keywords.Coverage(kws, resume, letter).Markdown(), nil
+}
+
+// purposeLabelCoverLetter is the purpose label of cover
+// letter PRs; every other job PR builds the resume.
+const purposeLabelCoverLetter = "cover letter"
+
+// siblingBranch returns the branch the document req's PR
+// does not build comes from.
+func siblingBranch(ctx context.Context, req KeywordCoverageRequest) (string, error) {
+	client, err := forge.New(req.ClientOptions)
+	if err != nil {
+		return "", temporal.NewNonRetryableApplicationError(
+			"failed to create forge client",
+			"ForgeClientError",
+			err,
+		)
+	}
+	prs, err := client.ListPullRequests(ctx)
+	if err != nil {
+		return "", err
+	}
+	return findSiblingBranch(prs, req.Branch, req.Target, req.Purpose), nil
+}
+
+// findSiblingBranch returns the head of the newest open PR
+// into target with a different purpose label than purpose,
+// or target if there is none.
+func findSiblingBranch(prs []github.PullRequestSummary, branch, target, purpose string) string {
+	sibling, number := target, 0
+	for _, pr := range prs {
+		if !pr.Open || pr.Base != target || pr.Head == branch || pr.Number < number {
+			continue
+		}
+		if slices.Contains(pr.Labels, purpose) || !hasPurposeLabel(pr.Labels) {
+			continue
+		}
+		sibling, number = pr.Head, pr.Number
+	}
+	return sibling
+}
+
+func hasPurposeLabel(labels []string) bool {
+	for _, l := range labels {
+		if slices.Contains(github.SupportedPRPurposeLabels, l) {
+			return true
+		}
+	}
+	return false
+}
+
+func builtPDFText(ctx context.Context, req KeywordCoverageRequest, branch, file string) (string, error) {
+	content, err := BuildFinalPDF(ctx, BuildFinalPDFRequest{
+		ClientOptions: req.ClientOptions,
+		Branch:        branch,
+		Builder:       req.Builder,
+		File:          file,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	pages, err := pdftext.Extract(bytes.NewReader(content))
+	if err != nil {
+		return "", err
+	}
+	texts := make([]string, len(pages))
+	for i, page := range pages {
+		texts[i] = page.Text()
+	}
+	return strings.Join(texts, "\n"), nil
+}
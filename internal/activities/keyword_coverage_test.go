@@ -0,0 +1,89 @@
+package activities
+
+import (
+	"testing"
+
+	"github.com/ansg191/job-temporal/internal/github"
+)
+
+func TestFindSiblingBranch(t *testing.T) {
+	t.Parallel()
+
+	pr := func(number int, head, base, label string, open bool) github.PullRequestSummary {
+		return github.PullRequestSummary{
+			Number: number,
+			Head:   head,
+			Base:   base,
+			Labels: []string{label},
+			Open:   open,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		prs     []github.PullRequestSummary
+		branch  string
+		purpose string
+		want    string
+	}{
+		{
+			name:    "no sibling falls back to target",
+			prs:     []github.PullRequestSummary{pr(1, "resume-1", "final", "resume", true)},
+			branch:  "resume-1",
+			purpose: "resume",
+			want:    "final",
+		},
+		{
+			name: "open letter PR into target",
+			prs: []github.PullRequestSummary{
+				pr(1, "resume-1", "final", "resume", true),
+				pr(2, "letter-1", "final", "cover letter", true),
+			},
+			branch:  "resume-1",
+			purpose: "resume",
+			want:    "letter-1",
+		},
+		{
+			name: "open resume PR into target",
+			prs: []github.PullRequestSummary{
+				pr(1, "resume-1", "final", "resume", true),
+				pr(2, "letter-1", "final", "cover letter", true),
+			},
+			branch:  "letter-1",
+			purpose: "cover letter",
+			want:    "resume-1",
+		},
+		{
+			name: "ignores closed and other targets",
+			prs: []github.PullRequestSummary{
+				pr(2, "letter-1", "final", "cover letter", false),
+				pr(3, "letter-2", "other", "cover letter", true),
+				pr(4, "fix", "final", "bug", true),
+			},
+			branch:  "resume-1",
+			purpose: "resume",
+			want:    "final",
+		},
+		{
+			name: "newest sibling wins",
+			prs: []github.PullRequestSummary{
+				pr(5, "letter-2", "final", "cover letter", true),
+				pr(2, "letter-1", "final", "cover letter", true),
+			},
+			branch:  "resume-1",
+			purpose: "resume",
+			want:    "letter-2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := findSiblingBranch(tt.prs, tt.branch, "final", tt.purpose)
+			if got != tt.want {
+				t.Errorf("findSiblingBranch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"go.temporal.io/sdk/temporal"
 
 	"github.com/ansg191/job-temporal/internal/builder"
+	"github.com/ansg191/job-temporal/internal/forge"
 	"github.com/ansg191/job-temporal/internal/git"
 	"github.com/ansg191/job-temporal/internal/github"
 )
@@ -74,7 +75,7 @@ func RenderLayoutReviewPages(ctx context.Context, req RenderLayoutReviewPagesReq
 		)
 	}
 
-	client, err := github.NewClient(req.ClientOptions)
+	client, err := forge.New(req.ClientOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -84,7 +85,7 @@ func RenderLayoutReviewPages(ctx context.Context, req RenderLayoutReviewPagesReq
 		return nil, err
 	}
 
-	repo, err := git.NewGitRepo(ctx, repoRemote)
+	repo, err := git.Open(ctx, repoRemote)
 	if err != nil {
 		return nil, err
 	}
@@ -104,29 +105,30 @@ func RenderLayoutReviewPages(ctx context.Context, req RenderLayoutReviewPagesReq
 	pageSelector := fmt.Sprintf("%d-%d", pageStart, pageEnd)
 	rootFile := path.Join(repo.Path(), req.File)
 
-	b, err := builder.NewBuilder(
+	b, err := newSandboxedBuilder(
 		req.Builder,
-		builder.WithTypstRootFile(rootFile),
-		builder.WithTypstFormat("png"),
-		builder.WithTypstPages(pageSelector),
-		builder.WithTypstPPI(layoutReviewDefaultPPI),
+		builder.WithRootFile(rootFile),
+		builder.WithFormat("png"),
+		builder.WithPages(pageSelector),
+		builder.WithPPI(layoutReviewDefaultPPI),
 		builder.WithPageLimit(0),
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	buildResult, err := b.Build(ctx, repo.Path(), outputPattern)
+	key := newBuildCacheKey(req.ClientOptions, req.Builder, req.File)
+	key.Format = "png"
+	key.Pages = pageSelector
+	key.PPI = layoutReviewDefaultPPI
+	key.Output = filepath.Base(outputPattern)
+
+	buildResult, err := cachedBuild(ctx, key, b, repo, outputPattern)
 	if err != nil {
 		return nil, err
 	}
 	if !buildResult.Success {
-		return nil, temporal.NewNonRetryableApplicationError(
-			"build failed",
-			ErrTypeBuildFailed,
-			nil,
-			buildResult.Errors,
-		)
+		return nil, newBuildFailedError(buildResult)
 	}
 
 	imagePaths, err := filepath.Glob(filepath.Join(tmpDir, "page-*.png"))
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/ansg191/job-temporal/internal/forge"
 	"github.com/ansg191/job-temporal/internal/git"
 	"github.com/ansg191/job-temporal/internal/github"
 )
@@ -39,7 +40,7 @@ type ReadLetterContentRequest struct {
 }
 
 func ReadLetterContent(ctx context.Context, req ReadLetterContentRequest) (string, error) {
-	client, err := github.NewClient(req.ClientOptions)
+	client, err := forge.New(req.ClientOptions)
 	if err != nil {
 		return "", err
 	}
@@ -49,7 +50,7 @@ func ReadLetterContent(ctx context.Context, req ReadLetterContentRequest) (strin
 		return "", err
 	}
 
-	repo, err := git.NewGitRepo(ctx, repoRemote)
+	repo, err := git.Open(ctx, repoRemote)
 	if err != nil {
 		return "", err
 	}
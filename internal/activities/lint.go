@@ -0,0 +1,405 @@
+package activities
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ansg191/job-temporal/internal/builder"
+	"github.com/ansg191/job-temporal/internal/forge"
+	"github.com/ansg191/job-temporal/internal/git"
+	"github.com/ansg191/job-temporal/internal/github"
+)
+
+const (
+	LintCheckFont    = "font"
+	LintCheckWarning = "warning"
+	LintCheckAsset   = "asset"
+	LintCheckLabel   = "label"
+)
+
+var (
+	// Matches a typst font argument, either one family or
+	// a fallback list: font: "Inter" or font: ("Inter", ...)
+	lintTypstFontRe = regexp.MustCompile(`\bfont\s*:\s*("[^"\n]*"|\([^)]*\))`)
+	// Matches the covers field of a font dictionary, whose
+	// string is not a family name.
+	lintTypstCoversRe = regexp.MustCompile(`covers\s*:\s*"[^"]*"`)
+	lintQuotedRe      = regexp.MustCompile(`"([^"\n]*)"`)
+	// Matches typst calls that read a file by path.
+	lintTypstAssetRe = regexp.MustCompile(`\b(?:image|read|json|yaml|toml|csv|xml|cbor|bibliography|plugin)\(\s*"([^"\n]+)"`)
+	// Matches typst includes and non-package imports.
+	lintTypstIncludeRe = regexp.MustCompile(`#?\b(?:include|import)\s+"([^"@\n][^"\n]*)"`)
+	// Matches LaTeX commands that read a file by path.
+	lintLatexAssetRe = regexp.MustCompile(`\\(includegraphics|input|include)(?:\[[^\]]*\])?\{([^}\n]+)\}`)
+)
+
+// lintGraphicsExtensions are tried, in order, for an
+// \includegraphics path without an extension.
+var lintGraphicsExtensions = []string{".pdf", ".png", ".jpg", ".jpeg", ".eps"}
+
+type LintRequest struct {
+	github.ClientOptions
+	Branch string `json:"branch"`
+	// BaseBranch, if set, is the branch whose section labels
+	// must survive on Branch.
+	BaseBranch string `json:"base_branch,omitempty"`
+	Builder    string `json:"builder"`
+	File       string `json:"file"`
+}
+
+// LintFinding is one pre-flight problem. Severity follows
+// the layout review model.
+type LintFinding struct {
+	Check    string `json:"check"`
+	Severity string `json:"severity"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Message  string `json:"message"`
+	FixHint  string `json:"fix_hint"`
+}
+
+func (f LintFinding) GetSeverity() string { return f.Severity }
+
+// String formats the finding as "file:line: message".
+func (f LintFinding) String() string {
+	switch {
+	case f.File != "" && f.Line > 0:
+		return fmt.Sprintf("%s:%d: %s", f.File, f.Line, f.Message)
+	case f.File != "":
+		return f.File + ": " + f.Message
+	default:
+		return f.Message
+	}
+}
+
+type LintOutput struct {
+	Summary  string        `json:"summary"`
+	Findings []LintFinding `json:"findings"`
+}
+
+// Lint checks a branch for problems that compile cleanly
+// but change the output: fonts typst would substitute,
+// compiler warnings, missing assets, and section labels
+// that existed on BaseBranch but are gone. It returns
+// LintOutput as JSON, or ErrTypeBuildFailed if the branch
+// does not build.
+func Lint(ctx context.Context, req LintRequest) (string, error) {
+	if req.Builder == "" {
+		req.Builder = "typst"
+	}
+
+	tmpFile, err := os.CreateTemp(os.TempDir(), "lint-*.pdf")
+	if err != nil {
+		return "", err
+	}
+	if err = tmpFile.Close(); err != nil {
+		return "", err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	result, err := runBuild(ctx, req.ClientOptions, req.Branch, req.Builder, req.File, "", tmpFile.Name())
+	if err != nil {
+		return "", err
+	}
+	if !result.Success {
+		return "", newBuildFailedError(result)
+	}
+
+	client, err := forge.New(req.ClientOptions)
+	if err != nil {
+		return "", err
+	}
+	repoRemote, err := client.GetAuthenticatedRemoteURL(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	repo, err := git.Open(ctx, repoRemote)
+	if err != nil {
+		return "", err
+	}
+	defer repo.Close()
+	if err = repo.SetBranch(ctx, req.Branch); err != nil {
+		return "", err
+	}
+
+	b, err := newSandboxedBuilder(
+		req.Builder,
+		builder.WithRootFile(path.Join(repo.Path(), req.File)),
+		builder.WithPageLimit(0),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	findings := []LintFinding{}
+	if lister, ok := b.(builder.FontLister); ok {
+		families, err := lister.Fonts(ctx)
+		if err != nil {
+			return "", err
+		}
+		fontFindings, err := lintFonts(repo.Path(), families)
+		if err != nil {
+			return "", err
+		}
+		findings = append(findings, fontFindings...)
+	}
+
+	assetFindings, err := lintAssets(repo.Path(), req.Builder)
+	if err != nil {
+		return "", err
+	}
+	findings = append(findings, assetFindings...)
+	findings = append(findings, lintWarnings(result.Diagnostics, findings)...)
+
+	if querier, ok := b.(builder.LabelQuerier); ok && req.BaseBranch != "" && req.BaseBranch != req.Branch {
+		labels, err := querier.QueryAllLabels(ctx, repo.Path())
+		if err != nil {
+			return "", err
+		}
+		baseLabels, err := listBranchLabels(ctx, repoRemote, req.BaseBranch, req.Builder, req.File)
+		if err != nil {
+			// A base that no longer builds has nothing to
+			// compare against.
+			slog.WarnContext(ctx, "Lint skipped label check", "base", req.BaseBranch, "error", err)
+		} else {
+			findings = append(findings, lintLabels(baseLabels, labels, req.File)...)
+		}
+	}
+
+	output := LintOutput{Findings: findings}
+	output.Summary = summarizeLint(output)
+	data, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal lint output: %w", err)
+	}
+	return string(data), nil
+}
+
+// listBranchLabels lists the section labels of file on
+// branch.
+func listBranchLabels(ctx context.Context, repoRemote, branch, builderName, file string) ([]string, error) {
+	repo, err := git.Open(ctx, repoRemote)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.Close()
+	if err = repo.SetBranch(ctx, branch); err != nil {
+		return nil, err
+	}
+
+	querier, err := newLabelQuerier(builderName, path.Join(repo.Path(), file))
+	if err != nil {
+		return nil, err
+	}
+	return querier.QueryAllLabels(ctx, repo.Path())
+}
+
+// walkLintSources calls fn with the path relative to root
+// and content of every file under root with extension ext,
+// skipping hidden directories.
+func walkLintSources(root, ext string, fn func(rel, content string) error) error {
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(p) != ext {
+			return nil
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		return fn(filepath.ToSlash(rel), string(content))
+	})
+}
+
+// lineAt returns the 1-based line of offset in content.
+func lineAt(content string, offset int) int {
+	return strings.Count(content[:offset], "\n") + 1
+}
+
+// lintFonts flags font families named in typst sources that
+// are not among families. Typst substitutes another font for
+// these with only a warning.
+func lintFonts(root string, families []string) ([]LintFinding, error) {
+	known := make(map[string]bool, len(families))
+	for _, f := range families {
+		known[strings.ToLower(f)] = true
+	}
+
+	var findings []LintFinding
+	err := walkLintSources(root, ".typ", func(rel, content string) error {
+		for _, m := range lintTypstFontRe.FindAllStringSubmatchIndex(content, -1) {
+			arg := lintTypstCoversRe.ReplaceAllString(content[m[2]:m[3]], "")
+			for _, q := range lintQuotedRe.FindAllStringSubmatch(arg, -1) {
+				family := q[1]
+				if family == "" || known[strings.ToLower(family)] {
+					continue
+				}
+				findings = append(findings, LintFinding{
+					Check:    LintCheckFont,
+					Severity: "high",
+					File:     rel,
+					Line:     lineAt(content, m[0]),
+					Message:  fmt.Sprintf("unknown font family %q", family),
+					FixHint:  "use an installed font family; typst silently substitutes another font, changing the layout",
+				})
+			}
+		}
+		return nil
+	})
+	return findings, err
+}
+
+// lintAssets flags files the sources read that do not
+// exist. Paths in typst are relative to the reading file,
+// or to the root when they start with "/"; paths in LaTeX
+// are relative to the root.
+func lintAssets(root, builderName string) ([]LintFinding, error) {
+	var findings []LintFinding
+	missing := func(rel, content string, offset int, ref string) {
+		findings = append(findings, LintFinding{
+			Check:    LintCheckAsset,
+			Severity: "high",
+			File:     rel,
+			Line:     lineAt(content, offset),
+			Message:  fmt.Sprintf("referenced file %q not found", ref),
+			FixHint:  "add the file to the repository or fix the path",
+		})
+	}
+
+	if builderName == "latex" {
+		err := walkLintSources(root, ".tex", func(rel, content string) error {
+			for _, m := range lintLatexAssetRe.FindAllStringSubmatchIndex(content, -1) {
+				cmd, ref := content[m[2]:m[3]], strings.TrimSpace(content[m[4]:m[5]])
+				candidates := []string{ref}
+				if filepath.Ext(ref) == "" {
+					if cmd == "includegraphics" {
+						candidates = nil
+						for _, ext := range lintGraphicsExtensions {
+							candidates = append(candidates, ref+ext)
+						}
+					} else {
+						candidates = []string{ref + ".tex"}
+					}
+				}
+				if !slices.ContainsFunc(candidates, func(c string) bool {
+					return fileExists(filepath.Join(root, filepath.FromSlash(c)))
+				}) {
+					missing(rel, content, m[0], ref)
+				}
+			}
+			return nil
+		})
+		return findings, err
+	}
+
+	err := walkLintSources(root, ".typ", func(rel, content string) error {
+		dir := filepath.Dir(filepath.Join(root, filepath.FromSlash(rel)))
+		for _, re := range []*regexp.Regexp{lintTypstAssetRe, lintTypstIncludeRe} {
+			for _, m := range re.FindAllStringSubmatchIndex(content, -1) {
+				ref := content[m[2]:m[3]]
+				if strings.Contains(ref, "://") {
+					continue
+				}
+				target := filepath.Join(dir, filepath.FromSlash(ref))
+				if strings.HasPrefix(ref, "/") {
+					target = filepath.Join(root, filepath.FromSlash(ref))
+				}
+				if !fileExists(target) {
+					missing(rel, content, m[0], ref)
+				}
+			}
+		}
+		return nil
+	})
+	return findings, err
+}
+
+func fileExists(p string) bool {
+	info, err := os.Stat(p)
+	return err == nil && !info.IsDir()
+}
+
+// lintWarnings turns compiler warnings into findings,
+// skipping locations another check already reported.
+func lintWarnings(diags []builder.Diagnostic, reported []LintFinding) []LintFinding {
+	var findings []LintFinding
+	for _, d := range diags {
+		if d.Severity != builder.SeverityWarning {
+			continue
+		}
+		if d.File != "" && slices.ContainsFunc(reported, func(f LintFinding) bool {
+			return f.File == d.File && f.Line == d.Line
+		}) {
+			continue
+		}
+		hint := strings.Join(d.Hints, "; ")
+		if hint == "" {
+			hint = "resolve the warning; the output may not look as intended"
+		}
+		findings = append(findings, LintFinding{
+			Check:    LintCheckWarning,
+			Severity: "medium",
+			File:     d.File,
+			Line:     d.Line,
+			Message:  d.Message,
+			FixHint:  hint,
+		})
+	}
+	return findings
+}
+
+// lintLabels flags labels on the base branch that are
+// missing from the branch. The oracle and layout review
+// locate sections by these labels.
+func lintLabels(base, labels []string, file string) []LintFinding {
+	var findings []LintFinding
+	for _, label := range base {
+		if slices.Contains(labels, label) {
+			continue
+		}
+		findings = append(findings, LintFinding{
+			Check:    LintCheckLabel,
+			Severity: "high",
+			File:     file,
+			Message:  fmt.Sprintf("section label <%s> was removed", label),
+			FixHint:  fmt.Sprintf("restore the <%s> label; section review and cropping depend on it", label),
+		})
+	}
+	return findings
+}
+
+func summarizeLint(output LintOutput) string {
+	if len(output.Findings) == 0 {
+		return "no problems found"
+	}
+	counts := map[string]int{}
+	for _, f := range output.Findings {
+		counts[f.Severity]++
+	}
+	return fmt.Sprintf(
+		"%d finding(s) (%d high, %d medium, %d low)",
+		len(output.Findings), counts["high"], counts["medium"], counts["low"],
+	)
+}
@@ -0,0 +1,131 @@
+package activities
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/ansg191/job-temporal/internal/builder"
+)
+
+func writeLintFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	root := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatalf("MkdirAll() unexpected error: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatalf("WriteFile() unexpected error: %v", err)
+		}
+	}
+	return root
+}
+
+func findingStrings(findings []LintFinding) []string {
+	ret := make([]string, len(findings))
+	for i, f := range findings {
+		ret[i] = f.String()
+	}
+	return ret
+}
+
+func TestLintFonts(t *testing.T) {
+	t.Parallel()
+
+	root := writeLintFiles(t, map[string]string{
+		"resume.typ": "#set text(font: \"Inter\")\n" +
+			"#set text(font: (\n  (name: \"Fira Sans\", covers: \"latin-in-cjk\"),\n  \"libertinus serif\",\n))\n",
+		".git/x.typ": "#set text(font: \"Hidden\")\n",
+	})
+
+	got, err := lintFonts(root, []string{"Libertinus Serif", "Inter"})
+	if err != nil {
+		t.Fatalf("lintFonts() unexpected error: %v", err)
+	}
+	want := []string{`resume.typ:2: unknown font family "Fira Sans"`}
+	if !slices.Equal(findingStrings(got), want) {
+		t.Errorf("lintFonts() = %q, want %q", findingStrings(got), want)
+	}
+}
+
+func TestLintAssets(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		builder string
+		files   map[string]string
+		want    []string
+	}{
+		{
+			name:    "typst",
+			builder: "typst",
+			files: map[string]string{
+				"resume.typ": "#import \"@preview/icons:1.0.0\": *\n" +
+					"#import \"sections/jobs.typ\": jobs\n" +
+					"#image(\"photo.png\")\n" +
+					"#let data = yaml(\"/data/missing.yaml\")\n",
+				"sections/jobs.typ": "#image(\"logo.svg\")\n#image(\"/photo.png\")\n",
+				"photo.png":         "",
+			},
+			want: []string{
+				`resume.typ:4: referenced file "/data/missing.yaml" not found`,
+				`sections/jobs.typ:1: referenced file "logo.svg" not found`,
+			},
+		},
+		{
+			name:    "latex",
+			builder: "latex",
+			files: map[string]string{
+				"resume.tex": "\\input{header}\n\\includegraphics[width=1in]{photo}\n\\include{missing}\n",
+				"header.tex": "",
+				"photo.jpg":  "",
+			},
+			want: []string{`resume.tex:3: referenced file "missing" not found`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := lintAssets(writeLintFiles(t, tt.files), tt.builder)
+			if err != nil {
+				t.Fatalf("lintAssets() unexpected error: %v", err)
+			}
+			if !slices.Equal(findingStrings(got), tt.want) {
+				t.Errorf("lintAssets() = %q, want %q", findingStrings(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestLintWarnings(t *testing.T) {
+	t.Parallel()
+
+	diags := []builder.Diagnostic{
+		{File: "resume.typ", Line: 1, Severity: builder.SeverityWarning, Message: "unknown font family: foo"},
+		{File: "resume.typ", Line: 9, Severity: builder.SeverityWarning, Message: "unused import", Hints: []string{"remove it"}},
+		{File: "resume.typ", Line: 12, Severity: builder.SeverityError, Message: "not a warning"},
+	}
+	reported := []LintFinding{{Check: LintCheckFont, File: "resume.typ", Line: 1}}
+
+	got := lintWarnings(diags, reported)
+	if len(got) != 1 || got[0].String() != "resume.typ:9: unused import" || got[0].FixHint != "remove it" {
+		t.Errorf("lintWarnings() = %+v, want only the unused import", got)
+	}
+}
+
+func TestLintLabels(t *testing.T) {
+	t.Parallel()
+
+	got := lintLabels([]string{"jobs", "skills", "document-end"}, []string{"skills", "document-end", "projects"}, "resume.typ")
+	want := []string{"resume.typ: section label <jobs> was removed"}
+	if !slices.Equal(findingStrings(got), want) {
+		t.Errorf("lintLabels() = %q, want %q", findingStrings(got), want)
+	}
+}
@@ -3,21 +3,24 @@ package activities
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"path"
 
 	"github.com/ansg191/job-temporal/internal/builder"
+	"github.com/ansg191/job-temporal/internal/forge"
 	"github.com/ansg191/job-temporal/internal/git"
 	"github.com/ansg191/job-temporal/internal/github"
 )
 
 type ListLabelsRequest struct {
 	github.ClientOptions
-	Branch string `json:"branch"`
-	File   string `json:"file"`
+	Branch  string `json:"branch"`
+	Builder string `json:"builder"`
+	File    string `json:"file"`
 }
 
 func ListLabels(ctx context.Context, req ListLabelsRequest) (string, error) {
-	client, err := github.NewClient(req.ClientOptions)
+	client, err := forge.New(req.ClientOptions)
 	if err != nil {
 		return "", err
 	}
@@ -27,7 +30,7 @@ func ListLabels(ctx context.Context, req ListLabelsRequest) (string, error) {
 		return "", err
 	}
 
-	repo, err := git.NewGitRepo(ctx, repoRemote)
+	repo, err := git.Open(ctx, repoRemote)
 	if err != nil {
 		return "", err
 	}
@@ -39,7 +42,12 @@ func ListLabels(ctx context.Context, req ListLabelsRequest) (string, error) {
 
 	rootFile := path.Join(repo.Path(), req.File)
 
-	labels, err := builder.QueryAllLabels(ctx, "", rootFile, repo.Path())
+	querier, err := newLabelQuerier(req.Builder, rootFile)
+	if err != nil {
+		return "", err
+	}
+
+	labels, err := querier.QueryAllLabels(ctx, repo.Path())
 	if err != nil {
 		return "", err
 	}
@@ -51,3 +59,27 @@ func ListLabels(ctx context.Context, req ListLabelsRequest) (string, error) {
 
 	return string(result), nil
 }
+
+// newLabelQuerier creates a builder for rootFile that can
+// answer section label queries. An empty builder name
+// defaults to typst.
+func newLabelQuerier(builderName, rootFile string) (builder.LabelQuerier, error) {
+	if builderName == "" {
+		builderName = "typst"
+	}
+
+	b, err := newSandboxedBuilder(
+		builderName,
+		builder.WithRootFile(rootFile),
+		builder.WithPageLimit(0),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	querier, ok := b.(builder.LabelQuerier)
+	if !ok {
+		return nil, fmt.Errorf("builder %q does not support label queries", builderName)
+	}
+	return querier, nil
+}
@@ -0,0 +1,155 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+
+	"go.temporal.io/sdk/temporal"
+
+	"github.com/ansg191/job-temporal/internal/git"
+	"github.com/ansg191/job-temporal/internal/github"
+)
+
+const ErrTypeUnresolvedConflicts = "UnresolvedConflicts"
+
+type CheckMergeRequest struct {
+	github.ClientOptions
+	Branch string `json:"branch"`
+	// Target is the branch trial-merged into Branch.
+	Target string `json:"target"`
+}
+
+// CheckMerge trial-merges Target into Branch and reports the
+// files that conflict.
+func CheckMerge(ctx context.Context, req CheckMergeRequest) (*git.MergeCheck, error) {
+	repo, err := openBranch(ctx, req.ClientOptions, req.Branch)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.Close()
+
+	return repo.CheckMerge(ctx, req.Target)
+}
+
+// FileResolution is the resolved content of a conflicted
+// file.
+type FileResolution struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+type ResolveMergeRequest struct {
+	github.ClientOptions
+	Branch string `json:"branch"`
+	// Head and Theirs are the commits CheckMerge reported.
+	Head        string           `json:"head"`
+	Theirs      string           `json:"theirs"`
+	Resolutions []FileResolution `json:"resolutions"`
+	Message     string           `json:"message"`
+	// Scratch is the branch the merge commit is pushed to.
+	// Branch itself is left unchanged.
+	Scratch string `json:"scratch"`
+	// Builder and Files are built on Scratch to validate the
+	// resolution.
+	Builder string   `json:"builder"`
+	Files   []string `json:"files"`
+}
+
+type ResolveMergeOutput struct {
+	Commit string `json:"commit"`
+	// BuildErrors is the diagnostic report of the first file
+	// that failed to build. It is empty if every file built.
+	BuildErrors []string `json:"build_errors,omitempty"`
+}
+
+// ResolveMerge commits the merge of Theirs into Branch with
+// the given resolutions to Scratch and builds it.
+func ResolveMerge(ctx context.Context, req ResolveMergeRequest) (*ResolveMergeOutput, error) {
+	repo, err := openBranch(ctx, req.ClientOptions, req.Branch)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.Close()
+
+	resolutions := make(map[string]string, len(req.Resolutions))
+	for _, r := range req.Resolutions {
+		resolutions[r.Path] = r.Content
+	}
+	commit, err := repo.ResolveMerge(ctx, req.Head, req.Theirs, resolutions, req.Message, "refs/heads/"+req.Scratch)
+	switch {
+	case errors.Is(err, git.ErrUnresolvedConflicts):
+		return nil, temporal.NewNonRetryableApplicationError(err.Error(), ErrTypeUnresolvedConflicts, err)
+	case errors.Is(err, git.ErrBranchMoved):
+		return nil, temporal.NewNonRetryableApplicationError(err.Error(), ErrTypeBranchMoved, err)
+	case err != nil:
+		return nil, err
+	}
+
+	tmpFile, err := os.CreateTemp(os.TempDir(), "merge-*.pdf")
+	if err != nil {
+		return nil, err
+	}
+	if err = tmpFile.Close(); err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	output := &ResolveMergeOutput{Commit: commit}
+	for _, file := range req.Files {
+		result, err := runBuild(ctx, req.ClientOptions, req.Scratch, req.Builder, file, "", tmpFile.Name())
+		if err != nil {
+			return nil, err
+		}
+		if !result.Success {
+			output.BuildErrors = append([]string{file + " failed to build:"}, result.Report()...)
+			break
+		}
+	}
+	return output, nil
+}
+
+type ApplyMergeRequest struct {
+	github.ClientOptions
+	Branch  string `json:"branch"`
+	Scratch string `json:"scratch"`
+	// Commit is the validated merge commit on Scratch.
+	Commit string `json:"commit"`
+}
+
+// ApplyMerge fast-forwards Branch to the merge commit on
+// Scratch and deletes Scratch.
+func ApplyMerge(ctx context.Context, req ApplyMergeRequest) error {
+	repo, err := openBranch(ctx, req.ClientOptions, req.Branch)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	if err = repo.FastForward(ctx, req.Scratch, req.Commit); err != nil {
+		return err
+	}
+	if err = repo.DeleteRemoteBranch(ctx, req.Scratch); err != nil {
+		slog.WarnContext(ctx, "failed to delete scratch branch", "branch", req.Scratch, "error", err)
+	}
+	return nil
+}
+
+type DeleteMergeScratchRequest struct {
+	github.ClientOptions
+	Branch  string `json:"branch"`
+	Scratch string `json:"scratch"`
+}
+
+// DeleteMergeScratch deletes the scratch branch of merges
+// that were not applied.
+func DeleteMergeScratch(ctx context.Context, req DeleteMergeScratchRequest) error {
+	repo, err := openBranch(ctx, req.ClientOptions, req.Branch)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	return repo.DeleteRemoteBranch(ctx, req.Scratch)
+}
@@ -13,6 +13,7 @@ import (
 	"go.temporal.io/sdk/temporal"
 
 	"github.com/ansg191/job-temporal/internal/builder"
+	"github.com/ansg191/job-temporal/internal/forge"
 	"github.com/ansg191/job-temporal/internal/git"
 	"github.com/ansg191/job-temporal/internal/github"
 )
@@ -51,7 +52,7 @@ type OracleRenderedPage struct {
 //
 // Pipeline:
 //  1. Clone repo and checkout branch
-//  2. Run `typst query` to get the label's bounding box
+//  2. Query the builder for the label's bounding box
 //  3. Compute per-page crop rectangles from the bbox
 //  4. Render the relevant pages as full-page PNGs
 //  5. Crop each PNG to the section's bounds
@@ -82,7 +83,7 @@ func RenderOraclePages(ctx context.Context, req OracleRenderRequest) ([]OracleRe
 
 	// --- Step 1: Clone repo and checkout branch ---
 
-	client, err := github.NewClient(req.ClientOptions)
+	client, err := forge.New(req.ClientOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -92,7 +93,7 @@ func RenderOraclePages(ctx context.Context, req OracleRenderRequest) ([]OracleRe
 		return nil, err
 	}
 
-	repo, err := git.NewGitRepo(ctx, repoRemote)
+	repo, err := git.Open(ctx, repoRemote)
 	if err != nil {
 		return nil, err
 	}
@@ -105,10 +106,15 @@ func RenderOraclePages(ctx context.Context, req OracleRenderRequest) ([]OracleRe
 	rootFile := path.Join(repo.Path(), req.File)
 
 	// --- Step 2: Query label bounding box ---
-	// `typst query` returns the label's position (page, x,
+	// The builder returns the label's position (page, x,
 	// y) and measured size (width, height) in points.
 
-	bbox, err := builder.QueryLabelBBox(ctx, "", rootFile, repo.Path(), req.Label)
+	querier, err := newLabelQuerier(req.Builder, rootFile)
+	if err != nil {
+		return nil, err
+	}
+
+	bbox, err := querier.QueryLabelBBox(ctx, repo.Path(), req.Label)
 	if err != nil {
 		return nil, temporal.NewNonRetryableApplicationError(
 			"invalid render arguments",
@@ -173,29 +179,30 @@ func RenderOraclePages(ctx context.Context, req OracleRenderRequest) ([]OracleRe
 	outputPattern := filepath.Join(tmpDir, "page-{0p}.png")
 	pageSelector := fmt.Sprintf("%d-%d", pageStart, pageEnd)
 
-	b, err := builder.NewBuilder(
+	b, err := newSandboxedBuilder(
 		req.Builder,
-		builder.WithTypstRootFile(rootFile),
-		builder.WithTypstFormat("png"),
-		builder.WithTypstPages(pageSelector),
-		builder.WithTypstPPI(oracleDefaultPPI),
+		builder.WithRootFile(rootFile),
+		builder.WithFormat("png"),
+		builder.WithPages(pageSelector),
+		builder.WithPPI(oracleDefaultPPI),
 		builder.WithPageLimit(0),
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	buildResult, err := b.Build(ctx, repo.Path(), outputPattern)
+	key := newBuildCacheKey(req.ClientOptions, req.Builder, req.File)
+	key.Format = "png"
+	key.Pages = pageSelector
+	key.PPI = oracleDefaultPPI
+	key.Output = filepath.Base(outputPattern)
+
+	buildResult, err := cachedBuild(ctx, key, b, repo, outputPattern)
 	if err != nil {
 		return nil, err
 	}
 	if !buildResult.Success {
-		return nil, temporal.NewNonRetryableApplicationError(
-			"build failed",
-			ErrTypeBuildFailed,
-			nil,
-			buildResult.Errors,
-		)
+		return nil, newBuildFailedError(buildResult)
 	}
 
 	imagePaths, err := filepath.Glob(filepath.Join(tmpDir, "page-*.png"))
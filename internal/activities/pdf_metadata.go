@@ -0,0 +1,268 @@
+package activities
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.temporal.io/sdk/temporal"
+
+	"github.com/ansg191/job-temporal/internal/forge"
+	"github.com/ansg191/job-temporal/internal/git"
+	"github.com/ansg191/job-temporal/internal/github"
+	"github.com/ansg191/job-temporal/internal/keywords"
+	"github.com/ansg191/job-temporal/internal/pdfmeta"
+	"github.com/ansg191/job-temporal/internal/pdftext"
+)
+
+// maxPDFKeywords caps the keywords written to document
+// metadata; portals display them, if at all, in one line.
+const maxPDFKeywords = 15
+
+const ErrTypeFinalizePDFFailed = "FinalizePDFFailed"
+
+type FinalizePDFRequest struct {
+	github.ClientOptions
+	Branch  string `json:"branch"`
+	Builder string `json:"builder"`
+	File    string `json:"file"`
+	Job     string `json:"job"`
+	Content []byte `json:"content"`
+}
+
+type FinalizePDFOutput struct {
+	Content  []byte `json:"content"`
+	Filename string `json:"filename"`
+}
+
+// FinalizePDF stamps a built PDF with its title, author,
+// subject and keywords, and names it for download. The
+// author comes from the person file in the repository,
+// falling back to the name printed at the top of the
+// document; the role and company come from the job
+// description headings.
+func FinalizePDF(ctx context.Context, req FinalizePDFRequest) (*FinalizePDFOutput, error) {
+	pages, err := pdftext.Extract(bytes.NewReader(req.Content))
+	if err != nil {
+		return nil, temporal.NewNonRetryableApplicationError(
+			"failed to extract pdf text",
+			ErrTypeFinalizePDFFailed,
+			err,
+		)
+	}
+
+	name, err := repoPersonName(ctx, req.ClientOptions, req.Branch, req.Builder)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to read person name from repository", "branch", req.Branch, "error", err)
+	}
+	if name == "" && len(pages) > 0 {
+		name = extractATSName(pages[0])
+	}
+
+	company, role := jobHeadings(req.Job)
+	kind := documentKind(req.File)
+
+	meta := pdfmeta.Metadata{
+		Title:    joinNonEmpty(" – ", name, kind),
+		Author:   name,
+		Subject:  jobSubject(company, role),
+		Keywords: documentKeywords(req.Job, pages),
+	}
+	content, err := pdfmeta.Apply(req.Content, meta, time.Now())
+	if err != nil {
+		return nil, temporal.NewNonRetryableApplicationError(
+			"failed to set pdf metadata",
+			ErrTypeFinalizePDFFailed,
+			err,
+		)
+	}
+
+	return &FinalizePDFOutput{
+		Content:  content,
+		Filename: pdfFilename(name, company, kind),
+	}, nil
+}
+
+func repoPersonName(ctx context.Context, clientOpts github.ClientOptions, branch, builderName string) (string, error) {
+	client, err := forge.New(clientOpts)
+	if err != nil {
+		return "", err
+	}
+	repoRemote, err := client.GetAuthenticatedRemoteURL(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	repo, err := git.Open(ctx, repoRemote)
+	if err != nil {
+		return "", err
+	}
+	defer repo.Close()
+
+	if err = repo.SetBranch(ctx, branch); err != nil {
+		return "", err
+	}
+
+	file := "person.typ"
+	if builderName == "latex" {
+		file = "person.tex"
+	}
+	content, err := repo.GetFile(ctx, file)
+	if err != nil {
+		return "", err
+	}
+	return parsePersonName(content), nil
+}
+
+var (
+	personFullNameRe  = regexp.MustCompile(`(?m)^\s*#?(?:let\s+)?(?:full[-_]?)?name\s*[:=]\s*"([^"]+)"`)
+	personFirstNameRe = regexp.MustCompile(`(?m)^\s*#?(?:let\s+)?first[-_]?name\s*[:=]\s*"([^"]+)"`)
+	personLastNameRe  = regexp.MustCompile(`(?m)^\s*#?(?:let\s+)?last[-_]?name\s*[:=]\s*"([^"]+)"`)
+	latexNameRe       = regexp.MustCompile(`\\(?:name|author)\{([^}]*)\}(?:\{([^}]*)\})?`)
+)
+
+// parsePersonName finds the applicant's name in a typst or
+// LaTeX person file, such as `#let name = "Jane Doe"`,
+// `first-name: "Jane"` with `last-name: "Doe"`, or
+// `\name{Jane}{Doe}`.
+func parsePersonName(content string) string {
+	if m := personFullNameRe.FindStringSubmatch(content); m != nil {
+		return strings.TrimSpace(m[1])
+	}
+	first := personFirstNameRe.FindStringSubmatch(content)
+	last := personLastNameRe.FindStringSubmatch(content)
+	if first != nil || last != nil {
+		var parts []string
+		if first != nil {
+			parts = append(parts, first[1])
+		}
+		if last != nil {
+			parts = append(parts, last[1])
+		}
+		return strings.Join(strings.Fields(strings.Join(parts, " ")), " ")
+	}
+	if m := latexNameRe.FindStringSubmatch(content); m != nil {
+		return strings.Join(strings.Fields(m[1]+" "+m[2]), " ")
+	}
+	return ""
+}
+
+// jobHeadings returns the company and role from the "# "
+// and "## " headings that lead a scraped job description.
+func jobHeadings(job string) (company, role string) {
+	for _, line := range strings.Split(job, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "### "):
+			return company, role
+		case strings.HasPrefix(line, "## "):
+			if role == "" {
+				role = strings.TrimSpace(strings.TrimPrefix(line, "## "))
+			}
+		case strings.HasPrefix(line, "# "):
+			if company == "" {
+				company = strings.TrimSpace(strings.TrimPrefix(line, "# "))
+			}
+		}
+	}
+	return company, role
+}
+
+func jobSubject(company, role string) string {
+	switch {
+	case role != "" && company != "":
+		return role + " at " + company
+	case role != "":
+		return role
+	default:
+		return company
+	}
+}
+
+func documentKind(file string) string {
+	if strings.HasPrefix(file, "cover_letter") {
+		return "Cover Letter"
+	}
+	return "Resume"
+}
+
+// documentKeywords lists the job keywords the document
+// actually mentions, required ones first.
+func documentKeywords(job string, pages []pdftext.Page) []string {
+	texts := make([]string, len(pages))
+	for i, page := range pages {
+		texts[i] = page.Text()
+	}
+	report := keywords.Coverage(keywords.Extract(job), strings.Join(texts, "\n"), "")
+
+	var ret []string
+	for _, row := range report.Rows {
+		if row.Status == keywords.StatusMatched && len(ret) < maxPDFKeywords {
+			ret = append(ret, row.Keyword)
+		}
+	}
+	return ret
+}
+
+// pdfFilename builds a download name such as
+// "Jane-Doe-Acme-Resume.pdf", reduced to ASCII letters,
+// digits and hyphens so every browser and portal keeps it.
+func pdfFilename(name, company, kind string) string {
+	var parts []string
+	for _, s := range []string{name, company, kind} {
+		if part := filenamePart(s); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "-") + ".pdf"
+}
+
+func filenamePart(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range latinFolder.Replace(s) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			hyphen = false
+			b.WriteRune(r)
+		case r == '\'' || r == '’':
+			// "O'Brien" reads better as "OBrien" than "O-Brien".
+		default:
+			hyphen = true
+		}
+	}
+	return b.String()
+}
+
+// latinFolder drops the accents of common Latin letters so
+// names like "José Müller" keep their letters in filenames.
+var latinFolder = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ä", "a", "ã", "a", "å", "a", "æ", "ae",
+	"Á", "A", "À", "A", "Â", "A", "Ä", "A", "Ã", "A", "Å", "A", "Æ", "AE",
+	"ç", "c", "Ç", "C", "ð", "d", "Ð", "D",
+	"é", "e", "è", "e", "ê", "e", "ë", "e", "É", "E", "È", "E", "Ê", "E", "Ë", "E",
+	"í", "i", "ì", "i", "î", "i", "ï", "i", "Í", "I", "Ì", "I", "Î", "I", "Ï", "I",
+	"ñ", "n", "Ñ", "N",
+	"ó", "o", "ò", "o", "ô", "o", "ö", "o", "õ", "o", "ø", "o", "œ", "oe",
+	"Ó", "O", "Ò", "O", "Ô", "O", "Ö", "O", "Õ", "O", "Ø", "O", "Œ", "OE",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u", "Ú", "U", "Ù", "U", "Û", "U", "Ü", "U",
+	"ý", "y", "ÿ", "y", "Ý", "Y", "ß", "ss", "þ", "th", "Þ", "Th",
+	"ł", "l", "Ł", "L", "š", "s", "Š", "S", "ž", "z", "Ž", "Z", "č", "c", "Č", "C",
+)
+
+func joinNonEmpty(sep string, parts ...string) string {
+	var ret []string
+	for _, p := range parts {
+		if p != "" {
+			ret = append(ret, p)
+		}
+	}
+	return strings.Join(ret, sep)
+}
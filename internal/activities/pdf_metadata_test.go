@@ -0,0 +1,73 @@
+package activities
+
+import "testing"
+
+func TestParsePersonName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"typst let", "#let name = \"Jane Doe\"\n#let email = \"jane@example.com\"", "Jane Doe"},
+		{"typst dict", "#let person = (\n  name: \"Jane Doe\",\n  email: \"jane@example.com\",\n)", "Jane Doe"},
+		{"first and last", "#let first-name = \"Jane\"\n#let last-name = \"Doe\"", "Jane Doe"},
+		{"latex moderncv", "\\name{Jane}{Doe}\n\\email{jane@example.com}", "Jane Doe"},
+		{"latex author", "\\author{Jane Doe}", "Jane Doe"},
+		{"ignores other names", "#let company-name = \"Acme\"", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := parsePersonName(tt.content); got != tt.want {
+				t.Errorf("parsePersonName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobHeadings(t *testing.T) {
+	t.Parallel()
+
+	job := "# Acme Corp\n\n## Senior Backend Engineer\n\n- Seniority level: Mid\n\n### Description:\n\n## Not a role\n"
+	company, role := jobHeadings(job)
+	if company != "Acme Corp" || role != "Senior Backend Engineer" {
+		t.Errorf("jobHeadings() = %q, %q, want %q, %q", company, role, "Acme Corp", "Senior Backend Engineer")
+	}
+	if got := jobSubject(company, role); got != "Senior Backend Engineer at Acme Corp" {
+		t.Errorf("jobSubject() = %q", got)
+	}
+}
+
+func TestPDFFilename(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name, person, company, kind string
+		want                        string
+	}{
+		{"plain", "Jane Doe", "Acme", "Resume", "Jane-Doe-Acme-Resume.pdf"},
+		{"accents and punctuation", "José O'Brien", "Müller & Söhne, Inc.", "Cover Letter", "Jose-OBrien-Muller-Sohne-Inc-Cover-Letter.pdf"},
+		{"missing parts", "", "", "Resume", "Resume.pdf"},
+		{"non latin dropped", "李 Wei", "Acme", "Resume", "Wei-Acme-Resume.pdf"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := pdfFilename(tt.person, tt.company, tt.kind); got != tt.want {
+				t.Errorf("pdfFilename() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContentDisposition(t *testing.T) {
+	t.Parallel()
+
+	got := contentDisposition("Jane-Doe-Acme-Resume.pdf")
+	want := `inline; filename="Jane-Doe-Acme-Resume.pdf"; filename*=UTF-8''Jane-Doe-Acme-Resume.pdf`
+	if got != want {
+		t.Errorf("contentDisposition() = %q, want %q", got, want)
+	}
+}
@@ -16,16 +16,35 @@ import (
 	"github.com/google/uuid"
 	"go.temporal.io/sdk/temporal"
 
+	"github.com/ansg191/job-temporal/internal/builder"
+	"github.com/ansg191/job-temporal/internal/export"
 	"github.com/ansg191/job-temporal/internal/github"
 )
 
 const ErrTypeBuildFailed = "BuildFailed"
 
+// newBuildFailedError wraps a failed build as a
+// non-retryable ErrTypeBuildFailed error. Its details are
+// the result's diagnostic report, one entry per problem,
+// followed by the diagnostics themselves.
+func newBuildFailedError(result *builder.BuildResult) error {
+	return temporal.NewNonRetryableApplicationError(
+		"build failed",
+		ErrTypeBuildFailed,
+		nil,
+		result.Report(),
+		result.Diagnostics,
+	)
+}
+
 type BuildFinalPDFRequest struct {
 	github.ClientOptions
 	Branch  string `json:"branch"`
 	Builder string `json:"builder"`
 	File    string `json:"file"`
+	// PDFStandard optionally requests a conforming PDF, such
+	// as "a-2b" for portals that require PDF/A.
+	PDFStandard string `json:"pdf_standard,omitempty"`
 }
 
 func BuildFinalPDF(ctx context.Context, req BuildFinalPDFRequest) ([]byte, error) {
@@ -38,17 +57,12 @@ func BuildFinalPDF(ctx context.Context, req BuildFinalPDFRequest) ([]byte, error
 	}
 	defer os.Remove(tmpFile.Name())
 
-	buildResult, err := runBuild(ctx, req.ClientOptions, req.Branch, req.Builder, req.File, tmpFile.Name())
+	buildResult, err := runBuild(ctx, req.ClientOptions, req.Branch, req.Builder, req.File, req.PDFStandard, tmpFile.Name())
 	if err != nil {
 		return nil, err
 	}
 	if !buildResult.Success {
-		return nil, temporal.NewNonRetryableApplicationError(
-			"build failed",
-			ErrTypeBuildFailed,
-			nil,
-			buildResult.Errors,
-		)
+		return nil, newBuildFailedError(buildResult)
 	}
 
 	return os.ReadFile(tmpFile.Name())
@@ -56,6 +70,9 @@ func BuildFinalPDF(ctx context.Context, req BuildFinalPDFRequest) ([]byte, error
 
 type UploadPDFRequest struct {
 	Content []byte `json:"content"`
+	// Filename, if set, names the download and the last
+	// segment of the artifact URL in place of a bare UUID.
+	Filename string `json:"filename,omitempty"`
 }
 
 func UploadPDF(ctx context.Context, req UploadPDFRequest) (string, error) {
@@ -65,7 +82,13 @@ func UploadPDF(ctx context.Context, req UploadPDFRequest) (string, error) {
 	}
 
 	key := uuid.NewString() + ".pdf"
-	if err = uploadBytesToR2(ctx, r2cfg, key, req.Content); err != nil {
+	var disposition string
+	if req.Filename != "" {
+		// Keep the UUID as a directory so names never collide.
+		key = uuid.NewString() + "/" + req.Filename
+		disposition = contentDisposition(req.Filename)
+	}
+	if err = putR2Object(ctx, r2cfg, key, req.Content, "application/pdf", disposition); err != nil {
 		return "", err
 	}
 
@@ -92,12 +115,20 @@ func DeletePDFByURL(ctx context.Context, req DeletePDFByURLRequest) error {
 		return err
 	}
 
-	_, err = s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
-		Bucket: aws.String(r2cfg.Bucket),
-		Key:    aws.String(key),
-	})
-	if err != nil {
-		return fmt.Errorf("failed to delete r2 object %q: %w", key, err)
+	// Exports live beside the PDF; deleting one that was
+	// never uploaded is a no-op.
+	keys := []string{key}
+	for _, format := range export.Formats {
+		keys = append(keys, exportObjectKey(key, format))
+	}
+	for _, key := range keys {
+		_, err = s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String(r2cfg.Bucket),
+			Key:    aws.String(key),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete r2 object %q: %w", key, err)
+		}
 	}
 	return nil
 }
@@ -128,25 +159,42 @@ func loadR2Config() (*r2Config, error) {
 	return cfg, nil
 }
 
-func uploadBytesToR2(ctx context.Context, cfg *r2Config, key string, content []byte) error {
-	return uploadBytesToR2WithContentType(ctx, cfg, key, content, "application/pdf")
+func uploadBytesToR2WithContentType(ctx context.Context, cfg *r2Config, key string, content []byte, contentType string) error {
+	return putR2Object(ctx, cfg, key, content, contentType, "")
 }
 
-func uploadBytesToR2WithContentType(ctx context.Context, cfg *r2Config, key string, content []byte, contentType string) error {
+func putR2Object(ctx context.Context, cfg *r2Config, key string, content []byte, contentType, disposition string) error {
 	s3Client, err := newR2S3Client(ctx, cfg.Endpoint)
 	if err != nil {
 		return err
 	}
 
-	_, err = s3Client.PutObject(ctx, &s3.PutObjectInput{
+	input := &s3.PutObjectInput{
 		Bucket:      aws.String(cfg.Bucket),
 		Key:         aws.String(key),
 		Body:        bytes.NewReader(content),
 		ContentType: aws.String(contentType),
-	})
+	}
+	if disposition != "" {
+		input.ContentDisposition = aws.String(disposition)
+	}
+	_, err = s3Client.PutObject(ctx, input)
 	return err
 }
 
+// contentDisposition opens the PDF in the browser while
+// naming it for download. The RFC 5987 filename* form
+// carries names the plain quoted form cannot.
+func contentDisposition(filename string) string {
+	plain := strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e || r == '"' || r == '\\' {
+			return '_'
+		}
+		return r
+	}, filename)
+	return fmt.Sprintf(`inline; filename="%s"; filename*=UTF-8''%s`, plain, url.PathEscape(filename))
+}
+
 func newR2S3Client(ctx context.Context, endpoint string) (*s3.Client, error) {
 	awsCfg, err := awsconfig.LoadDefaultConfig(ctx)
 	if err != nil {
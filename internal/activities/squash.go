@@ -0,0 +1,69 @@
+package activities
+
+import (
+	"context"
+	"errors"
+
+	"go.temporal.io/sdk/temporal"
+
+	"github.com/ansg191/job-temporal/internal/git"
+	"github.com/ansg191/job-temporal/internal/github"
+)
+
+const (
+	ErrTypeInvalidCommitGroups = "InvalidCommitGroups"
+	ErrTypeBranchMoved         = "BranchMoved"
+)
+
+// backupRefPrefix namespaces the refs keeping a branch's
+// history from before it was regrouped.
+const backupRefPrefix = "refs/backup/"
+
+type BranchHistoryRequest struct {
+	github.ClientOptions
+	Branch string `json:"branch"`
+	Base   string `json:"base"`
+}
+
+// BranchHistory lists Branch's commits since it forked from
+// Base.
+func BranchHistory(ctx context.Context, req BranchHistoryRequest) (*git.BranchHistory, error) {
+	repo, err := openBranch(ctx, req.ClientOptions, req.Branch)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.Close()
+
+	return repo.BranchHistory(ctx, req.Base)
+}
+
+type RegroupCommitsRequest struct {
+	github.ClientOptions
+	Branch string `json:"branch"`
+	Base   string `json:"base"`
+	// Head is the branch head the groups were made for.
+	Head   string            `json:"head"`
+	Groups []git.CommitGroup `json:"groups"`
+}
+
+// RegroupCommits rewrites Branch's commits since Base as one
+// commit per group and force-pushes it, keeping the old
+// history at refs/backup/<branch>. It returns the new head.
+func RegroupCommits(ctx context.Context, req RegroupCommitsRequest) (string, error) {
+	repo, err := openBranch(ctx, req.ClientOptions, req.Branch)
+	if err != nil {
+		return "", err
+	}
+	defer repo.Close()
+
+	head, err := repo.RegroupCommits(ctx, req.Base, req.Head, req.Groups, backupRefPrefix+req.Branch)
+	switch {
+	case errors.Is(err, git.ErrInvalidCommitGroups):
+		return "", temporal.NewNonRetryableApplicationError(err.Error(), ErrTypeInvalidCommitGroups, err)
+	case errors.Is(err, git.ErrBranchMoved):
+		return "", temporal.NewNonRetryableApplicationError(err.Error(), ErrTypeBranchMoved, err)
+	case err != nil:
+		return "", err
+	}
+	return head, nil
+}
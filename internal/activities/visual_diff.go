@@ -0,0 +1,241 @@
+package activities
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/ansg191/job-temporal/internal/builder"
+	"github.com/ansg191/job-temporal/internal/forge"
+	"github.com/ansg191/job-temporal/internal/git"
+	"github.com/ansg191/job-temporal/internal/github"
+	"github.com/ansg191/job-temporal/internal/pixeldiff"
+)
+
+// visualDiffPPI keeps overlays legible in a PR comment
+// without uploading print-resolution images.
+const visualDiffPPI = 96
+
+type VisualDiffRequest struct {
+	github.ClientOptions
+	BaseBranch string `json:"base_branch"`
+	HeadBranch string `json:"head_branch"`
+	Builder    string `json:"builder"`
+	File       string `json:"file"`
+}
+
+type VisualDiffRegion struct {
+	// Location is a coarse position such as "top left".
+	Location string `json:"location"`
+	// X, Y, Width and Height are in points from the top left
+	// of the page.
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+type VisualDiffPage struct {
+	Page           int                `json:"page"`
+	ChangedPercent float64            `json:"changed_percent"`
+	Regions        []VisualDiffRegion `json:"regions"`
+	OverlayURL     string             `json:"overlay_url"`
+}
+
+type VisualDiffOutput struct {
+	BasePages int `json:"base_pages"`
+	HeadPages int `json:"head_pages"`
+	// Pages lists only the pages that changed.
+	Pages []VisualDiffPage `json:"pages"`
+}
+
+// VisualDiff renders File from both branches to PNG, diffs
+// them page by page and uploads a highlighted overlay of
+// each changed page.
+func VisualDiff(ctx context.Context, req VisualDiffRequest) (*VisualDiffOutput, error) {
+	if req.Builder == "" {
+		req.Builder = "typst"
+	}
+
+	client, err := forge.New(req.ClientOptions)
+	if err != nil {
+		return nil, err
+	}
+	repoRemote, err := client.GetAuthenticatedRemoteURL(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	basePages, err := renderBranchPages(ctx, req.ClientOptions, repoRemote, req.BaseBranch, req.Builder, req.File)
+	if err != nil {
+		return nil, fmt.Errorf("render %s: %w", req.BaseBranch, err)
+	}
+	headPages, err := renderBranchPages(ctx, req.ClientOptions, repoRemote, req.HeadBranch, req.Builder, req.File)
+	if err != nil {
+		return nil, fmt.Errorf("render %s: %w", req.HeadBranch, err)
+	}
+
+	r2cfg, err := loadR2Config()
+	if err != nil {
+		return nil, err
+	}
+
+	output := &VisualDiffOutput{BasePages: len(basePages), HeadPages: len(headPages)}
+	for i := range max(len(basePages), len(headPages)) {
+		var base, head image.Image
+		if i < len(basePages) {
+			base = basePages[i]
+		}
+		if i < len(headPages) {
+			head = headPages[i]
+		}
+
+		result := pixeldiff.Diff(base, head, pixeldiff.Options{})
+		if !result.Changed() {
+			continue
+		}
+
+		var buf bytes.Buffer
+		if err = png.Encode(&buf, result.Overlay); err != nil {
+			return nil, fmt.Errorf("encode overlay: %w", err)
+		}
+		key := "visual-diff/" + uuid.NewString() + ".png"
+		if err = uploadBytesToR2WithContentType(ctx, r2cfg, key, buf.Bytes(), "image/png"); err != nil {
+			return nil, err
+		}
+
+		output.Pages = append(output.Pages, VisualDiffPage{
+			Page:           i + 1,
+			ChangedPercent: 100 * result.ChangedFraction(),
+			Regions:        visualDiffRegions(result.Regions, result.Overlay.Bounds()),
+			OverlayURL:     strings.TrimRight(r2cfg.PublicBaseURL, "/") + "/" + key,
+		})
+	}
+	return output, nil
+}
+
+// renderBranchPages renders every page of file on branch.
+func renderBranchPages(
+	ctx context.Context,
+	clientOpts github.ClientOptions,
+	repoRemote string,
+	branch string,
+	builderName string,
+	file string,
+) ([]image.Image, error) {
+	repo, err := git.Open(ctx, repoRemote)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.Close()
+
+	if err = repo.SetBranch(ctx, branch); err != nil {
+		return nil, err
+	}
+
+	tmpDir, err := os.MkdirTemp(os.TempDir(), "visual-diff-*.d")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	outputPattern := filepath.Join(tmpDir, "page-{0p}.png")
+	b, err := newSandboxedBuilder(
+		builderName,
+		builder.WithRootFile(path.Join(repo.Path(), file)),
+		builder.WithFormat("png"),
+		builder.WithPPI(visualDiffPPI),
+		builder.WithPageLimit(0),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	key := newBuildCacheKey(clientOpts, builderName, file)
+	key.Format = "png"
+	key.PPI = visualDiffPPI
+	key.Output = filepath.Base(outputPattern)
+
+	result, err := cachedBuild(ctx, key, b, repo, outputPattern)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Success {
+		return nil, newBuildFailedError(result)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(tmpDir, "page-*.png"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	pages := make([]image.Image, 0, len(paths))
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("decode %s: %w", filepath.Base(p), err)
+		}
+		pages = append(pages, img)
+	}
+	return pages, nil
+}
+
+// visualDiffRegions converts pixel regions to points and
+// names where on the page each one sits.
+func visualDiffRegions(regions []image.Rectangle, page image.Rectangle) []VisualDiffRegion {
+	const ptPerPx = 72.0 / visualDiffPPI
+	ret := make([]VisualDiffRegion, len(regions))
+	for i, r := range regions {
+		ret[i] = VisualDiffRegion{
+			Location: pageLocation(r, page),
+			X:        float64(r.Min.X) * ptPerPx,
+			Y:        float64(r.Min.Y) * ptPerPx,
+			Width:    float64(r.Dx()) * ptPerPx,
+			Height:   float64(r.Dy()) * ptPerPx,
+		}
+	}
+	return ret
+}
+
+func pageLocation(r, page image.Rectangle) string {
+	if page.Dx() == 0 || page.Dy() == 0 {
+		return ""
+	}
+	// Regions spanning most of the width are lines of body
+	// text; only name the column for narrower ones.
+	cy := float64(r.Min.Y+r.Max.Y) / 2 / float64(page.Dy())
+	vertical := "middle"
+	switch {
+	case cy < 1.0/3:
+		vertical = "top"
+	case cy >= 2.0/3:
+		vertical = "bottom"
+	}
+	if float64(r.Dx()) > 0.6*float64(page.Dx()) {
+		return vertical
+	}
+
+	cx := float64(r.Min.X+r.Max.X) / 2 / float64(page.Dx())
+	horizontal := "center"
+	switch {
+	case cx < 1.0/3:
+		horizontal = "left"
+	case cx >= 2.0/3:
+		horizontal = "right"
+	}
+	return vertical + " " + horizontal
+}
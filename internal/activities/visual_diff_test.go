@@ -0,0 +1,26 @@
+package activities
+
+import (
+	"image"
+	"testing"
+)
+
+func TestPageLocation(t *testing.T) {
+	t.Parallel()
+
+	page := image.Rect(0, 0, 816, 1056)
+	tests := []struct {
+		region image.Rectangle
+		want   string
+	}{
+		{image.Rect(40, 40, 780, 60), "top"},
+		{image.Rect(40, 500, 200, 540), "middle left"},
+		{image.Rect(600, 900, 780, 1000), "bottom right"},
+		{image.Rect(350, 100, 450, 140), "top center"},
+	}
+	for _, tt := range tests {
+		if got := pageLocation(tt.region, page); got != tt.want {
+			t.Errorf("pageLocation(%v) = %q, want %q", tt.region, got, tt.want)
+		}
+	}
+}
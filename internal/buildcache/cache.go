@@ -0,0 +1,193 @@
+// Package buildcache stores compiled documents keyed by the
+// commit and options they were built from, so repeat builds
+// of an unchanged commit skip the clone and compile.
+package buildcache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ansg191/job-temporal/internal/builder"
+)
+
+// ErrNotFound is returned by a Store that has no entry for
+// a hash.
+var ErrNotFound = errors.New("build cache entry not found")
+
+// Key identifies a build. Two builds with equal keys produce
+// identical output.
+type Key struct {
+	Repo      string `json:"repo"`
+	Commit    string `json:"commit"`
+	RootFile  string `json:"root_file"`
+	Builder   string `json:"builder"`
+	Format    string `json:"format"`
+	Pages     string `json:"pages"`
+	PPI       int    `json:"ppi"`
+	PageLimit int    `json:"page_limit"`
+	// PDFStandard is the requested conformance, e.g. "a-2b".
+	PDFStandard string `json:"pdf_standard"`
+	// Output is the file name pattern for multi-file
+	// formats, e.g. "page-{0p}.png". It is empty for PDFs.
+	Output string `json:"output"`
+}
+
+// Hash returns the hex SHA-256 of the key.
+func (k Key) Hash() string {
+	data, _ := json.Marshal(k)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Store is a byte store addressed by key hash.
+type Store interface {
+	Get(ctx context.Context, hash string) ([]byte, error)
+	Put(ctx context.Context, hash string, data []byte) error
+}
+
+// entry is the serialized form of a cached build.
+type entry struct {
+	Result *builder.BuildResult
+	// Files maps output file names to their contents. A PDF
+	// build has a single file named pdfEntryName.
+	Files map[string][]byte
+}
+
+const pdfEntryName = "output.pdf"
+
+// Cache looks builds up in a list of stores, fastest first.
+type Cache struct {
+	stores []Store
+}
+
+func New(stores ...Store) *Cache {
+	return &Cache{stores: stores}
+}
+
+// Get restores a cached build to outputPath and returns its
+// result. Store errors are logged and treated as misses so a
+// broken cache never fails a build.
+func (c *Cache) Get(ctx context.Context, key Key, outputPath string) (*builder.BuildResult, bool) {
+	if c == nil || key.Commit == "" {
+		return nil, false
+	}
+	hash := key.Hash()
+
+	for i, store := range c.stores {
+		data, err := store.Get(ctx, hash)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			slog.WarnContext(ctx, "build cache read failed", "hash", hash, "error", err)
+			continue
+		}
+
+		var e entry
+		if err = gob.NewDecoder(bytes.NewReader(data)).Decode(&e); err != nil {
+			slog.WarnContext(ctx, "build cache entry is corrupt", "hash", hash, "error", err)
+			continue
+		}
+		if err = e.restore(outputPath); err != nil {
+			slog.WarnContext(ctx, "build cache restore failed", "hash", hash, "error", err)
+			continue
+		}
+
+		// Backfill the faster stores we missed.
+		for _, faster := range c.stores[:i] {
+			if err = faster.Put(ctx, hash, data); err != nil {
+				slog.WarnContext(ctx, "build cache backfill failed", "hash", hash, "error", err)
+			}
+		}
+
+		slog.InfoContext(ctx, "build cache hit", "repo", key.Repo, "commit", key.Commit, "file", key.RootFile)
+		return e.Result, true
+	}
+	return nil, false
+}
+
+// Put stores the output at outputPath under key. Only
+// successful builds are cached.
+func (c *Cache) Put(ctx context.Context, key Key, outputPath string, result *builder.BuildResult) error {
+	if c == nil || key.Commit == "" || result == nil || !result.Success {
+		return nil
+	}
+
+	files, err := collectOutput(outputPath, key.Output != "")
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err = gob.NewEncoder(&buf).Encode(entry{Result: result, Files: files}); err != nil {
+		return fmt.Errorf("encode build cache entry: %w", err)
+	}
+
+	hash := key.Hash()
+	var errs []error
+	for _, store := range c.stores {
+		if err = store.Put(ctx, hash, buf.Bytes()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// collectOutput reads the build output. For multi-file
+// output, outputPath is a pattern and every file matching it
+// is collected by base name.
+func collectOutput(outputPath string, multiFile bool) (map[string][]byte, error) {
+	if !multiFile {
+		data, err := os.ReadFile(outputPath)
+		if err != nil {
+			return nil, err
+		}
+		return map[string][]byte{pdfEntryName: data}, nil
+	}
+
+	matches, err := filepath.Glob(patternGlob(outputPath))
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no build output matches %s", outputPath)
+	}
+	files := make(map[string][]byte, len(matches))
+	for _, match := range matches {
+		data, err := os.ReadFile(match)
+		if err != nil {
+			return nil, err
+		}
+		files[filepath.Base(match)] = data
+	}
+	return files, nil
+}
+
+func (e *entry) restore(outputPath string) error {
+	if data, ok := e.Files[pdfEntryName]; ok && len(e.Files) == 1 {
+		return os.WriteFile(outputPath, data, 0o644)
+	}
+	dir := filepath.Dir(outputPath)
+	for name, data := range e.Files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// patternGlob turns a builder output pattern with {p}, {0p}
+// and {t} placeholders into a glob.
+func patternGlob(pattern string) string {
+	return strings.NewReplacer("{p}", "*", "{0p}", "*", "{t}", "*").Replace(pattern)
+}
@@ -0,0 +1,206 @@
+package buildcache
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ansg191/job-temporal/internal/builder"
+)
+
+func newTestCache(t *testing.T, maxBytes int64) (*Cache, *DiskStore) {
+	t.Helper()
+
+	disk, err := NewDiskStore(t.TempDir(), maxBytes)
+	if err != nil {
+		t.Fatalf("NewDiskStore() unexpected error: %v", err)
+	}
+	return New(disk), disk
+}
+
+func TestKeyHash(t *testing.T) {
+	t.Parallel()
+
+	a := Key{Repo: "o/r", Commit: "abc", RootFile: "resume.typ", Builder: "typst", Format: "pdf"}
+	b := a
+	if a.Hash() != b.Hash() {
+		t.Fatalf("equal keys hash differently")
+	}
+	b.PPI = 192
+	if a.Hash() == b.Hash() {
+		t.Fatalf("keys differing in PPI hash equally")
+	}
+}
+
+func TestCache_PDFRoundTrip(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	cache, _ := newTestCache(t, 0)
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "build-1.pdf")
+	if err := os.WriteFile(src, []byte("%PDF-1.7 test"), 0o644); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+
+	key := Key{Repo: "o/r", Commit: "abc", RootFile: "resume.typ", Builder: "typst", Format: "pdf"}
+	if _, ok := cache.Get(ctx, key, filepath.Join(dir, "miss.pdf")); ok {
+		t.Fatalf("Get() hit on empty cache")
+	}
+
+	result := &builder.BuildResult{Success: true, PageCount: 1, PageLimit: 2}
+	if err := cache.Put(ctx, key, src, result); err != nil {
+		t.Fatalf("Put() unexpected error: %v", err)
+	}
+
+	dst := filepath.Join(dir, "build-2.pdf")
+	got, ok := cache.Get(ctx, key, dst)
+	if !ok {
+		t.Fatalf("Get() miss after Put()")
+	}
+	if !got.Success || got.PageCount != 1 || got.PageLimit != 2 {
+		t.Errorf("Get() result = %+v, want %+v", got, result)
+	}
+	data, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatalf("ReadFile() unexpected error: %v", err)
+	}
+	if string(data) != "%PDF-1.7 test" {
+		t.Errorf("restored content = %q", data)
+	}
+}
+
+func TestCache_PNGRoundTrip(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	cache, _ := newTestCache(t, 0)
+
+	srcDir := t.TempDir()
+	for _, name := range []string{"page-1.png", "page-2.png"} {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte(name), 0o644); err != nil {
+			t.Fatalf("WriteFile() unexpected error: %v", err)
+		}
+	}
+
+	key := Key{Repo: "o/r", Commit: "abc", Format: "png", Pages: "1-2", PPI: 192, Output: "page-{0p}.png"}
+	if err := cache.Put(ctx, key, filepath.Join(srcDir, "page-{0p}.png"), &builder.BuildResult{Success: true}); err != nil {
+		t.Fatalf("Put() unexpected error: %v", err)
+	}
+
+	dstDir := t.TempDir()
+	if _, ok := cache.Get(ctx, key, filepath.Join(dstDir, "page-{0p}.png")); !ok {
+		t.Fatalf("Get() miss after Put()")
+	}
+	for _, name := range []string{"page-1.png", "page-2.png"} {
+		data, err := os.ReadFile(filepath.Join(dstDir, name))
+		if err != nil {
+			t.Fatalf("restored %s: %v", name, err)
+		}
+		if string(data) != name {
+			t.Errorf("restored %s = %q", name, data)
+		}
+	}
+}
+
+func TestCache_SkipsFailedAndUnknownCommit(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	cache, disk := newTestCache(t, 0)
+
+	src := filepath.Join(t.TempDir(), "out.pdf")
+	if err := os.WriteFile(src, []byte("pdf"), 0o644); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+
+	failed := Key{Commit: "abc"}
+	if err := cache.Put(ctx, failed, src, &builder.BuildResult{Success: false}); err != nil {
+		t.Fatalf("Put() unexpected error: %v", err)
+	}
+	if _, err := disk.Get(ctx, failed.Hash()); err != ErrNotFound {
+		t.Errorf("failed build was cached, Get() err = %v", err)
+	}
+
+	unknown := Key{}
+	if err := cache.Put(ctx, unknown, src, &builder.BuildResult{Success: true}); err != nil {
+		t.Fatalf("Put() unexpected error: %v", err)
+	}
+	if _, err := disk.Get(ctx, unknown.Hash()); err != ErrNotFound {
+		t.Errorf("build without commit was cached, Get() err = %v", err)
+	}
+}
+
+func TestCache_BackfillsFasterStore(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+
+	local, err := NewDiskStore(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("NewDiskStore() unexpected error: %v", err)
+	}
+	remote, err := NewDiskStore(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("NewDiskStore() unexpected error: %v", err)
+	}
+
+	src := filepath.Join(t.TempDir(), "out.pdf")
+	if err = os.WriteFile(src, []byte("pdf"), 0o644); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+	key := Key{Commit: "abc"}
+	if err = New(remote).Put(ctx, key, src, &builder.BuildResult{Success: true}); err != nil {
+		t.Fatalf("Put() unexpected error: %v", err)
+	}
+
+	if _, ok := New(local, remote).Get(ctx, key, filepath.Join(t.TempDir(), "out.pdf")); !ok {
+		t.Fatalf("Get() miss, want hit from remote store")
+	}
+	if _, err = local.Get(ctx, key.Hash()); err != nil {
+		t.Errorf("local store not backfilled: %v", err)
+	}
+}
+
+func TestDiskStore_EvictsLeastRecentlyUsed(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+
+	disk, err := NewDiskStore(t.TempDir(), 25)
+	if err != nil {
+		t.Fatalf("NewDiskStore() unexpected error: %v", err)
+	}
+
+	hashes := []string{
+		strings.Repeat("a", 64),
+		strings.Repeat("b", 64),
+		strings.Repeat("c", 64),
+	}
+	past := time.Now().Add(-time.Hour)
+	for i, hash := range hashes[:2] {
+		if err = disk.Put(ctx, hash, bytes.Repeat([]byte{'x'}, 10)); err != nil {
+			t.Fatalf("Put() unexpected error: %v", err)
+		}
+		at := past.Add(time.Duration(i) * time.Minute)
+		if err = os.Chtimes(disk.path(hash), at, at); err != nil {
+			t.Fatalf("Chtimes() unexpected error: %v", err)
+		}
+	}
+
+	// Reading the older entry makes it the most recent.
+	if _, err = disk.Get(ctx, hashes[0]); err != nil {
+		t.Fatalf("Get() unexpected error: %v", err)
+	}
+	if err = disk.Put(ctx, hashes[2], bytes.Repeat([]byte{'x'}, 10)); err != nil {
+		t.Fatalf("Put() unexpected error: %v", err)
+	}
+
+	if _, err = disk.Get(ctx, hashes[1]); err != ErrNotFound {
+		t.Errorf("least recently used entry not evicted, Get() err = %v", err)
+	}
+	for _, hash := range []string{hashes[0], hashes[2]} {
+		if _, err = disk.Get(ctx, hash); err != nil {
+			t.Errorf("Get(%s) unexpected error: %v", hash[:1], err)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package buildcache
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DiskStore keeps entries as files under a directory and
+// evicts the least recently used ones once the total size
+// exceeds maxBytes.
+type DiskStore struct {
+	dir      string
+	maxBytes int64
+	mu       sync.Mutex
+}
+
+func NewDiskStore(dir string, maxBytes int64) (*DiskStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &DiskStore{dir: dir, maxBytes: maxBytes}, nil
+}
+
+func (d *DiskStore) path(hash string) string {
+	return filepath.Join(d.dir, hash[:2], hash)
+}
+
+func (d *DiskStore) Get(_ context.Context, hash string) ([]byte, error) {
+	p := d.path(hash)
+	data, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Bump the modification time so eviction sees the entry
+	// as recently used.
+	now := time.Now()
+	_ = os.Chtimes(p, now, now)
+	return data, nil
+}
+
+func (d *DiskStore) Put(_ context.Context, hash string, data []byte) error {
+	p := d.path(hash)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+
+	// Write to a temp file and rename so readers never see a
+	// partial entry.
+	tmp, err := os.CreateTemp(filepath.Dir(p), hash+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err = os.Rename(tmp.Name(), p); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	return d.evict()
+}
+
+// evict removes the oldest entries until the store fits in
+// maxBytes. A non-positive maxBytes disables eviction.
+func (d *DiskStore) evict() error {
+	if d.maxBytes <= 0 {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	type cached struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var entries []cached
+	var total int64
+	err := filepath.WalkDir(d.dir, func(p string, de fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if de.IsDir() || filepath.Ext(p) == ".tmp" {
+			return nil
+		}
+		info, err := de.Info()
+		if err != nil {
+			// Removed by a concurrent eviction.
+			return nil
+		}
+		entries = append(entries, cached{path: p, size: info.Size(), modTime: info.ModTime()})
+		total += info.Size()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].modTime.Before(entries[j].modTime)
+	})
+	for _, e := range entries {
+		if total <= d.maxBytes {
+			break
+		}
+		if err = os.Remove(e.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		total -= e.size
+	}
+	return nil
+}
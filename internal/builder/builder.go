@@ -9,16 +9,124 @@ type Builder interface {
 	Build(ctx context.Context, path, outputPath string) (*BuildResult, error)
 }
 
+// LabelQuerier is implemented by builders that can locate
+// labelled sections of a compiled document. The oracle and
+// layout review use it to crop rendered pages.
+type LabelQuerier interface {
+	// QueryLabelBBox returns the bounding box of the
+	// section marked with label.
+	QueryLabelBBox(ctx context.Context, path, label string) (*LabelBBox, error)
+	// QueryAllLabels lists every section label in the
+	// document.
+	QueryAllLabels(ctx context.Context, path string) ([]string, error)
+}
+
+// FontLister is implemented by builders that can list the
+// font families available to the compiler. The pre-flight
+// lint uses it to catch fonts that would silently fall back.
+type FontLister interface {
+	// Fonts lists the available font family names.
+	Fonts(ctx context.Context) ([]string, error)
+}
+
 type BuildResult struct {
 	Success bool
-	Errors  []string
+	// Errors holds the one-line form of every error
+	// diagnostic.
+	Errors      []string
+	Diagnostics []Diagnostic
+	// PageCount is the number of pages in the compiled
+	// document, or 0 if it was not counted.
+	PageCount int
+	PageLimit int
+	// Fill measures the last page, or is nil if the document
+	// has no DocumentEndLabel sentinel.
+	Fill *PageFill
 }
 
 func NewBuilder(builderType string, opts ...func(Builder)) (Builder, error) {
 	switch builderType {
 	case "typst":
 		return newTypstBuilder(opts...)
+	case "latex":
+		return newLatexBuilder(opts...)
 	default:
 		return nil, fmt.Errorf("unsupported builder type: %s", builderType)
 	}
 }
+
+// WithRootFile sets the document entrypoint for any builder.
+func WithRootFile(path string) func(Builder) {
+	return func(b Builder) {
+		switch b := b.(type) {
+		case *typstBuilder:
+			b.rootFile = path
+		case *latexBuilder:
+			b.rootFile = path
+		}
+	}
+}
+
+// WithFormat sets the output format ("pdf" or "png") for any
+// builder.
+func WithFormat(format string) func(Builder) {
+	return func(b Builder) {
+		switch b := b.(type) {
+		case *typstBuilder:
+			b.format = format
+		case *latexBuilder:
+			b.format = format
+		}
+	}
+}
+
+// WithPages restricts output to a page selection such as
+// "1-3" or "2,4" for any builder.
+func WithPages(pages string) func(Builder) {
+	return func(b Builder) {
+		switch b := b.(type) {
+		case *typstBuilder:
+			b.pages = pages
+		case *latexBuilder:
+			b.pages = pages
+		}
+	}
+}
+
+// WithPPI sets the PNG render resolution for any builder.
+func WithPPI(ppi int) func(Builder) {
+	return func(b Builder) {
+		switch b := b.(type) {
+		case *typstBuilder:
+			b.ppi = ppi
+		case *latexBuilder:
+			b.ppi = ppi
+		}
+	}
+}
+
+func WithPageLimit(limit int) func(Builder) {
+	return func(b Builder) {
+		switch b := b.(type) {
+		case *typstBuilder:
+			b.pageLimit = limit
+		case *latexBuilder:
+			b.pageLimit = limit
+		}
+	}
+}
+
+// WithPDFStandard requests PDF output conforming to a
+// standard such as "a-2b" for any builder. Builders that
+// cannot produce the standard fail the build rather than
+// silently ignoring it.
+func WithPDFStandard(standard string) func(Builder) {
+	return func(b Builder) {
+		switch b := b.(type) {
+		case *typstBuilder:
+			b.pdfStandard = standard
+		case *latexBuilder:
+			b.pdfStandard = standard
+		}
+	}
+}
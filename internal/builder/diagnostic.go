@@ -0,0 +1,296 @@
+package builder
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// excerptContextLines is the number of source lines shown
+// above and below the offending line in an excerpt.
+const excerptContextLines = 2
+
+// Diagnostic is a single compiler message with enough
+// context for an agent to fix it without re-reading the
+// file. Line and Column are 1-based; zero means unknown.
+type Diagnostic struct {
+	File     string   `json:"file,omitempty"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	Hints    []string `json:"hints,omitempty"`
+	Excerpt  string   `json:"excerpt,omitempty"`
+
+	// hasColumn records that the compiler reported a
+	// column, even a zero one, so String reproduces it.
+	hasColumn bool
+}
+
+// String formats the diagnostic on one line in typst's
+// short style: "file:line:col: error: message".
+func (d Diagnostic) String() string {
+	var loc string
+	switch {
+	case d.File != "" && d.Line > 0 && (d.Column > 0 || d.hasColumn):
+		loc = fmt.Sprintf("%s:%d:%d: ", d.File, d.Line, d.Column)
+	case d.File != "" && d.Line > 0:
+		loc = fmt.Sprintf("%s:%d: ", d.File, d.Line)
+	case d.File != "":
+		loc = d.File + ": "
+	}
+	msg := fmt.Sprintf("%s%s: %s", loc, d.Severity, d.Message)
+	if d.File == "" && d.Line > 0 {
+		msg += fmt.Sprintf(" (line %d)", d.Line)
+	}
+	return msg
+}
+
+// Format renders the diagnostic with its hints and source
+// excerpt on the lines following the summary.
+func (d Diagnostic) Format() string {
+	var sb strings.Builder
+	sb.WriteString(d.String())
+	for _, hint := range d.Hints {
+		sb.WriteString("\n  hint: ")
+		sb.WriteString(hint)
+	}
+	if d.Excerpt != "" {
+		sb.WriteString("\n")
+		sb.WriteString(d.Excerpt)
+	}
+	return sb.String()
+}
+
+// Report renders the result's diagnostics and page count
+// as one entry per problem, errors first. It is used for
+// both the build tool output and ErrTypeBuildFailed details.
+func (r *BuildResult) Report() []string {
+	var entries []string
+	for _, severity := range []Severity{SeverityError, SeverityWarning} {
+		for _, d := range r.Diagnostics {
+			if d.Severity == severity {
+				entries = append(entries, d.Format())
+			}
+		}
+	}
+	// Fall back to plain error lines for results that were
+	// not built from diagnostics.
+	if len(r.Diagnostics) == 0 {
+		entries = append(entries, r.Errors...)
+	}
+	if r.PageLimit > 0 && r.PageCount > 0 {
+		entries = append(entries, fmt.Sprintf("pages: %d (limit %d)", r.PageCount, r.PageLimit))
+	}
+	if r.Fill != nil {
+		entries = append(entries, "fill: "+r.Fill.String())
+	}
+	return entries
+}
+
+// errorStrings returns the one-line form of every error
+// diagnostic, for BuildResult.Errors.
+func errorStrings(diags []Diagnostic) []string {
+	var errors []string
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			errors = append(errors, d.String())
+		}
+	}
+	return errors
+}
+
+// pageLimitDiagnostic describes a document that compiled
+// but has more pages than allowed. With a fill measurement
+// the hint says how much to cut.
+func pageLimitDiagnostic(file string, count, limit int, fill *PageFill) Diagnostic {
+	hint := fmt.Sprintf("remove or condense %d page(s) of content", count-limit)
+	if fill != nil && fill.OverflowPt > 0 {
+		hint = fmt.Sprintf("cut about %.0fpt (~%d lines) of content to fit", fill.OverflowPt, fill.OverflowLines)
+	}
+	return Diagnostic{
+		File:     file,
+		Severity: SeverityError,
+		Message:  fmt.Sprintf("page limit exceeded: document has %d pages, limit is %d", count, limit),
+		Hints:    []string{hint},
+	}
+}
+
+var (
+	// Matches typst's short diagnostic header, with an
+	// optional location: file.typ:26:5: error: message
+	typstShortDiagRe = regexp.MustCompile(`^(?:(.+?):(\d+):(\d+): )?(error|warning|help): (.*)$`)
+	// Matches the location line of typst's human format:
+	// ┌─ file.typ:26:5
+	typstLocationRe = regexp.MustCompile(`^┌─ (.+?):(\d+):(\d+)$`)
+	// Matches a hint attached to the previous diagnostic,
+	// either "hint: ..." or "= hint: ...".
+	typstHintRe = regexp.MustCompile(`^(?:= )?hint: (.*)$`)
+)
+
+// parseTypstDiagnostics parses typst's short or human
+// diagnostic output. Trace entries ("help: error occurred
+// in this call") are dropped, except that a diagnostic
+// raised inside a package is moved to the first call site
+// in the project, since the package itself cannot be
+// edited.
+func parseTypstDiagnostics(output string) []Diagnostic {
+	var diags []Diagnostic
+	current := -1
+	// trace is the diagnostic still waiting for a call site
+	// from the trace entries that follow it.
+	trace := -1
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if m := typstShortDiagRe.FindStringSubmatch(line); m != nil {
+			if m[4] == "help" {
+				current = -1
+				if trace < 0 && len(diags) > 0 && isPackageFile(diags[len(diags)-1].File) {
+					trace = len(diags) - 1
+				}
+				if trace >= 0 && m[1] != "" && moveToCallSite(&diags[trace], m[1], m[2], m[3]) {
+					trace = -1
+				}
+				continue
+			}
+			d := Diagnostic{
+				File:     m[1],
+				Severity: Severity(m[4]),
+				Message:  m[5],
+			}
+			d.Line, _ = strconv.Atoi(m[2])
+			d.Column, _ = strconv.Atoi(m[3])
+			d.hasColumn = m[3] != ""
+			diags = append(diags, d)
+			current = len(diags) - 1
+			trace = -1
+			continue
+		}
+		if current < 0 {
+			if m := typstLocationRe.FindStringSubmatch(line); m != nil && trace >= 0 {
+				if moveToCallSite(&diags[trace], m[1], m[2], m[3]) {
+					trace = -1
+				}
+			}
+			continue
+		}
+
+		if m := typstLocationRe.FindStringSubmatch(line); m != nil {
+			d := &diags[current]
+			if d.File == "" {
+				d.File = m[1]
+				d.Line, _ = strconv.Atoi(m[2])
+				d.Column, _ = strconv.Atoi(m[3])
+				d.hasColumn = true
+			}
+			continue
+		}
+		if m := typstHintRe.FindStringSubmatch(line); m != nil {
+			diags[current].Hints = append(diags[current].Hints, m[1])
+		}
+	}
+	return diags
+}
+
+// isPackageFile reports whether a typst diagnostic path is
+// inside a package, e.g. "@preview/pkg:0.1.0/lib.typ".
+func isPackageFile(file string) bool {
+	return strings.HasPrefix(file, "@")
+}
+
+// moveToCallSite relocates a package diagnostic to a call
+// site in the project, noting where it was raised. Call
+// sites in other packages are skipped.
+func moveToCallSite(d *Diagnostic, file, line, col string) bool {
+	if isPackageFile(file) {
+		return false
+	}
+	d.Hints = append(d.Hints, fmt.Sprintf("raised in %s:%d:%d", d.File, d.Line, d.Column))
+	d.File = file
+	d.Line, _ = strconv.Atoi(line)
+	d.Column, _ = strconv.Atoi(col)
+	d.hasColumn = true
+	return true
+}
+
+// attachExcerpts fills in the source excerpt for every
+// diagnostic whose file can be found under root.
+func attachExcerpts(diags []Diagnostic, root string) {
+	for i := range diags {
+		d := &diags[i]
+		if d.File == "" || d.Line <= 0 || d.Excerpt != "" {
+			continue
+		}
+		file := d.File
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(root, file)
+		}
+		d.Excerpt = sourceExcerpt(file, d.Line, d.Column)
+	}
+}
+
+// sourceExcerpt returns the lines around line in file with
+// the offending line marked and, if col is known, a caret
+// under the column. It returns "" if the file is unreadable.
+func sourceExcerpt(file string, line, col int) string {
+	f, err := os.Open(file)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	first := max(line-excerptContextLines, 1)
+	last := line + excerptContextLines
+	width := len(strconv.Itoa(last))
+
+	var sb strings.Builder
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan() && n <= last; n++ {
+		if n < first {
+			continue
+		}
+		marker := " "
+		if n == line {
+			marker = ">"
+		}
+		text := scanner.Text()
+		fmt.Fprintf(&sb, "%s %*d | %s\n", marker, width, n, text)
+		if n == line && col > 0 {
+			fmt.Fprintf(&sb, "  %*s | %s^\n", width, "", caretPadding(text, col))
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// caretPadding returns whitespace that lines a caret up
+// under the 1-based column col, preserving tabs.
+func caretPadding(text string, col int) string {
+	var sb strings.Builder
+	for i, r := range []rune(text) {
+		if i >= col-1 {
+			break
+		}
+		if r == '\t' {
+			sb.WriteRune('\t')
+		} else {
+			sb.WriteRune(' ')
+		}
+	}
+	return sb.String()
+}
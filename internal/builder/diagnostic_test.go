@@ -0,0 +1,165 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseTypstDiagnostics_Human(t *testing.T) {
+	t.Parallel()
+
+	output := `error: unknown variable: foo
+  ┌─ resume.typ:3:2
+  │
+3 │ #foo
+  │  ^^^
+  │
+  = hint: if you meant to display multiple letters as is, try adding spaces
+
+help: error occurred in this function call
+  ┌─ template.typ:10:1
+  │
+10│ #show: body
+  │
+
+warning: unused import
+  ┌─ resume.typ:1:9
+`
+	diags := parseTypstDiagnostics(output)
+	if len(diags) != 2 {
+		t.Fatalf("len(diags) = %d, want 2: %+v", len(diags), diags)
+	}
+
+	got := diags[0]
+	if got.File != "resume.typ" || got.Line != 3 || got.Column != 2 {
+		t.Errorf("location = %s:%d:%d, want resume.typ:3:2", got.File, got.Line, got.Column)
+	}
+	if got.Severity != SeverityError || got.Message != "unknown variable: foo" {
+		t.Errorf("diag = %s %q, want error %q", got.Severity, got.Message, "unknown variable: foo")
+	}
+	if len(got.Hints) != 1 || !strings.HasPrefix(got.Hints[0], "if you meant") {
+		t.Errorf("Hints = %q, want one hint", got.Hints)
+	}
+
+	if diags[1].Severity != SeverityWarning || diags[1].File != "resume.typ" || diags[1].Line != 1 {
+		t.Errorf("warning = %+v, want resume.typ:1 warning", diags[1])
+	}
+	if errs := errorStrings(diags); !slices.Equal(errs, []string{"resume.typ:3:2: error: unknown variable: foo"}) {
+		t.Errorf("errorStrings() = %q", errs)
+	}
+}
+
+func TestParseLatexDiagnostics_Warnings(t *testing.T) {
+	t.Parallel()
+
+	output := "LaTeX Warning: Reference `x' on page 1 undefined on input line 3.\n" +
+		"Package hyperref Warning: Token not allowed in a PDF string."
+	diags := parseLatexDiagnostics(output)
+	if len(diags) != 2 {
+		t.Fatalf("len(diags) = %d, want 2: %+v", len(diags), diags)
+	}
+	if diags[0].Severity != SeverityWarning || diags[0].Line != 3 {
+		t.Errorf("diags[0] = %+v, want warning on line 3", diags[0])
+	}
+	if diags[1].Message != "Token not allowed in a PDF string" {
+		t.Errorf("diags[1].Message = %q", diags[1].Message)
+	}
+}
+
+func TestSourceExcerpt(t *testing.T) {
+	t.Parallel()
+
+	file := filepath.Join(t.TempDir(), "doc.typ")
+	content := "one\ntwo\nthree\n\tfour\nfive\nsix\n"
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+
+	want := "  2 | two\n" +
+		"  3 | three\n" +
+		"> 4 | \tfour\n" +
+		"    | \t ^\n" +
+		"  5 | five\n" +
+		"  6 | six"
+	if got := sourceExcerpt(file, 4, 3); got != want {
+		t.Errorf("sourceExcerpt() =\n%s\nwant\n%s", got, want)
+	}
+
+	if got := sourceExcerpt(filepath.Join(t.TempDir(), "missing.typ"), 1, 1); got != "" {
+		t.Errorf("sourceExcerpt(missing) = %q, want empty", got)
+	}
+}
+
+func TestBuildResult_Report(t *testing.T) {
+	t.Parallel()
+
+	result := &BuildResult{
+		Diagnostics: []Diagnostic{
+			{Severity: SeverityWarning, Message: "unused import"},
+			pageLimitDiagnostic("resume.typ", 3, 2, nil),
+		},
+		PageCount: 3,
+		PageLimit: 2,
+	}
+	report := result.Report()
+	if len(report) != 3 {
+		t.Fatalf("len(Report()) = %d, want 3: %q", len(report), report)
+	}
+	if !strings.HasPrefix(report[0], "resume.typ: error: page limit exceeded: document has 3 pages, limit is 2") {
+		t.Errorf("Report()[0] = %q, want page limit error first", report[0])
+	}
+	if !strings.Contains(report[0], "hint: remove or condense 1 page(s)") {
+		t.Errorf("Report()[0] = %q, want page limit hint", report[0])
+	}
+	if report[1] != "warning: unused import" {
+		t.Errorf("Report()[1] = %q, want warning", report[1])
+	}
+	if report[2] != "pages: 3 (limit 2)" {
+		t.Errorf("Report()[2] = %q, want page count", report[2])
+	}
+}
+
+func TestParseTypstDiagnostics_PackageCallSite(t *testing.T) {
+	t.Parallel()
+
+	output := `warning: unknown font family: fira sans
+  ┌─ @preview/icons:1.2.3/lib.typ:4:12
+  │
+4 │ #set text(font: "Fira Sans")
+  │            ^^^^^^^^^^^
+
+help: warning occurred in this call
+  ┌─ @preview/icons:1.2.3/lib.typ:20:3
+  │
+
+help: warning occurred in this call
+  ┌─ resume.typ:7:2
+  │
+7 │ #icon("github")
+  │  ^^^^^^^^^^^^^
+
+warning: unused import
+  ┌─ resume.typ:1:9
+
+help: warning occurred in this call
+  ┌─ template.typ:2:1
+`
+	diags := parseTypstDiagnostics(output)
+	if len(diags) != 2 {
+		t.Fatalf("len(diags) = %d, want 2: %+v", len(diags), diags)
+	}
+
+	got := diags[0]
+	if got.String() != "resume.typ:7:2: warning: unknown font family: fira sans" {
+		t.Errorf("diags[0] = %q, want moved to the project call site", got.String())
+	}
+	if !slices.Equal(got.Hints, []string{"raised in @preview/icons:1.2.3/lib.typ:4:12"}) {
+		t.Errorf("Hints = %q", got.Hints)
+	}
+	if diags[1].String() != "resume.typ:1:9: warning: unused import" {
+		t.Errorf("diags[1] = %q, want project diagnostic left in place", diags[1].String())
+	}
+}
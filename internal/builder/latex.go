@@ -0,0 +1,637 @@
+package builder
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+const (
+	latexEngineLatexmk  = "latexmk"
+	latexEngineTectonic = "tectonic"
+
+	// Typst renders PNGs at 144 PPI by default; match it so
+	// callers see the same image sizes from either builder.
+	latexDefaultPPI = 144
+
+	// TeX scaled points per typographic point.
+	scaledPointsPerPoint = 65536.0
+)
+
+// latexBuilder compiles LaTeX documents with latexmk or
+// tectonic. PNG output is rasterized from the compiled PDF
+// with pdftoppm.
+//
+// Section labels are read from zref-savepos positions in
+// the .aux file. A section "jobs" is marked with
+// \zsavepos{jobs} at its top-left corner and
+// \zsavepos{jobs-end} at its bottom-right corner. Page
+// numbers require the abspage property:
+//
+//	\usepackage{zref-savepos,zref-abspage}
+//	\makeatletter\zref@addprop{savepos}{abspage}\makeatother
+type latexBuilder struct {
+	execPath   string
+	engine     string
+	rasterPath string
+	rootFile   string
+	format     string
+	pages      string
+	ppi        int
+	pageLimit  int // 0 = no limit, default = 1
+	// pdfStandard is unsupported; pdfx and friends need
+	// per-document preamble changes.
+	pdfStandard string
+	limits      limits
+}
+
+// WithLatexEngine selects "latexmk" or "tectonic".
+func WithLatexEngine(engine string) func(Builder) {
+	return func(b Builder) {
+		if b, ok := b.(*latexBuilder); ok {
+			b.engine = engine
+		}
+	}
+}
+
+// WithLatexPath overrides the path to the engine binary.
+func WithLatexPath(path string) func(Builder) {
+	return func(b Builder) {
+		if b, ok := b.(*latexBuilder); ok {
+			b.execPath = path
+		}
+	}
+}
+
+// WithPdftoppmPath overrides the path to the pdftoppm
+// binary used for PNG output.
+func WithPdftoppmPath(path string) func(Builder) {
+	return func(b Builder) {
+		if b, ok := b.(*latexBuilder); ok {
+			b.rasterPath = path
+		}
+	}
+}
+
+func newLatexBuilder(opts ...func(Builder)) (*latexBuilder, error) {
+	ret := &latexBuilder{
+		format:    "pdf",
+		pageLimit: 1, // default to 1 page for resumes
+	}
+	for _, opt := range opts {
+		opt(ret)
+	}
+
+	if ret.execPath == "" {
+		engines := []string{latexEngineLatexmk, latexEngineTectonic}
+		if ret.engine != "" {
+			engines = []string{ret.engine}
+		}
+		for _, engine := range engines {
+			if execPath, err := exec.LookPath(engine); err == nil {
+				ret.execPath = execPath
+				ret.engine = engine
+				break
+			}
+		}
+		if ret.execPath == "" {
+			return nil, fmt.Errorf("failed to find latex binary (tried %s)", strings.Join(engines, ", "))
+		}
+	}
+	if ret.engine == "" {
+		ret.engine = filepath.Base(ret.execPath)
+	}
+	if ret.engine != latexEngineLatexmk && ret.engine != latexEngineTectonic {
+		return nil, fmt.Errorf("unsupported latex engine: %s", ret.engine)
+	}
+
+	return ret, nil
+}
+
+func (l *latexBuilder) Build(ctx context.Context, path string, outputPath string) (*BuildResult, error) {
+	if outputPath == "" {
+		return nil, fmt.Errorf("output path is required")
+	}
+	if l.format != "pdf" && l.format != "png" {
+		return nil, fmt.Errorf("unsupported latex output format: %s", l.format)
+	}
+	if l.pdfStandard != "" {
+		return nil, fmt.Errorf("latex builder does not support pdf standard %q", l.pdfStandard)
+	}
+
+	workDir, err := os.MkdirTemp(os.TempDir(), "latex-build-*.d")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	pdfPath, diags, result, err := l.compile(ctx, path, workDir)
+	if err != nil || result != nil {
+		return result, err
+	}
+
+	pageCount, err := api.PageCountFile(pdfPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count PDF pages: %w", err)
+	}
+
+	// Check page limit
+	if l.format == "pdf" && l.pageLimit > 0 {
+		slog.DebugContext(ctx, "PDF page count", "count", pageCount, "limit", l.pageLimit)
+		if pageCount > l.pageLimit {
+			diags = append(diags, pageLimitDiagnostic(relativeRootFile(path, l.rootFile), pageCount, l.pageLimit, nil))
+			return &BuildResult{
+				Success:     false,
+				Errors:      errorStrings(diags),
+				Diagnostics: diags,
+				PageCount:   pageCount,
+				PageLimit:   l.pageLimit,
+			}, nil
+		}
+	}
+
+	switch l.format {
+	case "png":
+		err = l.rasterize(ctx, pdfPath, outputPath, pageCount)
+	case "pdf":
+		if l.pages != "" {
+			err = api.TrimFile(pdfPath, outputPath, []string{l.pages}, nil)
+		} else {
+			err = copyFile(pdfPath, outputPath)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &BuildResult{
+		Success:     true,
+		Errors:      nil,
+		Diagnostics: diags,
+		PageCount:   pageCount,
+		PageLimit:   l.pageLimit,
+	}, nil
+}
+
+// compile runs the engine into workDir and returns the path
+// of the produced PDF along with any warnings. A non-nil
+// BuildResult means the document failed to compile.
+func (l *latexBuilder) compile(ctx context.Context, path, workDir string) (string, []Diagnostic, *BuildResult, error) {
+	rootFile := l.rootFile
+	if !filepath.IsAbs(rootFile) {
+		abs, err := filepath.Abs(rootFile)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		rootFile = abs
+	}
+	if _, err := os.Stat(rootFile); err != nil {
+		diags := []Diagnostic{{
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("input file not found (searched at %s)", l.rootFile),
+		}}
+		return "", nil, &BuildResult{
+			Success:     false,
+			Errors:      errorStrings(diags),
+			Diagnostics: diags,
+			PageLimit:   l.pageLimit,
+		}, nil
+	}
+
+	var args []string
+	switch l.engine {
+	case latexEngineTectonic:
+		// Queries read label positions from the .aux file,
+		// which tectonic otherwise deletes.
+		args = []string{"--keep-intermediates", "--keep-logs", "--synctex", "--chatter", "minimal", "--outdir", workDir, rootFile}
+	default:
+		args = []string{
+			"-pdf",
+			"-interaction=nonstopmode",
+			"-halt-on-error",
+			"-file-line-error",
+			"-synctex=1",
+			"-outdir=" + workDir,
+			rootFile,
+		}
+	}
+	cmd := l.limits.command(ctx, l.execPath, args...)
+	defer cmd.Close()
+	cmd.Dir = path
+	slog.InfoContext(ctx, "Running latex command", "cmd", cmd.String())
+	output, err := cmd.CombinedOutput()
+
+	base := strings.TrimSuffix(filepath.Base(rootFile), filepath.Ext(rootFile))
+	logContent, _ := os.ReadFile(filepath.Join(workDir, base+".log"))
+
+	diags := parseLatexDiagnostics(string(logContent) + "\n" + string(output))
+	// "!" errors and warnings only report a line number;
+	// they refer to the root file.
+	for i := range diags {
+		if diags[i].File == "" && diags[i].Line > 0 {
+			diags[i].File = relativeRootFile(path, rootFile)
+		}
+	}
+	if msg := cmd.limitExceeded(err); msg != "" {
+		diags = append(diags, limitDiagnostic(msg))
+	}
+	attachExcerpts(diags, path)
+
+	if err != nil {
+		if len(errorStrings(diags)) == 0 {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Message:  lastNonEmptyLine(string(output), err.Error()),
+			})
+		}
+		return "", nil, &BuildResult{
+			Success:     false,
+			Errors:      errorStrings(diags),
+			Diagnostics: diags,
+			PageLimit:   l.pageLimit,
+		}, nil
+	}
+
+	return filepath.Join(workDir, base+".pdf"), diags, nil, nil
+}
+
+// rasterize renders the selected pages of pdfPath to PNGs
+// named after outputPattern, which accepts the same {p},
+// {0p} and {t} placeholders as typst.
+func (l *latexBuilder) rasterize(ctx context.Context, pdfPath, outputPattern string, pageCount int) error {
+	rasterPath := l.rasterPath
+	if rasterPath == "" {
+		var err error
+		rasterPath, err = exec.LookPath("pdftoppm")
+		if err != nil {
+			return fmt.Errorf("failed to find pdftoppm binary: %w", err)
+		}
+	}
+
+	pages, err := parsePageSelection(l.pages, pageCount)
+	if err != nil {
+		return err
+	}
+	if len(pages) > 1 && !hasPagePlaceholder(outputPattern) {
+		return fmt.Errorf("output path %q must contain a page placeholder for multi-page export", outputPattern)
+	}
+
+	ppi := l.ppi
+	if ppi <= 0 {
+		ppi = latexDefaultPPI
+	}
+
+	for _, page := range pages {
+		outPath := expandPagePattern(outputPattern, page, pageCount)
+		prefix := strings.TrimSuffix(outPath, filepath.Ext(outPath))
+		cmd := l.limits.command(ctx, rasterPath,
+			"-png",
+			"-r", strconv.Itoa(ppi),
+			"-f", strconv.Itoa(page),
+			"-l", strconv.Itoa(page),
+			"-singlefile",
+			pdfPath,
+			prefix,
+		)
+		output, err := cmd.CombinedOutput()
+		msg := cmd.limitExceeded(err)
+		cmd.Close()
+		if msg != "" {
+			return fmt.Errorf("pdftoppm failed on page %d: %s", page, msg)
+		}
+		if err != nil {
+			return fmt.Errorf("pdftoppm failed on page %d: %w: %s", page, err, strings.TrimSpace(string(output)))
+		}
+		if rendered := prefix + ".png"; rendered != outPath {
+			if err := os.Rename(rendered, outPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (l *latexBuilder) QueryLabelBBox(ctx context.Context, path, label string) (*LabelBBox, error) {
+	label = strings.Trim(label, "<>")
+
+	positions, pageDims, err := l.queryPositions(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	start, ok := positions[label]
+	if !ok {
+		return nil, fmt.Errorf("label %q not found", label)
+	}
+	end, ok := positions[label+"-end"]
+	if !ok {
+		return nil, fmt.Errorf("label %q has no matching %q position", label, label+"-end")
+	}
+	if start.Page < 1 || start.Page > len(pageDims) || end.Page < start.Page || end.Page > len(pageDims) {
+		return nil, fmt.Errorf("label %q has invalid page range %d-%d", label, start.Page, end.Page)
+	}
+
+	// zref measures y from the page bottom; typst and the
+	// crop code measure from the top.
+	pageHeight := pageDims[start.Page-1]
+	top := pageHeight - start.Y
+	bottom := pageDims[end.Page-1] - end.Y
+	for page := start.Page; page < end.Page; page++ {
+		bottom += pageDims[page-1]
+	}
+
+	return &LabelBBox{
+		Pos: LabelPosition{
+			Page: start.Page,
+			X:    formatPoints(start.X),
+			Y:    formatPoints(top),
+		},
+		Size: LabelSize{
+			Width:  formatPoints(end.X - start.X),
+			Height: formatPoints(bottom - top),
+		},
+	}, nil
+}
+
+func (l *latexBuilder) QueryAllLabels(ctx context.Context, path string) ([]string, error) {
+	positions, _, err := l.queryPositions(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := make([]string, 0, len(positions))
+	for name := range positions {
+		if strings.HasSuffix(name, "-end") {
+			continue
+		}
+		if _, ok := positions[name+"-end"]; ok {
+			labels = append(labels, name)
+		}
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		a, b := positions[labels[i]], positions[labels[j]]
+		if a.Page != b.Page {
+			return a.Page < b.Page
+		}
+		// Higher y is nearer the top of the page.
+		return a.Y > b.Y
+	})
+	return labels, nil
+}
+
+// queryPositions compiles the document and returns its
+// zref positions along with each page's height in points.
+func (l *latexBuilder) queryPositions(ctx context.Context, path string) (map[string]zrefPosition, []float64, error) {
+	workDir, err := os.MkdirTemp(os.TempDir(), "latex-query-*.d")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	pdfPath, _, result, err := l.compile(ctx, path, workDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	if result != nil {
+		return nil, nil, fmt.Errorf("latex query failed: %s", strings.Join(result.Errors, "; "))
+	}
+
+	aux, err := os.ReadFile(strings.TrimSuffix(pdfPath, ".pdf") + ".aux")
+	if err != nil {
+		return nil, nil, fmt.Errorf("read latex aux file: %w", err)
+	}
+
+	dims, err := api.PageDimsFile(pdfPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read PDF page dimensions: %w", err)
+	}
+	heights := make([]float64, len(dims))
+	for i, dim := range dims {
+		heights[i] = dim.Height
+	}
+
+	return parseZrefPositions(string(aux)), heights, nil
+}
+
+// zrefPosition is a zref-savepos location converted to
+// points, with y measured from the bottom of the page.
+type zrefPosition struct {
+	Page int
+	X    float64
+	Y    float64
+}
+
+var (
+	zrefLabelRe = regexp.MustCompile(`\\zref@newlabel\{([^}]+)\}\{(.*)\}`)
+	zrefPosXRe  = regexp.MustCompile(`\\posx\{(-?\d+)\}`)
+	zrefPosYRe  = regexp.MustCompile(`\\posy\{(-?\d+)\}`)
+	zrefPageRe  = regexp.MustCompile(`\\abspage\{(\d+)\}`)
+)
+
+func parseZrefPositions(aux string) map[string]zrefPosition {
+	positions := make(map[string]zrefPosition)
+	for _, match := range zrefLabelRe.FindAllStringSubmatch(aux, -1) {
+		props := match[2]
+		x := zrefPosXRe.FindStringSubmatch(props)
+		y := zrefPosYRe.FindStringSubmatch(props)
+		if x == nil || y == nil {
+			continue
+		}
+		xsp, _ := strconv.ParseFloat(x[1], 64)
+		ysp, _ := strconv.ParseFloat(y[1], 64)
+
+		page := 1
+		if p := zrefPageRe.FindStringSubmatch(props); p != nil {
+			page, _ = strconv.Atoi(p[1])
+		}
+
+		positions[match[1]] = zrefPosition{
+			Page: page,
+			X:    xsp / scaledPointsPerPoint,
+			Y:    ysp / scaledPointsPerPoint,
+		}
+	}
+	return positions
+}
+
+var (
+	// Matches -file-line-error output: ./file.tex:12: message
+	latexFileLineErrorRe = regexp.MustCompile(`^(.+\.(?:tex|sty|cls|bib)):(\d+): (.+)$`)
+	// Matches the context line TeX prints after a "!" error.
+	latexContextLineRe = regexp.MustCompile(`^l\.(\d+)(.*)$`)
+	// Matches "LaTeX Warning: ..." and "Package foo Warning: ...",
+	// with the input line if TeX reported one.
+	latexWarningRe = regexp.MustCompile(`^(?:LaTeX|Package \S+) Warning: (.*?)(?: on input line (\d+))?\.?$`)
+)
+
+// parseLatexDiagnostics extracts errors and LaTeX warnings
+// from a LaTeX log. Errors from -file-line-error carry a
+// file and line; "!" errors carry the line from the "l.N"
+// context line that follows them, and the text of that
+// context line is kept as a hint.
+func parseLatexDiagnostics(output string) []Diagnostic {
+	var diags []Diagnostic
+	seen := make(map[string]bool)
+	add := func(d Diagnostic) {
+		key := d.String()
+		if !seen[key] {
+			seen[key] = true
+			diags = append(diags, d)
+		}
+	}
+
+	var pending string
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if m := latexFileLineErrorRe.FindStringSubmatch(line); m != nil {
+			pending = ""
+			lineNo, _ := strconv.Atoi(m[2])
+			add(Diagnostic{
+				File:     strings.TrimPrefix(m[1], "./"),
+				Line:     lineNo,
+				Severity: SeverityError,
+				Message:  m[3],
+			})
+			continue
+		}
+
+		if strings.HasPrefix(line, "! ") {
+			if pending != "" {
+				add(Diagnostic{Severity: SeverityError, Message: pending})
+			}
+			pending = strings.TrimSpace(strings.TrimPrefix(line, "! "))
+			if pending == "Emergency stop." {
+				pending = ""
+			}
+			continue
+		}
+
+		if m := latexWarningRe.FindStringSubmatch(line); m != nil {
+			d := Diagnostic{Severity: SeverityWarning, Message: strings.TrimSuffix(m[1], ".")}
+			if m[2] != "" {
+				d.Line, _ = strconv.Atoi(m[2])
+			}
+			add(d)
+			continue
+		}
+
+		if pending != "" {
+			if m := latexContextLineRe.FindStringSubmatch(line); m != nil {
+				lineNo, _ := strconv.Atoi(m[1])
+				d := Diagnostic{Severity: SeverityError, Line: lineNo, Message: pending}
+				if ctx := strings.TrimSpace(m[2]); ctx != "" {
+					d.Hints = []string{"error occurred after: " + ctx}
+				}
+				add(d)
+				pending = ""
+			}
+		}
+	}
+	if pending != "" {
+		add(Diagnostic{Severity: SeverityError, Message: pending})
+	}
+
+	return diags
+}
+
+func hasPagePlaceholder(pattern string) bool {
+	return strings.Contains(pattern, "{p}") || strings.Contains(pattern, "{0p}")
+}
+
+// expandPagePattern substitutes typst-style page
+// placeholders: {p} page number, {0p} zero-padded page
+// number and {t} total page count.
+func expandPagePattern(pattern string, page, total int) string {
+	width := len(strconv.Itoa(total))
+	return strings.NewReplacer(
+		"{0p}", fmt.Sprintf("%0*d", width, page),
+		"{p}", strconv.Itoa(page),
+		"{t}", strconv.Itoa(total),
+	).Replace(pattern)
+}
+
+// parsePageSelection expands a typst page selection such as
+// "1-3,5" or "2-" into page numbers. An empty selection
+// means every page.
+func parsePageSelection(selection string, total int) ([]int, error) {
+	selection = strings.TrimSpace(selection)
+	if selection == "" {
+		selection = "1-"
+	}
+
+	seen := make(map[int]bool)
+	var pages []int
+	for _, part := range strings.Split(selection, ",") {
+		part = strings.TrimSpace(part)
+		start, end := part, part
+		if before, after, ok := strings.Cut(part, "-"); ok {
+			start, end = before, after
+		}
+
+		first, last := 1, total
+		var err error
+		if start != "" {
+			if first, err = strconv.Atoi(start); err != nil {
+				return nil, fmt.Errorf("invalid page selection %q", selection)
+			}
+		}
+		if end != "" {
+			if last, err = strconv.Atoi(end); err != nil {
+				return nil, fmt.Errorf("invalid page selection %q", selection)
+			}
+		}
+		if first < 1 || last < first {
+			return nil, fmt.Errorf("invalid page selection %q", selection)
+		}
+		if last > total {
+			last = total
+		}
+
+		for page := first; page <= last; page++ {
+			if !seen[page] {
+				seen[page] = true
+				pages = append(pages, page)
+			}
+		}
+	}
+	sort.Ints(pages)
+	return pages, nil
+}
+
+func formatPoints(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64) + "pt"
+}
+
+func lastNonEmptyLine(output, fallback string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if line := strings.TrimSpace(lines[i]); line != "" {
+			return line
+		}
+	}
+	return fallback
+}
+
+func copyFile(src, dst string) error {
+	content, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, content, 0o644)
+}
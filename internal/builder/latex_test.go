@@ -0,0 +1,300 @@
+package builder
+
+import (
+	"context"
+	"math"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNewLatexBuilder_WithLatexPath(t *testing.T) {
+	t.Parallel()
+
+	builder, err := newLatexBuilder(
+		WithLatexPath("/fake/latexmk"),
+		WithRootFile("resume.tex"),
+		WithPageLimit(3),
+	)
+	if err != nil {
+		t.Fatalf("newLatexBuilder() unexpected error: %v", err)
+	}
+
+	if builder.execPath != "/fake/latexmk" {
+		t.Errorf("execPath = %q, want %q", builder.execPath, "/fake/latexmk")
+	}
+	if builder.engine != latexEngineLatexmk {
+		t.Errorf("engine = %q, want %q", builder.engine, latexEngineLatexmk)
+	}
+	if builder.rootFile != "resume.tex" {
+		t.Errorf("rootFile = %q, want %q", builder.rootFile, "resume.tex")
+	}
+	if builder.pageLimit != 3 {
+		t.Errorf("pageLimit = %d, want 3", builder.pageLimit)
+	}
+}
+
+func TestNewLatexBuilder_Defaults(t *testing.T) {
+	t.Parallel()
+
+	builder, err := newLatexBuilder(WithLatexPath("/fake/tectonic"))
+	if err != nil {
+		t.Fatalf("newLatexBuilder() unexpected error: %v", err)
+	}
+
+	if builder.engine != latexEngineTectonic {
+		t.Errorf("engine = %q, want %q", builder.engine, latexEngineTectonic)
+	}
+	if builder.format != "pdf" {
+		t.Errorf("format = %q, want pdf", builder.format)
+	}
+	if builder.pageLimit != 1 {
+		t.Errorf("pageLimit = %d, want 1 (default)", builder.pageLimit)
+	}
+}
+
+func TestNewLatexBuilder_UnsupportedEngine(t *testing.T) {
+	t.Parallel()
+
+	_, err := newLatexBuilder(WithLatexPath("/fake/pdflatex"))
+	if err == nil {
+		t.Fatal("newLatexBuilder() expected error for unsupported engine, got nil")
+	}
+}
+
+func TestNewLatexBuilder_GenericOptions(t *testing.T) {
+	t.Parallel()
+
+	builder, err := newLatexBuilder(
+		WithLatexPath("/fake/latexmk"),
+		WithFormat("png"),
+		WithPages("1-2"),
+		WithPPI(192),
+	)
+	if err != nil {
+		t.Fatalf("newLatexBuilder() unexpected error: %v", err)
+	}
+
+	if builder.format != "png" || builder.pages != "1-2" || builder.ppi != 192 {
+		t.Errorf("options not applied: format=%q pages=%q ppi=%d", builder.format, builder.pages, builder.ppi)
+	}
+}
+
+func TestNewLatexBuilder_IgnoresTypstOptions(t *testing.T) {
+	t.Parallel()
+
+	builder, err := newLatexBuilder(
+		WithLatexPath("/fake/latexmk"),
+		WithTypstPath("/fake/typst"),
+		WithTypstRootFile("other.typ"),
+		WithTypstPackageCache("/fake/cache"),
+		WithTypstOffline(true),
+	)
+	if err != nil {
+		t.Fatalf("newLatexBuilder() unexpected error: %v", err)
+	}
+	if builder.execPath != "/fake/latexmk" || builder.rootFile == "other.typ" {
+		t.Errorf("typst options changed the latex builder: execPath=%q rootFile=%q", builder.execPath, builder.rootFile)
+	}
+}
+
+func TestParseLatexDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		output   string
+		expected []string
+	}{
+		{
+			name:     "empty output",
+			output:   "",
+			expected: nil,
+		},
+		{
+			name:     "file line error",
+			output:   "./resume.tex:12: Undefined control sequence.\nl.12 \\foo",
+			expected: []string{"resume.tex:12: error: Undefined control sequence."},
+		},
+		{
+			name:     "bang error with context line",
+			output:   "! Missing $ inserted.\n<inserted text>\n                $\nl.33 a_b",
+			expected: []string{"error: Missing $ inserted. (line 33)"},
+		},
+		{
+			name:     "missing package",
+			output:   "! LaTeX Error: File `foo.sty' not found.\n\n! Emergency stop.\n<read *>",
+			expected: []string{"error: LaTeX Error: File `foo.sty' not found."},
+		},
+		{
+			name:     "duplicate errors collapse",
+			output:   "./resume.tex:4: Undefined control sequence.\n./resume.tex:4: Undefined control sequence.",
+			expected: []string{"resume.tex:4: error: Undefined control sequence."},
+		},
+		{
+			name:     "latex warning",
+			output:   "LaTeX Warning: Reference `x' undefined on input line 3.\nOverfull \\hbox (2.0pt too wide)",
+			expected: []string{"warning: Reference `x' undefined (line 3)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var result []string
+			for _, d := range parseLatexDiagnostics(tt.output) {
+				result = append(result, d.String())
+			}
+			if !slices.Equal(result, tt.expected) {
+				t.Errorf("parseLatexDiagnostics() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseZrefPositions(t *testing.T) {
+	t.Parallel()
+
+	aux := `\relax
+\zref@newlabel{jobs}{\posx{4736286}\posy{47185920}\abspage{1}}
+\zref@newlabel{jobs-end}{\posx{35389440}\posy{23592960}\abspage{1}}
+\zref@newlabel{noposition}{\default{1}}
+`
+	positions := parseZrefPositions(aux)
+
+	if len(positions) != 2 {
+		t.Fatalf("len(positions) = %d, want 2", len(positions))
+	}
+	jobs := positions["jobs"]
+	if jobs.Page != 1 {
+		t.Errorf("jobs.Page = %d, want 1", jobs.Page)
+	}
+	if math.Abs(jobs.X-72.27) > 0.01 {
+		t.Errorf("jobs.X = %v, want ~72.27", jobs.X)
+	}
+	if math.Abs(jobs.Y-720) > 0.01 {
+		t.Errorf("jobs.Y = %v, want 720", jobs.Y)
+	}
+}
+
+func TestParsePageSelection(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		selection string
+		total     int
+		want      []int
+		wantErr   bool
+	}{
+		{name: "empty means all", selection: "", total: 3, want: []int{1, 2, 3}},
+		{name: "single page", selection: "2", total: 3, want: []int{2}},
+		{name: "range", selection: "1-2", total: 3, want: []int{1, 2}},
+		{name: "open range", selection: "2-", total: 4, want: []int{2, 3, 4}},
+		{name: "clamped to total", selection: "1-5", total: 2, want: []int{1, 2}},
+		{name: "list with overlap", selection: "3,1-2,2", total: 3, want: []int{1, 2, 3}},
+		{name: "invalid", selection: "a-b", total: 3, wantErr: true},
+		{name: "reversed", selection: "3-1", total: 3, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parsePageSelection(tt.selection, tt.total)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parsePageSelection(%q) error = nil, want error", tt.selection)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parsePageSelection(%q) unexpected error: %v", tt.selection, err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parsePageSelection(%q) = %v, want %v", tt.selection, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandPagePattern(t *testing.T) {
+	t.Parallel()
+
+	if got := expandPagePattern("/tmp/page-{0p}.png", 3, 12); got != "/tmp/page-03.png" {
+		t.Errorf("expandPagePattern({0p}) = %q, want /tmp/page-03.png", got)
+	}
+	if got := expandPagePattern("page-{p}-of-{t}.png", 3, 12); got != "page-3-of-12.png" {
+		t.Errorf("expandPagePattern({p}) = %q, want page-3-of-12.png", got)
+	}
+}
+
+func TestLatexBuilder_Build_MissingRootFile(t *testing.T) {
+	t.Parallel()
+
+	builder, err := newLatexBuilder(
+		WithLatexPath("/fake/latexmk"),
+		WithRootFile("fixtures/nonexistent.tex"),
+	)
+	if err != nil {
+		t.Fatalf("newLatexBuilder() unexpected error: %v", err)
+	}
+
+	result, err := builder.Build(context.Background(), ".", makeTempPDFPath(t))
+	if err != nil {
+		t.Fatalf("Build() unexpected error: %v", err)
+	}
+	if result.Success {
+		t.Errorf("Build() Success = true for missing root file, want false")
+	}
+	if len(result.Errors) == 0 || !strings.Contains(result.Errors[0], "input file not found") {
+		t.Errorf("Build() Errors %v doesn't contain 'input file not found'", result.Errors)
+	}
+}
+
+func TestLatexBuilder_Build_Success(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("latexmk"); err != nil {
+		t.Skip("latexmk not installed")
+	}
+
+	builder, err := newLatexBuilder(WithRootFile("fixtures/valid.tex"))
+	if err != nil {
+		t.Fatalf("newLatexBuilder() unexpected error: %v", err)
+	}
+
+	result, err := builder.Build(context.Background(), ".", makeTempPDFPath(t))
+	if err != nil {
+		t.Fatalf("Build() unexpected error: %v", err)
+	}
+	if !result.Success {
+		t.Errorf("Build() Success = false, want true. Errors: %v", result.Errors)
+	}
+}
+
+func TestLatexBuilder_Build_CompilationError(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("latexmk"); err != nil {
+		t.Skip("latexmk not installed")
+	}
+
+	builder, err := newLatexBuilder(WithRootFile("fixtures/invalid.tex"))
+	if err != nil {
+		t.Fatalf("newLatexBuilder() unexpected error: %v", err)
+	}
+
+	result, err := builder.Build(context.Background(), ".", makeTempPDFPath(t))
+	if err != nil {
+		t.Fatalf("Build() unexpected error: %v", err)
+	}
+	if result.Success {
+		t.Errorf("Build() Success = true, want false")
+	}
+	if len(result.Errors) == 0 {
+		t.Errorf("Build() Errors is empty, want errors")
+	}
+}
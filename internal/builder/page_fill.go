@@ -0,0 +1,130 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// DocumentEndLabel marks the end of a document's content.
+// Templates place it after the last element so the builder
+// can measure how full the last page is:
+//
+//	#context [#metadata((pos: here().position(), size: (width: 0pt, height: 0pt))) <document-end>]
+const DocumentEndLabel = "document-end"
+
+const (
+	// defaultPageMarginPt is the top and bottom page margin
+	// assumed when measuring fill (1 inch).
+	defaultPageMarginPt = 72.0
+	// defaultLineHeightPt approximates one line of body text
+	// (11pt with leading) when converting overflow to lines.
+	defaultLineHeightPt = 14.0
+)
+
+// PageFill describes how much of the page budget a compiled
+// document uses, measured at the DocumentEndLabel sentinel.
+// Heights are in points within the content area (inside
+// the top and bottom margins).
+type PageFill struct {
+	// LastPage is the page the content ends on.
+	LastPage int `json:"last_page"`
+	// LastPageFillPt is the height used on the last page.
+	LastPageFillPt float64 `json:"last_page_fill_pt"`
+	// ContentHeightPt is the usable height of a page.
+	ContentHeightPt float64 `json:"content_height_pt"`
+	// OverflowPt is how far content runs past the page
+	// limit; zero when it fits.
+	OverflowPt float64 `json:"overflow_pt"`
+	// OverflowLines estimates OverflowPt in body text lines.
+	OverflowLines int `json:"overflow_lines"`
+}
+
+// FillRatio is the fraction of the last page's content area
+// that is used.
+func (f *PageFill) FillRatio() float64 {
+	if f.ContentHeightPt <= 0 {
+		return 0
+	}
+	return f.LastPageFillPt / f.ContentHeightPt
+}
+
+func (f *PageFill) String() string {
+	s := fmt.Sprintf(
+		"last page %d filled %.0fpt of %.0fpt (%.0f%%)",
+		f.LastPage, f.LastPageFillPt, f.ContentHeightPt, f.FillRatio()*100,
+	)
+	if f.OverflowPt > 0 {
+		s += fmt.Sprintf(", overflows page limit by %.0fpt (~%d lines)", f.OverflowPt, f.OverflowLines)
+	}
+	return s
+}
+
+// computePageFill derives fill and overflow from the
+// sentinel's position. pageHeightPt is the height of the
+// page the sentinel is on.
+func computePageFill(end LabelPosition, pageHeightPt, marginPt, lineHeightPt float64, pageLimit int) (*PageFill, error) {
+	y, err := parsePoints(end.Y)
+	if err != nil {
+		return nil, err
+	}
+
+	content := pageHeightPt - 2*marginPt
+	if content <= 0 {
+		return nil, fmt.Errorf("page height %.2fpt leaves no content area", pageHeightPt)
+	}
+	fill := math.Min(math.Max(y-marginPt, 0), content)
+
+	ret := &PageFill{
+		LastPage:        end.Page,
+		LastPageFillPt:  fill,
+		ContentHeightPt: content,
+	}
+	if pageLimit > 0 && end.Page > pageLimit {
+		ret.OverflowPt = float64(end.Page-pageLimit-1)*content + fill
+		if lineHeightPt > 0 {
+			ret.OverflowLines = int(math.Ceil(ret.OverflowPt / lineHeightPt))
+		}
+	}
+	return ret, nil
+}
+
+// measurePageFill locates the DocumentEndLabel in the
+// compiled PDF at pdfPath. It returns nil if the document
+// has no sentinel.
+func (t *typstBuilder) measurePageFill(ctx context.Context, path, pdfPath string) *PageFill {
+	bbox, err := t.queryLabelBBox(ctx, path, DocumentEndLabel)
+	if err != nil {
+		slog.DebugContext(ctx, "Page fill unavailable", "label", DocumentEndLabel, "error", err)
+		return nil
+	}
+
+	dims, err := api.PageDimsFile(pdfPath)
+	if err != nil || bbox.Pos.Page < 1 || bbox.Pos.Page > len(dims) {
+		slog.DebugContext(ctx, "Page fill unavailable, bad page dimensions", "page", bbox.Pos.Page, "error", err)
+		return nil
+	}
+
+	fill, err := computePageFill(bbox.Pos, dims[bbox.Pos.Page-1].Height, t.marginPt, t.lineHeightPt, t.pageLimit)
+	if err != nil {
+		slog.DebugContext(ctx, "Page fill unavailable", "error", err)
+		return nil
+	}
+	return fill
+}
+
+func parsePoints(s string) (float64, error) {
+	if !strings.HasSuffix(s, "pt") {
+		return 0, fmt.Errorf("invalid point value %q", s)
+	}
+	v, err := strconv.ParseFloat(strings.TrimSuffix(s, "pt"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse point value %q: %w", s, err)
+	}
+	return v, nil
+}
@@ -0,0 +1,98 @@
+package builder
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestComputePageFill(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		end           LabelPosition
+		pageLimit     int
+		wantFill      float64
+		wantOverflow  float64
+		wantLines     int
+		wantOverflows bool
+	}{
+		{
+			name:      "half page within limit",
+			end:       LabelPosition{Page: 1, X: "72pt", Y: "396pt"},
+			pageLimit: 1,
+			wantFill:  324,
+		},
+		{
+			name:          "spills onto second page",
+			end:           LabelPosition{Page: 2, X: "72pt", Y: "100pt"},
+			pageLimit:     1,
+			wantFill:      28,
+			wantOverflow:  28,
+			wantLines:     2,
+			wantOverflows: true,
+		},
+		{
+			name:          "spills two pages past limit",
+			end:           LabelPosition{Page: 3, X: "72pt", Y: "72pt"},
+			pageLimit:     1,
+			wantFill:      0,
+			wantOverflow:  648,
+			wantLines:     47,
+			wantOverflows: true,
+		},
+		{
+			name:     "no limit",
+			end:      LabelPosition{Page: 3, X: "72pt", Y: "720pt"},
+			wantFill: 648,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fill, err := computePageFill(tt.end, 792, defaultPageMarginPt, defaultLineHeightPt, tt.pageLimit)
+			if err != nil {
+				t.Fatalf("computePageFill() unexpected error: %v", err)
+			}
+			if fill.LastPage != tt.end.Page {
+				t.Errorf("LastPage = %d, want %d", fill.LastPage, tt.end.Page)
+			}
+			if math.Abs(fill.LastPageFillPt-tt.wantFill) > 0.01 {
+				t.Errorf("LastPageFillPt = %v, want %v", fill.LastPageFillPt, tt.wantFill)
+			}
+			if math.Abs(fill.OverflowPt-tt.wantOverflow) > 0.01 {
+				t.Errorf("OverflowPt = %v, want %v", fill.OverflowPt, tt.wantOverflow)
+			}
+			if fill.OverflowLines != tt.wantLines {
+				t.Errorf("OverflowLines = %d, want %d", fill.OverflowLines, tt.wantLines)
+			}
+			if got := strings.Contains(fill.String(), "overflows"); got != tt.wantOverflows {
+				t.Errorf("String() = %q, overflow mentioned = %v, want %v", fill.String(), got, tt.wantOverflows)
+			}
+		})
+	}
+}
+
+func TestComputePageFill_InvalidPosition(t *testing.T) {
+	t.Parallel()
+
+	if _, err := computePageFill(LabelPosition{Page: 1, Y: "abc"}, 792, 72, 14, 1); err == nil {
+		t.Error("computePageFill() expected error for invalid y, got nil")
+	}
+	if _, err := computePageFill(LabelPosition{Page: 1, Y: "10pt"}, 100, 72, 14, 1); err == nil {
+		t.Error("computePageFill() expected error for page without content area, got nil")
+	}
+}
+
+func TestPageLimitDiagnostic_WithFill(t *testing.T) {
+	t.Parallel()
+
+	fill := &PageFill{LastPage: 2, OverflowPt: 28, OverflowLines: 2}
+	d := pageLimitDiagnostic("resume.typ", 2, 1, fill)
+	if len(d.Hints) != 1 || d.Hints[0] != "cut about 28pt (~2 lines) of content to fit" {
+		t.Errorf("Hints = %q", d.Hints)
+	}
+}
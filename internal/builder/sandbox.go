@@ -0,0 +1,170 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// limitWaitDelay bounds how long a killed compiler may hold
+// its output pipes open before Wait gives up on it.
+const limitWaitDelay = 5 * time.Second
+
+// limits bounds the resources of one compiler process. Zero
+// values mean no limit.
+type limits struct {
+	// cpuTime is the CPU time after which the kernel kills
+	// the process, rounded up to whole seconds.
+	cpuTime time.Duration
+	// memoryBytes caps the process's address space.
+	memoryBytes int64
+	// timeout is the wall-clock limit, enforced from Go so it
+	// also catches processes blocked on I/O.
+	timeout time.Duration
+}
+
+// WithCPUTimeLimit kills the compiler once it has used d of
+// CPU time, for any builder.
+func WithCPUTimeLimit(d time.Duration) func(Builder) {
+	return func(b Builder) {
+		switch b := b.(type) {
+		case *typstBuilder:
+			b.limits.cpuTime = d
+		case *latexBuilder:
+			b.limits.cpuTime = d
+		}
+	}
+}
+
+// WithMemoryLimit caps the compiler's address space at
+// bytes, for any builder.
+func WithMemoryLimit(bytes int64) func(Builder) {
+	return func(b Builder) {
+		switch b := b.(type) {
+		case *typstBuilder:
+			b.limits.memoryBytes = bytes
+		case *latexBuilder:
+			b.limits.memoryBytes = bytes
+		}
+	}
+}
+
+// WithTimeout kills the compiler after d of wall-clock
+// time, for any builder.
+func WithTimeout(d time.Duration) func(Builder) {
+	return func(b Builder) {
+		switch b := b.(type) {
+		case *typstBuilder:
+			b.limits.timeout = d
+		case *latexBuilder:
+			b.limits.timeout = d
+		}
+	}
+}
+
+// limitedCmd is a compiler command running under limits.
+type limitedCmd struct {
+	*exec.Cmd
+	limits limits
+	// parent is the caller's context and ctx the one bounded
+	// by the timeout, to tell our deadline from the caller's.
+	parent context.Context
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// command returns a command running name under the limits.
+// Resource limits are applied by a shell wrapper that execs
+// name, since Go cannot set rlimits on a child directly.
+// Close must be called once the command is done.
+func (l limits) command(ctx context.Context, name string, args ...string) *limitedCmd {
+	c := &limitedCmd{limits: l, parent: ctx, ctx: ctx, cancel: func() {}}
+	if l.timeout > 0 {
+		c.ctx, c.cancel = context.WithTimeout(ctx, l.timeout)
+	}
+
+	if ulimits := l.ulimitScript(); ulimits != "" {
+		script := ulimits + ` && exec "$0" "$@"`
+		c.Cmd = exec.CommandContext(c.ctx, "/bin/sh", append([]string{"-c", script, name}, args...)...)
+	} else {
+		c.Cmd = exec.CommandContext(c.ctx, name, args...)
+	}
+	// Kill the whole process group; latexmk leaves its
+	// engine running if only latexmk is killed.
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	c.Cancel = func() error {
+		return syscall.Kill(-c.Process.Pid, syscall.SIGKILL)
+	}
+	c.WaitDelay = limitWaitDelay
+	return c
+}
+
+// ulimitScript returns the shell commands setting the
+// rlimits, or "" if none are set.
+func (l limits) ulimitScript() string {
+	var cmds []string
+	if l.cpuTime > 0 {
+		// The soft limit sends SIGXCPU, which we can tell
+		// apart from other kills; the hard limit a second
+		// later catches processes that ignore it.
+		secs := int64(math.Ceil(l.cpuTime.Seconds()))
+		cmds = append(cmds,
+			"ulimit -t "+strconv.FormatInt(secs+1, 10),
+			"ulimit -S -t "+strconv.FormatInt(secs, 10),
+		)
+	}
+	if l.memoryBytes > 0 {
+		// ulimit -v takes KiB.
+		cmds = append(cmds, "ulimit -v "+strconv.FormatInt((l.memoryBytes+1023)/1024, 10))
+	}
+	return strings.Join(cmds, " && ")
+}
+
+func (c *limitedCmd) Close() {
+	c.cancel()
+}
+
+// limitExceeded explains a failure caused by one of the
+// limits, or returns "" if err has another cause.
+func (c *limitedCmd) limitExceeded(err error) string {
+	if err == nil {
+		return ""
+	}
+	if errors.Is(c.ctx.Err(), context.DeadlineExceeded) && c.parent.Err() == nil {
+		return fmt.Sprintf("compile timed out after %s", c.limits.timeout)
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return ""
+	}
+	ws, ok := exitErr.Sys().(syscall.WaitStatus)
+	if !ok || !ws.Signaled() {
+		return ""
+	}
+	switch {
+	case c.limits.cpuTime > 0 && ws.Signal() == syscall.SIGXCPU,
+		c.limits.cpuTime > 0 && ws.Signal() == syscall.SIGKILL && exitErr.UserTime()+exitErr.SystemTime() >= c.limits.cpuTime:
+		return fmt.Sprintf("compile exceeded the %s CPU time limit", c.limits.cpuTime)
+	case c.limits.memoryBytes > 0 && (ws.Signal() == syscall.SIGABRT || ws.Signal() == syscall.SIGSEGV):
+		// Failed allocations abort the process.
+		return fmt.Sprintf("compile exceeded the %d byte memory limit", c.limits.memoryBytes)
+	}
+	return ""
+}
+
+// limitDiagnostic turns a limit failure into an error
+// diagnostic, so callers see it like any compile error.
+func limitDiagnostic(msg string) Diagnostic {
+	return Diagnostic{
+		Severity: SeverityError,
+		Message:  msg,
+		Hints:    []string{"the document may loop forever or build something very large; simplify the most recent change"},
+	}
+}
@@ -0,0 +1,111 @@
+package builder
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLimitsUlimitScript(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		limits limits
+		want   string
+	}{
+		{
+			name: "no limits",
+		},
+		{
+			name:   "cpu time rounds up",
+			limits: limits{cpuTime: 1500 * time.Millisecond},
+			want:   "ulimit -t 3 && ulimit -S -t 2",
+		},
+		{
+			name:   "memory in KiB",
+			limits: limits{cpuTime: 30 * time.Second, memoryBytes: 1 << 30},
+			want:   "ulimit -t 31 && ulimit -S -t 30 && ulimit -v 1048576",
+		},
+		{
+			name:   "timeout needs no ulimit",
+			limits: limits{timeout: time.Minute},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.limits.ulimitScript(); got != tt.want {
+				t.Errorf("ulimitScript() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimitsCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		limits     limits
+		script     string
+		wantOutput string
+		wantLimit  string
+	}{
+		{
+			name:       "passes arguments through the wrapper",
+			limits:     limits{cpuTime: 10 * time.Second},
+			script:     `echo "$1"`,
+			wantOutput: "hello world",
+		},
+		{
+			name:      "timeout",
+			limits:    limits{timeout: 100 * time.Millisecond},
+			script:    "sleep 10",
+			wantLimit: "compile timed out after 100ms",
+		},
+		{
+			name:      "cpu time",
+			limits:    limits{cpuTime: time.Second, timeout: 30 * time.Second},
+			script:    "while :; do :; done",
+			wantLimit: "compile exceeded the 1s CPU time limit",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := tt.limits.command(context.Background(), "/bin/sh", "-c", tt.script, "sh", "hello world")
+			defer cmd.Close()
+			output, err := cmd.Output()
+
+			if got := cmd.limitExceeded(err); got != tt.wantLimit {
+				t.Errorf("limitExceeded() = %q, want %q (err %v)", got, tt.wantLimit, err)
+			}
+			if tt.wantOutput != "" && strings.TrimSpace(string(output)) != tt.wantOutput {
+				t.Errorf("output = %q, want %q", output, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestLimitsCommandCallerCancel(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	cmd := limits{timeout: time.Minute}.command(ctx, "/bin/sh", "-c", "sleep 10")
+	defer cmd.Close()
+	err := cmd.Run()
+	if err == nil {
+		t.Fatal("Run() expected error")
+	}
+	if got := cmd.limitExceeded(err); got != "" {
+		t.Errorf("limitExceeded() = %q, want the caller's deadline not blamed on the limits", got)
+	}
+}
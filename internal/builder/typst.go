@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -18,51 +20,121 @@ type typstBuilder struct {
 	pages     string
 	ppi       int
 	pageLimit int // 0 = no limit, default = 1
+	// pdfStandard is passed to --pdf-standard, e.g. "a-2b".
+	pdfStandard string
+	limits      limits
+	// packageCache is passed to --package-cache-path.
+	packageCache string
+	// offline fails builds that import a package missing
+	// from the package cache instead of downloading it.
+	offline bool
+
+	// Page geometry used to measure fill at DocumentEndLabel.
+	marginPt     float64
+	lineHeightPt float64
+	// measureFill measures fill on every PDF build, not only
+	// on builds over the page limit.
+	measureFill bool
 }
 
 func WithTypstPath(path string) func(Builder) {
 	return func(b Builder) {
-		b.(*typstBuilder).execPath = path
+		if b, ok := b.(*typstBuilder); ok {
+			b.execPath = path
+		}
 	}
 }
 
 func WithTypstRootFile(path string) func(Builder) {
 	return func(b Builder) {
-		b.(*typstBuilder).rootFile = path
+		if b, ok := b.(*typstBuilder); ok {
+			b.rootFile = path
+		}
 	}
 }
 
 func WithTypstFormat(format string) func(Builder) {
 	return func(b Builder) {
-		b.(*typstBuilder).format = format
+		if b, ok := b.(*typstBuilder); ok {
+			b.format = format
+		}
 	}
 }
 
 func WithTypstPages(pages string) func(Builder) {
 	return func(b Builder) {
-		b.(*typstBuilder).pages = pages
+		if b, ok := b.(*typstBuilder); ok {
+			b.pages = pages
+		}
 	}
 }
 
 func WithTypstPPI(ppi int) func(Builder) {
 	return func(b Builder) {
-		b.(*typstBuilder).ppi = ppi
+		if b, ok := b.(*typstBuilder); ok {
+			b.ppi = ppi
+		}
+	}
+}
+
+// WithTypstPageMargin sets the top and bottom page margin,
+// in points, assumed when measuring page fill.
+func WithTypstPageMargin(pt float64) func(Builder) {
+	return func(b Builder) {
+		if b, ok := b.(*typstBuilder); ok {
+			b.marginPt = pt
+		}
+	}
+}
+
+// WithTypstLineHeight sets the body line height, in points,
+// used to express overflow as a number of lines.
+func WithTypstLineHeight(pt float64) func(Builder) {
+	return func(b Builder) {
+		if b, ok := b.(*typstBuilder); ok {
+			b.lineHeightPt = pt
+		}
+	}
+}
+
+// WithTypstMeasureFill reports page fill on every PDF
+// build. By default fill is measured only when a build
+// exceeds the page limit, since it costs a typst query.
+func WithTypstMeasureFill(measure bool) func(Builder) {
+	return func(b Builder) {
+		if b, ok := b.(*typstBuilder); ok {
+			b.measureFill = measure
+		}
+	}
+}
+
+// WithTypstPackageCache points typst at a package cache,
+// typically one vendored with SeedPackageCache.
+func WithTypstPackageCache(dir string) func(Builder) {
+	return func(b Builder) {
+		if b, ok := b.(*typstBuilder); ok {
+			b.packageCache = dir
+		}
 	}
 }
 
-func WithPageLimit(limit int) func(Builder) {
+// WithTypstOffline stops typst from downloading packages.
+// Builds importing a package missing from the package cache
+// fail before typst runs.
+func WithTypstOffline(offline bool) func(Builder) {
 	return func(b Builder) {
-		switch b := b.(type) {
-		case *typstBuilder:
-			b.pageLimit = limit
+		if b, ok := b.(*typstBuilder); ok {
+			b.offline = offline
 		}
 	}
 }
 
 func newTypstBuilder(opts ...func(Builder)) (*typstBuilder, error) {
 	ret := &typstBuilder{
-		format:    "pdf",
-		pageLimit: 1, // default to 1 page for resumes
+		format:       "pdf",
+		pageLimit:    1, // default to 1 page for resumes
+		marginPt:     defaultPageMarginPt,
+		lineHeightPt: defaultLineHeightPt,
 	}
 	for _, opt := range opts {
 		opt(ret)
@@ -91,7 +163,7 @@ func (t *typstBuilder) Build(ctx context.Context, path string, outputPath string
 		outputPath,
 		"--root",
 		path,
-		"--diagnostic-format=short",
+		"--diagnostic-format=human",
 	}
 	if t.format != "" {
 		args = append(args, "--format", t.format)
@@ -102,56 +174,163 @@ func (t *typstBuilder) Build(ctx context.Context, path string, outputPath string
 	if t.format == "png" && t.ppi > 0 {
 		args = append(args, "--ppi", strconv.Itoa(t.ppi))
 	}
-	cmd := exec.CommandContext(ctx, t.execPath, args...)
+	if t.format == "pdf" && t.pdfStandard != "" {
+		args = append(args, "--pdf-standard", t.pdfStandard)
+	}
+
+	// Fail fast on packages that cannot be fetched offline
+	if diags, err := t.checkPackages(path); err != nil {
+		return nil, err
+	} else if len(diags) > 0 {
+		attachExcerpts(diags, path)
+		return &BuildResult{
+			Success:     false,
+			Errors:      errorStrings(diags),
+			Diagnostics: diags,
+			PageLimit:   t.pageLimit,
+		}, nil
+	}
+
+	cmd := t.command(ctx, args...)
+	defer cmd.Close()
 	slog.InfoContext(ctx, "Running typst command", "cmd", cmd.String())
 	output, err := cmd.CombinedOutput()
 
-	// Parse errors from output
-	errors := parseTypstErrors(string(output))
+	// Parse diagnostics from output
+	diags := parseTypstDiagnostics(string(output))
+	if msg := cmd.limitExceeded(err); msg != "" {
+		diags = append(diags, limitDiagnostic(msg))
+	}
+	attachExcerpts(diags, path)
 
 	// If command failed, return result with errors
 	if err != nil {
 		return &BuildResult{
-			Success: false,
-			Errors:  errors,
+			Success:     false,
+			Errors:      errorStrings(diags),
+			Diagnostics: diags,
+			PageLimit:   t.pageLimit,
 		}, nil
 	}
 
-	// Check page limit
-	if t.format == "pdf" && t.pageLimit > 0 {
-		pageCount, err := api.PageCountFile(outputPath)
+	// Count pages and measure fill
+	var pageCount int
+	var fill *PageFill
+	if t.format == "pdf" {
+		pageCount, err = api.PageCountFile(outputPath)
 		if err != nil {
 			return nil, fmt.Errorf("failed to count PDF pages: %w", err)
 		}
+		if t.measureFill || (t.pageLimit > 0 && pageCount > t.pageLimit) {
+			fill = t.measurePageFill(ctx, path, outputPath)
+		}
+	}
+
+	// Check page limit
+	if t.format == "pdf" && t.pageLimit > 0 {
 		slog.DebugContext(ctx, "PDF page count", "count", pageCount, "limit", t.pageLimit)
 		if pageCount > t.pageLimit {
+			diags = append(diags, pageLimitDiagnostic(relativeRootFile(path, t.rootFile), pageCount, t.pageLimit, fill))
 			return &BuildResult{
-				Success: false,
-				Errors:  []string{"page limit exceeded"},
+				Success:     false,
+				Errors:      errorStrings(diags),
+				Diagnostics: diags,
+				PageCount:   pageCount,
+				PageLimit:   t.pageLimit,
+				Fill:        fill,
 			}, nil
 		}
 	}
 
 	// Success case
 	return &BuildResult{
-		Success: true,
-		Errors:  nil,
+		Success:     true,
+		Errors:      nil,
+		Diagnostics: diags,
+		PageCount:   pageCount,
+		PageLimit:   t.pageLimit,
+		Fill:        fill,
 	}, nil
 }
 
-func parseTypstErrors(output string) []string {
-	var errors []string
-	lines := strings.Split(output, "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
+func (t *typstBuilder) QueryLabelBBox(ctx context.Context, path, label string) (*LabelBBox, error) {
+	return t.queryLabelBBox(ctx, path, label)
+}
+
+func (t *typstBuilder) QueryAllLabels(ctx context.Context, path string) ([]string, error) {
+	return t.queryAllLabels(ctx, path)
+}
+
+// Fonts lists the font families typst can use, including
+// its embedded fonts and any in TYPST_FONT_PATHS.
+func (t *typstBuilder) Fonts(ctx context.Context) ([]string, error) {
+	cmd := t.limits.command(ctx, t.execPath, "fonts")
+	defer cmd.Close()
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := cmd.limitExceeded(err); msg != "" {
+			return nil, fmt.Errorf("typst fonts failed: %s", msg)
 		}
-		// Match format: file:line:col: error: message
-		// or: error: message (e.g., "error: input file not found")
-		if strings.Contains(line, ": error:") || strings.HasPrefix(line, "error:") {
-			errors = append(errors, line)
+		return nil, fmt.Errorf("typst fonts failed: %w", err)
+	}
+
+	var families []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if family := strings.TrimSpace(line); family != "" {
+			families = append(families, family)
 		}
 	}
-	return errors
+	return families, nil
+}
+
+// command returns a typst command with args under the
+// builder's limits and package settings.
+func (t *typstBuilder) command(ctx context.Context, args ...string) *limitedCmd {
+	if t.packageCache != "" {
+		args = append(args, "--package-cache-path", t.packageCache)
+	}
+	cmd := t.limits.command(ctx, t.execPath, args...)
+	if t.offline {
+		cmd.Env = append(os.Environ(),
+			"HTTPS_PROXY="+offlineProxy,
+			"HTTP_PROXY="+offlineProxy,
+			"ALL_PROXY="+offlineProxy,
+			"https_proxy="+offlineProxy,
+			"http_proxy="+offlineProxy,
+			"all_proxy="+offlineProxy,
+			"NO_PROXY=",
+			"no_proxy=",
+		)
+	}
+	return cmd
+}
+
+// checkPackages reports package imports under path that an
+// offline build cannot resolve. Online builds always pass.
+func (t *typstBuilder) checkPackages(path string) ([]Diagnostic, error) {
+	if !t.offline {
+		return nil, nil
+	}
+	cacheDir := t.packageCache
+	if cacheDir == "" {
+		cacheDir = defaultPackageCache()
+	}
+	diags, err := missingPackages(path, cacheDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check typst packages: %w", err)
+	}
+	return diags, nil
+}
+
+func parseTypstErrors(output string) []string {
+	return errorStrings(parseTypstDiagnostics(output))
+}
+
+// relativeRootFile returns rootFile relative to the project
+// root when possible, matching how compilers report paths.
+func relativeRootFile(root, rootFile string) string {
+	if rel, err := filepath.Rel(root, rootFile); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return rootFile
 }
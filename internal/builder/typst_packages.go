@@ -0,0 +1,304 @@
+package builder
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// DefaultPackageRegistry serves typst's @preview packages.
+const DefaultPackageRegistry = "https://packages.typst.org"
+
+// offlineProxy is an address nothing listens on. Offline
+// compiles route package downloads to it so a package the
+// pre-check missed fails at once instead of reaching the
+// registry.
+const offlineProxy = "http://127.0.0.1:9"
+
+// maxPackageArchiveBytes bounds a downloaded package archive.
+const maxPackageArchiveBytes = 64 << 20
+
+// PackageSpec pins one typst package, e.g.
+// @preview/fontawesome:0.5.0, to the digest of its archive.
+type PackageSpec struct {
+	Namespace string
+	Name      string
+	Version   string
+	// SHA256 is the hex digest of the .tar.gz archive.
+	SHA256 string
+}
+
+func (p PackageSpec) String() string {
+	return fmt.Sprintf("@%s/%s:%s", p.Namespace, p.Name, p.Version)
+}
+
+// dir returns where typst looks for the package in a
+// package cache.
+func (p PackageSpec) dir(cacheDir string) string {
+	return filepath.Join(cacheDir, p.Namespace, p.Name, p.Version)
+}
+
+var (
+	packageSpecRe   = regexp.MustCompile(`^@([a-z][a-z0-9-]*)/([A-Za-z0-9_-]+):(\d+\.\d+\.\d+)$`)
+	packageImportRe = regexp.MustCompile(`"@([a-z][a-z0-9-]*)/([A-Za-z0-9_-]+):(\d+\.\d+\.\d+)"`)
+)
+
+// ParsePackageLock reads a package lockfile. Each line pins
+// one package to the digest of its archive:
+//
+//	# comments and blank lines are ignored
+//	@preview/fontawesome:0.5.0 sha256:<hex digest>
+func ParsePackageLock(r io.Reader) ([]PackageSpec, error) {
+	var specs []PackageSpec
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: want \"@namespace/name:version sha256:<digest>\"", lineNo)
+		}
+		m := packageSpecRe.FindStringSubmatch(fields[0])
+		if m == nil {
+			return nil, fmt.Errorf("line %d: invalid package %q", lineNo, fields[0])
+		}
+		digest, ok := strings.CutPrefix(fields[1], "sha256:")
+		if _, err := hex.DecodeString(digest); !ok || err != nil || len(digest) != sha256.Size*2 {
+			return nil, fmt.Errorf("line %d: invalid digest %q", lineNo, fields[1])
+		}
+		specs = append(specs, PackageSpec{
+			Namespace: m[1],
+			Name:      m[2],
+			Version:   m[3],
+			SHA256:    strings.ToLower(digest),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return specs, nil
+}
+
+// ReadPackageLock reads the package lockfile at path.
+func ReadPackageLock(path string) ([]PackageSpec, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	specs, err := ParsePackageLock(f)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return specs, nil
+}
+
+// SeedPackageCache downloads every locked package missing
+// from cacheDir from registry, verifies its digest and
+// unpacks it where typst's --package-cache-path expects it.
+// Packages already in the cache are left alone.
+func SeedPackageCache(ctx context.Context, client *http.Client, registry, cacheDir string, specs []PackageSpec) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if registry == "" {
+		registry = DefaultPackageRegistry
+	}
+
+	for _, spec := range specs {
+		dir := spec.dir(cacheDir)
+		if _, err := os.Stat(filepath.Join(dir, "typst.toml")); err == nil {
+			continue
+		}
+		archive, err := downloadPackage(ctx, client, registry, spec)
+		if err != nil {
+			return fmt.Errorf("download %s: %w", spec, err)
+		}
+		if err = installPackage(archive, dir); err != nil {
+			return fmt.Errorf("install %s: %w", spec, err)
+		}
+	}
+	return nil
+}
+
+func downloadPackage(ctx context.Context, client *http.Client, registry string, spec PackageSpec) ([]byte, error) {
+	url := fmt.Sprintf("%s/%s/%s-%s.tar.gz", strings.TrimRight(registry, "/"), spec.Namespace, spec.Name, spec.Version)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	archive, err := io.ReadAll(io.LimitReader(resp.Body, maxPackageArchiveBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(archive) > maxPackageArchiveBytes {
+		return nil, fmt.Errorf("archive larger than %d bytes", maxPackageArchiveBytes)
+	}
+	sum := sha256.Sum256(archive)
+	if got := hex.EncodeToString(sum[:]); got != spec.SHA256 {
+		return nil, fmt.Errorf("digest mismatch: got sha256:%s, want sha256:%s", got, spec.SHA256)
+	}
+	return archive, nil
+}
+
+// installPackage unpacks archive beside dir and renames it
+// into place, so a partly unpacked package is never seen.
+func installPackage(archive []byte, dir string) error {
+	if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return err
+	}
+	tmpDir, err := os.MkdirTemp(filepath.Dir(dir), ".seed-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		name := filepath.Clean(filepath.FromSlash(hdr.Name))
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("archive entry %q escapes the package", hdr.Name)
+		}
+		target := filepath.Join(tmpDir, name)
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err = os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err = os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, err = os.Stat(filepath.Join(tmpDir, "typst.toml")); err != nil {
+		return fmt.Errorf("archive has no typst.toml")
+	}
+	_ = os.RemoveAll(dir)
+	return os.Rename(tmpDir, dir)
+}
+
+// defaultPackageCache is where typst caches packages when no
+// --package-cache-path is given.
+func defaultPackageCache() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "typst", "packages")
+}
+
+// missingPackages scans the .typ files under root, and the
+// cached packages they import, for package imports that are
+// not in cacheDir. It reports one diagnostic per import.
+func missingPackages(root, cacheDir string) ([]Diagnostic, error) {
+	var diags []Diagnostic
+	seen := make(map[string]bool)
+
+	var scan func(dir, prefix string) error
+	scan = func(dir, prefix string) error {
+		return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if p != dir && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if filepath.Ext(p) != ".typ" {
+				return nil
+			}
+			content, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			rel, _ := filepath.Rel(dir, p)
+			file := filepath.ToSlash(filepath.Join(prefix, rel))
+
+			for lineNo, line := range strings.Split(string(content), "\n") {
+				for _, m := range packageImportRe.FindAllStringSubmatchIndex(line, -1) {
+					spec := PackageSpec{
+						Namespace: line[m[2]:m[3]],
+						Name:      line[m[4]:m[5]],
+						Version:   line[m[6]:m[7]],
+					}
+					pkgDir := spec.dir(cacheDir)
+					if _, err := os.Stat(filepath.Join(pkgDir, "typst.toml")); err != nil {
+						diags = append(diags, Diagnostic{
+							File:     file,
+							Line:     lineNo + 1,
+							Column:   m[0] + 1,
+							Severity: SeverityError,
+							Message:  fmt.Sprintf("package %s is not in the offline package cache", spec),
+							Hints:    []string{"builds cannot download packages; use a package version pinned in the worker's package lockfile"},
+						})
+						continue
+					}
+					if !seen[pkgDir] {
+						seen[pkgDir] = true
+						if err := scan(pkgDir, spec.String()); err != nil {
+							return err
+						}
+					}
+				}
+			}
+			return nil
+		})
+	}
+
+	if err := scan(root, ""); err != nil {
+		return nil, err
+	}
+	return diags, nil
+}
@@ -0,0 +1,240 @@
+package builder
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testDigest = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+func TestParsePackageLock(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    []PackageSpec
+		wantErr string
+	}{
+		{
+			name:  "comments and blank lines",
+			input: "# vendored packages\n\n@preview/fontawesome:0.5.0 sha256:" + strings.ToUpper(testDigest) + "\n",
+			want: []PackageSpec{
+				{Namespace: "preview", Name: "fontawesome", Version: "0.5.0", SHA256: testDigest},
+			},
+		},
+		{
+			name:    "missing digest",
+			input:   "@preview/fontawesome:0.5.0\n",
+			wantErr: "line 1",
+		},
+		{
+			name:    "bad package",
+			input:   "preview/fontawesome sha256:" + testDigest + "\n",
+			wantErr: `invalid package "preview/fontawesome"`,
+		},
+		{
+			name:    "short digest",
+			input:   "@preview/fontawesome:0.5.0 sha256:abcd\n",
+			wantErr: "invalid digest",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParsePackageLock(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParsePackageLock() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePackageLock() unexpected error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParsePackageLock() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("spec[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func packageArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatalf("WriteHeader() unexpected error: %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("Write() unexpected error: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Close() unexpected error: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("Close() unexpected error: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestSeedPackageCache(t *testing.T) {
+	t.Parallel()
+
+	archive := packageArchive(t, map[string]string{
+		"typst.toml":  "[package]\nname = \"icons\"\n",
+		"lib/lib.typ": "#let icon = none\n",
+	})
+	sum := sha256.Sum256(archive)
+
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/preview/icons-1.2.3.tar.gz" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(archive)
+	}))
+	defer srv.Close()
+
+	cacheDir := t.TempDir()
+	spec := PackageSpec{Namespace: "preview", Name: "icons", Version: "1.2.3", SHA256: hex.EncodeToString(sum[:])}
+
+	if err := SeedPackageCache(context.Background(), srv.Client(), srv.URL, cacheDir, []PackageSpec{spec}); err != nil {
+		t.Fatalf("SeedPackageCache() unexpected error: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(cacheDir, "preview", "icons", "1.2.3", "lib", "lib.typ"))
+	if err != nil {
+		t.Fatalf("ReadFile() unexpected error: %v", err)
+	}
+	if string(got) != "#let icon = none\n" {
+		t.Errorf("lib.typ = %q", got)
+	}
+
+	// Seeding again finds the package already cached.
+	if err = SeedPackageCache(context.Background(), srv.Client(), srv.URL, cacheDir, []PackageSpec{spec}); err != nil {
+		t.Fatalf("SeedPackageCache() unexpected error: %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("requests = %d, want 1", requests)
+	}
+
+	bad := spec
+	bad.Version = "1.2.4"
+	bad.SHA256 = testDigest
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(archive)
+	})
+	err = SeedPackageCache(context.Background(), srv.Client(), srv.URL, cacheDir, []PackageSpec{bad})
+	if err == nil || !strings.Contains(err.Error(), "digest mismatch") {
+		t.Fatalf("SeedPackageCache() error = %v, want digest mismatch", err)
+	}
+	if _, err = os.Stat(filepath.Join(cacheDir, "preview", "icons", "1.2.4")); !os.IsNotExist(err) {
+		t.Errorf("mismatched package was installed")
+	}
+}
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		p := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatalf("MkdirAll() unexpected error: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatalf("WriteFile() unexpected error: %v", err)
+		}
+	}
+}
+
+func TestMissingPackages(t *testing.T) {
+	t.Parallel()
+
+	cacheDir := t.TempDir()
+	writeFiles(t, cacheDir, map[string]string{
+		"preview/icons/1.2.3/typst.toml": "",
+		"preview/icons/1.2.3/lib.typ":    "#import \"@preview/shapes:0.1.0\": *\n",
+	})
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"main.typ":         "#import \"@preview/icons:1.2.3\": icon\n#import \"template.typ\": *\n",
+		"template.typ":     "// header\n#import \"@preview/tables:2.0.0\": table\n",
+		".git/ignored.typ": "#import \"@preview/ignored:1.0.0\"\n",
+	})
+
+	diags, err := missingPackages(root, cacheDir)
+	if err != nil {
+		t.Fatalf("missingPackages() unexpected error: %v", err)
+	}
+
+	got := make([]string, len(diags))
+	for i, d := range diags {
+		got[i] = d.String()
+	}
+	want := []string{
+		"@preview/icons:1.2.3/lib.typ:1:9: error: package @preview/shapes:0.1.0 is not in the offline package cache",
+		"template.typ:2:9: error: package @preview/tables:2.0.0 is not in the offline package cache",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("missingPackages() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestTypstBuilderOfflineMissingPackage(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"main.typ": "#import \"@preview/icons:1.2.3\": icon\n",
+	})
+
+	// The binary does not exist; the pre-check fails first.
+	b, err := newTypstBuilder(
+		WithTypstPath(filepath.Join(root, "no-typst")),
+		WithTypstRootFile(filepath.Join(root, "main.typ")),
+		WithTypstPackageCache(t.TempDir()),
+		WithTypstOffline(true),
+	)
+	if err != nil {
+		t.Fatalf("newTypstBuilder() unexpected error: %v", err)
+	}
+
+	result, err := b.Build(context.Background(), root, makeTempPDFPath(t))
+	if err != nil {
+		t.Fatalf("Build() unexpected error: %v", err)
+	}
+	if result.Success {
+		t.Fatal("Build() succeeded, want missing package failure")
+	}
+	if len(result.Diagnostics) != 1 || result.Diagnostics[0].Excerpt == "" {
+		t.Errorf("Diagnostics = %+v, want one with an excerpt", result.Diagnostics)
+	}
+
+	if _, err = b.QueryAllLabels(context.Background(), root); err == nil || !strings.Contains(err.Error(), "@preview/icons:1.2.3") {
+		t.Errorf("QueryAllLabels() error = %v, want missing package", err)
+	}
+}
@@ -32,6 +32,32 @@ type typstQueryResult struct {
 }
 
 func TypstQuery(ctx context.Context, typstBin, rootFile, root, selector string, extraFlags ...string) ([]byte, error) {
+	t, err := queryBuilder(typstBin, rootFile)
+	if err != nil {
+		return nil, err
+	}
+	return t.query(ctx, root, selector, extraFlags...)
+}
+
+func QueryLabelBBox(ctx context.Context, typstBin, rootFile, root, label string) (*LabelBBox, error) {
+	t, err := queryBuilder(typstBin, rootFile)
+	if err != nil {
+		return nil, err
+	}
+	return t.queryLabelBBox(ctx, root, label)
+}
+
+func QueryAllLabels(ctx context.Context, typstBin, rootFile, root string) ([]string, error) {
+	t, err := queryBuilder(typstBin, rootFile)
+	if err != nil {
+		return nil, err
+	}
+	return t.queryAllLabels(ctx, root)
+}
+
+// queryBuilder returns a builder with no limits for the
+// package-level query functions.
+func queryBuilder(typstBin, rootFile string) (*typstBuilder, error) {
 	if typstBin == "" {
 		var err error
 		typstBin, err = exec.LookPath("typst")
@@ -39,13 +65,28 @@ func TypstQuery(ctx context.Context, typstBin, rootFile, root, selector string,
 			return nil, fmt.Errorf("failed to find typst binary: %w", err)
 		}
 	}
+	return &typstBuilder{execPath: typstBin, rootFile: rootFile}, nil
+}
 
-	args := []string{"query", rootFile, selector, "--root", root}
+func (t *typstBuilder) query(ctx context.Context, root, selector string, extraFlags ...string) ([]byte, error) {
+	diags, err := t.checkPackages(root)
+	if err != nil {
+		return nil, err
+	}
+	if len(diags) > 0 {
+		return nil, fmt.Errorf("typst query failed: %s", diags[0])
+	}
+
+	args := []string{"query", t.rootFile, selector, "--root", root}
 	args = append(args, extraFlags...)
 
-	cmd := exec.CommandContext(ctx, typstBin, args...)
+	cmd := t.command(ctx, args...)
+	defer cmd.Close()
 	stdout, err := cmd.Output()
 	if err != nil {
+		if msg := cmd.limitExceeded(err); msg != "" {
+			return nil, fmt.Errorf("typst query failed: %s", msg)
+		}
 		var exitErr *exec.ExitError
 		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
 			return nil, fmt.Errorf("typst query failed: %w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
@@ -56,15 +97,7 @@ func TypstQuery(ctx context.Context, typstBin, rootFile, root, selector string,
 	return stdout, nil
 }
 
-func QueryLabelBBox(ctx context.Context, typstBin, rootFile, root, label string) (*LabelBBox, error) {
-	if typstBin == "" {
-		var err error
-		typstBin, err = exec.LookPath("typst")
-		if err != nil {
-			return nil, fmt.Errorf("failed to find typst binary: %w", err)
-		}
-	}
-
+func (t *typstBuilder) queryLabelBBox(ctx context.Context, root, label string) (*LabelBBox, error) {
 	if !strings.HasPrefix(label, "<") {
 		label = "<" + label
 	}
@@ -72,7 +105,7 @@ func QueryLabelBBox(ctx context.Context, typstBin, rootFile, root, label string)
 		label += ">"
 	}
 
-	output, err := TypstQuery(ctx, typstBin, rootFile, root, label, "--field", "value", "--one")
+	output, err := t.query(ctx, root, label, "--field", "value", "--one")
 	if err != nil {
 		return nil, err
 	}
@@ -85,8 +118,8 @@ func QueryLabelBBox(ctx context.Context, typstBin, rootFile, root, label string)
 	return &bbox, nil
 }
 
-func QueryAllLabels(ctx context.Context, typstBin, rootFile, root string) ([]string, error) {
-	output, err := TypstQuery(ctx, typstBin, rootFile, root, "metadata")
+func (t *typstBuilder) queryAllLabels(ctx context.Context, root string) ([]string, error) {
+	output, err := t.query(ctx, root, "metadata")
 	if err != nil {
 		return nil, err
 	}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -110,6 +112,30 @@ func TestNewTypstBuilder_DefaultPageLimit(t *testing.T) {
 	}
 }
 
+func TestNewTypstBuilder_WithPageGeometry(t *testing.T) {
+	t.Parallel()
+
+	builder, err := newTypstBuilder(
+		WithTypstPath("/fake/typst"),
+		WithTypstPageMargin(36),
+		WithTypstLineHeight(12),
+		WithTypstMeasureFill(true),
+	)
+	if err != nil {
+		t.Fatalf("newTypstBuilder() unexpected error: %v", err)
+	}
+
+	if builder.marginPt != 36 {
+		t.Errorf("marginPt = %v, want 36", builder.marginPt)
+	}
+	if builder.lineHeightPt != 12 {
+		t.Errorf("lineHeightPt = %v, want 12", builder.lineHeightPt)
+	}
+	if !builder.measureFill {
+		t.Error("measureFill = false, want true")
+	}
+}
+
 func TestNewTypstBuilder_DefaultExecPath(t *testing.T) {
 	t.Parallel()
 
@@ -431,3 +457,26 @@ func TestTypstBuilder_Build_Cancelled(t *testing.T) {
 		t.Errorf("Build() expected failure for cancelled context, got success")
 	}
 }
+
+func TestTypstBuilderFonts(t *testing.T) {
+	t.Parallel()
+
+	// Stand in for typst with a script printing its output.
+	bin := filepath.Join(t.TempDir(), "typst")
+	script := "#!/bin/sh\n[ \"$1\" = fonts ] || exit 1\nprintf 'DejaVu Sans Mono\\nLibertinus Serif\\n\\n'\n"
+	if err := os.WriteFile(bin, []byte(script), 0o755); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+
+	b, err := newTypstBuilder(WithTypstPath(bin))
+	if err != nil {
+		t.Fatalf("newTypstBuilder() unexpected error: %v", err)
+	}
+	got, err := b.Fonts(context.Background())
+	if err != nil {
+		t.Fatalf("Fonts() unexpected error: %v", err)
+	}
+	if want := []string{"DejaVu Sans Mono", "Libertinus Serif"}; !slices.Equal(got, want) {
+		t.Errorf("Fonts() = %q, want %q", got, want)
+	}
+}
@@ -7,9 +7,10 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 var ErrNotFound = errors.New("not found")
@@ -39,6 +40,50 @@ type Database interface {
 	// DeleteMemory removes a memory entry by ID, scoped to owner/repo. Returns true if a row was deleted.
 	// The owner/repo check prevents cross-repo deletion.
 	DeleteMemory(ctx context.Context, owner, repo string, id int) (bool, error)
+	// CreateLocalPullRequest stores a pull request for the local forge, numbering it after the repo's
+	// existing pull requests. Returns the new pull request's number.
+	CreateLocalPullRequest(ctx context.Context, pr LocalPullRequest) (int, error)
+	// GetLocalPullRequest returns a local forge pull request.
+	// Will return ErrNotFound if the pull request does not exist.
+	GetLocalPullRequest(ctx context.Context, owner, repo string, number int) (*LocalPullRequest, error)
+	// UpdateLocalPullRequestBody replaces a local forge pull request's description.
+	// Will return ErrNotFound if the pull request does not exist.
+	UpdateLocalPullRequestBody(ctx context.Context, owner, repo string, number int, body string) error
+	// AddLocalPullRequestComment adds a conversation comment to a local forge pull request.
+	AddLocalPullRequestComment(ctx context.Context, owner, repo string, number int, body string) error
+	// ListLocalPullRequestComments returns a local forge pull request's comments, oldest first.
+	ListLocalPullRequestComments(ctx context.Context, owner, repo string, number int) ([]LocalPullRequestComment, error)
+	// ListLocalPullRequests returns a repo's local forge pull requests, open and closed, by number.
+	ListLocalPullRequests(ctx context.Context, owner, repo string) ([]LocalPullRequest, error)
+	// CloseLocalPullRequest closes a local forge pull request.
+	// Will return ErrNotFound if the pull request does not exist.
+	CloseLocalPullRequest(ctx context.Context, owner, repo string, number int) error
+	// AddInstallationRepos records repos as accessible to a GitHub App installation.
+	AddInstallationRepos(ctx context.Context, installationID int64, repos []InstallationRepo) error
+	// RemoveInstallationRepos forgets repos an installation can no longer access.
+	RemoveInstallationRepos(ctx context.Context, installationID int64, repos []InstallationRepo) error
+	// DeleteInstallation forgets every repo of an installation.
+	DeleteInstallation(ctx context.Context, installationID int64) error
+	// ReplaceInstallations replaces every recorded installation's repos with repos, keyed by installation ID.
+	ReplaceInstallations(ctx context.Context, repos map[int64][]InstallationRepo) error
+	// IsRepoInstalled reports whether any installation can access owner/repo.
+	IsRepoInstalled(ctx context.Context, owner, repo string) (bool, error)
+	// InsertWebhookDelivery stores a pending webhook delivery. Returns false, without
+	// changing it, if a delivery with the same ID is already stored.
+	InsertWebhookDelivery(ctx context.Context, deliveryID, eventType string, payload []byte) (bool, error)
+	// ClaimWebhookDeliveries returns up to limit pending deliveries that are due, oldest first,
+	// and holds them back from other claims for lease.
+	ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]WebhookDelivery, error)
+	// UpdateWebhookDelivery records an attempt to dispatch a delivery and its outcome.
+	// A pending delivery is attempted again at nextAttemptAt.
+	UpdateWebhookDelivery(ctx context.Context, deliveryID string, status WebhookDeliveryStatus, lastError string, nextAttemptAt time.Time) error
+	// ListWebhookDeliveries returns the most recent deliveries with one of statuses, without their payloads.
+	ListWebhookDeliveries(ctx context.Context, statuses []WebhookDeliveryStatus, limit int) ([]WebhookDelivery, error)
+	// ReplayWebhookDelivery makes a failed or skipped delivery pending again.
+	// Will return ErrNotFound if there is no such failed or skipped delivery.
+	ReplayWebhookDelivery(ctx context.Context, deliveryID string) error
+	// PruneWebhookDeliveries deletes deliveries received before before that are no longer pending.
+	PruneWebhookDeliveries(ctx context.Context, before time.Time) (int64, error)
 }
 
 type JobRun struct {
@@ -55,6 +100,63 @@ type MemoryEntry struct {
 	CreatedAt time.Time
 }
 
+// LocalPullRequest is a pull request on the local forge,
+// which has no server of its own to hold them.
+type LocalPullRequest struct {
+	Owner     string
+	Repo      string
+	Number    int
+	Title     string
+	Body      string
+	Head      string
+	Base      string
+	Labels    []string
+	Closed    bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// InstallationRepo is a repository a GitHub App installation
+// has been granted access to.
+type InstallationRepo struct {
+	Owner string
+	Repo  string
+}
+
+// WebhookDeliveryStatus is how far a webhook delivery has
+// been dispatched.
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	// WebhookDeliverySkipped deliveries needed no action,
+	// such as events for PRs no workflow is reviewing.
+	WebhookDeliverySkipped WebhookDeliveryStatus = "skipped"
+	// WebhookDeliveryFailed deliveries ran out of attempts.
+	WebhookDeliveryFailed WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is a GitHub webhook delivery, kept so it
+// can be dispatched once and replayed.
+type WebhookDelivery struct {
+	DeliveryID    string                `json:"delivery_id"`
+	EventType     string                `json:"event_type"`
+	Payload       []byte                `json:"-"`
+	Status        WebhookDeliveryStatus `json:"status"`
+	Attempts      int                   `json:"attempts"`
+	LastError     string                `json:"last_error,omitempty"`
+	NextAttemptAt time.Time             `json:"next_attempt_at"`
+	ReceivedAt    time.Time             `json:"received_at"`
+	UpdatedAt     time.Time             `json:"updated_at"`
+}
+
+type LocalPullRequestComment struct {
+	ID        int
+	Body      string
+	CreatedAt time.Time
+}
+
 type postgresDatabase struct {
 	db *sql.DB
 }
@@ -239,6 +341,354 @@ func (p *postgresDatabase) DeleteMemory(ctx context.Context, owner, repo string,
 	return affected == 1, nil
 }
 
+func (p *postgresDatabase) CreateLocalPullRequest(ctx context.Context, pr LocalPullRequest) (int, error) {
+	labels := pr.Labels
+	if labels == nil {
+		labels = []string{}
+	}
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("create local pull request begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The counter row serializes concurrent creates for a
+	// repo, which reading MAX(number) would not.
+	var number int
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO local_pull_request_counters (owner, repo, last_number) VALUES ($1, $2, 1)
+ON CONFLICT (owner, repo) DO UPDATE SET last_number = local_pull_request_counters.last_number + 1
+RETURNING last_number`,
+		pr.Owner, pr.Repo).Scan(&number)
+	if err != nil {
+		return 0, fmt.Errorf("create local pull request number: %w", err)
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO local_pull_requests (owner, repo, number, title, body, head, base, labels)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		pr.Owner, pr.Repo, number, pr.Title, pr.Body, pr.Head, pr.Base, pq.Array(labels))
+	if err != nil {
+		return 0, fmt.Errorf("create local pull request: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("create local pull request commit: %w", err)
+	}
+	return number, nil
+}
+
+func (p *postgresDatabase) GetLocalPullRequest(ctx context.Context, owner, repo string, number int) (*LocalPullRequest, error) {
+	pr := LocalPullRequest{Owner: owner, Repo: repo, Number: number}
+	err := p.db.QueryRowContext(ctx,
+		"SELECT title, body, head, base, labels, closed, created_at, updated_at FROM local_pull_requests "+
+			"WHERE owner = $1 AND repo = $2 AND number = $3",
+		owner, repo, number).Scan(&pr.Title, &pr.Body, &pr.Head, &pr.Base, pq.Array(&pr.Labels), &pr.Closed, &pr.CreatedAt, &pr.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get local pull request: %w", err)
+	}
+	return &pr, nil
+}
+
+func (p *postgresDatabase) UpdateLocalPullRequestBody(ctx context.Context, owner, repo string, number int, body string) error {
+	result, err := p.db.ExecContext(ctx,
+		"UPDATE local_pull_requests SET body = $1, updated_at = NOW() WHERE owner = $2 AND repo = $3 AND number = $4",
+		body, owner, repo, number)
+	if err != nil {
+		return fmt.Errorf("update local pull request body: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("update local pull request body rows affected: %w", err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (p *postgresDatabase) AddLocalPullRequestComment(ctx context.Context, owner, repo string, number int, body string) error {
+	_, err := p.db.ExecContext(ctx,
+		"INSERT INTO local_pull_request_comments (owner, repo, number, body) VALUES ($1, $2, $3, $4)",
+		owner, repo, number, body)
+	if err != nil {
+		return fmt.Errorf("add local pull request comment: %w", err)
+	}
+	return nil
+}
+
+func (p *postgresDatabase) ListLocalPullRequestComments(ctx context.Context, owner, repo string, number int) ([]LocalPullRequestComment, error) {
+	rows, err := p.db.QueryContext(ctx,
+		"SELECT id, body, created_at FROM local_pull_request_comments "+
+			"WHERE owner = $1 AND repo = $2 AND number = $3 ORDER BY created_at ASC, id ASC",
+		owner, repo, number)
+	if err != nil {
+		return nil, fmt.Errorf("list local pull request comments: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []LocalPullRequestComment
+	for rows.Next() {
+		var c LocalPullRequestComment
+		if err := rows.Scan(&c.ID, &c.Body, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("list local pull request comments scan: %w", err)
+		}
+		comments = append(comments, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list local pull request comments rows: %w", err)
+	}
+	return comments, nil
+}
+
+func (p *postgresDatabase) ListLocalPullRequests(ctx context.Context, owner, repo string) ([]LocalPullRequest, error) {
+	rows, err := p.db.QueryContext(ctx,
+		"SELECT number, title, body, head, base, labels, closed, created_at, updated_at FROM local_pull_requests "+
+			"WHERE owner = $1 AND repo = $2 ORDER BY number ASC",
+		owner, repo)
+	if err != nil {
+		return nil, fmt.Errorf("list local pull requests: %w", err)
+	}
+	defer rows.Close()
+
+	var prs []LocalPullRequest
+	for rows.Next() {
+		pr := LocalPullRequest{Owner: owner, Repo: repo}
+		err := rows.Scan(&pr.Number, &pr.Title, &pr.Body, &pr.Head, &pr.Base, pq.Array(&pr.Labels), &pr.Closed, &pr.CreatedAt, &pr.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("list local pull requests scan: %w", err)
+		}
+		prs = append(prs, pr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list local pull requests rows: %w", err)
+	}
+	return prs, nil
+}
+
+func (p *postgresDatabase) CloseLocalPullRequest(ctx context.Context, owner, repo string, number int) error {
+	result, err := p.db.ExecContext(ctx,
+		"UPDATE local_pull_requests SET closed = TRUE, updated_at = NOW() WHERE owner = $1 AND repo = $2 AND number = $3",
+		owner, repo, number)
+	if err != nil {
+		return fmt.Errorf("close local pull request: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("close local pull request rows affected: %w", err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (p *postgresDatabase) AddInstallationRepos(ctx context.Context, installationID int64, repos []InstallationRepo) error {
+	return addInstallationRepos(ctx, p.db, installationID, repos)
+}
+
+// execer is a *sql.DB or *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func addInstallationRepos(ctx context.Context, db execer, installationID int64, repos []InstallationRepo) error {
+	for _, r := range repos {
+		_, err := db.ExecContext(ctx,
+			`INSERT INTO installation_repos (installation_id, owner, repo) VALUES ($1, $2, $3)
+ON CONFLICT (owner, repo) DO UPDATE SET installation_id = EXCLUDED.installation_id`,
+			installationID, r.Owner, r.Repo)
+		if err != nil {
+			return fmt.Errorf("add installation repo %s/%s: %w", r.Owner, r.Repo, err)
+		}
+	}
+	return nil
+}
+
+func (p *postgresDatabase) RemoveInstallationRepos(ctx context.Context, installationID int64, repos []InstallationRepo) error {
+	for _, r := range repos {
+		_, err := p.db.ExecContext(ctx,
+			"DELETE FROM installation_repos WHERE installation_id = $1 AND owner = $2 AND repo = $3",
+			installationID, r.Owner, r.Repo)
+		if err != nil {
+			return fmt.Errorf("remove installation repo %s/%s: %w", r.Owner, r.Repo, err)
+		}
+	}
+	return nil
+}
+
+func (p *postgresDatabase) DeleteInstallation(ctx context.Context, installationID int64) error {
+	_, err := p.db.ExecContext(ctx, "DELETE FROM installation_repos WHERE installation_id = $1", installationID)
+	if err != nil {
+		return fmt.Errorf("delete installation: %w", err)
+	}
+	return nil
+}
+
+func (p *postgresDatabase) ReplaceInstallations(ctx context.Context, repos map[int64][]InstallationRepo) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("replace installations begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM installation_repos"); err != nil {
+		return fmt.Errorf("replace installations delete: %w", err)
+	}
+	for id, installed := range repos {
+		if err = addInstallationRepos(ctx, tx, id, installed); err != nil {
+			return err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("replace installations commit: %w", err)
+	}
+	return nil
+}
+
+func (p *postgresDatabase) IsRepoInstalled(ctx context.Context, owner, repo string) (bool, error) {
+	var installed bool
+	err := p.db.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM installation_repos WHERE owner = $1 AND repo = $2)",
+		owner, repo).Scan(&installed)
+	if err != nil {
+		return false, fmt.Errorf("is repo installed: %w", err)
+	}
+	return installed, nil
+}
+
+func (p *postgresDatabase) InsertWebhookDelivery(ctx context.Context, deliveryID, eventType string, payload []byte) (bool, error) {
+	result, err := p.db.ExecContext(ctx,
+		`INSERT INTO webhook_deliveries (delivery_id, event_type, payload) VALUES ($1, $2, $3)
+ON CONFLICT (delivery_id) DO NOTHING`,
+		deliveryID, eventType, payload)
+	if err != nil {
+		return false, fmt.Errorf("insert webhook delivery: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("insert webhook delivery rows affected: %w", err)
+	}
+	return affected == 1, nil
+}
+
+func (p *postgresDatabase) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]WebhookDelivery, error) {
+	rows, err := p.db.QueryContext(ctx,
+		`UPDATE webhook_deliveries SET next_attempt_at = NOW() + $2 * INTERVAL '1 millisecond'
+WHERE delivery_id IN (
+	SELECT delivery_id FROM webhook_deliveries
+	WHERE status = 'pending' AND next_attempt_at <= NOW()
+	ORDER BY received_at LIMIT $1
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING delivery_id, event_type, payload, status, attempts, last_error, next_attempt_at, received_at, updated_at`,
+		limit, lease.Milliseconds())
+	if err != nil {
+		return nil, fmt.Errorf("claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []WebhookDelivery
+	for rows.Next() {
+		var d WebhookDelivery
+		err := rows.Scan(&d.DeliveryID, &d.EventType, &d.Payload, &d.Status, &d.Attempts, &d.LastError,
+			&d.NextAttemptAt, &d.ReceivedAt, &d.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("claim webhook deliveries scan: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("claim webhook deliveries rows: %w", err)
+	}
+	// RETURNING does not keep the subquery's order.
+	slices.SortFunc(deliveries, func(a, b WebhookDelivery) int {
+		return a.ReceivedAt.Compare(b.ReceivedAt)
+	})
+	return deliveries, nil
+}
+
+func (p *postgresDatabase) UpdateWebhookDelivery(ctx context.Context, deliveryID string, status WebhookDeliveryStatus, lastError string, nextAttemptAt time.Time) error {
+	_, err := p.db.ExecContext(ctx,
+		`UPDATE webhook_deliveries
+SET status = $2, last_error = $3, next_attempt_at = $4, attempts = attempts + 1, updated_at = NOW()
+WHERE delivery_id = $1`,
+		deliveryID, status, lastError, nextAttemptAt)
+	if err != nil {
+		return fmt.Errorf("update webhook delivery: %w", err)
+	}
+	return nil
+}
+
+func (p *postgresDatabase) ListWebhookDeliveries(ctx context.Context, statuses []WebhookDeliveryStatus, limit int) ([]WebhookDelivery, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	names := make([]string, len(statuses))
+	for i, status := range statuses {
+		names[i] = string(status)
+	}
+
+	rows, err := p.db.QueryContext(ctx,
+		`SELECT delivery_id, event_type, status, attempts, last_error, next_attempt_at, received_at, updated_at
+FROM webhook_deliveries WHERE status = ANY($1) ORDER BY received_at DESC LIMIT $2`,
+		pq.Array(names), limit)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []WebhookDelivery
+	for rows.Next() {
+		var d WebhookDelivery
+		err := rows.Scan(&d.DeliveryID, &d.EventType, &d.Status, &d.Attempts, &d.LastError,
+			&d.NextAttemptAt, &d.ReceivedAt, &d.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("list webhook deliveries scan: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list webhook deliveries rows: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (p *postgresDatabase) ReplayWebhookDelivery(ctx context.Context, deliveryID string) error {
+	result, err := p.db.ExecContext(ctx,
+		`UPDATE webhook_deliveries
+SET status = 'pending', attempts = 0, last_error = '', next_attempt_at = NOW(), updated_at = NOW()
+WHERE delivery_id = $1 AND status IN ('failed', 'skipped')`,
+		deliveryID)
+	if err != nil {
+		return fmt.Errorf("replay webhook delivery: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("replay webhook delivery rows affected: %w", err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (p *postgresDatabase) PruneWebhookDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	result, err := p.db.ExecContext(ctx,
+		"DELETE FROM webhook_deliveries WHERE status <> 'pending' AND received_at < $1",
+		before)
+	if err != nil {
+		return 0, fmt.Errorf("prune webhook deliveries: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("prune webhook deliveries rows affected: %w", err)
+	}
+	return affected, nil
+}
+
 func getDBUrl() string {
 	return os.Getenv("DATABASE_URL")
 }
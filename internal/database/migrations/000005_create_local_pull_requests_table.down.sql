@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS local_pull_request_comments;
+DROP TABLE IF EXISTS local_pull_requests;
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS local_pull_requests (
+    owner      VARCHAR(255) NOT NULL,
+    repo       VARCHAR(255) NOT NULL,
+    number     INTEGER NOT NULL,
+    title      TEXT NOT NULL,
+    body       TEXT NOT NULL,
+    head       VARCHAR(255) NOT NULL,
+    base       VARCHAR(255) NOT NULL,
+    labels     TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (owner, repo, number)
+);
+
+CREATE TABLE IF NOT EXISTS local_pull_request_comments (
+    id         SERIAL PRIMARY KEY,
+    owner      VARCHAR(255) NOT NULL,
+    repo       VARCHAR(255) NOT NULL,
+    number     INTEGER NOT NULL,
+    body       TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (owner, repo, number) REFERENCES local_pull_requests (owner, repo, number) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_local_pull_request_comments_pr
+    ON local_pull_request_comments (owner, repo, number, created_at);
@@ -0,0 +1,2 @@
+ALTER TABLE local_pull_requests
+    DROP COLUMN IF EXISTS closed;
@@ -0,0 +1,2 @@
+ALTER TABLE local_pull_requests
+    ADD COLUMN IF NOT EXISTS closed BOOLEAN NOT NULL DEFAULT FALSE;
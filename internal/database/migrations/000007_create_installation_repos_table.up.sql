@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS installation_repos (
+    installation_id BIGINT NOT NULL,
+    owner           VARCHAR(255) NOT NULL,
+    repo            VARCHAR(255) NOT NULL,
+    PRIMARY KEY (owner, repo)
+);
+CREATE INDEX IF NOT EXISTS idx_installation_repos_installation
+    ON installation_repos (installation_id);
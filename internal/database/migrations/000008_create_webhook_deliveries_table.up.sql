@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    delivery_id     VARCHAR(255) PRIMARY KEY,
+    event_type      VARCHAR(255) NOT NULL,
+    payload         BYTEA NOT NULL,
+    status          VARCHAR(32) NOT NULL DEFAULT 'pending',
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    last_error      TEXT NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    received_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_pending
+    ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status
+    ON webhook_deliveries (status, received_at);
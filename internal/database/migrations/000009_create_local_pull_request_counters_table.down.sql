@@ -0,0 +1 @@
+DROP TABLE IF EXISTS local_pull_request_counters;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS local_pull_request_counters (
+    owner       VARCHAR(255) NOT NULL,
+    repo        VARCHAR(255) NOT NULL,
+    last_number INTEGER NOT NULL,
+    PRIMARY KEY (owner, repo)
+);
+
+INSERT INTO local_pull_request_counters (owner, repo, last_number)
+SELECT owner, repo, MAX(number) FROM local_pull_requests GROUP BY owner, repo
+ON CONFLICT (owner, repo) DO NOTHING;
@@ -0,0 +1,267 @@
+// Package export converts a built PDF into the other formats
+// application portals ask for: plain text to paste into a
+// form, semantic HTML, and DOCX. Every format is rendered
+// from one Document recovered from the PDF's text, so they
+// stay in the reading order an ATS sees.
+package export
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/ansg191/job-temporal/internal/pdftext"
+)
+
+// BlockKind is the role of a block of text.
+type BlockKind int
+
+const (
+	BlockParagraph BlockKind = iota
+	BlockHeading
+	BlockListItem
+)
+
+// Block is a heading, paragraph or list item. Lines are the
+// hard line breaks within it; wrapped lines are rejoined.
+type Block struct {
+	Kind BlockKind
+	// Level is the heading level, 1 for the document title.
+	Level int
+	Lines []string
+}
+
+// Text returns the block's lines joined with newlines.
+func (b Block) Text() string {
+	return strings.Join(b.Lines, "\n")
+}
+
+// Document is the structure recovered from a PDF.
+type Document struct {
+	// Title is the text of the first level 1 heading, which
+	// on a resume or letter is the applicant's name.
+	Title  string
+	Blocks []Block
+}
+
+const (
+	// Lines this much larger than the body text are the
+	// title, and lines slightly larger are section headings.
+	titleScale   = 1.5
+	sectionScale = 1.15
+	// paragraphGap is the baseline distance, in body font
+	// sizes, beyond which lines start a new paragraph.
+	paragraphGap = 1.6
+	// wrapFill is the fraction of the text column a line
+	// must fill to count as wrapped rather than broken.
+	wrapFill = 0.85
+	// maxBoldHeading is the longest bold line treated as a
+	// heading rather than emphasised text.
+	maxBoldHeading = 60
+)
+
+var bulletPrefixes = []string{"•", "◦", "▪", "‣", "●", "–", "-", "*"}
+
+// FromPages recovers headings, paragraphs and list items
+// from extracted pages using font sizes, weights and line
+// spacing.
+func FromPages(pages []pdftext.Page) Document {
+	var doc Document
+	body := bodyFontSize(pages)
+
+	for _, page := range pages {
+		lines := page.Lines()
+		left, right := textColumn(lines)
+
+		var prev *pdftext.Line
+		for i := range lines {
+			line := lines[i]
+			text := strings.Join(strings.Fields(line.Text), " ")
+			if text == "" {
+				continue
+			}
+
+			wrapped := prev != nil && right > left && prev.EndX-left >= wrapFill*(right-left)
+			near := prev != nil && prev.Y-line.Y > 0 && prev.Y-line.Y <= paragraphGap*math.Max(body, line.FontSize)
+			last := lastBlock(&doc)
+
+			switch level := headingLevel(line, text, body); {
+			case level > 0:
+				if level == 1 && doc.Title == "" {
+					doc.Title = text
+				}
+				doc.Blocks = append(doc.Blocks, Block{Kind: BlockHeading, Level: level, Lines: []string{text}})
+			case hasBullet(text):
+				doc.Blocks = append(doc.Blocks, Block{Kind: BlockListItem, Lines: []string{trimBullet(text)}})
+			case last != nil && last.Kind == BlockListItem && near && line.X > left+0.5*body:
+				// A hanging indent continues the list item.
+				appendWrapped(last, text, true)
+			case last != nil && last.Kind == BlockParagraph && near:
+				appendWrapped(last, text, wrapped)
+			default:
+				doc.Blocks = append(doc.Blocks, Block{Kind: BlockParagraph, Lines: []string{text}})
+			}
+			prev = &lines[i]
+		}
+	}
+	return doc
+}
+
+func lastBlock(doc *Document) *Block {
+	if len(doc.Blocks) == 0 {
+		return nil
+	}
+	return &doc.Blocks[len(doc.Blocks)-1]
+}
+
+func appendWrapped(b *Block, text string, wrapped bool) {
+	if wrapped {
+		n := len(b.Lines) - 1
+		if strings.HasSuffix(b.Lines[n], "-") && !strings.HasSuffix(b.Lines[n], " -") {
+			// Rejoin a word hyphenated across the break.
+			b.Lines[n] = strings.TrimSuffix(b.Lines[n], "-") + text
+			return
+		}
+		b.Lines[n] += " " + text
+		return
+	}
+	b.Lines = append(b.Lines, text)
+}
+
+func headingLevel(line pdftext.Line, text string, body float64) int {
+	switch {
+	case body <= 0:
+		return 0
+	case line.FontSize >= titleScale*body:
+		return 1
+	case line.FontSize >= sectionScale*body:
+		return 2
+	case isBold(line.Font) && len(text) <= maxBoldHeading && !strings.HasSuffix(text, "."):
+		return 3
+	}
+	return 0
+}
+
+func isBold(font string) bool {
+	f := strings.ToLower(font)
+	return strings.Contains(f, "bold") || strings.Contains(f, "black") || strings.Contains(f, "heavy")
+}
+
+func hasBullet(text string) bool {
+	for _, p := range bulletPrefixes {
+		if strings.HasPrefix(text, p+" ") {
+			return true
+		}
+	}
+	// Icon fonts often draw bullets as a lone symbol.
+	r := []rune(text)
+	return len(r) > 2 && r[1] == ' ' && (r[0] == '•' || r[0] >= 0xe000 && r[0] <= 0xf8ff)
+}
+
+func trimBullet(text string) string {
+	_, rest, _ := strings.Cut(text, " ")
+	return strings.TrimSpace(rest)
+}
+
+// bodyFontSize is the font size carrying the most text.
+func bodyFontSize(pages []pdftext.Page) float64 {
+	weight := make(map[float64]int)
+	for _, page := range pages {
+		for _, run := range page.Runs {
+			size := math.Round(run.FontSize*2) / 2
+			weight[size] += len(strings.TrimSpace(run.Text))
+		}
+	}
+	var best float64
+	for size, w := range weight {
+		if w > weight[best] || w == weight[best] && size < best {
+			best = size
+		}
+	}
+	return best
+}
+
+// textColumn returns the left edge and the typical right
+// edge of the page's text, ignoring the longest lines so a
+// single wide line does not define the column.
+func textColumn(lines []pdftext.Line) (left, right float64) {
+	if len(lines) == 0 {
+		return 0, 0
+	}
+	left = math.Inf(1)
+	ends := make([]float64, 0, len(lines))
+	for _, line := range lines {
+		left = math.Min(left, line.X)
+		ends = append(ends, line.EndX)
+	}
+	slices.Sort(ends)
+	return left, ends[len(ends)*9/10]
+}
+
+// Format is an export file format.
+type Format string
+
+const (
+	FormatText Format = "txt"
+	FormatHTML Format = "html"
+	FormatDOCX Format = "docx"
+)
+
+// Formats lists every supported format.
+var Formats = []Format{FormatText, FormatHTML, FormatDOCX}
+
+// ParseFormats validates a list of format names, dropping
+// duplicates.
+func ParseFormats(names []string) ([]Format, error) {
+	var ret []Format
+	for _, name := range names {
+		f := Format(strings.ToLower(strings.TrimSpace(name)))
+		if !slices.Contains(Formats, f) {
+			return nil, fmt.Errorf("unsupported export format %q", name)
+		}
+		if !slices.Contains(ret, f) {
+			ret = append(ret, f)
+		}
+	}
+	return ret, nil
+}
+
+// Label is the format's display name.
+func (f Format) Label() string {
+	switch f {
+	case FormatText:
+		return "Plain text"
+	case FormatHTML:
+		return "HTML"
+	case FormatDOCX:
+		return "DOCX"
+	}
+	return string(f)
+}
+
+// ContentType is the format's MIME type.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatText:
+		return "text/plain; charset=utf-8"
+	case FormatHTML:
+		return "text/html; charset=utf-8"
+	case FormatDOCX:
+		return "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	}
+	return "application/octet-stream"
+}
+
+// Render encodes doc in format f.
+func Render(doc Document, f Format) ([]byte, error) {
+	switch f {
+	case FormatText:
+		return []byte(Text(doc)), nil
+	case FormatHTML:
+		return []byte(HTML(doc)), nil
+	case FormatDOCX:
+		return DOCX(doc)
+	}
+	return nil, fmt.Errorf("unsupported export format %q", f)
+}
@@ -0,0 +1,134 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ansg191/job-temporal/internal/pdftext"
+)
+
+func run(x, y, size float64, font, text string) pdftext.Run {
+	return pdftext.Run{X: x, Y: y, EndX: x + float64(len(text))*size/2, FontSize: size, Font: font, Text: text}
+}
+
+// resumePages is a one page resume with a title, contact
+// line, section headings, bullets that wrap and a paragraph.
+func resumePages() []pdftext.Page {
+	return []pdftext.Page{{
+		Number: 1, Width: 612, Height: 792,
+		Runs: []pdftext.Run{
+			run(72, 720, 24, "Body-Bold", "Jane Doe"),
+			run(72, 700, 10, "Body", "jane@example.com | 555-123-4567"),
+			run(72, 670, 13, "Body-Bold", "Experience"),
+			run(72, 652, 10, "Body-Bold", "Software Engineer, Acme"),
+			run(72, 640, 10, "Body", "• Built a distributed job scheduler in Go that handles millions"),
+			run(82, 628, 10, "Body", "of tasks per day"),
+			run(72, 616, 10, "Body", "• Cut p99 latency by 40%"),
+			run(72, 586, 13, "Body-Bold", "Summary"),
+			run(72, 568, 10, "Body", "Backend engineer focused on reliable systems and developer experience at"),
+			run(72, 556, 10, "Body", "scale."),
+		},
+	}}
+}
+
+func TestFromPages(t *testing.T) {
+	t.Parallel()
+
+	doc := FromPages(resumePages())
+	want := []Block{
+		{Kind: BlockHeading, Level: 1, Lines: []string{"Jane Doe"}},
+		{Kind: BlockParagraph, Lines: []string{"jane@example.com | 555-123-4567"}},
+		{Kind: BlockHeading, Level: 2, Lines: []string{"Experience"}},
+		{Kind: BlockHeading, Level: 3, Lines: []string{"Software Engineer, Acme"}},
+		{Kind: BlockListItem, Lines: []string{"Built a distributed job scheduler in Go that handles millions of tasks per day"}},
+		{Kind: BlockListItem, Lines: []string{"Cut p99 latency by 40%"}},
+		{Kind: BlockHeading, Level: 2, Lines: []string{"Summary"}},
+		{Kind: BlockParagraph, Lines: []string{"Backend engineer focused on reliable systems and developer experience at scale."}},
+	}
+	if !reflect.DeepEqual(doc.Blocks, want) {
+		t.Errorf("FromPages() blocks =\n%+v\nwant\n%+v", doc.Blocks, want)
+	}
+	if doc.Title != "Jane Doe" {
+		t.Errorf("Title = %q, want %q", doc.Title, "Jane Doe")
+	}
+}
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	doc := FromPages(resumePages())
+
+	text := Text(doc)
+	for _, want := range []string{
+		"Jane Doe\n\njane@example.com",
+		"EXPERIENCE\n\nSoftware Engineer, Acme\n\n- Built a distributed",
+		"per day\n- Cut p99",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Text() missing %q:\n%s", want, text)
+		}
+	}
+
+	page := HTML(doc)
+	for _, want := range []string{
+		"<title>Jane Doe</title>",
+		"<h1>Jane Doe</h1>",
+		"<h2>Experience</h2>",
+		"<ul>\n<li>Built a distributed",
+		"<li>Cut p99 latency by 40%</li>\n</ul>",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("HTML() missing %q:\n%s", want, page)
+		}
+	}
+
+	docx, err := DOCX(doc)
+	if err != nil {
+		t.Fatalf("DOCX() unexpected error: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(docx), int64(len(docx)))
+	if err != nil {
+		t.Fatalf("DOCX() is not a zip: %v", err)
+	}
+	var document string
+	for _, f := range zr.File {
+		if f.Name != "word/document.xml" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open document.xml: %v", err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		document = string(data)
+	}
+	for _, want := range []string{
+		`<w:pStyle w:val="Title"/></w:pPr><w:r><w:t xml:space="preserve">Jane Doe</w:t>`,
+		`<w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t xml:space="preserve">Experience</w:t>`,
+		`<w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t xml:space="preserve">Cut p99 latency by 40%</w:t>`,
+	} {
+		if !strings.Contains(document, want) {
+			t.Errorf("document.xml missing %q:\n%s", want, document)
+		}
+	}
+}
+
+func TestParseFormats(t *testing.T) {
+	t.Parallel()
+
+	got, err := ParseFormats([]string{"TXT", "docx", "txt"})
+	if err != nil {
+		t.Fatalf("ParseFormats() unexpected error: %v", err)
+	}
+	if want := []Format{FormatText, FormatDOCX}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseFormats() = %v, want %v", got, want)
+	}
+	if _, err = ParseFormats([]string{"rtf"}); err == nil {
+		t.Error("ParseFormats(rtf) expected error")
+	}
+}
@@ -0,0 +1,49 @@
+package export
+
+import (
+	"html"
+	"strings"
+)
+
+// HTML renders doc as a standalone semantic HTML page.
+func HTML(doc Document) string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	b.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n")
+	b.WriteString("<title>" + html.EscapeString(doc.Title) + "</title>\n</head>\n<body>\n<main>\n")
+
+	inList := false
+	for _, block := range doc.Blocks {
+		if block.Kind != BlockListItem && inList {
+			b.WriteString("</ul>\n")
+			inList = false
+		}
+		switch block.Kind {
+		case BlockHeading:
+			tag := "h" + string(rune('0'+min(block.Level, 6)))
+			b.WriteString("<" + tag + ">" + htmlLines(block.Lines) + "</" + tag + ">\n")
+		case BlockListItem:
+			if !inList {
+				b.WriteString("<ul>\n")
+				inList = true
+			}
+			b.WriteString("<li>" + htmlLines(block.Lines) + "</li>\n")
+		default:
+			b.WriteString("<p>" + htmlLines(block.Lines) + "</p>\n")
+		}
+	}
+	if inList {
+		b.WriteString("</ul>\n")
+	}
+
+	b.WriteString("</main>\n</body>\n</html>\n")
+	return b.String()
+}
+
+func htmlLines(lines []string) string {
+	escaped := make([]string, len(lines))
+	for i, line := range lines {
+		escaped[i] = html.EscapeString(line)
+	}
+	return strings.Join(escaped, "<br>")
+}
@@ -0,0 +1,32 @@
+package export
+
+import "strings"
+
+// Text renders doc as plain text for pasting into portal
+// forms: headings on their own line after a blank line, and
+// list items prefixed with "- ".
+func Text(doc Document) string {
+	var b strings.Builder
+	for i, block := range doc.Blocks {
+		if i > 0 {
+			b.WriteByte('\n')
+			if block.Kind != BlockListItem || doc.Blocks[i-1].Kind != BlockListItem {
+				b.WriteByte('\n')
+			}
+		}
+		switch block.Kind {
+		case BlockHeading:
+			if block.Level == 2 {
+				b.WriteString(strings.ToUpper(block.Text()))
+			} else {
+				b.WriteString(block.Text())
+			}
+		case BlockListItem:
+			b.WriteString("- " + strings.Join(block.Lines, "\n  "))
+		default:
+			b.WriteString(block.Text())
+		}
+	}
+	b.WriteByte('\n')
+	return b.String()
+}
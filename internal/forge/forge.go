@@ -0,0 +1,118 @@
+// Package forge abstracts the service hosting the
+// repositories the agents edit, so the pipeline can run
+// against GitHub or against bare repositories on disk.
+package forge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ansg191/job-temporal/internal/github"
+)
+
+// ErrAlreadyExists is returned when creating a branch or
+// pull request that already exists.
+var ErrAlreadyExists = errors.New("already exists")
+
+// ErrNotFound is returned for a branch or tag that does not
+// exist.
+var ErrNotFound = errors.New("not found")
+
+// Forge covers the repository operations the workflows
+// need beyond plain git.
+type Forge interface {
+	// GetAuthenticatedRemoteURL returns a git remote that can
+	// be cloned and pushed to without prompting.
+	GetAuthenticatedRemoteURL(ctx context.Context) (string, error)
+	ListBranches(ctx context.Context) ([]string, error)
+	DefaultBranch(ctx context.Context) (string, error)
+	// CreateBranch creates branch from the default branch.
+	CreateBranch(ctx context.Context, branch string) error
+	GetBranchHeadSHA(ctx context.Context, branch string) (string, error)
+	// CreatePullRequest opens a pull request from head into
+	// base labelled with purposeLabel and returns its number.
+	CreatePullRequest(ctx context.Context, title, description, head, base, purposeLabel string) (int, error)
+	GetPullRequestBody(ctx context.Context, prNumber int) (string, error)
+	// GetPullRequestLabels returns the labels currently on a
+	// pull request.
+	GetPullRequestLabels(ctx context.Context, prNumber int) ([]string, error)
+	UpdatePullRequestBody(ctx context.Context, prNumber int, body string) error
+	// CommentOnPullRequest adds a conversation comment to a
+	// pull request.
+	CommentOnPullRequest(ctx context.Context, prNumber int, body string) error
+	// ReplyToReviewComment replies in the thread of a review
+	// comment on a pull request.
+	ReplyToReviewComment(ctx context.Context, prNumber int, commentID int64, body string) error
+	// ResolveReviewThread resolves the review thread
+	// containing a review comment.
+	ResolveReviewThread(ctx context.Context, prNumber int, commentID int64) error
+	// SuggestChanges posts suggested edits to a pull request
+	// as one review, without committing them.
+	SuggestChanges(ctx context.Context, prNumber int, body string, suggestions []github.ReviewSuggestion) error
+	// ProtectBranch rejects further pushes to branch.
+	ProtectBranch(ctx context.Context, branch string) error
+	// ListPullRequests returns every pull request, open and
+	// closed.
+	ListPullRequests(ctx context.Context) ([]github.PullRequestSummary, error)
+	// ClosePullRequest closes a pull request without merging
+	// it.
+	ClosePullRequest(ctx context.Context, prNumber int) error
+	// DeleteBranch deletes branch, lifting any protection
+	// ProtectBranch put on it.
+	DeleteBranch(ctx context.Context, branch string) error
+	// CreateTag creates a lightweight tag pointing at sha.
+	CreateTag(ctx context.Context, tag, sha string) error
+	// GetTagSHA returns the commit a lightweight tag points
+	// at.
+	GetTagSHA(ctx context.Context, tag string) (string, error)
+	// CreateCheckRun reports a check on a commit and returns
+	// its ID, or 0 if the forge does not show checks.
+	CreateCheckRun(ctx context.Context, run github.CheckRun) (int64, error)
+	// UpdateCheckRun updates a check CreateCheckRun reported.
+	UpdateCheckRun(ctx context.Context, id int64, run github.CheckRun) error
+}
+
+var _ Forge = (*github.Client)(nil)
+
+// New returns the worker's forge for the repository in
+// opts. It is configured from the environment:
+//
+//   - FORGE: "github" (default) or "local"
+//   - LOCAL_FORGE_DIR: root of the local forge's bare
+//     repositories, laid out as <owner>/<repo>.git
+//     (default: $TMPDIR/job-temporal-forge)
+//
+// The local forge stores pull requests in the database at
+// DATABASE_URL.
+func New(opts github.ClientOptions) (Forge, error) {
+	switch kind := Kind(); kind {
+	case "github":
+		client, err := github.NewClient(opts)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	case "local":
+		return NewLocal(localDir(), opts, openDatabase)
+	default:
+		return nil, fmt.Errorf("unsupported forge: %s", kind)
+	}
+}
+
+// Kind returns the configured forge, "github" or "local".
+func Kind() string {
+	if kind := os.Getenv("FORGE"); kind != "" {
+		return kind
+	}
+	return "github"
+}
+
+func localDir() string {
+	if dir := os.Getenv("LOCAL_FORGE_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.TempDir(), "job-temporal-forge")
+}
@@ -0,0 +1,389 @@
+package forge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ansg191/job-temporal/internal/database"
+	"github.com/ansg191/job-temporal/internal/github"
+)
+
+// protectedBranchesFile lists the protected branches of a
+// local repository, one per line, inside its git dir.
+const protectedBranchesFile = "protected-branches"
+
+// preReceiveHook rejects pushes to the branches listed in
+// protectedBranchesFile.
+const preReceiveHook = `#!/bin/sh
+# Installed by the job-temporal local forge.
+protected="$GIT_DIR/` + protectedBranchesFile + `"
+[ -f "$protected" ] || exit 0
+while read -r old new ref; do
+	branch="${ref#refs/heads/}"
+	if grep -qxF "$branch" "$protected"; then
+		echo "branch $branch is protected" >&2
+		exit 1
+	fi
+done
+`
+
+// PullRequestStore persists the local forge's pull
+// requests. database.Database implements it.
+type PullRequestStore interface {
+	io.Closer
+	CreateLocalPullRequest(ctx context.Context, pr database.LocalPullRequest) (int, error)
+	GetLocalPullRequest(ctx context.Context, owner, repo string, number int) (*database.LocalPullRequest, error)
+	UpdateLocalPullRequestBody(ctx context.Context, owner, repo string, number int, body string) error
+	AddLocalPullRequestComment(ctx context.Context, owner, repo string, number int, body string) error
+	ListLocalPullRequests(ctx context.Context, owner, repo string) ([]database.LocalPullRequest, error)
+	CloseLocalPullRequest(ctx context.Context, owner, repo string, number int) error
+}
+
+func openDatabase() (PullRequestStore, error) {
+	return database.NewPostgresDatabase()
+}
+
+// Local is a forge backed by bare git repositories on disk,
+// for running the pipeline without GitHub. Pull requests and
+// comments live in a PullRequestStore; branch protection is
+// enforced by a pre-receive hook.
+type Local struct {
+	path  string
+	owner string
+	repo  string
+	store func() (PullRequestStore, error)
+}
+
+var _ Forge = (*Local)(nil)
+
+// NewLocal returns the local forge for the repository at
+// <root>/<owner>/<repo>.git, which must exist. store opens
+// the pull request store for each call that needs it.
+func NewLocal(root string, opts github.ClientOptions, store func() (PullRequestStore, error)) (*Local, error) {
+	if opts.Owner == "" || opts.Repo == "" {
+		return nil, fmt.Errorf("owner and repo are required")
+	}
+	path, err := filepath.Abs(LocalRepositoryPath(root, opts))
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("local repository %s/%s not found at %s", opts.Owner, opts.Repo, path)
+	}
+	return &Local{path: path, owner: opts.Owner, repo: opts.Repo, store: store}, nil
+}
+
+// LocalRepositoryPath returns where the local forge keeps
+// the bare repository for opts.
+func LocalRepositoryPath(root string, opts github.ClientOptions) string {
+	return filepath.Join(root, opts.Owner, opts.Repo+".git")
+}
+
+// CreateLocalRepository initializes an empty bare repository
+// for opts under root whose default branch is
+// defaultBranch. Pushing the first commit creates the
+// branch.
+func CreateLocalRepository(ctx context.Context, root string, opts github.ClientOptions, defaultBranch string) error {
+	path := LocalRepositoryPath(root, opts)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	_, err := runGit(ctx, "", "init", "--bare", "--initial-branch="+defaultBranch, path)
+	return err
+}
+
+// GetAuthenticatedRemoteURL returns the repository's path,
+// which git clones and pushes to directly.
+func (l *Local) GetAuthenticatedRemoteURL(context.Context) (string, error) {
+	return l.path, nil
+}
+
+func (l *Local) ListBranches(ctx context.Context) ([]string, error) {
+	out, err := l.git(ctx, "for-each-ref", "--format=%(refname:short)", "refs/heads/")
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(out), nil
+}
+
+func (l *Local) DefaultBranch(ctx context.Context) (string, error) {
+	return l.git(ctx, "symbolic-ref", "--short", "HEAD")
+}
+
+// CreateBranch creates a new branch from the default branch.
+func (l *Local) CreateBranch(ctx context.Context, branch string) error {
+	if _, err := l.GetBranchHeadSHA(ctx, branch); err == nil {
+		return fmt.Errorf("branch %s: %w", branch, ErrAlreadyExists)
+	}
+	defaultBranch, err := l.DefaultBranch(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = l.git(ctx, "branch", branch, defaultBranch)
+	return err
+}
+
+func (l *Local) GetBranchHeadSHA(ctx context.Context, branch string) (string, error) {
+	return l.resolveRef(ctx, "refs/heads/"+branch)
+}
+
+// resolveRef returns the commit ref points at, or
+// ErrNotFound if there is no such ref.
+func (l *Local) resolveRef(ctx context.Context, ref string) (string, error) {
+	sha, err := l.git(ctx, "rev-parse", "--verify", "--quiet", ref)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return "", fmt.Errorf("%s: %w", ref, ErrNotFound)
+	}
+	return sha, err
+}
+
+func (l *Local) CreatePullRequest(ctx context.Context, title, description, head, base, purposeLabel string) (int, error) {
+	if !slices.Contains(github.SupportedPRPurposeLabels, purposeLabel) {
+		return 0, fmt.Errorf("invalid purpose label %q", purposeLabel)
+	}
+	for _, branch := range []string{head, base} {
+		if _, err := l.GetBranchHeadSHA(ctx, branch); err != nil {
+			return 0, fmt.Errorf("branch %s not found", branch)
+		}
+	}
+
+	var number int
+	err := l.withStore(func(store PullRequestStore) error {
+		var err error
+		number, err = store.CreateLocalPullRequest(ctx, database.LocalPullRequest{
+			Owner:  l.owner,
+			Repo:   l.repo,
+			Title:  title,
+			Body:   description,
+			Head:   head,
+			Base:   base,
+			Labels: []string{purposeLabel},
+		})
+		return err
+	})
+	return number, err
+}
+
+func (l *Local) GetPullRequestBody(ctx context.Context, prNumber int) (string, error) {
+	var body string
+	err := l.withStore(func(store PullRequestStore) error {
+		pr, err := store.GetLocalPullRequest(ctx, l.owner, l.repo, prNumber)
+		if err != nil {
+			return err
+		}
+		body = pr.Body
+		return nil
+	})
+	return body, err
+}
+
+// GetPullRequestLabels returns the labels a PR was opened
+// with; the local forge has no way to change them.
+func (l *Local) GetPullRequestLabels(ctx context.Context, prNumber int) ([]string, error) {
+	var labels []string
+	err := l.withStore(func(store PullRequestStore) error {
+		pr, err := store.GetLocalPullRequest(ctx, l.owner, l.repo, prNumber)
+		if err != nil {
+			return err
+		}
+		labels = pr.Labels
+		return nil
+	})
+	return labels, err
+}
+
+func (l *Local) UpdatePullRequestBody(ctx context.Context, prNumber int, body string) error {
+	return l.withStore(func(store PullRequestStore) error {
+		return store.UpdateLocalPullRequestBody(ctx, l.owner, l.repo, prNumber, body)
+	})
+}
+
+// CommentOnPullRequest adds a conversation comment to a PR.
+func (l *Local) CommentOnPullRequest(ctx context.Context, prNumber int, body string) error {
+	return l.withStore(func(store PullRequestStore) error {
+		if _, err := store.GetLocalPullRequest(ctx, l.owner, l.repo, prNumber); err != nil {
+			return err
+		}
+		return store.AddLocalPullRequestComment(ctx, l.owner, l.repo, prNumber, body)
+	})
+}
+
+// ReplyToReviewComment adds a conversation comment, since
+// the local forge has no review threads.
+func (l *Local) ReplyToReviewComment(ctx context.Context, prNumber int, _ int64, body string) error {
+	return l.CommentOnPullRequest(ctx, prNumber, body)
+}
+
+// ResolveReviewThread does nothing; the local forge has no
+// review threads.
+func (l *Local) ResolveReviewThread(context.Context, int, int64) error {
+	return nil
+}
+
+// SuggestChanges adds the suggestions to a conversation
+// comment, since the local forge has no reviews. They are
+// applied by pushing them.
+func (l *Local) SuggestChanges(ctx context.Context, prNumber int, body string, suggestions []github.ReviewSuggestion) error {
+	parts := []string{body}
+	for _, s := range suggestions {
+		parts = append(parts, fmt.Sprintf("**%s**\n\n%s", s.Location(), s.Markdown()))
+	}
+	return l.CommentOnPullRequest(ctx, prNumber, strings.TrimSpace(strings.Join(parts, "\n\n")))
+}
+
+// ProtectBranch lists branch in the repository's protected
+// branches and installs the hook that enforces them.
+func (l *Local) ProtectBranch(ctx context.Context, branch string) error {
+	if _, err := l.GetBranchHeadSHA(ctx, branch); err != nil {
+		return fmt.Errorf("branch %s not found", branch)
+	}
+
+	listPath := filepath.Join(l.path, protectedBranchesFile)
+	data, err := os.ReadFile(listPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	branches := strings.Fields(string(data))
+	if !slices.Contains(branches, branch) {
+		branches = append(branches, branch)
+		if err = os.WriteFile(listPath, []byte(strings.Join(branches, "\n")+"\n"), 0o644); err != nil {
+			return err
+		}
+	}
+
+	hookPath := filepath.Join(l.path, "hooks", "pre-receive")
+	if err = os.MkdirAll(filepath.Dir(hookPath), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(hookPath, []byte(preReceiveHook), 0o755)
+}
+
+// ListPullRequests returns the repository's pull requests. A
+// pull request counts as merged once its head is part of its
+// base, since the local forge has no merge button of its own.
+func (l *Local) ListPullRequests(ctx context.Context) ([]github.PullRequestSummary, error) {
+	var stored []database.LocalPullRequest
+	err := l.withStore(func(store PullRequestStore) error {
+		var err error
+		stored, err = store.ListLocalPullRequests(ctx, l.owner, l.repo)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	prs := make([]github.PullRequestSummary, len(stored))
+	for i, pr := range stored {
+		prs[i] = github.PullRequestSummary{
+			Number:    pr.Number,
+			Head:      pr.Head,
+			Base:      pr.Base,
+			Labels:    pr.Labels,
+			Open:      !pr.Closed,
+			Merged:    l.isAncestor(ctx, pr.Head, pr.Base),
+			UpdatedAt: pr.UpdatedAt,
+		}
+	}
+	return prs, nil
+}
+
+// isAncestor reports whether branch head is part of branch
+// base. It is false if either branch is missing.
+func (l *Local) isAncestor(ctx context.Context, head, base string) bool {
+	_, err := l.git(ctx, "merge-base", "--is-ancestor", "refs/heads/"+head, "refs/heads/"+base)
+	return err == nil
+}
+
+func (l *Local) ClosePullRequest(ctx context.Context, prNumber int) error {
+	return l.withStore(func(store PullRequestStore) error {
+		return store.CloseLocalPullRequest(ctx, l.owner, l.repo, prNumber)
+	})
+}
+
+// DeleteBranch removes branch from the protected branches
+// and deletes it.
+func (l *Local) DeleteBranch(ctx context.Context, branch string) error {
+	listPath := filepath.Join(l.path, protectedBranchesFile)
+	data, err := os.ReadFile(listPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	branches := strings.Fields(string(data))
+	if i := slices.Index(branches, branch); i >= 0 {
+		branches = slices.Delete(branches, i, i+1)
+		content := ""
+		if len(branches) > 0 {
+			content = strings.Join(branches, "\n") + "\n"
+		}
+		if err = os.WriteFile(listPath, []byte(content), 0o644); err != nil {
+			return err
+		}
+	}
+
+	_, err = l.git(ctx, "update-ref", "-d", "refs/heads/"+branch)
+	return err
+}
+
+func (l *Local) CreateTag(ctx context.Context, tag, sha string) error {
+	if _, err := l.resolveRef(ctx, "refs/tags/"+tag); err == nil {
+		return fmt.Errorf("tag %s: %w", tag, ErrAlreadyExists)
+	}
+	_, err := l.git(ctx, "update-ref", "refs/tags/"+tag, sha)
+	return err
+}
+
+func (l *Local) GetTagSHA(ctx context.Context, tag string) (string, error) {
+	return l.resolveRef(ctx, "refs/tags/"+tag)
+}
+
+// CreateCheckRun does nothing and returns 0: the local forge
+// has no checks, so builds are only reported in the PR
+// description.
+func (l *Local) CreateCheckRun(context.Context, github.CheckRun) (int64, error) {
+	return 0, nil
+}
+
+// UpdateCheckRun does nothing; see CreateCheckRun.
+func (l *Local) UpdateCheckRun(context.Context, int64, github.CheckRun) error {
+	return nil
+}
+
+func (l *Local) withStore(fn func(PullRequestStore) error) error {
+	store, err := l.store()
+	if err != nil {
+		return fmt.Errorf("open pull request store: %w", err)
+	}
+	defer store.Close()
+	return fn(store)
+}
+
+func (l *Local) git(ctx context.Context, args ...string) (string, error) {
+	return runGit(ctx, l.path, args...)
+}
+
+// runGit runs git, in gitDir if set, isolated from the
+// user's and system's git configuration.
+func runGit(ctx context.Context, gitDir string, args ...string) (string, error) {
+	if gitDir != "" {
+		args = append([]string{"--git-dir", gitDir}, args...)
+	}
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Env = append(os.Environ(),
+		"GIT_CONFIG_GLOBAL=/dev/null",
+		"GIT_CONFIG_SYSTEM=/dev/null",
+		"GIT_TERMINAL_PROMPT=0",
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %s: %w", args[0], strings.TrimSpace(string(out)), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
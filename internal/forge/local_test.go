@@ -0,0 +1,344 @@
+package forge
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ansg191/job-temporal/internal/database"
+	"github.com/ansg191/job-temporal/internal/github"
+)
+
+func ensureGitAvailable(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary not found in PATH")
+	}
+}
+
+func runTestGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"GIT_CONFIG_GLOBAL=/dev/null",
+		"GIT_CONFIG_SYSTEM=/dev/null",
+		"GIT_TERMINAL_PROMPT=0",
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// memoryStore is an in-memory PullRequestStore.
+type memoryStore struct {
+	mu       sync.Mutex
+	prs      []database.LocalPullRequest
+	comments map[int][]string
+}
+
+func (s *memoryStore) Close() error { return nil }
+
+func (s *memoryStore) CreateLocalPullRequest(_ context.Context, pr database.LocalPullRequest) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pr.Number = len(s.prs) + 1
+	s.prs = append(s.prs, pr)
+	return pr.Number, nil
+}
+
+func (s *memoryStore) GetLocalPullRequest(_ context.Context, owner, repo string, number int) (*database.LocalPullRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, pr := range s.prs {
+		if pr.Owner == owner && pr.Repo == repo && pr.Number == number {
+			return &pr, nil
+		}
+	}
+	return nil, database.ErrNotFound
+}
+
+func (s *memoryStore) UpdateLocalPullRequestBody(_ context.Context, owner, repo string, number int, body string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, pr := range s.prs {
+		if pr.Owner == owner && pr.Repo == repo && pr.Number == number {
+			s.prs[i].Body = body
+			return nil
+		}
+	}
+	return database.ErrNotFound
+}
+
+func (s *memoryStore) AddLocalPullRequestComment(_ context.Context, _, _ string, number int, body string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.comments == nil {
+		s.comments = make(map[int][]string)
+	}
+	s.comments[number] = append(s.comments[number], body)
+	return nil
+}
+
+func (s *memoryStore) ListLocalPullRequests(_ context.Context, owner, repo string) ([]database.LocalPullRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var prs []database.LocalPullRequest
+	for _, pr := range s.prs {
+		if pr.Owner == owner && pr.Repo == repo {
+			prs = append(prs, pr)
+		}
+	}
+	return prs, nil
+}
+
+func (s *memoryStore) CloseLocalPullRequest(_ context.Context, owner, repo string, number int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, pr := range s.prs {
+		if pr.Owner == owner && pr.Repo == repo && pr.Number == number {
+			s.prs[i].Closed = true
+			return nil
+		}
+	}
+	return database.ErrNotFound
+}
+
+// newTestLocal creates a local forge whose repository has a
+// main branch with one commit.
+func newTestLocal(t *testing.T) (*Local, *memoryStore) {
+	t.Helper()
+	ensureGitAvailable(t)
+
+	root := t.TempDir()
+	opts := github.ClientOptions{Owner: "acme", Repo: "resume"}
+	if err := CreateLocalRepository(t.Context(), root, opts, "main"); err != nil {
+		t.Fatalf("CreateLocalRepository: %v", err)
+	}
+
+	store := &memoryStore{}
+	l, err := NewLocal(root, opts, func() (PullRequestStore, error) { return store, nil })
+	if err != nil {
+		t.Fatalf("NewLocal: %v", err)
+	}
+
+	work := t.TempDir()
+	runTestGit(t, work, "init", "-b", "main")
+	runTestGit(t, work, "config", "user.email", "test@test.com")
+	runTestGit(t, work, "config", "user.name", "Test")
+	if err = os.WriteFile(work+"/main.typ", []byte("= Resume\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runTestGit(t, work, "add", ".")
+	runTestGit(t, work, "commit", "-m", "initial commit")
+	remote, _ := l.GetAuthenticatedRemoteURL(t.Context())
+	runTestGit(t, work, "push", remote, "main")
+	return l, store
+}
+
+func TestNewLocal_MissingRepository(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewLocal(t.TempDir(), github.ClientOptions{Owner: "acme", Repo: "missing"}, openDatabase)
+	if err == nil {
+		t.Fatal("expected error for missing repository")
+	}
+}
+
+func TestLocal_Branches(t *testing.T) {
+	t.Parallel()
+	l, _ := newTestLocal(t)
+	ctx := t.Context()
+
+	if err := l.CreateBranch(ctx, "feature"); err != nil {
+		t.Fatalf("CreateBranch: %v", err)
+	}
+	if err := l.CreateBranch(ctx, "feature"); !errors.Is(err, ErrAlreadyExists) {
+		t.Fatalf("CreateBranch again = %v, want ErrAlreadyExists", err)
+	}
+
+	branches, err := l.ListBranches(ctx)
+	if err != nil {
+		t.Fatalf("ListBranches: %v", err)
+	}
+	if !slices.Equal(branches, []string{"feature", "main"}) {
+		t.Errorf("ListBranches = %v, want [feature main]", branches)
+	}
+
+	mainSHA, err := l.GetBranchHeadSHA(ctx, "main")
+	if err != nil {
+		t.Fatalf("GetBranchHeadSHA(main): %v", err)
+	}
+	featureSHA, err := l.GetBranchHeadSHA(ctx, "feature")
+	if err != nil {
+		t.Fatalf("GetBranchHeadSHA(feature): %v", err)
+	}
+	if mainSHA != featureSHA {
+		t.Errorf("feature head = %s, want main head %s", featureSHA, mainSHA)
+	}
+	if _, err = l.GetBranchHeadSHA(ctx, "nope"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetBranchHeadSHA(nope) = %v, want ErrNotFound", err)
+	}
+}
+
+func TestLocal_PullRequests(t *testing.T) {
+	t.Parallel()
+	l, store := newTestLocal(t)
+	ctx := t.Context()
+
+	if err := l.CreateBranch(ctx, "feature"); err != nil {
+		t.Fatalf("CreateBranch: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		head, base  string
+		label       string
+		errExpected bool
+	}{
+		{name: "invalid label", head: "feature", base: "main", label: "bug", errExpected: true},
+		{name: "missing head", head: "nope", base: "main", label: "resume", errExpected: true},
+		{name: "valid", head: "feature", base: "main", label: "resume"},
+	}
+	for _, tt := range tests {
+		_, err := l.CreatePullRequest(ctx, "Title", "Body", tt.head, tt.base, tt.label)
+		if (err != nil) != tt.errExpected {
+			t.Errorf("%s: CreatePullRequest error = %v, errExpected %v", tt.name, err, tt.errExpected)
+		}
+	}
+
+	number, err := l.CreatePullRequest(ctx, "Second", "First body", "feature", "main", "cover letter")
+	if err != nil {
+		t.Fatalf("CreatePullRequest: %v", err)
+	}
+	if number != 2 {
+		t.Errorf("PR number = %d, want 2", number)
+	}
+
+	if err = l.UpdatePullRequestBody(ctx, number, "New body"); err != nil {
+		t.Fatalf("UpdatePullRequestBody: %v", err)
+	}
+	body, err := l.GetPullRequestBody(ctx, number)
+	if err != nil {
+		t.Fatalf("GetPullRequestBody: %v", err)
+	}
+	if body != "New body" {
+		t.Errorf("body = %q, want %q", body, "New body")
+	}
+	labels, err := l.GetPullRequestLabels(ctx, number)
+	if err != nil {
+		t.Fatalf("GetPullRequestLabels: %v", err)
+	}
+	if want := []string{"cover letter"}; !slices.Equal(labels, want) {
+		t.Errorf("labels = %v, want %v", labels, want)
+	}
+
+	if err = l.CommentOnPullRequest(ctx, number, "hello"); err != nil {
+		t.Fatalf("CommentOnPullRequest: %v", err)
+	}
+	if got := store.comments[number]; !slices.Equal(got, []string{"hello"}) {
+		t.Errorf("comments = %v, want [hello]", got)
+	}
+	if err = l.CommentOnPullRequest(ctx, 99, "hello"); !errors.Is(err, database.ErrNotFound) {
+		t.Errorf("CommentOnPullRequest(99) = %v, want ErrNotFound", err)
+	}
+
+	if err = l.ClosePullRequest(ctx, 1); err != nil {
+		t.Fatalf("ClosePullRequest: %v", err)
+	}
+	prs, err := l.ListPullRequests(ctx)
+	if err != nil {
+		t.Fatalf("ListPullRequests: %v", err)
+	}
+	if len(prs) != 2 {
+		t.Fatalf("ListPullRequests returned %d PRs, want 2", len(prs))
+	}
+	if prs[0].Open || !prs[1].Open {
+		t.Errorf("open = %v, %v, want false, true", prs[0].Open, prs[1].Open)
+	}
+	// feature has no commits of its own, so it is already
+	// part of main.
+	if !prs[1].Merged || prs[1].Head != "feature" || !slices.Equal(prs[1].Labels, []string{"cover letter"}) {
+		t.Errorf("unexpected PR: %+v", prs[1])
+	}
+}
+
+func TestLocal_ProtectBranch(t *testing.T) {
+	t.Parallel()
+	l, _ := newTestLocal(t)
+	ctx := t.Context()
+
+	if err := l.CreateBranch(ctx, "feature"); err != nil {
+		t.Fatalf("CreateBranch: %v", err)
+	}
+	if err := l.ProtectBranch(ctx, "main"); err != nil {
+		t.Fatalf("ProtectBranch: %v", err)
+	}
+	// Protecting twice is a no-op.
+	if err := l.ProtectBranch(ctx, "main"); err != nil {
+		t.Fatalf("ProtectBranch again: %v", err)
+	}
+
+	remote, _ := l.GetAuthenticatedRemoteURL(ctx)
+	work := t.TempDir()
+	runTestGit(t, work, "clone", remote, ".")
+	runTestGit(t, work, "config", "user.email", "test@test.com")
+	runTestGit(t, work, "config", "user.name", "Test")
+	runTestGit(t, work, "commit", "--allow-empty", "-m", "change")
+
+	if err := exec.Command("git", "-C", work, "push", "origin", "HEAD:main").Run(); err == nil {
+		t.Error("push to protected branch succeeded")
+	}
+	runTestGit(t, work, "push", "origin", "HEAD:feature")
+}
+
+func TestLocal_DeleteBranchAndTag(t *testing.T) {
+	t.Parallel()
+	l, _ := newTestLocal(t)
+	ctx := t.Context()
+
+	if err := l.CreateBranch(ctx, "final"); err != nil {
+		t.Fatalf("CreateBranch: %v", err)
+	}
+	sha, err := l.GetBranchHeadSHA(ctx, "final")
+	if err != nil {
+		t.Fatalf("GetBranchHeadSHA: %v", err)
+	}
+	if err = l.ProtectBranch(ctx, "final"); err != nil {
+		t.Fatalf("ProtectBranch: %v", err)
+	}
+
+	if err = l.CreateTag(ctx, "archive/final", sha); err != nil {
+		t.Fatalf("CreateTag: %v", err)
+	}
+	if err = l.CreateTag(ctx, "archive/final", sha); !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("CreateTag again = %v, want ErrAlreadyExists", err)
+	}
+	if got, err := l.GetTagSHA(ctx, "archive/final"); err != nil || got != sha {
+		t.Errorf("GetTagSHA = %s, %v, want %s", got, err, sha)
+	}
+	if _, err = l.GetTagSHA(ctx, "archive/nope"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetTagSHA(archive/nope) = %v, want ErrNotFound", err)
+	}
+
+	if err = l.DeleteBranch(ctx, "final"); err != nil {
+		t.Fatalf("DeleteBranch: %v", err)
+	}
+	if _, err = l.GetBranchHeadSHA(ctx, "final"); err == nil {
+		t.Error("final still exists after DeleteBranch")
+	}
+	data, err := os.ReadFile(filepath.Join(l.path, protectedBranchesFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(data)) != "" {
+		t.Errorf("protected branches = %q, want none", data)
+	}
+}
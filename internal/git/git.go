@@ -3,10 +3,13 @@ package git
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -15,10 +18,20 @@ const (
 	gitAuthorEmail = "job-temporal@anshulg.com"
 )
 
-// Repository references a git repository on the system stored in a tmp directory
+// Repository references a git repository on the system stored in a tmp directory.
+// It is either a standalone shallow clone (NewGitRepo) or a worktree of a
+// pooled mirror (Open).
 type Repository struct {
 	path   string
 	remote string
+
+	// mirror is set for worktrees. heldBranches are the
+	// branches this worktree has locked in the mirror.
+	mirror       *mirror
+	heldBranches []string
+
+	// signing signs the agent's commits if set.
+	signing *Signing
 }
 
 func NewGitRepo(ctx context.Context, remote string) (*Repository, error) {
@@ -28,8 +41,9 @@ func NewGitRepo(ctx context.Context, remote string) (*Repository, error) {
 	}
 
 	ret := &Repository{
-		path:   tmpPath,
-		remote: remote,
+		path:    tmpPath,
+		remote:  remote,
+		signing: defaultSigning(),
 	}
 	if err := ret.clone(ctx); err != nil {
 		_ = ret.Close()
@@ -39,6 +53,9 @@ func NewGitRepo(ctx context.Context, remote string) (*Repository, error) {
 }
 
 func (r *Repository) Close() error {
+	if r.mirror != nil {
+		return r.mirror.release(r)
+	}
 	return os.RemoveAll(r.path)
 }
 
@@ -49,14 +66,22 @@ func (r *Repository) Path() string {
 // gitCmd creates an exec.Cmd for git with proper environment variables set
 func (r *Repository) gitCmd(ctx context.Context, args ...string) *exec.Cmd {
 	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", r.path}, args...)...)
-	cmd.Env = append(os.Environ(),
-		"GIT_CONFIG_GLOBAL=/dev/null",
-		"GIT_CONFIG_SYSTEM=/dev/null",
-		"GIT_TERMINAL_PROMPT=0",
-	)
+	cmd.Env = gitEnv()
 	return cmd
 }
 
+// withMirrorLock runs fn holding the mirror lock for
+// worktrees, since fetches update refs shared with other
+// worktrees.
+func (r *Repository) withMirrorLock(fn func() error) error {
+	if r.mirror == nil {
+		return fn()
+	}
+	r.mirror.mu.Lock()
+	defer r.mirror.mu.Unlock()
+	return fn()
+}
+
 // Clones the repo into the path
 func (r *Repository) clone(ctx context.Context) error {
 	if r.remote == "" {
@@ -140,6 +165,15 @@ func (r *Repository) SetBranch(ctx context.Context, branch string) error {
 		return fmt.Errorf("branch name is required")
 	}
 
+	// Worktrees of the same mirror share local branches, so
+	// only one may have a branch checked out at a time.
+	if r.mirror != nil && !slices.Contains(r.heldBranches, branch) {
+		if err := r.mirror.lockBranch(ctx, branch); err != nil {
+			return err
+		}
+		r.heldBranches = append(r.heldBranches, branch)
+	}
+
 	// Check if local branch exists
 	verifyLocal := r.gitCmd(ctx, "rev-parse", "--verify", fmt.Sprintf("refs/heads/%s", branch))
 	var verifyLocalErr bytes.Buffer
@@ -170,11 +204,17 @@ func (r *Repository) SetBranch(ctx context.Context, branch string) error {
 		// Local branch exists, just checkout
 		cmd = r.gitCmd(ctx, "checkout", branch)
 	} else if remoteBranchExists {
-		// Fetch the branch (this populates FETCH_HEAD)
-		fetchCmd := r.gitCmd(ctx, "fetch", "--depth", "1", "origin", branch)
+		// Fetch the branch (this populates FETCH_HEAD). Mirrors
+		// keep full history, so only standalone clones are
+		// fetched shallow.
+		fetchArgs := []string{"fetch", "--depth", "1", "origin", branch}
+		if r.mirror != nil {
+			fetchArgs = []string{"fetch", "origin", branch}
+		}
+		fetchCmd := r.gitCmd(ctx, fetchArgs...)
 		var fetchStderr bytes.Buffer
 		fetchCmd.Stderr = &fetchStderr
-		if err := fetchCmd.Run(); err != nil {
+		if err := r.withMirrorLock(fetchCmd.Run); err != nil {
 			if errMsg := strings.TrimSpace(fetchStderr.String()); errMsg != "" {
 				return fmt.Errorf("git fetch failed: %s: %w", errMsg, err)
 			}
@@ -203,7 +243,8 @@ func (r *Repository) SetBranch(ctx context.Context, branch string) error {
 		var pushStderr bytes.Buffer
 		pushCmd.Stderr = &pushStderr
 
-		if err := pushCmd.Run(); err != nil {
+		// -u writes the branch's upstream to the shared config.
+		if err := r.withMirrorLock(pushCmd.Run); err != nil {
 			if errMsg := strings.TrimSpace(pushStderr.String()); errMsg != "" {
 				return fmt.Errorf("git push failed: %s: %w", errMsg, err)
 			}
@@ -236,6 +277,33 @@ func (r *Repository) GetHeadCommit(ctx context.Context) (string, error) {
 	return sha, nil
 }
 
+// RemoteBranchHead returns the SHA that branch points to on
+// the remote without cloning it. It returns "" if the branch
+// does not exist.
+func RemoteBranchHead(ctx context.Context, remote, branch string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "ls-remote", "--heads", remote, "refs/heads/"+branch)
+	cmd.Env = gitEnv()
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if errMsg := strings.TrimSpace(stderr.String()); errMsg != "" {
+			return "", fmt.Errorf("git ls-remote failed: %s: %w", errMsg, err)
+		}
+		return "", fmt.Errorf("git ls-remote failed: %w", err)
+	}
+
+	// Format: <sha>\trefs/heads/<branch>
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		parts := strings.Split(strings.TrimSpace(line), "\t")
+		if len(parts) == 2 && parts[1] == "refs/heads/"+branch {
+			return parts[0], nil
+		}
+	}
+	return "", nil
+}
+
 // GetFile returns the contents of a file from the working directory
 func (r *Repository) GetFile(ctx context.Context, filePath string) (string, error) {
 	filePath = strings.TrimSpace(filePath)
@@ -259,7 +327,7 @@ func (r *Repository) pullBranch(ctx context.Context) error {
 	var fetchStderr bytes.Buffer
 	fetchCmd.Stderr = &fetchStderr
 
-	if err := fetchCmd.Run(); err != nil {
+	if err := r.withMirrorLock(fetchCmd.Run); err != nil {
 		if errMsg := strings.TrimSpace(fetchStderr.String()); errMsg != "" {
 			return fmt.Errorf("git fetch failed: %s: %w", errMsg, err)
 		}
@@ -286,7 +354,7 @@ func (r *Repository) pullBranch(ctx context.Context) error {
 	var pullStderr bytes.Buffer
 	pullCmd.Stderr = &pullStderr
 
-	if err := pullCmd.Run(); err != nil {
+	if err := r.withMirrorLock(pullCmd.Run); err != nil {
 		if errMsg := strings.TrimSpace(pullStderr.String()); errMsg != "" {
 			return fmt.Errorf("git pull failed: %s: %w", errMsg, err)
 		}
@@ -305,18 +373,32 @@ func (r *Repository) pullBranch(ctx context.Context) error {
 // 5. Pushes branch to remote
 // 6. Returns the new HEAD commit SHA.
 func (r *Repository) EditFile(ctx context.Context, commitID, patch, msg string) (string, error) {
+	return r.EditFiles(ctx, commitID, []string{patch}, msg)
+}
+
+// EditFiles is EditFile for several patches, typically to
+// different files. The patches are applied in order and all
+// must apply; if any fails, none are kept. They are then
+// committed and pushed together as a single commit.
+func (r *Repository) EditFiles(ctx context.Context, commitID string, patches []string, msg string) (string, error) {
 	commitID = strings.TrimSpace(commitID)
 	msg = strings.TrimSpace(msg)
 
 	if commitID == "" {
 		return "", fmt.Errorf("commit ID is required")
 	}
-	if strings.TrimSpace(patch) == "" {
+	if len(patches) == 0 {
 		return "", fmt.Errorf("patch is required")
 	}
-	// Ensure patch ends with newline (required by git apply)
-	if !strings.HasSuffix(patch, "\n") {
-		patch = patch + "\n"
+	patches = slices.Clone(patches)
+	for i, patch := range patches {
+		if strings.TrimSpace(patch) == "" {
+			return "", fmt.Errorf("patch is required")
+		}
+		// Ensure patch ends with newline (required by git apply)
+		if !strings.HasSuffix(patch, "\n") {
+			patches[i] = patch + "\n"
+		}
 	}
 	if msg == "" {
 		return "", fmt.Errorf("commit message is required")
@@ -343,35 +425,22 @@ func (r *Repository) EditFile(ctx context.Context, commitID, patch, msg string)
 		}
 	}
 
-	// Step 3: Apply the patch
-	applyCmd := r.gitCmd(ctx, "apply", "--index")
-	applyCmd.Stdin = strings.NewReader(patch)
-	var applyStderr bytes.Buffer
-	applyCmd.Stderr = &applyStderr
-
-	if err := applyCmd.Run(); err != nil {
-		if errMsg := strings.TrimSpace(applyStderr.String()); errMsg != "" {
-			return "", fmt.Errorf("git apply failed: %s: %w", errMsg, err)
+	// Step 3: Apply the patches, undoing them all if any fails
+	for i, patch := range patches {
+		if err := r.applyPatch(ctx, patch); err != nil {
+			if resetErr := r.resetHard(ctx, headCommit); resetErr != nil {
+				return "", fmt.Errorf("%w (undoing earlier patches also failed: %v)", err, resetErr)
+			}
+			if len(patches) > 1 {
+				return "", fmt.Errorf("patch %d: %w", i+1, err)
+			}
+			return "", err
 		}
-		return "", fmt.Errorf("git apply failed: %w", err)
 	}
 
 	// Step 4: Commit with message
-	commitCmd := r.gitCmd(ctx, "commit", "-m", msg)
-	commitCmd.Env = append(commitCmd.Env,
-		"GIT_AUTHOR_NAME="+gitAuthorName,
-		"GIT_AUTHOR_EMAIL="+gitAuthorEmail,
-		"GIT_COMMITTER_NAME="+gitAuthorName,
-		"GIT_COMMITTER_EMAIL="+gitAuthorEmail,
-	)
-	var commitStderr bytes.Buffer
-	commitCmd.Stderr = &commitStderr
-
-	if err := commitCmd.Run(); err != nil {
-		if errMsg := strings.TrimSpace(commitStderr.String()); errMsg != "" {
-			return "", fmt.Errorf("git commit failed: %s: %w", errMsg, err)
-		}
-		return "", fmt.Errorf("git commit failed: %w", err)
+	if err := r.commit(ctx, msg); err != nil {
+		return "", err
 	}
 
 	// Step 5: Push branch to remote
@@ -384,7 +453,7 @@ func (r *Repository) EditFile(ctx context.Context, commitID, patch, msg string)
 	var pushStderr bytes.Buffer
 	pushCmd.Stderr = &pushStderr
 
-	if err := pushCmd.Run(); err != nil {
+	if err := r.withMirrorLock(pushCmd.Run); err != nil {
 		if errMsg := strings.TrimSpace(pushStderr.String()); errMsg != "" {
 			return "", fmt.Errorf("git push failed: %s: %w", errMsg, err)
 		}
@@ -400,6 +469,64 @@ func (r *Repository) EditFile(ctx context.Context, commitID, patch, msg string)
 	return newHead, nil
 }
 
+// applyPatch applies patch to the working tree and index.
+func (r *Repository) applyPatch(ctx context.Context, patch string) error {
+	applyCmd := r.gitCmd(ctx, "apply", "--index")
+	applyCmd.Stdin = strings.NewReader(patch)
+	var applyStderr bytes.Buffer
+	applyCmd.Stderr = &applyStderr
+
+	if err := applyCmd.Run(); err != nil {
+		if errMsg := strings.TrimSpace(applyStderr.String()); errMsg != "" {
+			return fmt.Errorf("git apply failed: %s: %w", errMsg, err)
+		}
+		return fmt.Errorf("git apply failed: %w", err)
+	}
+	return nil
+}
+
+// resetHard discards working tree and index changes back to
+// commit.
+func (r *Repository) resetHard(ctx context.Context, commit string) error {
+	resetCmd := r.gitCmd(ctx, "reset", "--hard", commit)
+	var resetStderr bytes.Buffer
+	resetCmd.Stderr = &resetStderr
+
+	if err := resetCmd.Run(); err != nil {
+		if errMsg := strings.TrimSpace(resetStderr.String()); errMsg != "" {
+			return fmt.Errorf("git reset failed: %s: %w", errMsg, err)
+		}
+		return fmt.Errorf("git reset failed: %w", err)
+	}
+	return nil
+}
+
+// commit commits the index as the agent, signed if the
+// repository has signing configured.
+func (r *Repository) commit(ctx context.Context, msg string) error {
+	args := append(r.signing.configArgs(), "commit", "--quiet", "-m", msg)
+	commitCmd := r.gitCmd(ctx, args...)
+	commitCmd.Env = append(commitCmd.Env, gitIdentityEnv()...)
+	var commitStderr bytes.Buffer
+	commitCmd.Stderr = &commitStderr
+	if err := commitCmd.Run(); err != nil {
+		if errMsg := strings.TrimSpace(commitStderr.String()); errMsg != "" {
+			return fmt.Errorf("git commit failed: %s: %w", errMsg, err)
+		}
+		return fmt.Errorf("git commit failed: %w", err)
+	}
+	return nil
+}
+
+func gitIdentityEnv() []string {
+	return []string{
+		"GIT_AUTHOR_NAME=" + gitAuthorName,
+		"GIT_AUTHOR_EMAIL=" + gitAuthorEmail,
+		"GIT_COMMITTER_NAME=" + gitAuthorName,
+		"GIT_COMMITTER_EMAIL=" + gitAuthorEmail,
+	}
+}
+
 // EditFileByLines edits a file by replacing lines in a range with new content.
 // Line numbers are 1-indexed and inclusive.
 // Special cases:
@@ -444,6 +571,15 @@ func (r *Repository) EditFileByLines(ctx context.Context, commitID, filePath str
 		if err := verifyCmd.Run(); err != nil {
 			return "", fmt.Errorf("commit %s is not an ancestor of HEAD %s, cannot safely edit", commitID, headCommit)
 		}
+		// Line numbers refer to the file as of commitID, so
+		// they are only safe if the file hasn't changed since.
+		changed, err := r.fileChanged(ctx, commitID, headCommit, filePath)
+		if err != nil {
+			return "", err
+		}
+		if changed {
+			return "", fmt.Errorf("file %s changed since commit %s, read it again before editing by line", filePath, commitID)
+		}
 	}
 
 	// Step 3: Read file, split into lines, replace lines[startLine-1:endLine] with newContent
@@ -498,21 +634,8 @@ func (r *Repository) EditFileByLines(ctx context.Context, commitID, filePath str
 	}
 
 	// Step 5: Commit with message
-	commitCmd := r.gitCmd(ctx, "commit", "-m", msg)
-	commitCmd.Env = append(commitCmd.Env,
-		"GIT_AUTHOR_NAME="+gitAuthorName,
-		"GIT_AUTHOR_EMAIL="+gitAuthorEmail,
-		"GIT_COMMITTER_NAME="+gitAuthorName,
-		"GIT_COMMITTER_EMAIL="+gitAuthorEmail,
-	)
-	var commitStderr bytes.Buffer
-	commitCmd.Stderr = &commitStderr
-
-	if err := commitCmd.Run(); err != nil {
-		if errMsg := strings.TrimSpace(commitStderr.String()); errMsg != "" {
-			return "", fmt.Errorf("git commit failed: %s: %w", errMsg, err)
-		}
-		return "", fmt.Errorf("git commit failed: %w", err)
+	if err := r.commit(ctx, msg); err != nil {
+		return "", err
 	}
 
 	// Step 6: Push branch to remote
@@ -525,7 +648,7 @@ func (r *Repository) EditFileByLines(ctx context.Context, commitID, filePath str
 	var pushStderr bytes.Buffer
 	pushCmd.Stderr = &pushStderr
 
-	if err := pushCmd.Run(); err != nil {
+	if err := r.withMirrorLock(pushCmd.Run); err != nil {
 		if errMsg := strings.TrimSpace(pushStderr.String()); errMsg != "" {
 			return "", fmt.Errorf("git push failed: %s: %w", errMsg, err)
 		}
@@ -540,3 +663,99 @@ func (r *Repository) EditFileByLines(ctx context.Context, commitID, filePath str
 
 	return newHead, nil
 }
+
+// fileChanged reports whether filePath differs between two
+// commits.
+func (r *Repository) fileChanged(ctx context.Context, from, to, filePath string) (bool, error) {
+	cmd := r.gitCmd(ctx, "diff", "--quiet", from, to, "--", filePath)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return false, nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		return true, nil
+	case strings.TrimSpace(stderr.String()) != "":
+		return false, fmt.Errorf("git diff failed: %s: %w", strings.TrimSpace(stderr.String()), err)
+	default:
+		return false, fmt.Errorf("git diff failed: %w", err)
+	}
+}
+
+// ListFiles returns the paths of the files tracked at HEAD,
+// relative to the repository root.
+func (r *Repository) ListFiles(ctx context.Context) ([]string, error) {
+	cmd := r.gitCmd(ctx, "ls-files", "-z")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if errMsg := strings.TrimSpace(stderr.String()); errMsg != "" {
+			return nil, fmt.Errorf("git ls-files failed: %s: %w", errMsg, err)
+		}
+		return nil, fmt.Errorf("git ls-files failed: %w", err)
+	}
+
+	var files []string
+	for _, f := range strings.Split(stdout.String(), "\x00") {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// PatchFiles returns every path a git-format patch touches,
+// including both sides of renames and copies, so callers can
+// vet a patch before EditFile applies it.
+func PatchFiles(patch string) []string {
+	var files []string
+	add := func(p string) {
+		p = strings.TrimSpace(p)
+		if unquoted, err := strconv.Unquote(p); err == nil {
+			p = unquoted
+		}
+		if p == "" || p == "/dev/null" || slices.Contains(files, p) {
+			return
+		}
+		files = append(files, p)
+	}
+
+	for _, line := range strings.Split(patch, "\n") {
+		switch {
+		case strings.HasPrefix(line, "--- "), strings.HasPrefix(line, "+++ "):
+			p, _, _ := strings.Cut(line[4:], "\t")
+			p = strings.TrimSpace(p)
+			if unquoted, err := strconv.Unquote(p); err == nil {
+				p = unquoted
+			}
+			if p != "/dev/null" {
+				// git apply strips one leading directory.
+				if _, rest, ok := strings.Cut(p, "/"); ok {
+					p = rest
+				}
+			}
+			add(p)
+		case strings.HasPrefix(line, "rename from "), strings.HasPrefix(line, "copy from "):
+			_, p, _ := strings.Cut(line, " from ")
+			add(p)
+		case strings.HasPrefix(line, "rename to "), strings.HasPrefix(line, "copy to "):
+			_, p, _ := strings.Cut(line, " to ")
+			add(p)
+		case strings.HasPrefix(line, "diff --git "):
+			// Mode-only changes have no ---/+++ lines. Names
+			// with spaces are ambiguous here; their ---/+++ or
+			// rename lines cover them.
+			names := strings.Fields(strings.TrimPrefix(line, "diff --git "))
+			if len(names) == 2 {
+				add(strings.TrimPrefix(names[0], "a/"))
+				add(strings.TrimPrefix(names[1], "b/"))
+			}
+		}
+	}
+	return files
+}
@@ -1,10 +1,12 @@
 package git
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -338,6 +340,33 @@ func TestRepositoryGetHeadCommit(t *testing.T) {
 	}
 }
 
+func TestRemoteBranchHead(t *testing.T) {
+	t.Parallel()
+	repo, _, _ := newLocalRepoFixture(t)
+	ctx := t.Context()
+
+	want, err := repo.GetHeadCommit(ctx)
+	if err != nil {
+		t.Fatalf("GetHeadCommit failed: %v", err)
+	}
+
+	got, err := RemoteBranchHead(ctx, repo.remote, "main")
+	if err != nil {
+		t.Fatalf("RemoteBranchHead failed: %v", err)
+	}
+	if got != want {
+		t.Fatalf("RemoteBranchHead = %q, want %q", got, want)
+	}
+
+	missing, err := RemoteBranchHead(ctx, repo.remote, "does-not-exist")
+	if err != nil {
+		t.Fatalf("RemoteBranchHead(missing) failed: %v", err)
+	}
+	if missing != "" {
+		t.Fatalf("RemoteBranchHead(missing) = %q, want empty", missing)
+	}
+}
+
 func TestRepositoryListBranches(t *testing.T) {
 	t.Parallel()
 
@@ -747,3 +776,447 @@ func TestRepositoryEditFileAncestorCheck(t *testing.T) {
 		t.Fatalf("expected 'final content\\n', got %q", data)
 	}
 }
+
+func TestPatchFiles(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		patch string
+		want  []string
+	}{
+		{
+			name:  "modify",
+			patch: "--- a/jobs.typ\n+++ b/jobs.typ\n@@ -1 +1 @@\n-a\n+b\n",
+			want:  []string{"jobs.typ"},
+		},
+		{
+			name:  "new file",
+			patch: "diff --git a/new.typ b/new.typ\nnew file mode 100644\n--- /dev/null\n+++ b/new.typ\n@@ -0,0 +1 @@\n+a\n",
+			want:  []string{"new.typ"},
+		},
+		{
+			name:  "pure rename",
+			patch: "diff --git a/jobs.typ b/resume.typ\nsimilarity index 100%\nrename from jobs.typ\nrename to resume.typ\n",
+			want:  []string{"jobs.typ", "resume.typ"},
+		},
+		{
+			name:  "mode change",
+			patch: "diff --git a/run.sh b/run.sh\nold mode 100644\nnew mode 100755\n",
+			want:  []string{"run.sh"},
+		},
+		{
+			name:  "quoted name",
+			patch: "--- \"a/my jobs.typ\"\n+++ \"b/my jobs.typ\"\n@@ -1 +1 @@\n-a\n+b\n",
+			want:  []string{"my jobs.typ"},
+		},
+		{
+			name:  "multiple files",
+			patch: "--- a/jobs.typ\n+++ b/jobs.typ\n@@ -1 +1 @@\n-a\n+b\n--- a/sub/person.typ\n+++ b/sub/person.typ\n@@ -1 +1 @@\n-a\n+b\n",
+			want:  []string{"jobs.typ", "sub/person.typ"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := PatchFiles(tt.patch); !slices.Equal(got, tt.want) {
+				t.Errorf("PatchFiles() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepositoryListFiles(t *testing.T) {
+	t.Parallel()
+	repo, fileName, _ := newLocalRepoFixture(t)
+
+	files, err := repo.ListFiles(t.Context())
+	if err != nil {
+		t.Fatalf("ListFiles failed: %v", err)
+	}
+	if !slices.Contains(files, fileName) {
+		t.Fatalf("ListFiles() = %q, want it to contain %q", files, fileName)
+	}
+}
+
+func TestRepositoryEditFileByLinesStaleCommit(t *testing.T) {
+	t.Parallel()
+	repo, fileName, _ := newLocalRepoFixture(t)
+	ctx := t.Context()
+
+	oldHead, err := repo.GetHeadCommit(ctx)
+	if err != nil {
+		t.Fatalf("getHeadCommit failed: %v", err)
+	}
+
+	// Move HEAD forward with a change to the file.
+	filePath := filepath.Join(repo.path, fileName)
+	if err := os.WriteFile(filePath, []byte("inserted line\ninitial content\n"), 0o644); err != nil {
+		t.Fatalf("write file failed: %v", err)
+	}
+	runGit(t, repo.path, "add", fileName)
+	runGit(t, repo.path, "commit", "-m", "intermediate commit")
+	runGit(t, repo.path, "push", "origin", "main")
+
+	_, err = repo.EditFileByLines(ctx, oldHead, fileName, 1, 1, "replaced", "stale edit")
+	if err == nil || !strings.Contains(err.Error(), "changed since commit") {
+		t.Fatalf("EditFileByLines() error = %v, want stale commit error", err)
+	}
+}
+
+func TestRepositoryEditFiles(t *testing.T) {
+	t.Parallel()
+	repo, fileName, _ := newLocalRepoFixture(t)
+	ctx := t.Context()
+
+	// Add a second file to edit alongside the first.
+	otherName := "other_" + fileName
+	if err := os.WriteFile(filepath.Join(repo.path, otherName), []byte("other content\n"), 0o644); err != nil {
+		t.Fatalf("write file failed: %v", err)
+	}
+	runGit(t, repo.path, "add", otherName)
+	runGit(t, repo.path, "commit", "-m", "add other file")
+	runGit(t, repo.path, "push", "origin", "main")
+
+	headCommit, err := repo.GetHeadCommit(ctx)
+	if err != nil {
+		t.Fatalf("getHeadCommit failed: %v", err)
+	}
+
+	first := fmt.Sprintf("--- a/%s\n+++ b/%s\n@@ -1 +1 @@\n-initial content\n+edited content\n", fileName, fileName)
+	second := fmt.Sprintf("--- a/%s\n+++ b/%s\n@@ -1 +1 @@\n-other content\n+edited other\n", otherName, otherName)
+	bad := fmt.Sprintf("--- a/%s\n+++ b/%s\n@@ -1 +1 @@\n-missing line\n+edited other\n", otherName, otherName)
+
+	// A patch that fails to apply undoes the ones before it.
+	if _, err = repo.EditFiles(ctx, headCommit, []string{first, bad}, "partial edit"); err == nil {
+		t.Fatal("expected error for a patch that does not apply")
+	}
+	if status := runGit(t, repo.path, "status", "--porcelain"); status != "" {
+		t.Fatalf("expected clean worktree after failed edit, got %q", status)
+	}
+
+	newHead, err := repo.EditFiles(ctx, headCommit, []string{first, second}, "edit both files")
+	if err != nil {
+		t.Fatalf("EditFiles failed: %v", err)
+	}
+	if newHead == headCommit {
+		t.Fatal("expected new HEAD to differ from old HEAD")
+	}
+	if got := runGit(t, repo.path, "rev-list", "--count", headCommit+"..HEAD"); got != "1" {
+		t.Fatalf("expected exactly one new commit, got %s", got)
+	}
+
+	for name, want := range map[string]string{fileName: "edited content\n", otherName: "edited other\n"} {
+		data, err := repo.GetFile(ctx, name)
+		if err != nil {
+			t.Fatalf("GetFile(%s) failed: %v", name, err)
+		}
+		if data != want {
+			t.Errorf("%s = %q, want %q", name, data, want)
+		}
+	}
+}
+
+func TestValidateCommitGroups(t *testing.T) {
+	t.Parallel()
+
+	changed := []string{"jobs.typ", "person.typ"}
+	tests := []struct {
+		name        string
+		groups      []CommitGroup
+		errExpected bool
+	}{
+		{
+			name: "valid",
+			groups: []CommitGroup{
+				{Message: "Tailor experience", Files: []string{"jobs.typ"}},
+				{Message: "Update summary", Files: []string{"person.typ"}},
+			},
+		},
+		{name: "no groups", errExpected: true},
+		{
+			name:        "missing file",
+			groups:      []CommitGroup{{Message: "Tailor experience", Files: []string{"jobs.typ"}}},
+			errExpected: true,
+		},
+		{
+			name: "duplicate file",
+			groups: []CommitGroup{
+				{Message: "a", Files: []string{"jobs.typ", "person.typ"}},
+				{Message: "b", Files: []string{"jobs.typ"}},
+			},
+			errExpected: true,
+		},
+		{
+			name:        "unchanged file",
+			groups:      []CommitGroup{{Message: "a", Files: []string{"jobs.typ", "person.typ", "resume.typ"}}},
+			errExpected: true,
+		},
+		{
+			name:        "empty message",
+			groups:      []CommitGroup{{Message: " ", Files: changed}},
+			errExpected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := ValidateCommitGroups(changed, tt.groups)
+			if (err != nil) != tt.errExpected {
+				t.Errorf("ValidateCommitGroups() error = %v, errExpected %v", err, tt.errExpected)
+			}
+		})
+	}
+}
+
+func TestRepositoryRegroupCommits(t *testing.T) {
+	t.Parallel()
+	repo, fileName, _ := newLocalRepoFixture(t)
+	ctx := t.Context()
+
+	runGit(t, repo.path, "checkout", "-b", "feature")
+	otherName := "other_" + fileName
+	commit := func(name, content, msg string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(repo.path, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write file failed: %v", err)
+		}
+		runGit(t, repo.path, "add", name)
+		runGit(t, repo.path, "commit", "-m", msg)
+	}
+	commit(fileName, "first\n", "fix overflow")
+	commit(otherName, "other\n", "tweak bullet")
+	commit(fileName, "second\n", "fix overflow again")
+	runGit(t, repo.path, "push", "origin", "feature")
+
+	history, err := repo.BranchHistory(ctx, "main")
+	if err != nil {
+		t.Fatalf("BranchHistory failed: %v", err)
+	}
+	if len(history.Commits) != 3 {
+		t.Fatalf("expected 3 commits, got %d", len(history.Commits))
+	}
+	if history.Commits[0].Message != "fix overflow" || !slices.Equal(history.Commits[0].Files, []string{fileName}) {
+		t.Errorf("unexpected first commit: %+v", history.Commits[0])
+	}
+	if !slices.Equal(history.Files, []string{fileName, otherName}) {
+		t.Errorf("history.Files = %q", history.Files)
+	}
+
+	oldHead := history.Head
+	groups := []CommitGroup{
+		{Message: "Rewrite test file", Files: []string{fileName}},
+		{Message: "Add other file", Files: []string{otherName}},
+	}
+	newHead, err := repo.RegroupCommits(ctx, "main", oldHead, groups, "refs/backup/feature")
+	if err != nil {
+		t.Fatalf("RegroupCommits failed: %v", err)
+	}
+
+	if got := runGit(t, repo.path, "rev-list", "--count", history.MergeBase+".."+newHead); got != "2" {
+		t.Errorf("expected 2 commits after regrouping, got %s", got)
+	}
+	if got := runGit(t, repo.path, "log", "-1", "--format=%s", newHead); got != "Add other file" {
+		t.Errorf("head message = %q", got)
+	}
+	if runGit(t, repo.path, "rev-parse", newHead+"^{tree}") != runGit(t, repo.path, "rev-parse", oldHead+"^{tree}") {
+		t.Error("regrouped tree differs from the old head's")
+	}
+	if got := runGit(t, repo.remote, "rev-parse", "refs/heads/feature"); got != newHead {
+		t.Errorf("remote feature = %s, want %s", got, newHead)
+	}
+	if got := runGit(t, repo.remote, "rev-parse", "refs/backup/feature"); got != oldHead {
+		t.Errorf("backup ref = %s, want %s", got, oldHead)
+	}
+
+	// A stale head is refused.
+	if _, err = repo.RegroupCommits(ctx, "main", oldHead, groups, "refs/backup/feature"); err == nil {
+		t.Error("expected error regrouping from a stale head")
+	}
+}
+
+func TestConflictHunks(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "no conflicts",
+			content: "a\nb\n",
+		},
+		{
+			name:    "one hunk",
+			content: "a\n<<<<<<< HEAD\nb\n=======\nc\n>>>>>>> feature\nd\n",
+			want:    []string{"<<<<<<< HEAD\nb\n=======\nc\n>>>>>>> feature\n"},
+		},
+		{
+			name:    "two hunks",
+			content: "<<<<<<< HEAD\nb\n=======\nc\n>>>>>>> x\nmid\n<<<<<<< HEAD\ne\n=======\n>>>>>>> x",
+			want: []string{
+				"<<<<<<< HEAD\nb\n=======\nc\n>>>>>>> x\n",
+				"<<<<<<< HEAD\ne\n=======\n>>>>>>> x",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := ConflictHunks(tt.content); !slices.Equal(got, tt.want) {
+				t.Errorf("ConflictHunks() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepositoryMergeConflicts(t *testing.T) {
+	t.Parallel()
+	repo, fileName, _ := newLocalRepoFixture(t)
+	ctx := t.Context()
+
+	write := func(content, msg string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(repo.path, fileName), []byte(content), 0o644); err != nil {
+			t.Fatalf("write file failed: %v", err)
+		}
+		runGit(t, repo.path, "commit", "-am", msg)
+	}
+	runGit(t, repo.path, "checkout", "-b", "other")
+	write("other content\n", "other change")
+	runGit(t, repo.path, "push", "origin", "other")
+	runGit(t, repo.path, "checkout", "main")
+	runGit(t, repo.path, "checkout", "-b", "feature")
+	write("feature content\n", "feature change")
+	runGit(t, repo.path, "push", "origin", "feature")
+
+	check, err := repo.CheckMerge(ctx, "other")
+	if err != nil {
+		t.Fatalf("CheckMerge failed: %v", err)
+	}
+	if len(check.Conflicts) != 1 || check.Conflicts[0].Path != fileName {
+		t.Fatalf("unexpected conflicts: %+v", check.Conflicts)
+	}
+	if len(check.Conflicts[0].Hunks) != 1 || !strings.Contains(check.Conflicts[0].Hunks[0], "other content") {
+		t.Errorf("unexpected hunks: %q", check.Conflicts[0].Hunks)
+	}
+	if got := runGit(t, repo.path, "status", "--porcelain"); got != "" {
+		t.Errorf("worktree not clean after CheckMerge: %s", got)
+	}
+
+	// Conflict markers left in a resolution are refused.
+	_, err = repo.ResolveMerge(ctx, check.Head, check.Theirs,
+		map[string]string{fileName: check.Conflicts[0].Content}, "Merge other", "refs/heads/scratch")
+	if !errors.Is(err, ErrUnresolvedConflicts) {
+		t.Fatalf("expected ErrUnresolvedConflicts, got %v", err)
+	}
+
+	merge, err := repo.ResolveMerge(ctx, check.Head, check.Theirs,
+		map[string]string{fileName: "merged content\n"}, "Merge other", "refs/heads/scratch")
+	if err != nil {
+		t.Fatalf("ResolveMerge failed: %v", err)
+	}
+	if got := runGit(t, repo.remote, "rev-parse", "refs/heads/scratch"); got != merge {
+		t.Errorf("scratch = %s, want %s", got, merge)
+	}
+	if got := runGit(t, repo.path, "rev-parse", "HEAD"); got != check.Head {
+		t.Errorf("ResolveMerge moved the current branch to %s", got)
+	}
+
+	if err = repo.FastForward(ctx, "scratch", merge); err != nil {
+		t.Fatalf("FastForward failed: %v", err)
+	}
+	if got := runGit(t, repo.remote, "rev-parse", "refs/heads/feature"); got != merge {
+		t.Errorf("remote feature = %s, want %s", got, merge)
+	}
+	if err = repo.DeleteRemoteBranch(ctx, "scratch"); err != nil {
+		t.Fatalf("DeleteRemoteBranch failed: %v", err)
+	}
+}
+
+func TestRepositorySignedCommits(t *testing.T) {
+	t.Parallel()
+	repo, fileName, content := newLocalRepoFixture(t)
+	ctx := t.Context()
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen not found in PATH")
+	}
+
+	keyDir := t.TempDir()
+	keyPath := filepath.Join(keyDir, "signing_key")
+	out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", gitAuthorEmail, "-f", keyPath).CombinedOutput()
+	if err != nil {
+		t.Fatalf("ssh-keygen failed: %v\n%s", err, out)
+	}
+	pubKey, err := os.ReadFile(keyPath + ".pub")
+	if err != nil {
+		t.Fatalf("read public key failed: %v", err)
+	}
+	allowedSigners := filepath.Join(keyDir, "allowed_signers")
+	if err = os.WriteFile(allowedSigners, []byte(gitAuthorEmail+" "+string(pubKey)), 0o644); err != nil {
+		t.Fatalf("write allowed signers failed: %v", err)
+	}
+
+	repo.signing = &Signing{Format: "ssh", Key: keyPath}
+	head := runGit(t, repo.path, "rev-parse", "HEAD")
+	patch := fmt.Sprintf("--- a/%s\n+++ b/%s\n@@ -1 +1 @@\n-%s+signed content\n", fileName, fileName, content)
+	newHead, err := repo.EditFile(ctx, head, patch, "signed edit")
+	if err != nil {
+		t.Fatalf("EditFile failed: %v", err)
+	}
+
+	runGit(t, repo.path, "-c", "gpg.ssh.allowedSignersFile="+allowedSigners, "verify-commit", newHead)
+	if got := runGit(t, repo.path, "-c", "gpg.ssh.allowedSignersFile="+allowedSigners, "log", "-1", "--format=%G?", newHead); got != "G" {
+		t.Errorf("signature status = %q, want G", got)
+	}
+
+	// Without signing, commits are unsigned.
+	repo.signing = nil
+	patch = fmt.Sprintf("--- a/%s\n+++ b/%s\n@@ -1 +1 @@\n-signed content\n+unsigned content\n", fileName, fileName)
+	newHead, err = repo.EditFile(ctx, newHead, patch, "unsigned edit")
+	if err != nil {
+		t.Fatalf("EditFile failed: %v", err)
+	}
+	if got := runGit(t, repo.path, "log", "-1", "--format=%G?", newHead); got != "N" {
+		t.Errorf("signature status = %q, want N", got)
+	}
+}
+
+func TestSigningValidate(t *testing.T) {
+	t.Parallel()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary not found in PATH")
+	}
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen not found in PATH")
+	}
+
+	keyPath := filepath.Join(t.TempDir(), "signing_key")
+	out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", gitAuthorEmail, "-f", keyPath).CombinedOutput()
+	if err != nil {
+		t.Fatalf("ssh-keygen failed: %v\n%s", err, out)
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{name: "usable key", key: keyPath},
+		{name: "missing key", key: keyPath + ".missing", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			s := &Signing{Format: "ssh", Key: tt.key}
+			if err := s.validate(t.Context()); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
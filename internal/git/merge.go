@@ -0,0 +1,231 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnresolvedConflicts is returned by ResolveMerge when a
+// conflicted file has no resolution or still has conflict
+// markers.
+var ErrUnresolvedConflicts = errors.New("unresolved merge conflicts")
+
+// MergeConflict is a file that conflicts when merging.
+type MergeConflict struct {
+	Path string `json:"path"`
+	// Content is the file with git's conflict markers.
+	Content string `json:"content"`
+	// Hunks are the conflicting regions of Content, markers
+	// included.
+	Hunks []string `json:"hunks"`
+}
+
+// MergeCheck is the result of a trial merge.
+type MergeCheck struct {
+	// Head is the current branch's head and Theirs the head
+	// of the branch merged into it.
+	Head      string          `json:"head"`
+	Theirs    string          `json:"theirs"`
+	Conflicts []MergeConflict `json:"conflicts"`
+}
+
+// CheckMerge trial-merges branch from the remote into the
+// current branch and reports the files that conflict. The
+// current branch is left unchanged.
+func (r *Repository) CheckMerge(ctx context.Context, branch string) (*MergeCheck, error) {
+	if err := r.pullBranch(ctx); err != nil {
+		return nil, fmt.Errorf("failed to pull latest changes: %w", err)
+	}
+	head, err := r.GetHeadCommit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	theirs, err := r.fetchCommit(ctx, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts, err := r.startMerge(ctx, theirs)
+	if resetErr := r.resetHard(ctx, head); resetErr != nil && err == nil {
+		err = resetErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &MergeCheck{Head: head, Theirs: theirs, Conflicts: conflicts}, nil
+}
+
+// ResolveMerge merges theirs into the current branch, which
+// must still be at head, replaces each conflicted file with
+// its entry in resolutions and commits the merge. The merge
+// commit is force-pushed to ref rather than the current
+// branch, so it can be validated before the branch moves,
+// and the current branch is left unchanged. It returns the
+// merge commit.
+func (r *Repository) ResolveMerge(ctx context.Context, head, theirs string, resolutions map[string]string, msg, ref string) (string, error) {
+	msg = strings.TrimSpace(msg)
+	if msg == "" {
+		return "", fmt.Errorf("commit message is required")
+	}
+	if err := r.pullBranch(ctx); err != nil {
+		return "", fmt.Errorf("failed to pull latest changes: %w", err)
+	}
+	current, err := r.GetHeadCommit(ctx)
+	if err != nil {
+		return "", err
+	}
+	if current != head {
+		return "", fmt.Errorf("%w from %s to %s", ErrBranchMoved, head, current)
+	}
+
+	merge, err := r.resolveMerge(ctx, theirs, resolutions, msg)
+	if err == nil {
+		err = r.push(ctx, "--force", "origin", merge+":"+ref)
+	}
+	if resetErr := r.resetHard(ctx, head); resetErr != nil && err == nil {
+		err = resetErr
+	}
+	if err != nil {
+		return "", err
+	}
+	return merge, nil
+}
+
+func (r *Repository) resolveMerge(ctx context.Context, theirs string, resolutions map[string]string, msg string) (string, error) {
+	conflicts, err := r.startMerge(ctx, theirs)
+	if err != nil {
+		return "", err
+	}
+	for _, c := range conflicts {
+		content, ok := resolutions[c.Path]
+		if !ok {
+			return "", fmt.Errorf("%w: %s has no resolution", ErrUnresolvedConflicts, c.Path)
+		}
+		if len(ConflictHunks(content)) > 0 {
+			return "", fmt.Errorf("%w: %s still has conflict markers", ErrUnresolvedConflicts, c.Path)
+		}
+		if err = os.WriteFile(filepath.Join(r.path, c.Path), []byte(content), 0o644); err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", c.Path, err)
+		}
+		if _, err = r.output(ctx, "add", "--", c.Path); err != nil {
+			return "", err
+		}
+	}
+	if err = r.commit(ctx, msg); err != nil {
+		return "", err
+	}
+	return r.GetHeadCommit(ctx)
+}
+
+// FastForward fetches branch from the remote, fast-forwards
+// the current branch to commit on it and pushes the result.
+// It fails if the current branch is not an ancestor of
+// commit.
+func (r *Repository) FastForward(ctx context.Context, branch, commit string) error {
+	if err := r.pullBranch(ctx); err != nil {
+		return fmt.Errorf("failed to pull latest changes: %w", err)
+	}
+	if _, err := r.fetchCommit(ctx, branch); err != nil {
+		return err
+	}
+	if _, err := r.output(ctx, "merge", "--ff-only", "--quiet", commit); err != nil {
+		return err
+	}
+	current, err := r.GetBranch(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get current branch: %w", err)
+	}
+	return r.push(ctx, "origin", current)
+}
+
+// DeleteRemoteBranch deletes branch from the remote.
+func (r *Repository) DeleteRemoteBranch(ctx context.Context, branch string) error {
+	return r.push(ctx, "origin", "--delete", branch)
+}
+
+// startMerge merges commit into the worktree without
+// committing and returns the files that conflict.
+func (r *Repository) startMerge(ctx context.Context, commit string) ([]MergeConflict, error) {
+	mergeCmd := r.gitCmd(ctx, "merge", "--no-ff", "--no-commit", commit)
+	mergeCmd.Env = append(mergeCmd.Env, gitIdentityEnv()...)
+	var mergeStderr bytes.Buffer
+	mergeCmd.Stderr = &mergeStderr
+	mergeErr := mergeCmd.Run()
+
+	unmerged, err := r.output(ctx, "diff", "--name-only", "--diff-filter=U", "-z")
+	if err != nil {
+		return nil, err
+	}
+	paths := splitNul(unmerged)
+	if mergeErr != nil && len(paths) == 0 {
+		if errMsg := strings.TrimSpace(mergeStderr.String()); errMsg != "" {
+			return nil, fmt.Errorf("git merge failed: %s: %w", errMsg, mergeErr)
+		}
+		return nil, fmt.Errorf("git merge failed: %w", mergeErr)
+	}
+
+	conflicts := make([]MergeConflict, 0, len(paths))
+	for _, p := range paths {
+		data, err := os.ReadFile(filepath.Join(r.path, p))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read %s: %w", p, err)
+		}
+		// A file deleted on one side has no markers; the
+		// whole file is the conflict.
+		hunks := ConflictHunks(string(data))
+		if len(hunks) == 0 {
+			hunks = []string{string(data)}
+		}
+		conflicts = append(conflicts, MergeConflict{
+			Path:    p,
+			Content: string(data),
+			Hunks:   hunks,
+		})
+	}
+	return conflicts, nil
+}
+
+// fetchCommit fetches branch from the remote and returns its
+// head.
+func (r *Repository) fetchCommit(ctx context.Context, branch string) (string, error) {
+	fetchCmd := r.gitCmd(ctx, "fetch", "origin", branch)
+	var fetchStderr bytes.Buffer
+	fetchCmd.Stderr = &fetchStderr
+	if err := r.withMirrorLock(fetchCmd.Run); err != nil {
+		if errMsg := strings.TrimSpace(fetchStderr.String()); errMsg != "" {
+			return "", fmt.Errorf("git fetch failed: %s: %w", errMsg, err)
+		}
+		return "", fmt.Errorf("git fetch failed: %w", err)
+	}
+	return r.output(ctx, "rev-parse", "FETCH_HEAD")
+}
+
+// ConflictHunks returns the regions of content between git's
+// conflict markers, markers included.
+func ConflictHunks(content string) []string {
+	var (
+		hunks   []string
+		current []string
+		inHunk  bool
+	)
+	for _, line := range strings.SplitAfter(content, "\n") {
+		trimmed := strings.TrimRight(line, "\r\n")
+		switch {
+		case !inHunk && strings.HasPrefix(trimmed, "<<<<<<< "), !inHunk && trimmed == "<<<<<<<":
+			inHunk = true
+			current = []string{line}
+		case inHunk:
+			current = append(current, line)
+			if strings.HasPrefix(trimmed, ">>>>>>> ") || trimmed == ">>>>>>>" {
+				hunks = append(hunks, strings.Join(current, ""))
+				inHunk = false
+			}
+		}
+	}
+	return hunks
+}
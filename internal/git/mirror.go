@@ -0,0 +1,277 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Pool keeps one bare mirror per remote on disk and checks
+// repositories out of it as worktrees. Opening a repository
+// fetches only what changed since the last open instead of
+// cloning.
+//
+// Worktrees share the mirror's local branches, so a branch
+// can only be checked out by one worktree at a time:
+// SetBranch blocks until any other worktree holding the
+// branch is closed. Locks are per process; a mirror
+// directory must not be shared between workers.
+type Pool struct {
+	dir string
+
+	mu      sync.Mutex
+	mirrors map[string]*mirror
+}
+
+func NewPool(dir string) *Pool {
+	return &Pool{
+		dir:     dir,
+		mirrors: make(map[string]*mirror),
+	}
+}
+
+var defaultPool = sync.OnceValue(func() *Pool {
+	dir := os.Getenv("GIT_MIRROR_DIR")
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "job-temporal-git-mirrors")
+	}
+	return NewPool(dir)
+})
+
+// Open checks out remote into a new worktree from the
+// worker's default pool, configured by GIT_MIRROR_DIR. The
+// worktree starts detached at the remote's default branch.
+func Open(ctx context.Context, remote string) (*Repository, error) {
+	return defaultPool().Open(ctx, remote)
+}
+
+// Open checks out remote into a new worktree. The worktree
+// starts detached at the remote's default branch and is
+// removed by Close.
+func (p *Pool) Open(ctx context.Context, remote string) (*Repository, error) {
+	if remote == "" {
+		return nil, fmt.Errorf("remote repository is required")
+	}
+
+	m, err := p.mirror(ctx, remote)
+	if err != nil {
+		return nil, err
+	}
+
+	// worktree add needs a path that does not exist yet.
+	tmpPath, err := os.MkdirTemp(os.TempDir(), "*-gitrepo")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	if err = os.Remove(tmpPath); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err = m.fetch(ctx, remote); err != nil {
+		return nil, err
+	}
+	if _, err = m.git(ctx, "worktree", "add", "--detach", tmpPath, "refs/remotes/origin/HEAD"); err != nil {
+		_ = os.RemoveAll(tmpPath)
+		return nil, err
+	}
+
+	return &Repository{
+		path:    tmpPath,
+		remote:  remote,
+		mirror:  m,
+		signing: defaultSigning(),
+	}, nil
+}
+
+// mirror returns the mirror for remote, creating it on
+// first use.
+func (p *Pool) mirror(ctx context.Context, remote string) (*mirror, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := mirrorKey(remote)
+	if m, ok := p.mirrors[key]; ok {
+		return m, nil
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	m := &mirror{
+		path:     filepath.Join(p.dir, hex.EncodeToString(sum[:8])+".git"),
+		branches: make(map[string]chan struct{}),
+	}
+	if err := m.init(ctx, remote); err != nil {
+		return nil, err
+	}
+	p.mirrors[key] = m
+	return m, nil
+}
+
+// mirrorKey identifies a remote independent of the
+// credentials embedded in its URL, which rotate.
+func mirrorKey(remote string) string {
+	u, err := url.Parse(remote)
+	if err != nil || u.Host == "" {
+		return remote
+	}
+	u.User = nil
+	return u.String()
+}
+
+type mirror struct {
+	path string
+
+	// mu serializes operations on the shared repository:
+	// fetches, worktree add/remove and branch deletion.
+	mu sync.Mutex
+
+	branchMu sync.Mutex
+	branches map[string]chan struct{}
+}
+
+// init creates the bare mirror, or cleans up after a
+// previous process that exited without closing its
+// worktrees.
+func (m *mirror) init(ctx context.Context, remote string) error {
+	if _, err := os.Stat(m.path); err == nil {
+		// No worktree can be live yet in this process.
+		if err = os.RemoveAll(filepath.Join(m.path, "worktrees")); err != nil {
+			return err
+		}
+		if _, err = m.git(ctx, "worktree", "prune"); err != nil {
+			return err
+		}
+		out, err := m.git(ctx, "for-each-ref", "--format=%(refname:short)", "refs/heads/")
+		if err != nil {
+			return err
+		}
+		for _, branch := range strings.Fields(out) {
+			if _, err = m.git(ctx, "branch", "-D", branch); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(m.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create mirror dir: %w", err)
+	}
+	cmd := exec.CommandContext(ctx, "git", "init", "--bare", m.path)
+	cmd.Env = gitEnv()
+	if out, err := cmd.CombinedOutput(); err != nil {
+		_ = os.RemoveAll(m.path)
+		return fmt.Errorf("git init --bare failed: %s: %w", strings.TrimSpace(string(out)), err)
+	}
+	if _, err := m.git(ctx, "remote", "add", "origin", remote); err != nil {
+		_ = os.RemoveAll(m.path)
+		return err
+	}
+	if err := m.fetch(ctx, remote); err != nil {
+		_ = os.RemoveAll(m.path)
+		return err
+	}
+	if _, err := m.git(ctx, "remote", "set-head", "origin", "--auto"); err != nil {
+		_ = os.RemoveAll(m.path)
+		return err
+	}
+	return nil
+}
+
+// fetch updates the mirror's remote-tracking refs. The
+// remote URL is reset each time since it carries a
+// short-lived token. The caller must hold m.mu, except
+// during init.
+func (m *mirror) fetch(ctx context.Context, remote string) error {
+	if _, err := m.git(ctx, "remote", "set-url", "origin", remote); err != nil {
+		return err
+	}
+	_, err := m.git(ctx, "fetch", "--prune", "origin")
+	return err
+}
+
+func (m *mirror) git(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", m.path}, args...)...)
+	cmd.Env = gitEnv()
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if errMsg := strings.TrimSpace(stderr.String()); errMsg != "" {
+			return "", fmt.Errorf("git %s failed: %s: %w", args[0], errMsg, err)
+		}
+		return "", fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
+
+// lockBranch blocks until no other worktree holds branch.
+func (m *mirror) lockBranch(ctx context.Context, branch string) error {
+	m.branchMu.Lock()
+	lock, ok := m.branches[branch]
+	if !ok {
+		lock = make(chan struct{}, 1)
+		m.branches[branch] = lock
+	}
+	m.branchMu.Unlock()
+
+	select {
+	case lock <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for branch %s: %w", branch, ctx.Err())
+	}
+}
+
+func (m *mirror) unlockBranch(branch string) {
+	m.branchMu.Lock()
+	lock := m.branches[branch]
+	m.branchMu.Unlock()
+	<-lock
+}
+
+// release removes a worktree, deletes the local branches it
+// checked out so the next worktree starts from the remote,
+// and releases its branch locks.
+func (m *mirror) release(r *Repository) error {
+	// Cleanup must run even if the activity was cancelled.
+	ctx := context.Background()
+
+	m.mu.Lock()
+	_, err := m.git(ctx, "worktree", "remove", "--force", r.path)
+	for _, branch := range r.heldBranches {
+		// The branch may never have been created locally.
+		_, _ = m.git(ctx, "branch", "-D", branch)
+	}
+	m.mu.Unlock()
+
+	for _, branch := range r.heldBranches {
+		m.unlockBranch(branch)
+	}
+	r.heldBranches = nil
+
+	if rmErr := os.RemoveAll(r.path); rmErr != nil && err == nil {
+		err = rmErr
+	}
+	return err
+}
+
+// gitEnv is the environment for git commands, isolated from
+// the user's and system's git configuration.
+func gitEnv() []string {
+	return append(os.Environ(),
+		"GIT_CONFIG_GLOBAL=/dev/null",
+		"GIT_CONFIG_SYSTEM=/dev/null",
+		"GIT_TERMINAL_PROMPT=0",
+	)
+}
@@ -0,0 +1,107 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// Signing configures how the agent's commits are signed.
+type Signing struct {
+	// Format is git's gpg.format: "openpgp", "x509" or "ssh".
+	Format string
+	// Key is git's user.signingkey: a key ID for openpgp and
+	// x509, or the path to a private key (or a "key::"
+	// literal public key held by ssh-agent) for ssh.
+	Key string
+}
+
+// configArgs returns the git options that sign commits.
+func (s *Signing) configArgs() []string {
+	if s == nil {
+		return nil
+	}
+	return []string{
+		"-c", "gpg.format=" + s.Format,
+		"-c", "user.signingkey=" + s.Key,
+		"-c", "commit.gpgsign=true",
+	}
+}
+
+// defaultSigning is the commit signing configured by the
+// environment, or nil if commits are not signed:
+//
+//   - GIT_SIGNING_KEY: the signing key, as for Signing.Key
+//   - GIT_SIGNING_FORMAT: "openpgp" (default), "x509" or
+//     "ssh"
+var defaultSigning = sync.OnceValue(func() *Signing {
+	key := strings.TrimSpace(os.Getenv("GIT_SIGNING_KEY"))
+	if key == "" {
+		return nil
+	}
+	format := strings.TrimSpace(os.Getenv("GIT_SIGNING_FORMAT"))
+	switch format {
+	case "":
+		format = "openpgp"
+	case "openpgp", "x509", "ssh":
+	default:
+		slog.Warn("invalid GIT_SIGNING_FORMAT, commits will not be signed", "value", format)
+		return nil
+	}
+	return &Signing{Format: format, Key: key}
+})
+
+// ValidateSigning signs a commit in a scratch repository
+// with the configured signing, so a missing or unusable key
+// fails at startup instead of on the agent's first edit. It
+// returns the signing format, or "" if commits are not
+// signed.
+func ValidateSigning(ctx context.Context) (string, error) {
+	s := defaultSigning()
+	if s == nil {
+		if strings.TrimSpace(os.Getenv("GIT_SIGNING_KEY")) != "" {
+			return "", errors.New("invalid GIT_SIGNING_FORMAT")
+		}
+		return "", nil
+	}
+	if err := s.validate(ctx); err != nil {
+		return "", err
+	}
+	return s.Format, nil
+}
+
+// validate makes a signed empty commit in a scratch
+// repository.
+func (s *Signing) validate(ctx context.Context) error {
+	dir, err := os.MkdirTemp("", "job-temporal-signing-*")
+	if err != nil {
+		return fmt.Errorf("failed to create signing check repository: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	initCmd := exec.CommandContext(ctx, "git", "init", "--quiet", dir)
+	initCmd.Env = gitEnv()
+	if out, err := initCmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git init failed: %s: %w", strings.TrimSpace(string(out)), err)
+	}
+
+	r := &Repository{path: dir, signing: s}
+	args := append(s.configArgs(), "commit", "--quiet", "--allow-empty", "-m", "signing check")
+	commitCmd := r.gitCmd(ctx, args...)
+	commitCmd.Env = append(commitCmd.Env, gitIdentityEnv()...)
+	var stderr bytes.Buffer
+	commitCmd.Stderr = &stderr
+	if err = commitCmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("signed commit failed: %s: %w", msg, err)
+		}
+		return fmt.Errorf("signed commit failed: %w", err)
+	}
+	return nil
+}
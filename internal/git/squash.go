@@ -0,0 +1,244 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var (
+	// ErrInvalidCommitGroups is returned by RegroupCommits
+	// when the groups do not commit exactly the changed files.
+	ErrInvalidCommitGroups = errors.New("invalid commit groups")
+	// ErrBranchMoved is returned by RegroupCommits when the
+	// branch is no longer at the expected head.
+	ErrBranchMoved = errors.New("branch moved")
+)
+
+// BranchCommit is a commit on a branch since it forked from
+// its base.
+type BranchCommit struct {
+	SHA     string `json:"sha"`
+	Message string `json:"message"`
+	// Files are the paths the commit changed.
+	Files []string `json:"files"`
+}
+
+// CommitGroup is one commit of a regrouped branch history.
+type CommitGroup struct {
+	Message string   `json:"message"`
+	Files   []string `json:"files"`
+}
+
+// BranchHistory describes the current branch since it forked
+// from base.
+type BranchHistory struct {
+	Head      string `json:"head"`
+	MergeBase string `json:"merge_base"`
+	// Commits are oldest first.
+	Commits []BranchCommit `json:"commits"`
+	// Files are the paths that differ between the merge base
+	// and the head.
+	Files []string `json:"files"`
+}
+
+// BranchHistory returns the history of the current branch
+// since it forked from base on the remote.
+func (r *Repository) BranchHistory(ctx context.Context, base string) (*BranchHistory, error) {
+	if err := r.pullBranch(ctx); err != nil {
+		return nil, fmt.Errorf("failed to pull latest changes: %w", err)
+	}
+	head, err := r.GetHeadCommit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mergeBase, err := r.mergeBase(ctx, base)
+	if err != nil {
+		return nil, err
+	}
+
+	log, err := r.output(ctx, "log", "--reverse", "--no-renames", "--name-only", "-z",
+		"--format=%x1e%H%x1f%B%x1f", mergeBase+"..HEAD")
+	if err != nil {
+		return nil, err
+	}
+	var commits []BranchCommit
+	for _, record := range strings.Split(log, "\x1e") {
+		fields := strings.SplitN(record, "\x1f", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		commits = append(commits, BranchCommit{
+			SHA:     fields[0],
+			Message: strings.TrimSpace(fields[1]),
+			Files:   splitNul(fields[2]),
+		})
+	}
+
+	diff, err := r.output(ctx, "diff", "--no-renames", "--name-only", "-z", mergeBase, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	return &BranchHistory{
+		Head:      head,
+		MergeBase: mergeBase,
+		Commits:   commits,
+		Files:     splitNul(diff),
+	}, nil
+}
+
+// RegroupCommits replaces the current branch's commits since
+// it forked from base with one commit per group, each
+// committing the group's files as they are at head. The
+// resulting tree is identical to head's.
+//
+// The branch must still be at head, and every changed file
+// must be in exactly one group. The old history is pushed to
+// backupRef, then the branch is force-pushed with a lease on
+// head so concurrent pushes are never overwritten. It
+// returns the new head.
+func (r *Repository) RegroupCommits(ctx context.Context, base, head string, groups []CommitGroup, backupRef string) (string, error) {
+	history, err := r.BranchHistory(ctx, base)
+	if err != nil {
+		return "", err
+	}
+	if history.Head != head {
+		return "", fmt.Errorf("%w from %s to %s", ErrBranchMoved, head, history.Head)
+	}
+	if err = ValidateCommitGroups(history.Files, groups); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidCommitGroups, err)
+	}
+	branch, err := r.GetBranch(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get current branch: %w", err)
+	}
+
+	if err = r.push(ctx, "--force", "origin", head+":"+backupRef); err != nil {
+		return "", fmt.Errorf("failed to back up history: %w", err)
+	}
+
+	// Keep head's files in the worktree but unstage
+	// everything since the merge base.
+	if _, err = r.output(ctx, "reset", "--mixed", "--quiet", history.MergeBase); err != nil {
+		return "", err
+	}
+	newHead, err := r.commitGroups(ctx, groups)
+	if err == nil {
+		var tree, headTree string
+		tree, err = r.output(ctx, "rev-parse", "HEAD^{tree}")
+		if err == nil {
+			headTree, err = r.output(ctx, "rev-parse", head+"^{tree}")
+		}
+		if err == nil && tree != headTree {
+			err = fmt.Errorf("regrouped tree %s does not match head tree %s", tree, headTree)
+		}
+	}
+	if err != nil {
+		if resetErr := r.resetHard(ctx, head); resetErr != nil {
+			return "", fmt.Errorf("%w (restoring head also failed: %v)", err, resetErr)
+		}
+		return "", err
+	}
+
+	lease := fmt.Sprintf("--force-with-lease=refs/heads/%s:%s", branch, head)
+	if err = r.push(ctx, lease, "origin", "HEAD:refs/heads/"+branch); err != nil {
+		if resetErr := r.resetHard(ctx, head); resetErr != nil {
+			return "", fmt.Errorf("%w (restoring head also failed: %v)", err, resetErr)
+		}
+		return "", err
+	}
+	return newHead, nil
+}
+
+func (r *Repository) commitGroups(ctx context.Context, groups []CommitGroup) (string, error) {
+	for _, group := range groups {
+		if _, err := r.output(ctx, append([]string{"add", "-A", "--"}, group.Files...)...); err != nil {
+			return "", err
+		}
+		if err := r.commit(ctx, strings.TrimSpace(group.Message)); err != nil {
+			return "", err
+		}
+	}
+	return r.GetHeadCommit(ctx)
+}
+
+// ValidateCommitGroups checks that groups commit exactly the
+// changed files, each once, with a message.
+func ValidateCommitGroups(changed []string, groups []CommitGroup) error {
+	if len(groups) == 0 {
+		return fmt.Errorf("at least one group is required")
+	}
+	seen := make(map[string]bool)
+	for i, group := range groups {
+		if strings.TrimSpace(group.Message) == "" {
+			return fmt.Errorf("group %d has no commit message", i+1)
+		}
+		if len(group.Files) == 0 {
+			return fmt.Errorf("group %d has no files", i+1)
+		}
+		for _, f := range group.Files {
+			if !slices.Contains(changed, f) {
+				return fmt.Errorf("group %d lists %s, which the branch does not change", i+1, f)
+			}
+			if seen[f] {
+				return fmt.Errorf("%s is in more than one group", f)
+			}
+			seen[f] = true
+		}
+	}
+	for _, f := range changed {
+		if !seen[f] {
+			return fmt.Errorf("%s is not in any group", f)
+		}
+	}
+	return nil
+}
+
+// mergeBase returns where the current branch forked from
+// base on the remote.
+func (r *Repository) mergeBase(ctx context.Context, base string) (string, error) {
+	baseHead, err := r.fetchCommit(ctx, base)
+	if err != nil {
+		return "", err
+	}
+	return r.output(ctx, "merge-base", baseHead, "HEAD")
+}
+
+// output runs git and returns its trimmed stdout.
+func (r *Repository) output(ctx context.Context, args ...string) (string, error) {
+	cmd := r.gitCmd(ctx, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if errMsg := strings.TrimSpace(stderr.String()); errMsg != "" {
+			return "", fmt.Errorf("git %s failed: %s: %w", args[0], errMsg, err)
+		}
+		return "", fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// push runs git push with args under the mirror lock,
+// since pushes update refs shared with other worktrees.
+func (r *Repository) push(ctx context.Context, args ...string) error {
+	return r.withMirrorLock(func() error {
+		_, err := r.output(ctx, append([]string{"push"}, args...)...)
+		return err
+	})
+}
+
+func splitNul(s string) []string {
+	var ret []string
+	for _, f := range strings.Split(s, "\x00") {
+		if f = strings.TrimSpace(f); f != "" {
+			ret = append(ret, f)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,118 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// AuthMode is how requests to GitHub are authenticated.
+type AuthMode string
+
+const (
+	// AuthModeApp authenticates as the installations of a
+	// GitHub App, from GITHUB_APP_ID and the private key at
+	// GITHUB_APP_PRIVATE_KEY.
+	AuthModeApp AuthMode = "app"
+	// AuthModeToken authenticates as the user owning the
+	// personal access token in GITHUB_TOKEN.
+	AuthModeToken AuthMode = "token"
+)
+
+// getAuthMode returns GITHUB_AUTH, or if unset, token mode
+// when GITHUB_TOKEN is set and app mode otherwise.
+func getAuthMode() (AuthMode, error) {
+	switch mode := AuthMode(os.Getenv("GITHUB_AUTH")); mode {
+	case AuthModeApp, AuthModeToken:
+		return mode, nil
+	case "":
+		if getToken() != "" {
+			return AuthModeToken, nil
+		}
+		return AuthModeApp, nil
+	default:
+		return "", fmt.Errorf("unsupported GITHUB_AUTH %q: must be %q or %q", mode, AuthModeApp, AuthModeToken)
+	}
+}
+
+func getToken() string {
+	return os.Getenv("GITHUB_TOKEN")
+}
+
+// ValidateAuth checks the credentials of the configured auth
+// mode are set, and returns the mode.
+func ValidateAuth() (AuthMode, error) {
+	mode, err := getAuthMode()
+	if err != nil {
+		return "", err
+	}
+	switch mode {
+	case AuthModeApp:
+		if _, err = getAppID(); err != nil {
+			return mode, fmt.Errorf("app mode needs GITHUB_APP_ID: %w", err)
+		}
+		if getPrivateKey() == "" {
+			return mode, errors.New("app mode needs GITHUB_APP_PRIVATE_KEY")
+		}
+		if _, err = os.Stat(getPrivateKey()); err != nil {
+			return mode, fmt.Errorf("app mode needs a readable GITHUB_APP_PRIVATE_KEY: %w", err)
+		}
+	case AuthModeToken:
+		if getToken() == "" {
+			return mode, errors.New("token mode needs GITHUB_TOKEN")
+		}
+	}
+	return mode, nil
+}
+
+// AppConfigured reports whether requests are authenticated
+// as a GitHub App whose credentials are set.
+func AppConfigured() bool {
+	mode, err := ValidateAuth()
+	return err == nil && mode == AuthModeApp
+}
+
+// personalToken is a personal access token.
+type personalToken string
+
+func (t personalToken) Token(context.Context) (string, error) {
+	return string(t), nil
+}
+
+func newTokenTransport() transport {
+	return &bearerTokenTransport{
+		itr:  personalToken(getToken()),
+		http: http.DefaultTransport,
+	}
+}
+
+// repoTransport returns the transport for requests about
+// owner/repo.
+func repoTransport(ctx context.Context, owner, repo string) (transport, error) {
+	mode, err := getAuthMode()
+	if err != nil {
+		return nil, err
+	}
+	if mode == AuthModeToken {
+		return newTokenTransport(), nil
+	}
+	if _, err = sharedInstallations.forRepo(ctx, owner, repo); err != nil {
+		return nil, err
+	}
+	return &repoInstallation{installs: sharedInstallations, owner: owner, repo: repo}, nil
+}
+
+// defaultTransport returns the transport for requests that
+// are not about one repository, such as the GitHub MCP's.
+func defaultTransport(ctx context.Context) (transport, error) {
+	mode, err := getAuthMode()
+	if err != nil {
+		return nil, err
+	}
+	if mode == AuthModeToken {
+		return newTokenTransport(), nil
+	}
+	return defaultInstallationTransport(ctx)
+}
@@ -0,0 +1,182 @@
+package github
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/go-github/v81/github"
+)
+
+// maxCheckAnnotations is the most annotations GitHub accepts
+// in one check run request; more are sent in further
+// updates.
+const maxCheckAnnotations = 50
+
+// Check run statuses and conclusions.
+const (
+	CheckStatusInProgress = "in_progress"
+	CheckStatusCompleted  = "completed"
+
+	CheckConclusionSuccess = "success"
+	CheckConclusionFailure = "failure"
+)
+
+// CheckRun is a check run on a commit, as created or
+// updated by the worker.
+type CheckRun struct {
+	Name    string `json:"name"`
+	HeadSHA string `json:"head_sha"`
+	// ExternalID ties the run back to what it checked, such
+	// as the branch that was built.
+	ExternalID string `json:"external_id,omitempty"`
+	DetailsURL string `json:"details_url,omitempty"`
+	Status     string `json:"status"`
+	// Conclusion is required once Status is completed.
+	Conclusion  string            `json:"conclusion,omitempty"`
+	Title       string            `json:"title,omitempty"`
+	Summary     string            `json:"summary,omitempty"`
+	Text        string            `json:"text,omitempty"`
+	Annotations []CheckAnnotation `json:"annotations,omitempty"`
+}
+
+// CheckAnnotation marks a line range of a file in a check
+// run. Level is "notice", "warning" or "failure".
+type CheckAnnotation struct {
+	Path        string `json:"path"`
+	StartLine   int    `json:"start_line"`
+	EndLine     int    `json:"end_line"`
+	StartColumn int    `json:"start_column,omitempty"`
+	EndColumn   int    `json:"end_column,omitempty"`
+	Level       string `json:"level"`
+	Title       string `json:"title,omitempty"`
+	Message     string `json:"message"`
+	RawDetails  string `json:"raw_details,omitempty"`
+}
+
+// CreateCheckRun creates a check run and returns its ID.
+// Annotations beyond the first 50 are added by follow-up
+// updates. Only apps can create check runs, so in token
+// mode it does nothing and returns 0.
+func (c *Client) CreateCheckRun(ctx context.Context, run CheckRun) (int64, error) {
+	if ok, err := checksAvailable(); !ok || err != nil {
+		return 0, err
+	}
+	first, rest := splitAnnotations(run.Annotations)
+	res, _, err := c.Checks.CreateCheckRun(ctx, c.owner, c.repo, github.CreateCheckRunOptions{
+		Name:        run.Name,
+		HeadSHA:     run.HeadSHA,
+		DetailsURL:  optionalString(run.DetailsURL),
+		ExternalID:  optionalString(run.ExternalID),
+		Status:      optionalString(run.Status),
+		Conclusion:  optionalString(run.Conclusion),
+		CompletedAt: completedAt(run),
+		Output:      checkRunOutput(run, first),
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err = c.addAnnotations(ctx, res.GetID(), run, rest); err != nil {
+		return 0, err
+	}
+	return res.GetID(), nil
+}
+
+// UpdateCheckRun replaces the status and output of a check
+// run. It does nothing in token mode; see CreateCheckRun.
+func (c *Client) UpdateCheckRun(ctx context.Context, id int64, run CheckRun) error {
+	if ok, err := checksAvailable(); !ok || err != nil {
+		return err
+	}
+	first, rest := splitAnnotations(run.Annotations)
+	_, _, err := c.Checks.UpdateCheckRun(ctx, c.owner, c.repo, id, github.UpdateCheckRunOptions{
+		Name:        run.Name,
+		DetailsURL:  optionalString(run.DetailsURL),
+		ExternalID:  optionalString(run.ExternalID),
+		Status:      optionalString(run.Status),
+		Conclusion:  optionalString(run.Conclusion),
+		CompletedAt: completedAt(run),
+		Output:      checkRunOutput(run, first),
+	})
+	if err != nil {
+		return err
+	}
+	return c.addAnnotations(ctx, id, run, rest)
+}
+
+// addAnnotations appends annotations to a check run in
+// batches GitHub accepts. The output is resent unchanged
+// since it is required with every batch.
+func (c *Client) addAnnotations(ctx context.Context, id int64, run CheckRun, annotations []CheckAnnotation) error {
+	for len(annotations) > 0 {
+		var batch []CheckAnnotation
+		batch, annotations = splitAnnotations(annotations)
+		_, _, err := c.Checks.UpdateCheckRun(ctx, c.owner, c.repo, id, github.UpdateCheckRunOptions{
+			Name:   run.Name,
+			Output: checkRunOutput(run, batch),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checksAvailable reports whether the Checks API can be
+// used: it rejects personal access tokens.
+func checksAvailable() (bool, error) {
+	mode, err := getAuthMode()
+	if err != nil {
+		return false, err
+	}
+	return mode == AuthModeApp, nil
+}
+
+func splitAnnotations(annotations []CheckAnnotation) (first, rest []CheckAnnotation) {
+	if len(annotations) <= maxCheckAnnotations {
+		return annotations, nil
+	}
+	return annotations[:maxCheckAnnotations], annotations[maxCheckAnnotations:]
+}
+
+func checkRunOutput(run CheckRun, annotations []CheckAnnotation) *github.CheckRunOutput {
+	if run.Title == "" && run.Summary == "" {
+		return nil
+	}
+	out := &github.CheckRunOutput{
+		Title:   github.Ptr(run.Title),
+		Summary: github.Ptr(run.Summary),
+		Text:    optionalString(run.Text),
+	}
+	for _, a := range annotations {
+		annotation := &github.CheckRunAnnotation{
+			Path:            github.Ptr(a.Path),
+			StartLine:       github.Ptr(a.StartLine),
+			EndLine:         github.Ptr(a.EndLine),
+			AnnotationLevel: github.Ptr(a.Level),
+			Message:         github.Ptr(a.Message),
+			Title:           optionalString(a.Title),
+			RawDetails:      optionalString(a.RawDetails),
+		}
+		// Columns are only allowed on single line annotations.
+		if a.StartLine == a.EndLine && a.StartColumn > 0 {
+			annotation.StartColumn = github.Ptr(a.StartColumn)
+			annotation.EndColumn = github.Ptr(max(a.EndColumn, a.StartColumn))
+		}
+		out.Annotations = append(out.Annotations, annotation)
+	}
+	return out
+}
+
+func completedAt(run CheckRun) *github.Timestamp {
+	if run.Status != CheckStatusCompleted {
+		return nil
+	}
+	return &github.Timestamp{Time: time.Now()}
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -6,14 +6,14 @@ import (
 	"log"
 	"net/http"
 	"slices"
+	"time"
 
-	"github.com/bradleyfalzon/ghinstallation/v2"
 	"github.com/google/go-github/v81/github"
 )
 
 type Client struct {
 	*github.Client
-	itr   *ghinstallation.Transport
+	itr   transport
 	owner string
 	repo  string
 }
@@ -23,10 +23,30 @@ type ClientOptions struct {
 	Repo  string `json:"repo"`
 }
 
-var supportedPRPurposeLabels = []string{"resume", "cover letter"}
+// SupportedPRPurposeLabels are the labels marking what a PR
+// builds.
+var SupportedPRPurposeLabels = []string{"resume", "cover letter"}
 
+// PullRequestSummary is the state of a pull request, as
+// needed to decide whether its branches are still in use.
+type PullRequestSummary struct {
+	Number    int       `json:"number"`
+	Head      string    `json:"head"`
+	Base      string    `json:"base"`
+	Labels    []string  `json:"labels"`
+	Open      bool      `json:"open"`
+	Merged    bool      `json:"merged"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NewClient returns a client for the repository in opts,
+// authenticated as configured by GITHUB_AUTH: as the app
+// installation that serves it, or with a personal access
+// token.
 func NewClient(opts ClientOptions) (*Client, error) {
-	itr, err := getTransport()
+	ctx, cancel := context.WithTimeout(context.Background(), installationLookupTimeout)
+	defer cancel()
+	itr, err := repoTransport(ctx, opts.Owner, opts.Repo)
 	if err != nil {
 		return nil, err
 	}
@@ -40,7 +60,7 @@ func NewClient(opts ClientOptions) (*Client, error) {
 func (c *Client) GetAuthenticatedRemoteURL(ctx context.Context) (string, error) {
 	token, err := c.itr.Token(ctx)
 	if err != nil {
-		return "", fmt.Errorf("failed to get installation token: %w", err)
+		return "", fmt.Errorf("failed to get token: %w", err)
 	}
 	return fmt.Sprintf("https://x-access-token:%s@github.com/%s/%s.git", token, c.owner, c.repo), nil
 }
@@ -79,7 +99,7 @@ func (c *Client) ListBranches(ctx context.Context) ([]string, error) {
 	return branches, nil
 }
 
-func (c *Client) getDefaultBranch(ctx context.Context) (string, error) {
+func (c *Client) DefaultBranch(ctx context.Context) (string, error) {
 	repo, _, err := c.Repositories.Get(ctx, c.owner, c.repo)
 	if err != nil {
 		return "", err
@@ -89,7 +109,7 @@ func (c *Client) getDefaultBranch(ctx context.Context) (string, error) {
 
 // CreateBranch creates a new branch in the repository from the default branch.
 func (c *Client) CreateBranch(ctx context.Context, branch string) error {
-	defaultBranch, err := c.getDefaultBranch(ctx)
+	defaultBranch, err := c.DefaultBranch(ctx)
 	if err != nil {
 		return err
 	}
@@ -109,7 +129,7 @@ func (c *Client) CreateBranch(ctx context.Context, branch string) error {
 }
 
 func (c *Client) CreatePullRequest(ctx context.Context, title, description, head, base, purposeLabel string) (int, error) {
-	if !slices.Contains(supportedPRPurposeLabels, purposeLabel) {
+	if !slices.Contains(SupportedPRPurposeLabels, purposeLabel) {
 		return 0, fmt.Errorf("invalid purpose label %q", purposeLabel)
 	}
 
@@ -142,7 +162,7 @@ func (c *Client) CreatePullRequest(ctx context.Context, title, description, head
 }
 
 func (c *Client) ensurePurposeLabelsExist(ctx context.Context) error {
-	existing := make(map[string]struct{}, len(supportedPRPurposeLabels))
+	existing := make(map[string]struct{}, len(SupportedPRPurposeLabels))
 	opts := &github.ListOptions{PerPage: 100}
 	for {
 		labels, resp, err := c.Issues.ListLabels(ctx, c.owner, c.repo, opts)
@@ -158,7 +178,7 @@ func (c *Client) ensurePurposeLabelsExist(ctx context.Context) error {
 		opts.Page = resp.NextPage
 	}
 
-	for _, label := range supportedPRPurposeLabels {
+	for _, label := range SupportedPRPurposeLabels {
 		if _, ok := existing[label]; ok {
 			continue
 		}
@@ -191,6 +211,25 @@ func (c *Client) GetPullRequestBody(ctx context.Context, prNumber int) (string,
 	return pr.GetBody(), nil
 }
 
+// GetPullRequestLabels returns the labels currently on a PR.
+func (c *Client) GetPullRequestLabels(ctx context.Context, prNumber int) ([]string, error) {
+	var labels []string
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := c.Issues.ListLabelsByIssue(ctx, c.owner, c.repo, prNumber, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range page {
+			labels = append(labels, l.GetName())
+		}
+		if resp.NextPage == 0 {
+			return labels, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
 func (c *Client) UpdatePullRequestBody(ctx context.Context, prNumber int, body string) error {
 	_, _, err := c.PullRequests.Edit(
 		ctx,
@@ -204,6 +243,16 @@ func (c *Client) UpdatePullRequestBody(ctx context.Context, prNumber int, body s
 	return err
 }
 
+// CommentOnPullRequest adds a conversation comment to a PR,
+// marked as the worker's.
+func (c *Client) CommentOnPullRequest(ctx context.Context, prNumber int, body string) error {
+	body = MarkAgentBody(body)
+	_, _, err := c.Issues.CreateComment(ctx, c.owner, c.repo, prNumber, &github.IssueComment{
+		Body: &body,
+	})
+	return err
+}
+
 func (c *Client) ProtectBranch(ctx context.Context, branch string) error {
 	lockBranch := true
 	allowForcePushes := false
@@ -226,3 +275,77 @@ func (c *Client) ProtectBranch(ctx context.Context, branch string) error {
 	)
 	return err
 }
+
+// ListPullRequests returns every pull request in the
+// repository, open and closed.
+func (c *Client) ListPullRequests(ctx context.Context) ([]PullRequestSummary, error) {
+	var prs []PullRequestSummary
+	opts := &github.PullRequestListOptions{
+		State:       "all",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		res, resp, err := c.PullRequests.List(ctx, c.owner, c.repo, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, pr := range res {
+			labels := make([]string, 0, len(pr.Labels))
+			for _, l := range pr.Labels {
+				labels = append(labels, l.GetName())
+			}
+			prs = append(prs, PullRequestSummary{
+				Number:    pr.GetNumber(),
+				Head:      pr.GetHead().GetRef(),
+				Base:      pr.GetBase().GetRef(),
+				Labels:    labels,
+				Open:      pr.GetState() == "open",
+				Merged:    pr.MergedAt != nil,
+				UpdatedAt: pr.GetUpdatedAt().Time,
+			})
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return prs, nil
+}
+
+// ClosePullRequest closes a PR without merging it.
+func (c *Client) ClosePullRequest(ctx context.Context, prNumber int) error {
+	state := "closed"
+	_, _, err := c.PullRequests.Edit(ctx, c.owner, c.repo, prNumber, &github.PullRequest{
+		State: &state,
+	})
+	return err
+}
+
+// DeleteBranch deletes a branch, first lifting the
+// protection ProtectBranch put on it.
+func (c *Client) DeleteBranch(ctx context.Context, branch string) error {
+	resp, err := c.Repositories.RemoveBranchProtection(ctx, c.owner, c.repo, branch)
+	if err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+		return err
+	}
+	_, err = c.Git.DeleteRef(ctx, c.owner, c.repo, "heads/"+branch)
+	return err
+}
+
+// CreateTag creates a lightweight tag pointing at sha.
+func (c *Client) CreateTag(ctx context.Context, tag, sha string) error {
+	_, _, err := c.Git.CreateRef(ctx, c.owner, c.repo, github.CreateRef{
+		Ref: "refs/tags/" + tag,
+		SHA: sha,
+	})
+	return err
+}
+
+// GetTagSHA returns the commit a lightweight tag points at.
+func (c *Client) GetTagSHA(ctx context.Context, tag string) (string, error) {
+	ref, _, err := c.Git.GetRef(ctx, c.owner, c.repo, "tags/"+tag)
+	if err != nil {
+		return "", err
+	}
+	return ref.GetObject().GetSHA(), nil
+}
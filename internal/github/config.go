@@ -1,7 +1,7 @@
 package github
 
 import (
-	"fmt"
+	"context"
 	"net/http"
 	"os"
 	"strconv"
@@ -18,6 +18,9 @@ func getAppID() (int64, error) {
 	return appId, nil
 }
 
+// getInstallID returns GITHUB_INSTALL_ID, the installation
+// used for requests not about one repository. Repository
+// clients find their own installation.
 func getInstallID() (int64, error) {
 	installIDStr := os.Getenv("GITHUB_INSTALL_ID")
 	installID, err := strconv.ParseInt(installIDStr, 10, 64)
@@ -31,25 +34,31 @@ func getPrivateKey() string {
 	return os.Getenv("GITHUB_APP_PRIVATE_KEY")
 }
 
-func getTransport() (*ghinstallation.Transport, error) {
-	appId, err := getAppID()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get app id: %w", err)
-	}
-	installId, err := getInstallID()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get install id: %w", err)
-	}
+// tokenSource hands out the token requests to GitHub are
+// authenticated with.
+type tokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
 
-	tr := http.DefaultTransport
-	return ghinstallation.NewKeyFromFile(tr, appId, installId, getPrivateKey())
+// transport authenticates requests to GitHub and hands out
+// its token for use outside the REST API, such as in git
+// remotes.
+type transport interface {
+	http.RoundTripper
+	tokenSource
 }
 
+var _ transport = (*ghinstallation.Transport)(nil)
+
 type bearerTokenTransport struct {
-	itr  *ghinstallation.Transport
+	itr  tokenSource
 	http http.RoundTripper
 }
 
+func (t *bearerTokenTransport) Token(ctx context.Context) (string, error) {
+	return t.itr.Token(ctx)
+}
+
 func (t *bearerTokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	reqBodyClosed := false
 	if req.Body != nil {
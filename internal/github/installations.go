@@ -0,0 +1,268 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bradleyfalzon/ghinstallation/v2"
+	"github.com/google/go-github/v81/github"
+)
+
+// installationLookupTimeout bounds finding a repository's
+// installation when a client is created.
+const installationLookupTimeout = 30 * time.Second
+
+// installations resolves which of the app's installations
+// serves each repository, and keeps one transport per
+// installation so its token is shared by every client.
+type installations struct {
+	mu         sync.Mutex
+	repos      map[ClientOptions]int64
+	transports map[int64]*ghinstallation.Transport
+
+	// find looks up the installation serving a repository.
+	find func(ctx context.Context, owner, repo string) (int64, error)
+	// transport creates an installation's transport.
+	transport func(installationID int64) (*ghinstallation.Transport, error)
+}
+
+var sharedInstallations = &installations{
+	find:      findRepoInstallation,
+	transport: newInstallationTransport,
+}
+
+// forRepo returns the transport of the installation serving
+// owner/repo.
+func (i *installations) forRepo(ctx context.Context, owner, repo string) (*ghinstallation.Transport, error) {
+	key := ClientOptions{Owner: owner, Repo: repo}
+	i.mu.Lock()
+	id, ok := i.repos[key]
+	i.mu.Unlock()
+	if !ok {
+		var err error
+		if id, err = i.find(ctx, owner, repo); err != nil {
+			return nil, err
+		}
+		i.mu.Lock()
+		if i.repos == nil {
+			i.repos = make(map[ClientOptions]int64)
+		}
+		i.repos[key] = id
+		i.mu.Unlock()
+	}
+	return i.forInstallation(id)
+}
+
+// forget drops the cached installation of owner/repo and its
+// transport, so the next request looks the repository up
+// again.
+func (i *installations) forget(owner, repo string) {
+	key := ClientOptions{Owner: owner, Repo: repo}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if id, ok := i.repos[key]; ok {
+		delete(i.repos, key)
+		delete(i.transports, id)
+	}
+}
+
+// forInstallation returns an installation's transport.
+func (i *installations) forInstallation(id int64) (*ghinstallation.Transport, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if itr, ok := i.transports[id]; ok {
+		return itr, nil
+	}
+	itr, err := i.transport(id)
+	if err != nil {
+		return nil, err
+	}
+	if i.transports == nil {
+		i.transports = make(map[int64]*ghinstallation.Transport)
+	}
+	i.transports[id] = itr
+	return itr, nil
+}
+
+// repoInstallation authenticates requests about one
+// repository as the installation serving it. When the
+// installation's token is refused, because the app was
+// uninstalled or moved to another installation, it forgets
+// the cached installation and looks the repository up again.
+type repoInstallation struct {
+	installs    *installations
+	owner, repo string
+}
+
+var _ transport = (*repoInstallation)(nil)
+
+func (t *repoInstallation) Token(ctx context.Context) (string, error) {
+	itr, err := t.installs.forRepo(ctx, t.owner, t.repo)
+	if err != nil {
+		return "", err
+	}
+	token, err := itr.Token(ctx)
+	if !staleInstallation(err) {
+		return token, err
+	}
+	t.installs.forget(t.owner, t.repo)
+	if itr, err = t.installs.forRepo(ctx, t.owner, t.repo); err != nil {
+		return "", err
+	}
+	return itr.Token(ctx)
+}
+
+func (t *repoInstallation) RoundTrip(req *http.Request) (*http.Response, error) {
+	itr, err := t.installs.forRepo(req.Context(), t.owner, t.repo)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := itr.RoundTrip(req)
+	switch {
+	case staleInstallation(err):
+		// The token is fetched before the request is sent,
+		// so the request can be sent again.
+		t.installs.forget(t.owner, t.repo)
+		if itr, err = t.installs.forRepo(req.Context(), t.owner, t.repo); err != nil {
+			return nil, err
+		}
+		return itr.RoundTrip(req)
+	case err == nil && resp.StatusCode == http.StatusUnauthorized:
+		t.installs.forget(t.owner, t.repo)
+	}
+	return resp, err
+}
+
+// staleInstallation reports whether err is an installation
+// token request refused with 401 or 404.
+func staleInstallation(err error) bool {
+	var httpErr *ghinstallation.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.Response == nil {
+		return false
+	}
+	code := httpErr.Response.StatusCode
+	return code == http.StatusUnauthorized || code == http.StatusNotFound
+}
+
+// getAppsTransport authenticates as the app itself, with a
+// JWT signed by its private key, rather than as one of its
+// installations.
+var getAppsTransport = sync.OnceValues(func() (*ghinstallation.AppsTransport, error) {
+	appId, err := getAppID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get app id: %w", err)
+	}
+	return ghinstallation.NewAppsTransportKeyFromFile(http.DefaultTransport, appId, getPrivateKey())
+})
+
+func appClient() (*github.Client, error) {
+	atr, err := getAppsTransport()
+	if err != nil {
+		return nil, err
+	}
+	return github.NewClient(&http.Client{Transport: atr}), nil
+}
+
+func findRepoInstallation(ctx context.Context, owner, repo string) (int64, error) {
+	client, err := appClient()
+	if err != nil {
+		return 0, err
+	}
+	installation, _, err := client.Apps.FindRepositoryInstallation(ctx, owner, repo)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find installation for %s/%s: %w", owner, repo, err)
+	}
+	return installation.GetID(), nil
+}
+
+func newInstallationTransport(installationID int64) (*ghinstallation.Transport, error) {
+	atr, err := getAppsTransport()
+	if err != nil {
+		return nil, err
+	}
+	return ghinstallation.NewFromAppsTransport(atr, installationID), nil
+}
+
+// listInstallationIDs returns the IDs of every installation
+// of the app.
+func listInstallationIDs(ctx context.Context) ([]int64, error) {
+	client, err := appClient()
+	if err != nil {
+		return nil, err
+	}
+	var ids []int64
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := client.Apps.ListInstallations(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list installations: %w", err)
+		}
+		for _, installation := range page {
+			ids = append(ids, installation.GetID())
+		}
+		if resp.NextPage == 0 {
+			return ids, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// defaultInstallationTransport returns the transport of
+// GITHUB_INSTALL_ID if set, otherwise of the app's only
+// installation.
+func defaultInstallationTransport(ctx context.Context) (*ghinstallation.Transport, error) {
+	if installID, err := getInstallID(); err == nil {
+		return sharedInstallations.forInstallation(installID)
+	}
+	ids, err := listInstallationIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	switch len(ids) {
+	case 0:
+		return nil, errors.New("app has no installations")
+	case 1:
+		return sharedInstallations.forInstallation(ids[0])
+	default:
+		return nil, fmt.Errorf("app has %d installations; set GITHUB_INSTALL_ID to choose one", len(ids))
+	}
+}
+
+// ListInstallationRepos returns the repositories each of the
+// app's installations can access, by installation ID.
+func ListInstallationRepos(ctx context.Context) (map[int64][]ClientOptions, error) {
+	ids, err := listInstallationIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[int64][]ClientOptions, len(ids))
+	for _, id := range ids {
+		itr, err := sharedInstallations.forInstallation(id)
+		if err != nil {
+			return nil, err
+		}
+		client := github.NewClient(&http.Client{Transport: itr})
+		opts := &github.ListOptions{PerPage: 100}
+		for {
+			page, resp, err := client.Apps.ListRepos(ctx, opts)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list repositories of installation %d: %w", id, err)
+			}
+			for _, repo := range page.Repositories {
+				ret[id] = append(ret[id], ClientOptions{
+					Owner: repo.GetOwner().GetLogin(),
+					Repo:  repo.GetName(),
+				})
+			}
+			if resp.NextPage == 0 {
+				break
+			}
+			opts.Page = resp.NextPage
+		}
+	}
+	return ret, nil
+}
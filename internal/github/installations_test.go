@@ -0,0 +1,138 @@
+package github
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/bradleyfalzon/ghinstallation/v2"
+)
+
+func TestInstallationsCachesLookupsAndTransports(t *testing.T) {
+	t.Parallel()
+
+	installs := map[string]int64{
+		"acme/jobs":   1,
+		"acme/resume": 1,
+		"other/jobs":  2,
+	}
+	var finds []string
+	var created []int64
+	i := &installations{
+		find: func(_ context.Context, owner, repo string) (int64, error) {
+			finds = append(finds, owner+"/"+repo)
+			id, ok := installs[owner+"/"+repo]
+			if !ok {
+				return 0, errors.New("not installed")
+			}
+			return id, nil
+		},
+		transport: func(id int64) (*ghinstallation.Transport, error) {
+			created = append(created, id)
+			return &ghinstallation.Transport{}, nil
+		},
+	}
+
+	ctx := context.Background()
+	jobs, err := i.forRepo(ctx, "acme", "jobs")
+	if err != nil {
+		t.Fatalf("forRepo(acme/jobs): %v", err)
+	}
+	again, err := i.forRepo(ctx, "acme", "jobs")
+	if err != nil {
+		t.Fatalf("forRepo(acme/jobs) again: %v", err)
+	}
+	resume, err := i.forRepo(ctx, "acme", "resume")
+	if err != nil {
+		t.Fatalf("forRepo(acme/resume): %v", err)
+	}
+	other, err := i.forRepo(ctx, "other", "jobs")
+	if err != nil {
+		t.Fatalf("forRepo(other/jobs): %v", err)
+	}
+	if _, err = i.forRepo(ctx, "acme", "missing"); err == nil {
+		t.Fatal("forRepo(acme/missing) succeeded, want error")
+	}
+
+	if jobs != again || jobs != resume {
+		t.Error("repos of one installation got different transports")
+	}
+	if jobs == other {
+		t.Error("repos of different installations share a transport")
+	}
+	wantFinds := []string{"acme/jobs", "acme/resume", "other/jobs", "acme/missing"}
+	if !slices.Equal(finds, wantFinds) {
+		t.Errorf("finds = %v, want %v", finds, wantFinds)
+	}
+	if !slices.Equal(created, []int64{1, 2}) {
+		t.Errorf("created transports for %v, want [1 2]", created)
+	}
+}
+
+func TestRepoInstallationLooksUpAgainWhenRefused(t *testing.T) {
+	t.Parallel()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	atr, err := ghinstallation.NewAppsTransport(http.DefaultTransport, 1, keyPEM)
+	if err != nil {
+		t.Fatalf("apps transport: %v", err)
+	}
+
+	// Installation 1 is gone; installation 2 now serves the
+	// repository.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app/installations/2/access_tokens":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"token":"token-2","expires_at":"2099-01-01T00:00:00Z"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	ids := []int64{1, 2}
+	var finds int
+	i := &installations{
+		find: func(context.Context, string, string) (int64, error) {
+			if finds >= len(ids) {
+				return 0, fmt.Errorf("unexpected lookup %d", finds+1)
+			}
+			finds++
+			return ids[finds-1], nil
+		},
+		transport: func(id int64) (*ghinstallation.Transport, error) {
+			itr := ghinstallation.NewFromAppsTransport(atr, id)
+			itr.BaseURL = srv.URL
+			return itr, nil
+		},
+	}
+
+	tr := &repoInstallation{installs: i, owner: "acme", repo: "jobs"}
+	token, err := tr.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Token: %v", err)
+	}
+	if token != "token-2" {
+		t.Errorf("Token = %q, want token-2", token)
+	}
+	if finds != 2 {
+		t.Errorf("looked the repository up %d times, want 2", finds)
+	}
+	if id := i.repos[ClientOptions{Owner: "acme", Repo: "jobs"}]; id != 2 {
+		t.Errorf("cached installation %d, want 2", id)
+	}
+}
@@ -0,0 +1,22 @@
+package github
+
+import "strings"
+
+// agentMarker is appended to everything the worker posts,
+// hidden when GitHub renders it. Whoever the token acts
+// as, the events our own comments cause carry it.
+const agentMarker = "<!-- job-temporal -->"
+
+// MarkAgentBody appends the agent marker to body, unless it
+// is already marked.
+func MarkAgentBody(body string) string {
+	if IsAgentBody(body) {
+		return body
+	}
+	return body + "\n\n" + agentMarker
+}
+
+// IsAgentBody reports whether body was posted by the worker.
+func IsAgentBody(body string) bool {
+	return strings.Contains(body, agentMarker)
+}
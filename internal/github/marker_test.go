@@ -0,0 +1,31 @@
+package github
+
+import "testing"
+
+func TestMarkAgentBody(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "plain", body: "Done."},
+		{name: "empty", body: ""},
+		{name: "already marked", body: MarkAgentBody("Done.")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			marked := MarkAgentBody(tt.body)
+			if !IsAgentBody(marked) {
+				t.Errorf("IsAgentBody(%q) = false, want true", marked)
+			}
+			if MarkAgentBody(marked) != marked {
+				t.Errorf("MarkAgentBody marked %q twice", marked)
+			}
+		})
+	}
+	if IsAgentBody("Please fix the dates.") {
+		t.Error("IsAgentBody reported a reviewer's comment as ours")
+	}
+}
@@ -0,0 +1,126 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// ReplyToReviewComment replies in the thread of a review
+// comment. commentID may be any comment in the thread;
+// GitHub only accepts replies to the first.
+func (c *Client) ReplyToReviewComment(ctx context.Context, prNumber int, commentID int64, body string) error {
+	comment, _, err := c.PullRequests.GetComment(ctx, c.owner, c.repo, commentID)
+	if err != nil {
+		return err
+	}
+	if comment.InReplyTo != nil {
+		commentID = comment.GetInReplyTo()
+	}
+	_, _, err = c.PullRequests.CreateCommentInReplyTo(ctx, c.owner, c.repo, prNumber, MarkAgentBody(body), commentID)
+	return err
+}
+
+const reviewThreadsQuery = `query($owner: String!, $repo: String!, $pr: Int!, $cursor: String) {
+  repository(owner: $owner, name: $repo) {
+    pullRequest(number: $pr) {
+      reviewThreads(first: 100, after: $cursor) {
+        nodes {
+          id
+          isResolved
+          comments(first: 100) { nodes { databaseId } }
+        }
+        pageInfo { hasNextPage endCursor }
+      }
+    }
+  }
+}`
+
+const resolveReviewThreadMutation = `mutation($thread: ID!) {
+  resolveReviewThread(input: {threadId: $thread}) { thread { isResolved } }
+}`
+
+type reviewThreadsResponse struct {
+	Repository struct {
+		PullRequest struct {
+			ReviewThreads struct {
+				Nodes []struct {
+					ID         string `json:"id"`
+					IsResolved bool   `json:"isResolved"`
+					Comments   struct {
+						Nodes []struct {
+							DatabaseID int64 `json:"databaseId"`
+						} `json:"nodes"`
+					} `json:"comments"`
+				} `json:"nodes"`
+				PageInfo struct {
+					HasNextPage bool   `json:"hasNextPage"`
+					EndCursor   string `json:"endCursor"`
+				} `json:"pageInfo"`
+			} `json:"reviewThreads"`
+		} `json:"pullRequest"`
+	} `json:"repository"`
+}
+
+// ResolveReviewThread marks the review thread containing
+// commentID as resolved. Review threads are only exposed by
+// GitHub's GraphQL API.
+func (c *Client) ResolveReviewThread(ctx context.Context, prNumber int, commentID int64) error {
+	threadID, resolved, err := c.findReviewThread(ctx, prNumber, commentID)
+	if err != nil || resolved {
+		return err
+	}
+	return c.graphQL(ctx, resolveReviewThreadMutation, map[string]any{"thread": threadID}, nil)
+}
+
+func (c *Client) findReviewThread(ctx context.Context, prNumber int, commentID int64) (string, bool, error) {
+	vars := map[string]any{"owner": c.owner, "repo": c.repo, "pr": prNumber}
+	for {
+		var res reviewThreadsResponse
+		if err := c.graphQL(ctx, reviewThreadsQuery, vars, &res); err != nil {
+			return "", false, err
+		}
+		threads := res.Repository.PullRequest.ReviewThreads
+		for _, thread := range threads.Nodes {
+			for _, comment := range thread.Comments.Nodes {
+				if comment.DatabaseID == commentID {
+					return thread.ID, thread.IsResolved, nil
+				}
+			}
+		}
+		if !threads.PageInfo.HasNextPage {
+			return "", false, fmt.Errorf("no review thread contains comment %d", commentID)
+		}
+		vars["cursor"] = threads.PageInfo.EndCursor
+	}
+}
+
+type graphQLError struct {
+	Message string `json:"message"`
+}
+
+// graphQL runs a GraphQL query or mutation with the client's
+// credentials and decodes its data into out.
+func (c *Client) graphQL(ctx context.Context, query string, vars map[string]any, out any) error {
+	req, err := c.NewRequest("POST", "graphql", map[string]any{
+		"query":     query,
+		"variables": vars,
+	})
+	if err != nil {
+		return err
+	}
+	var res struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []graphQLError  `json:"errors"`
+	}
+	if _, err = c.Do(ctx, req, &res); err != nil {
+		return err
+	}
+	if len(res.Errors) > 0 {
+		return fmt.Errorf("graphql: %s", res.Errors[0].Message)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(res.Data, out)
+}
@@ -0,0 +1,82 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestReplyToReviewCommentRepliesToThreadRoot(t *testing.T) {
+	t.Parallel()
+
+	var repliedTo int64
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/jobs/pulls/comments/7":
+			_, _ = w.Write([]byte(`{"id": 7, "in_reply_to_id": 5}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/acme/jobs/pulls/3/comments":
+			var req struct {
+				InReplyTo int64 `json:"in_reply_to"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Errorf("decode reply: %v", err)
+			}
+			repliedTo = req.InReplyTo
+			_, _ = w.Write([]byte(`{"id": 8}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	if err := client.ReplyToReviewComment(context.Background(), 3, 7, "Done"); err != nil {
+		t.Fatalf("ReplyToReviewComment() error = %v", err)
+	}
+	if repliedTo != 5 {
+		t.Fatalf("replied to comment %d, want thread root 5", repliedTo)
+	}
+}
+
+func TestResolveReviewThreadFindsThreadAcrossPages(t *testing.T) {
+	t.Parallel()
+
+	var resolved string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/graphql" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var req struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode query: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasPrefix(req.Query, "mutation"):
+			resolved, _ = req.Variables["thread"].(string)
+			_, _ = w.Write([]byte(`{"data": {"resolveReviewThread": {"thread": {"isResolved": true}}}}`))
+		case req.Variables["cursor"] == nil:
+			_, _ = w.Write([]byte(`{"data": {"repository": {"pullRequest": {"reviewThreads": {
+				"nodes": [{"id": "T1", "isResolved": false, "comments": {"nodes": [{"databaseId": 1}]}}],
+				"pageInfo": {"hasNextPage": true, "endCursor": "c1"}}}}}}`))
+		default:
+			_, _ = w.Write([]byte(`{"data": {"repository": {"pullRequest": {"reviewThreads": {
+				"nodes": [{"id": "T2", "isResolved": false, "comments": {"nodes": [{"databaseId": 5}, {"databaseId": 7}]}}],
+				"pageInfo": {"hasNextPage": false, "endCursor": "c2"}}}}}}`))
+		}
+	}))
+
+	if err := client.ResolveReviewThread(context.Background(), 3, 7); err != nil {
+		t.Fatalf("ResolveReviewThread() error = %v", err)
+	}
+	if resolved != "T2" {
+		t.Fatalf("resolved thread %q, want T2", resolved)
+	}
+}
@@ -0,0 +1,155 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v81/github"
+)
+
+// ReviewSuggestion suggests replacing lines StartLine to
+// EndLine, 1-based and inclusive, of a file on a PR's head.
+// An empty Replacement deletes the lines.
+type ReviewSuggestion struct {
+	Path        string `json:"path"`
+	StartLine   int    `json:"start_line"`
+	EndLine     int    `json:"end_line"`
+	Replacement string `json:"replacement"`
+	Comment     string `json:"comment,omitempty"`
+}
+
+// Markdown renders the suggestion as a review comment that
+// GitHub offers to apply.
+func (s ReviewSuggestion) Markdown() string {
+	fence := "```"
+	for strings.Contains(s.Replacement, fence) {
+		fence += "`"
+	}
+	var sb strings.Builder
+	if comment := strings.TrimSpace(s.Comment); comment != "" {
+		sb.WriteString(comment)
+		sb.WriteString("\n\n")
+	}
+	sb.WriteString(fence + "suggestion\n")
+	if s.Replacement != "" {
+		sb.WriteString(strings.TrimSuffix(s.Replacement, "\n"))
+		sb.WriteString("\n")
+	}
+	sb.WriteString(fence)
+	return sb.String()
+}
+
+// Location names the suggestion's lines, e.g. "jobs.typ
+// lines 3-5".
+func (s ReviewSuggestion) Location() string {
+	if s.StartLine == s.EndLine {
+		return fmt.Sprintf("%s line %d", s.Path, s.StartLine)
+	}
+	return fmt.Sprintf("%s lines %d-%d", s.Path, s.StartLine, s.EndLine)
+}
+
+// SuggestChanges posts suggestions as one review on the PR's
+// head commit, with body as the review's summary. GitHub
+// only accepts review comments on lines in the PR's diff, so
+// suggestions elsewhere are listed in the body instead.
+func (c *Client) SuggestChanges(ctx context.Context, prNumber int, body string, suggestions []ReviewSuggestion) error {
+	pr, _, err := c.PullRequests.Get(ctx, c.owner, c.repo, prNumber)
+	if err != nil {
+		return err
+	}
+	hunks, err := c.diffHunks(ctx, prNumber)
+	if err != nil {
+		return err
+	}
+
+	var (
+		comments []*github.DraftReviewComment
+		outside  []string
+	)
+	for _, s := range suggestions {
+		if !inSameHunk(hunks[s.Path], s.StartLine, s.EndLine) {
+			outside = append(outside, fmt.Sprintf("**%s**\n\n%s", s.Location(), s.Markdown()))
+			continue
+		}
+		comment := &github.DraftReviewComment{
+			Path: github.Ptr(s.Path),
+			Body: github.Ptr(MarkAgentBody(s.Markdown())),
+			Line: github.Ptr(s.EndLine),
+			Side: github.Ptr("RIGHT"),
+		}
+		if s.StartLine < s.EndLine {
+			comment.StartLine = github.Ptr(s.StartLine)
+			comment.StartSide = github.Ptr("RIGHT")
+		}
+		comments = append(comments, comment)
+	}
+	if len(outside) > 0 {
+		body = strings.TrimSpace(body + "\n\nThese suggestions are on lines outside this PR's diff, so they " +
+			"cannot be applied from the review:\n\n" + strings.Join(outside, "\n\n"))
+	}
+
+	_, _, err = c.PullRequests.CreateReview(ctx, c.owner, c.repo, prNumber, &github.PullRequestReviewRequest{
+		CommitID: github.Ptr(pr.GetHead().GetSHA()),
+		Body:     github.Ptr(MarkAgentBody(body)),
+		Event:    github.Ptr("COMMENT"),
+		Comments: comments,
+	})
+	return err
+}
+
+// lineRange is the lines of a file, 1-based and inclusive,
+// one diff hunk covers on the head side.
+type lineRange struct {
+	start, end int
+}
+
+var hunkHeaderRe = regexp.MustCompile(`(?m)^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// diffHunks returns the head side hunks of each file the PR
+// changes.
+func (c *Client) diffHunks(ctx context.Context, prNumber int) (map[string][]lineRange, error) {
+	hunks := make(map[string][]lineRange)
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		files, resp, err := c.PullRequests.ListFiles(ctx, c.owner, c.repo, prNumber, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			hunks[f.GetFilename()] = patchHunks(f.GetPatch())
+		}
+		if resp.NextPage == 0 {
+			return hunks, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// patchHunks parses the head side line ranges of a patch's
+// hunks.
+func patchHunks(patch string) []lineRange {
+	var ret []lineRange
+	for _, m := range hunkHeaderRe.FindAllStringSubmatch(patch, -1) {
+		start, _ := strconv.Atoi(m[1])
+		count := 1
+		if m[2] != "" {
+			count, _ = strconv.Atoi(m[2])
+		}
+		if count > 0 {
+			ret = append(ret, lineRange{start: start, end: start + count - 1})
+		}
+	}
+	return ret
+}
+
+func inSameHunk(hunks []lineRange, start, end int) bool {
+	for _, h := range hunks {
+		if start >= h.start && end <= h.end {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestReviewSuggestionMarkdown(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		s    ReviewSuggestion
+		want string
+	}{
+		{
+			name: "replacement with comment",
+			s:    ReviewSuggestion{Replacement: "- Led the team\n", Comment: "Stronger verb."},
+			want: "Stronger verb.\n\n```suggestion\n- Led the team\n```",
+		},
+		{
+			name: "deletion",
+			s:    ReviewSuggestion{},
+			want: "```suggestion\n```",
+		},
+		{
+			name: "replacement containing a fence",
+			s:    ReviewSuggestion{Replacement: "```typ\n#x\n```"},
+			want: "````suggestion\n```typ\n#x\n```\n````",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.s.Markdown(); got != tt.want {
+				t.Fatalf("Markdown() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuggestChangesMovesSuggestionsOutsideDiffToBody(t *testing.T) {
+	t.Parallel()
+
+	var review struct {
+		CommitID string `json:"commit_id"`
+		Body     string `json:"body"`
+		Event    string `json:"event"`
+		Comments []struct {
+			Path      string `json:"path"`
+			Line      int    `json:"line"`
+			StartLine int    `json:"start_line"`
+		} `json:"comments"`
+	}
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/jobs/pulls/3":
+			_, _ = w.Write([]byte(`{"number": 3, "head": {"sha": "abc"}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/jobs/pulls/3/files":
+			_, _ = w.Write([]byte(`[{"filename": "jobs.typ", "patch": "@@ -1,2 +1,4 @@\n a\n+b\n+c\n d\n@@ -10 +12,2 @@\n-x\n+y\n+z"}]`))
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/acme/jobs/pulls/3/reviews":
+			if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+				t.Errorf("decode review: %v", err)
+			}
+			_, _ = w.Write([]byte(`{"id": 1}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	err := client.SuggestChanges(context.Background(), 3, "Some suggestions.", []ReviewSuggestion{
+		{Path: "jobs.typ", StartLine: 2, EndLine: 3, Replacement: "B\nC"},
+		{Path: "jobs.typ", StartLine: 13, EndLine: 13, Replacement: "Z"},
+		{Path: "jobs.typ", StartLine: 4, EndLine: 12, Replacement: "spans hunks"},
+		{Path: "person.typ", StartLine: 1, EndLine: 1, Replacement: "not in diff"},
+	})
+	if err != nil {
+		t.Fatalf("SuggestChanges() error = %v", err)
+	}
+
+	if review.CommitID != "abc" || review.Event != "COMMENT" {
+		t.Fatalf("unexpected review commit %q event %q", review.CommitID, review.Event)
+	}
+	if len(review.Comments) != 2 {
+		t.Fatalf("expected 2 inline suggestions, got %+v", review.Comments)
+	}
+	if c := review.Comments[0]; c.StartLine != 2 || c.Line != 3 {
+		t.Fatalf("unexpected multi-line comment range %d-%d", c.StartLine, c.Line)
+	}
+	if c := review.Comments[1]; c.StartLine != 0 || c.Line != 13 {
+		t.Fatalf("unexpected single-line comment range %d-%d", c.StartLine, c.Line)
+	}
+	for _, want := range []string{"Some suggestions.", "jobs.typ lines 4-12", "person.typ line 1"} {
+		if !strings.Contains(review.Body, want) {
+			t.Fatalf("review body %q missing %q", review.Body, want)
+		}
+	}
+}
@@ -52,7 +52,7 @@ func newToolsManager(url string) *toolsManager {
 
 // NewTools creates a new Tools, connecting to the github MCP located at URL.
 func NewTools(ctx context.Context, url string) (*Tools, error) {
-	itr, err := getTransport()
+	itr, err := defaultTransport(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get transport: %w", err)
 	}
@@ -0,0 +1,125 @@
+package keywords
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Status is how a keyword is covered by the documents.
+type Status string
+
+const (
+	StatusMatched    Status = "matched"
+	StatusLetterOnly Status = "letter only"
+	StatusMissing    Status = "missing"
+)
+
+// Row is one keyword's coverage.
+type Row struct {
+	Keyword  string   `json:"keyword"`
+	Priority Priority `json:"priority"`
+	Resume   bool     `json:"resume"`
+	Letter   bool     `json:"letter"`
+	Status   Status   `json:"status"`
+}
+
+// Report is keyword coverage across a resume and a cover
+// letter.
+type Report struct {
+	Rows []Row `json:"rows"`
+}
+
+// Coverage checks each keyword against the resume and cover
+// letter text. A keyword is matched when the resume mentions
+// it, whether or not the letter does.
+func Coverage(kws []Keyword, resume, letter string) Report {
+	rows := make([]Row, 0, len(kws))
+	for _, kw := range kws {
+		row := Row{
+			Keyword:  kw.Term,
+			Priority: kw.Priority,
+			Resume:   kw.Matches(resume),
+			Letter:   kw.Matches(letter),
+		}
+		switch {
+		case row.Resume:
+			row.Status = StatusMatched
+		case row.Letter:
+			row.Status = StatusLetterOnly
+		default:
+			row.Status = StatusMissing
+		}
+		rows = append(rows, row)
+	}
+
+	// Required first, then gaps before matches, keeping job
+	// description order otherwise.
+	slices.SortStableFunc(rows, func(a, b Row) int {
+		if a.Priority != b.Priority {
+			if a.Priority == PriorityRequired {
+				return -1
+			}
+			return 1
+		}
+		return statusRank(a.Status) - statusRank(b.Status)
+	})
+	return Report{Rows: rows}
+}
+
+func statusRank(s Status) int {
+	switch s {
+	case StatusMissing:
+		return 0
+	case StatusLetterOnly:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Counts returns how many keywords of a priority are matched
+// in the resume, out of the total.
+func (r Report) Counts(p Priority) (matched, total int) {
+	for _, row := range r.Rows {
+		if row.Priority != p {
+			continue
+		}
+		total++
+		if row.Status == StatusMatched {
+			matched++
+		}
+	}
+	return matched, total
+}
+
+// Markdown renders the report as a summary line and table.
+func (r Report) Markdown() string {
+	if len(r.Rows) == 0 {
+		return "No skills or keywords found in the job description."
+	}
+
+	var b strings.Builder
+	reqMatched, reqTotal := r.Counts(PriorityRequired)
+	prefMatched, prefTotal := r.Counts(PriorityPreferred)
+	fmt.Fprintf(&b, "Resume covers %d/%d required and %d/%d preferred keywords.\n\n", reqMatched, reqTotal, prefMatched, prefTotal)
+
+	b.WriteString("| Keyword | Priority | Resume | Letter | Status |\n")
+	b.WriteString("| --- | --- | :---: | :---: | --- |\n")
+	for _, row := range r.Rows {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n",
+			escapeCell(row.Keyword), row.Priority, check(row.Resume), check(row.Letter), row.Status)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func check(v bool) string {
+	if v {
+		return "✓"
+	}
+	return ""
+}
+
+func escapeCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
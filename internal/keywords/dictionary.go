@@ -0,0 +1,161 @@
+package keywords
+
+import "regexp"
+
+type skill struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// skillDef lists a canonical skill name and its aliases.
+// Names that are also common English words are matched case
+// sensitively.
+type skillDef struct {
+	aliases       []string
+	caseSensitive bool
+}
+
+var skillDefs = []skillDef{
+	// Languages
+	{aliases: []string{"Go", "Golang"}, caseSensitive: true},
+	{aliases: []string{"Python"}},
+	{aliases: []string{"Java"}},
+	{aliases: []string{"JavaScript", "JS"}},
+	{aliases: []string{"TypeScript", "TS"}, caseSensitive: true},
+	{aliases: []string{"C++", "CPP"}},
+	{aliases: []string{"C#", "CSharp"}},
+	{aliases: []string{"C"}, caseSensitive: true},
+	{aliases: []string{"Rust"}, caseSensitive: true},
+	{aliases: []string{"Ruby"}},
+	{aliases: []string{"PHP"}},
+	{aliases: []string{"Kotlin"}},
+	{aliases: []string{"Swift"}, caseSensitive: true},
+	{aliases: []string{"Scala"}},
+	{aliases: []string{"R"}, caseSensitive: true},
+	{aliases: []string{"SQL"}},
+	{aliases: []string{"Bash", "shell scripting"}},
+	{aliases: []string{"Elixir"}},
+	{aliases: []string{"Haskell"}},
+	{aliases: []string{"Lua"}},
+	{aliases: []string{"MATLAB"}},
+	{aliases: []string{"Solidity"}},
+	{aliases: []string{"Zig"}, caseSensitive: true},
+
+	// Frontend
+	{aliases: []string{"React", "React.js", "ReactJS"}, caseSensitive: true},
+	{aliases: []string{"Angular"}, caseSensitive: true},
+	{aliases: []string{"Vue", "Vue.js"}},
+	{aliases: []string{"Svelte"}},
+	{aliases: []string{"Next.js", "NextJS"}},
+	{aliases: []string{"HTML"}},
+	{aliases: []string{"CSS"}},
+	{aliases: []string{"Tailwind"}},
+	{aliases: []string{"Redux"}},
+
+	// Backend and frameworks
+	{aliases: []string{"Node.js", "NodeJS", "Node"}, caseSensitive: true},
+	{aliases: []string{"Express"}, caseSensitive: true},
+	{aliases: []string{"Django"}},
+	{aliases: []string{"Flask"}},
+	{aliases: []string{"FastAPI"}},
+	{aliases: []string{"Spring", "Spring Boot"}, caseSensitive: true},
+	{aliases: []string{"Rails", "Ruby on Rails"}, caseSensitive: true},
+	{aliases: []string{".NET", "dotnet"}},
+	{aliases: []string{"GraphQL"}},
+	{aliases: []string{"REST", "RESTful"}, caseSensitive: true},
+	{aliases: []string{"gRPC"}},
+	{aliases: []string{"Protobuf", "Protocol Buffers"}},
+	{aliases: []string{"microservices", "microservice"}},
+	{aliases: []string{"distributed systems"}},
+	{aliases: []string{"Temporal"}, caseSensitive: true},
+
+	// Data
+	{aliases: []string{"PostgreSQL", "Postgres"}},
+	{aliases: []string{"MySQL"}},
+	{aliases: []string{"SQLite"}},
+	{aliases: []string{"MongoDB", "Mongo"}},
+	{aliases: []string{"Redis"}},
+	{aliases: []string{"Cassandra"}},
+	{aliases: []string{"DynamoDB"}},
+	{aliases: []string{"Elasticsearch", "OpenSearch"}},
+	{aliases: []string{"Kafka"}},
+	{aliases: []string{"RabbitMQ"}},
+	{aliases: []string{"Spark", "PySpark"}, caseSensitive: true},
+	{aliases: []string{"Hadoop"}},
+	{aliases: []string{"Airflow"}},
+	{aliases: []string{"dbt"}, caseSensitive: true},
+	{aliases: []string{"Snowflake"}, caseSensitive: true},
+	{aliases: []string{"BigQuery"}},
+	{aliases: []string{"Redshift"}},
+	{aliases: []string{"ETL"}},
+	{aliases: []string{"data pipelines", "data pipeline"}},
+	{aliases: []string{"pandas"}},
+	{aliases: []string{"NumPy"}},
+
+	// ML
+	{aliases: []string{"machine learning", "ML"}},
+	{aliases: []string{"deep learning"}},
+	{aliases: []string{"PyTorch"}},
+	{aliases: []string{"TensorFlow"}},
+	{aliases: []string{"scikit-learn", "sklearn"}},
+	{aliases: []string{"LLM", "LLMs", "large language models"}},
+	{aliases: []string{"NLP", "natural language processing"}},
+	{aliases: []string{"computer vision"}},
+
+	// Cloud and infrastructure
+	{aliases: []string{"AWS", "Amazon Web Services"}},
+	{aliases: []string{"GCP", "Google Cloud"}},
+	{aliases: []string{"Azure"}},
+	{aliases: []string{"Kubernetes", "K8s"}},
+	{aliases: []string{"Docker"}},
+	{aliases: []string{"Terraform"}},
+	{aliases: []string{"Ansible"}},
+	{aliases: []string{"Helm"}, caseSensitive: true},
+	{aliases: []string{"Linux"}},
+	{aliases: []string{"CI/CD", "continuous integration", "continuous delivery"}},
+	{aliases: []string{"GitHub Actions"}},
+	{aliases: []string{"Jenkins"}},
+	{aliases: []string{"Git"}, caseSensitive: true},
+	{aliases: []string{"Prometheus"}},
+	{aliases: []string{"Grafana"}},
+	{aliases: []string{"Datadog"}},
+	{aliases: []string{"OpenTelemetry"}},
+	{aliases: []string{"observability"}},
+	{aliases: []string{"serverless"}},
+	{aliases: []string{"Lambda"}, caseSensitive: true},
+	{aliases: []string{"S3"}},
+	{aliases: []string{"EC2"}},
+
+	// Practices
+	{aliases: []string{"Agile"}, caseSensitive: true},
+	{aliases: []string{"Scrum"}},
+	{aliases: []string{"TDD", "test-driven development"}},
+	{aliases: []string{"unit testing"}},
+	{aliases: []string{"code review", "code reviews"}},
+	{aliases: []string{"system design"}},
+	{aliases: []string{"security"}},
+	{aliases: []string{"mentoring", "mentorship"}},
+	{aliases: []string{"API design", "APIs", "API"}},
+
+	// Tools
+	{aliases: []string{"Figma"}},
+	{aliases: []string{"Jira"}},
+	{aliases: []string{"Excel"}, caseSensitive: true},
+	{aliases: []string{"Tableau"}},
+	{aliases: []string{"Salesforce"}},
+}
+
+// dictionary is skillDefs compiled; the canonical name is
+// the first alias.
+var dictionary = compileDictionary(skillDefs)
+
+func compileDictionary(defs []skillDef) []skill {
+	ret := make([]skill, len(defs))
+	for i, d := range defs {
+		ret[i] = skill{
+			name:    d.aliases[0],
+			pattern: termPattern(d.aliases, d.caseSensitive),
+		}
+	}
+	return ret
+}
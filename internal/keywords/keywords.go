@@ -0,0 +1,281 @@
+// Package keywords extracts skills and keywords from a job
+// description and measures how well documents cover them.
+// Extraction is deterministic: a dictionary of known skills
+// plus acronym and technical-token heuristics, classified by
+// the section of the description they appear in.
+package keywords
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Priority is how strongly a job description asks for a
+// keyword.
+type Priority string
+
+const (
+	PriorityRequired  Priority = "required"
+	PriorityPreferred Priority = "preferred"
+)
+
+// Keyword is a skill or term from a job description.
+type Keyword struct {
+	Term     string   `json:"term"`
+	Priority Priority `json:"priority"`
+
+	pattern *regexp.Regexp
+}
+
+// newKeyword returns a keyword for a term outside the
+// dictionary, with its pattern compiled once.
+func newKeyword(term string, priority Priority) Keyword {
+	return Keyword{Term: term, Priority: priority, pattern: termPattern([]string{term}, false)}
+}
+
+// Matches reports whether text mentions the keyword.
+// Keywords not made by Extract, such as decoded ones,
+// compile their pattern on every call.
+func (k Keyword) Matches(text string) bool {
+	pattern := k.pattern
+	if pattern == nil {
+		pattern = termPattern([]string{k.Term}, false)
+	}
+	return pattern.MatchString(normalize(text))
+}
+
+type section int
+
+const (
+	sectionOther section = iota
+	sectionRequired
+	sectionPreferred
+)
+
+var (
+	preferredCues = []string{"preferred", "nice to have", "nice-to-have", "bonus", "a plus", "desired", "good to have", "ideally"}
+	requiredCues  = []string{"require", "qualification", "must", "need", "you have", "you bring", "skills", "looking for"}
+
+	headingRe       = regexp.MustCompile(`^(?:#{1,6}\s+(.+)|\*\*(.+?)\*\*:?|__(.+?)__:?|([^.!?]{3,60}):)$`)
+	sentenceSplitRe = regexp.MustCompile(`[.;!?]\s+`)
+	acronymRe       = regexp.MustCompile(`\b[A-Z][A-Z0-9]{1,5}(?:/[A-Z]{1,5})?\b`)
+	techWordRe      = regexp.MustCompile(`[A-Za-z][A-Za-z0-9]*(?:\.[A-Za-z0-9]+|[+#]+[A-Za-z0-9]*)+|\b[A-Z]?[a-z]+[A-Z][A-Za-z0-9]*\b`)
+)
+
+// acronymStopwords are uppercase tokens common in job posts
+// that are not skills.
+var acronymStopwords = map[string]bool{
+	"US": true, "USA": true, "UK": true, "EU": true, "EEO": true, "EOE": true,
+	"PTO": true, "CEO": true, "CTO": true, "LLC": true, "INC": true, "OR": true,
+	"AND": true, "THE": true, "YOU": true, "WE": true, "IT": true, "HR": true,
+	"NYC": true, "SF": true, "PST": true, "EST": true, "ET": true, "PT": true,
+	"FAQ": true, "ID": true, "TBD": true, "N/A": true, "OK": true, "AM": true,
+	"PM": true, "BA": true, "BS": true, "MS": true, "PHD": true, "USD": true,
+}
+
+// Extract returns the keywords in a job description written
+// in markdown. Keywords under required or preferred sections
+// take that priority; if the description has no such
+// sections, every keyword is required. A keyword that is both
+// required and preferred is required.
+func Extract(markdown string) []Keyword {
+	type found struct {
+		kw    Keyword
+		order int
+	}
+	seen := make(map[string]*found)
+	var order int
+	add := func(term string, prio Priority, pattern *regexp.Regexp) {
+		key := strings.ToLower(term)
+		if f, ok := seen[key]; ok {
+			if prio == PriorityRequired {
+				f.kw.Priority = PriorityRequired
+			}
+			return
+		}
+		seen[key] = &found{kw: Keyword{Term: term, Priority: prio, pattern: pattern}, order: order}
+		order++
+	}
+
+	lines := strings.Split(normalize(markdown), "\n")
+	hasSections := false
+	for _, line := range lines {
+		if heading, ok := headingText(line); ok && classifyHeading(heading) != sectionOther {
+			hasSections = true
+			break
+		}
+	}
+
+	current := sectionOther
+	if !hasSections {
+		current = sectionRequired
+	}
+	for _, line := range lines {
+		if heading, ok := headingText(line); ok && hasSections {
+			current = classifyHeading(heading)
+			continue
+		}
+
+		for _, sentence := range sentenceSplitRe.Split(line, -1) {
+			prio, ok := linePriority(sentence, current)
+			if !ok {
+				continue
+			}
+			for _, s := range dictionary {
+				if s.pattern.MatchString(sentence) {
+					add(s.name, prio, s.pattern)
+				}
+			}
+			for _, term := range heuristicTerms(sentence) {
+				add(term, prio, nil)
+			}
+		}
+	}
+
+	ret := make([]found, 0, len(seen))
+	for _, f := range seen {
+		ret = append(ret, *f)
+	}
+	slices.SortFunc(ret, func(a, b found) int { return a.order - b.order })
+
+	kws := make([]Keyword, len(ret))
+	for i, f := range ret {
+		if f.kw.pattern == nil {
+			f.kw = newKeyword(f.kw.Term, f.kw.Priority)
+		}
+		kws[i] = f.kw
+	}
+	return kws
+}
+
+// headingText returns the text of a markdown heading or a
+// bold line. A short line ending in a colon, such as
+// "Requirements:", only counts when it names a required or
+// preferred section, since ordinary sentences end in colons
+// too.
+func headingText(line string) (string, bool) {
+	m := headingRe.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return "", false
+	}
+	for _, g := range m[1:4] {
+		if g != "" {
+			return strings.TrimSuffix(g, ":"), true
+		}
+	}
+	if classifyHeading(m[4]) != sectionOther {
+		return m[4], true
+	}
+	return "", false
+}
+
+func classifyHeading(heading string) section {
+	h := strings.ToLower(heading)
+	if containsAny(h, preferredCues) {
+		return sectionPreferred
+	}
+	if containsAny(h, requiredCues) {
+		return sectionRequired
+	}
+	return sectionOther
+}
+
+// linePriority classifies a sentence by its section,
+// letting explicit cues in the sentence override the section.
+func linePriority(line string, current section) (Priority, bool) {
+	l := strings.ToLower(line)
+	switch {
+	case containsAny(l, preferredCues):
+		return PriorityPreferred, true
+	case current == sectionRequired:
+		return PriorityRequired, true
+	case current == sectionPreferred:
+		return PriorityPreferred, true
+	}
+	return "", false
+}
+
+// heuristicTerms finds skills missing from the dictionary:
+// acronyms such as "ETL" and technical tokens such as
+// "Node.js" or "PyTorch".
+func heuristicTerms(line string) []string {
+	var terms []string
+	// Lines in capitals are shouting, not acronyms.
+	if !mostlyUpper(line) {
+		for _, m := range acronymRe.FindAllString(line, -1) {
+			if !acronymStopwords[m] && !isDictionaryTerm(m) {
+				terms = append(terms, m)
+			}
+		}
+	}
+	for _, m := range techWordRe.FindAllString(line, -1) {
+		// Skip abbreviations such as "e.g" and "i.e".
+		if len(m) <= 4 && strings.Contains(m, ".") {
+			continue
+		}
+		if !isDictionaryTerm(m) {
+			terms = append(terms, m)
+		}
+	}
+	return terms
+}
+
+func mostlyUpper(s string) bool {
+	upper, letters := 0, 0
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+	return letters > 0 && upper*2 > letters
+}
+
+func isDictionaryTerm(s string) bool {
+	for _, d := range dictionary {
+		if d.pattern.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize folds typographic variants, including ligatures
+// that PDF text extraction may produce.
+func normalize(s string) string {
+	return normalizer.Replace(s)
+}
+
+var normalizer = strings.NewReplacer(
+	"ﬀ", "ff", "ﬁ", "fi", "ﬂ", "fl", "ﬃ", "ffi", "ﬄ", "ffl",
+	"ﬅ", "st", "ﬆ", "st", "’", "'", "–", "-", "—", "-",
+	" ", " ",
+)
+
+func containsAny(s string, subs []string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// termPattern matches any alias as a whole word, where word
+// characters include the '+', '#' and '.' used in names like
+// "C++", "C#" and "Node.js".
+func termPattern(aliases []string, caseSensitive bool) *regexp.Regexp {
+	quoted := make([]string, len(aliases))
+	for i, a := range aliases {
+		quoted[i] = regexp.QuoteMeta(a)
+	}
+	flags := "(?i)"
+	if caseSensitive {
+		flags = ""
+	}
+	return regexp.MustCompile(flags + `(?:^|[^\p{L}\p{N}+#.])(?:` + strings.Join(quoted, "|") + `)(?:$|[^\p{L}\p{N}+#.]|\.(?:$|\s))`)
+}
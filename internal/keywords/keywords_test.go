@@ -0,0 +1,146 @@
+package keywords
+
+import (
+	"strings"
+	"testing"
+)
+
+const jobDesc = `# Backend Engineer
+
+## About us
+We go fast and use Kubernetes everywhere.
+
+## Requirements
+- 3+ years of Go or Python
+- Experience with PostgreSQL and Node.js services
+- Familiar with CI/CD and ETL pipelines
+
+## Nice to have
+- Kafka or RabbitMQ
+- Exposure to PyTorch, e.g. for ranking models
+- Python scripting
+`
+
+func TestExtract(t *testing.T) {
+	t.Parallel()
+
+	got := map[string]Priority{}
+	for _, kw := range Extract(jobDesc) {
+		got[kw.Term] = kw.Priority
+	}
+
+	want := map[string]Priority{
+		"Go":         PriorityRequired,
+		"Python":     PriorityRequired,
+		"PostgreSQL": PriorityRequired,
+		"Node.js":    PriorityRequired,
+		"CI/CD":      PriorityRequired,
+		"ETL":        PriorityRequired,
+		"Kafka":      PriorityPreferred,
+		"RabbitMQ":   PriorityPreferred,
+		"PyTorch":    PriorityPreferred,
+	}
+	for term, prio := range want {
+		if got[term] != prio {
+			t.Errorf("Extract()[%q] = %q, want %q", term, got[term], prio)
+		}
+	}
+	for _, unwanted := range []string{"Kubernetes", "e.g", "Node"} {
+		if _, ok := got[unwanted]; ok {
+			t.Errorf("Extract() unexpectedly contains %q", unwanted)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("Extract() = %v, want %d keywords", got, len(want))
+	}
+}
+
+func TestExtract_NoSections(t *testing.T) {
+	t.Parallel()
+
+	kws := Extract("We want someone who knows Terraform and AWS. Docker is a plus.")
+	got := map[string]Priority{}
+	for _, kw := range kws {
+		got[kw.Term] = kw.Priority
+	}
+	if got["Terraform"] != PriorityRequired || got["AWS"] != PriorityRequired {
+		t.Errorf("Extract() = %v, want Terraform and AWS required", got)
+	}
+	if got["Docker"] != PriorityPreferred {
+		t.Errorf("Extract()[Docker] = %q, want preferred", got["Docker"])
+	}
+}
+
+func TestKeyword_Matches(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		term string
+		text string
+		want bool
+	}{
+		{"Go", "Built services in Go.", true},
+		{"Go", "Built services in Golang", true},
+		{"Go", "Ready to go live", false},
+		{"C++", "Wrote C++ and Rust", true},
+		{"C", "Wrote C++ and Rust", false},
+		{"Node.js", "Node.js APIs", true},
+		{"PostgreSQL", "Tuned Postgres queries", true},
+		{"Kafka", "Streaming with Kaﬀka", false},
+		{"Terraform", "Managed infra with Terraform, Helm", true},
+		{"ETL", "Ran nightly ETL jobs", true},
+		{"ETL", "Ran nightly jobs", false},
+	}
+	for _, tt := range tests {
+		var kw Keyword
+		for _, k := range Extract("Requirements:\n- " + tt.term) {
+			if k.Term == tt.term {
+				kw = k
+			}
+		}
+		if kw.Term == "" {
+			t.Fatalf("Extract(%q) did not find the term", tt.term)
+		}
+		if got := kw.Matches(tt.text); got != tt.want {
+			t.Errorf("Keyword(%q).Matches(%q) = %v, want %v", tt.term, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	t.Parallel()
+
+	kws := Extract(jobDesc)
+	report := Coverage(kws,
+		"Go developer. Ran PostgreSQL and Kafka in production with CI/CD. Python tooling, Node.js, ETL.",
+		"I am excited to learn PyTorch.",
+	)
+
+	status := map[string]Status{}
+	for _, row := range report.Rows {
+		status[row.Keyword] = row.Status
+	}
+	if status["Go"] != StatusMatched || status["Kafka"] != StatusMatched {
+		t.Errorf("status = %v, want Go and Kafka matched", status)
+	}
+	if status["PyTorch"] != StatusLetterOnly {
+		t.Errorf("status[PyTorch] = %q, want letter only", status["PyTorch"])
+	}
+	if status["RabbitMQ"] != StatusMissing {
+		t.Errorf("status[RabbitMQ] = %q, want missing", status["RabbitMQ"])
+	}
+	if report.Rows[0].Priority != PriorityRequired || report.Rows[len(report.Rows)-1].Priority != PriorityPreferred {
+		t.Errorf("rows not ordered required first: %+v", report.Rows)
+	}
+
+	md := report.Markdown()
+	if !strings.HasPrefix(md, "Resume covers 6/6 required and 1/3 preferred keywords.") {
+		t.Errorf("Markdown() summary = %q", strings.SplitN(md, "\n", 2)[0])
+	}
+	if !strings.Contains(md, "| RabbitMQ | preferred |  |  | missing |") {
+		t.Errorf("Markdown() missing RabbitMQ row:\n%s", md)
+	}
+	if got := (Report{}).Markdown(); got != "No skills or keywords found in the job description." {
+		t.Errorf("empty Markdown() = %q", got)
+	}
+}
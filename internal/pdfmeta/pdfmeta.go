@@ -0,0 +1,221 @@
+// Package pdfmeta sets the document metadata of a finished
+// PDF: the Info dictionary that viewers show as the title and
+// author, and the matching XMP packet that PDF/A requires.
+//
+// Metadata is written as an incremental update appended to
+// the original file, so the compiled content, fonts and any
+// PDF/A identification are left byte for byte intact.
+package pdfmeta
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// Metadata is the descriptive metadata of a document.
+type Metadata struct {
+	Title    string   `json:"title"`
+	Author   string   `json:"author"`
+	Subject  string   `json:"subject"`
+	Keywords []string `json:"keywords"`
+	// Creator is the tool that authored the source. The
+	// original creator is kept when empty.
+	Creator string `json:"creator"`
+}
+
+var startXRefRe = regexp.MustCompile(`startxref\s+(\d+)\s+%%EOF\s*$`)
+
+// Apply returns pdf with its metadata replaced by meta,
+// stamped with now as the creation and modification date.
+func Apply(pdf []byte, meta Metadata, now time.Time) ([]byte, error) {
+	ctx, err := api.ReadContext(bytes.NewReader(pdf), model.NewDefaultConfiguration())
+	if err != nil {
+		return nil, fmt.Errorf("read pdf: %w", err)
+	}
+	if ctx.Encrypt != nil {
+		return nil, fmt.Errorf("cannot set metadata of an encrypted pdf")
+	}
+	if ctx.Root == nil || ctx.Size == nil {
+		return nil, fmt.Errorf("pdf trailer has no catalog")
+	}
+
+	tail := pdf[max(0, len(pdf)-1024):]
+	m := startXRefRe.FindSubmatch(tail)
+	if m == nil {
+		return nil, fmt.Errorf("pdf has no startxref")
+	}
+	prev, _ := strconv.ParseInt(string(m[1]), 10, 64)
+
+	catalog, err := ctx.Catalog()
+	if err != nil {
+		return nil, fmt.Errorf("read catalog: %w", err)
+	}
+	conformance, err := pdfaConformance(ctx.XRefTable, catalog)
+	if err != nil {
+		return nil, err
+	}
+
+	if meta.Creator == "" {
+		meta.Creator = ctx.Creator
+	}
+	producer := ctx.Producer
+	now = now.UTC().Truncate(time.Second)
+
+	size := *ctx.Size
+	rootNr, rootGen := ctx.Root.ObjectNumber.Value(), ctx.Root.GenerationNumber.Value()
+	infoNr, xmpNr := size, size+1
+
+	catalog = catalog.Clone().(types.Dict)
+	catalog.Update("Metadata", *types.NewIndirectRef(xmpNr, 0))
+
+	var buf bytes.Buffer
+	buf.Write(pdf)
+	if !bytes.HasSuffix(pdf, []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+
+	offsets := make(map[int]int)
+	writeObj := func(nr, gen int, body string) {
+		offsets[nr] = buf.Len()
+		fmt.Fprintf(&buf, "%d %d obj\n%s\nendobj\n", nr, gen, body)
+	}
+
+	writeObj(rootNr, rootGen, catalog.PDFString())
+	writeObj(infoNr, 0, infoDict(meta, producer, now))
+	packet := xmpPacket(meta, producer, now, conformance)
+	writeObj(xmpNr, 0, fmt.Sprintf("<</Type /Metadata /Subtype /XML /Length %d>>\nstream\n%s\nendstream", len(packet), packet))
+
+	trailer := fmt.Sprintf("/Root %d %d R /Info %d 0 R /Prev %d", rootNr, rootGen, infoNr, prev)
+	if len(ctx.ID) == 2 {
+		trailer += " /ID " + ctx.ID.PDFString()
+	}
+
+	if ctx.Read.UsingXRefStreams {
+		writeXRefStream(&buf, offsets, rootNr, rootGen, infoNr, trailer)
+	} else {
+		writeXRefTable(&buf, offsets, rootNr, rootGen, infoNr, trailer)
+	}
+	return buf.Bytes(), nil
+}
+
+// writeXRefTable ends the update with a classic xref table,
+// matching a base file that uses one.
+func writeXRefTable(buf *bytes.Buffer, offsets map[int]int, rootNr, rootGen, infoNr int, trailer string) {
+	start := buf.Len()
+	fmt.Fprintf(buf, "xref\n%d 1\n%010d %05d n \n", rootNr, offsets[rootNr], rootGen)
+	fmt.Fprintf(buf, "%d 2\n", infoNr)
+	for nr := infoNr; nr < infoNr+2; nr++ {
+		fmt.Fprintf(buf, "%010d 00000 n \n", offsets[nr])
+	}
+	fmt.Fprintf(buf, "trailer\n<<%s /Size %d>>\nstartxref\n%d\n%%%%EOF\n", trailer, infoNr+2, start)
+}
+
+// writeXRefStream ends the update with an uncompressed xref
+// stream, since a reader that found xref streams in the base
+// file expects them throughout.
+func writeXRefStream(buf *bytes.Buffer, offsets map[int]int, rootNr, rootGen, infoNr int, trailer string) {
+	xrefNr := infoNr + 2
+	start := buf.Len()
+	offsets[xrefNr] = start
+
+	var entries bytes.Buffer
+	entry := func(offset, gen int) {
+		entries.WriteByte(1)
+		entries.Write([]byte{byte(offset >> 24), byte(offset >> 16), byte(offset >> 8), byte(offset)})
+		entries.Write([]byte{byte(gen >> 8), byte(gen)})
+	}
+	entry(offsets[rootNr], rootGen)
+	for nr := infoNr; nr <= xrefNr; nr++ {
+		entry(offsets[nr], 0)
+	}
+
+	fmt.Fprintf(buf, "%d 0 obj\n<</Type /XRef %s /Size %d /W [1 4 2] /Index [%d 1 %d 3] /Length %d>>\nstream\n",
+		xrefNr, trailer, xrefNr+1, rootNr, infoNr, entries.Len())
+	buf.Write(entries.Bytes())
+	fmt.Fprintf(buf, "\nendstream\nendobj\nstartxref\n%d\n%%%%EOF\n", start)
+}
+
+func infoDict(meta Metadata, producer string, now time.Time) string {
+	var b strings.Builder
+	b.WriteString("<<")
+	entry := func(key, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "/%s %s ", key, textString(value))
+		}
+	}
+	entry("Title", meta.Title)
+	entry("Author", meta.Author)
+	entry("Subject", meta.Subject)
+	entry("Keywords", strings.Join(meta.Keywords, ", "))
+	entry("Creator", meta.Creator)
+	entry("Producer", producer)
+	date := types.DateString(now)
+	fmt.Fprintf(&b, "/CreationDate (%s) /ModDate (%s)>>", date, date)
+	return b.String()
+}
+
+// textString encodes s as a PDF text string: a literal for
+// printable ASCII, otherwise UTF-16BE hex with a byte order
+// mark.
+func textString(s string) string {
+	ascii := true
+	for _, r := range s {
+		if r < 0x20 || r > 0x7e {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		r := strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`)
+		return "(" + r.Replace(s) + ")"
+	}
+
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+var (
+	pdfaPartRe        = regexp.MustCompile(`pdfaid:part(?:\s*=\s*["']|>)\s*(\d)`)
+	pdfaConformanceRe = regexp.MustCompile(`pdfaid:conformance(?:\s*=\s*["']|>)\s*([ABUabu])`)
+)
+
+// pdfaConformance returns the PDF/A part and level declared
+// in the existing XMP packet, such as "2B", or "" if the
+// document does not claim PDF/A.
+func pdfaConformance(xRefTable *model.XRefTable, catalog types.Dict) (string, error) {
+	obj, ok := catalog.Find("Metadata")
+	if !ok {
+		return "", nil
+	}
+	sd, _, err := xRefTable.DereferenceStreamDict(obj)
+	if err != nil || sd == nil {
+		return "", fmt.Errorf("read xmp metadata: %w", err)
+	}
+	if err = sd.Decode(); err != nil {
+		return "", fmt.Errorf("decode xmp metadata: %w", err)
+	}
+
+	part := pdfaPartRe.FindSubmatch(sd.Content)
+	if part == nil {
+		return "", nil
+	}
+	level := pdfaConformanceRe.FindSubmatch(sd.Content)
+	if level == nil {
+		return "", nil
+	}
+	return string(part[1]) + strings.ToUpper(string(level[1])), nil
+}
@@ -0,0 +1,170 @@
+package pdfmeta
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// buildPDF assembles a PDF from numbered object bodies,
+// starting at object 1, with a valid xref table.
+func buildPDF(objects ...string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /ID [<01> <01>] >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+func basePDF(xmp string) []byte {
+	catalog := "<< /Type /Catalog /Pages 2 0 R >>"
+	objects := []string{
+		catalog,
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R >>",
+		"<< /Length 0 >>\nstream\n\nendstream",
+	}
+	if xmp != "" {
+		objects[0] = "<< /Type /Catalog /Pages 2 0 R /Metadata 5 0 R >>"
+		objects = append(objects, fmt.Sprintf("<< /Type /Metadata /Subtype /XML /Length %d >>\nstream\n%s\nendstream", len(xmp), xmp))
+	}
+	return buildPDF(objects...)
+}
+
+// xrefStreamPDF rewrites pdf with an xref stream, as LaTeX
+// engines produce.
+func xrefStreamPDF(t *testing.T, pdf []byte) []byte {
+	t.Helper()
+	conf := model.NewDefaultConfiguration()
+	conf.WriteXRefStream = true
+	conf.WriteObjectStream = true
+	var out bytes.Buffer
+	if err := api.Optimize(bytes.NewReader(pdf), &out, conf); err != nil {
+		t.Fatalf("rewrite with xref stream: %v", err)
+	}
+	return out.Bytes()
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	meta := Metadata{
+		Title:    "Jane Doe – Resume",
+		Author:   "Jane Doe",
+		Subject:  "Backend Engineer at Acme (Data)",
+		Keywords: []string{"Go", "Kubernetes"},
+		Creator:  "Typst",
+	}
+	now := time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC)
+	pdfaXMP := `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF><rdf:Description pdfaid:part="2" pdfaid:conformance="B"/></rdf:RDF></x:xmpmeta>`
+
+	tests := []struct {
+		name     string
+		pdf      func(t *testing.T) []byte
+		wantPDFA bool
+	}{
+		{
+			name: "xref table",
+			pdf:  func(*testing.T) []byte { return basePDF("") },
+		},
+		{
+			name:     "keeps pdfa identification",
+			pdf:      func(*testing.T) []byte { return basePDF(pdfaXMP) },
+			wantPDFA: true,
+		},
+		{
+			name: "xref stream",
+			pdf:  func(t *testing.T) []byte { return xrefStreamPDF(t, basePDF("")) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			base := tt.pdf(t)
+			got, err := Apply(base, meta, now)
+			if err != nil {
+				t.Fatalf("Apply() unexpected error: %v", err)
+			}
+			if !bytes.HasPrefix(got, base) {
+				t.Error("Apply() modified the original bytes, want an appended update")
+			}
+
+			ctx, err := api.ReadAndValidate(bytes.NewReader(got), model.NewDefaultConfiguration())
+			if err != nil {
+				t.Fatalf("ReadAndValidate() on updated pdf: %v", err)
+			}
+			if ctx.Title != meta.Title {
+				t.Errorf("Title = %q, want %q", ctx.Title, meta.Title)
+			}
+			if ctx.Author != meta.Author {
+				t.Errorf("Author = %q, want %q", ctx.Author, meta.Author)
+			}
+			if ctx.Subject != meta.Subject {
+				t.Errorf("Subject = %q, want %q", ctx.Subject, meta.Subject)
+			}
+			if ctx.PageCount != 1 {
+				t.Errorf("PageCount = %d, want 1", ctx.PageCount)
+			}
+
+			catalog, err := ctx.Catalog()
+			if err != nil {
+				t.Fatalf("Catalog(): %v", err)
+			}
+			sd, _, err := ctx.DereferenceStreamDict(catalog["Metadata"])
+			if err != nil || sd == nil {
+				t.Fatalf("catalog metadata stream: %v", err)
+			}
+			if err = sd.Decode(); err != nil {
+				t.Fatalf("decode metadata: %v", err)
+			}
+			xmp := string(sd.Content)
+			for _, want := range []string{
+				"Jane Doe – Resume",
+				"Backend Engineer at Acme (Data)",
+				"<pdf:Keywords>Go, Kubernetes</pdf:Keywords>",
+				"<xmp:ModifyDate>2026-03-01T12:30:00Z</xmp:ModifyDate>",
+			} {
+				if !strings.Contains(xmp, want) {
+					t.Errorf("xmp missing %q:\n%s", want, xmp)
+				}
+			}
+			if gotPDFA := strings.Contains(xmp, "<pdfaid:part>2</pdfaid:part>"); gotPDFA != tt.wantPDFA {
+				t.Errorf("xmp pdfaid = %v, want %v:\n%s", gotPDFA, tt.wantPDFA, xmp)
+			}
+		})
+	}
+}
+
+func TestTextString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Resume (2026)", `(Resume \(2026\))`},
+		{`a\b`, `(a\\b)`},
+		{"José", "<FEFF004A006F007300E9>"},
+	}
+	for _, tt := range tests {
+		if got := textString(tt.in); got != tt.want {
+			t.Errorf("textString(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
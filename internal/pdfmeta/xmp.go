@@ -0,0 +1,69 @@
+package pdfmeta
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// xmpPacket renders the XMP packet mirroring the Info
+// dictionary. PDF/A requires the two to agree, and requires
+// the pdfaid identification when conformance is non-empty.
+func xmpPacket(meta Metadata, producer string, now time.Time, conformance string) string {
+	var b strings.Builder
+	b.WriteString("<?xpacket begin=\"\ufeff\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	b.WriteString(`<x:xmpmeta xmlns:x="adobe:ns:meta/">` + "\n")
+	b.WriteString(`<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` + "\n")
+	b.WriteString(`<rdf:Description rdf:about=""` +
+		` xmlns:dc="http://purl.org/dc/elements/1.1/"` +
+		` xmlns:pdf="http://ns.adobe.com/pdf/1.3/"` +
+		` xmlns:xmp="http://ns.adobe.com/xap/1.0/"`)
+	if conformance != "" {
+		b.WriteString(` xmlns:pdfaid="http://www.aiim.org/pdfa/ns/id/"`)
+	}
+	b.WriteString(">\n")
+
+	b.WriteString("<dc:format>application/pdf</dc:format>\n")
+	if meta.Title != "" {
+		fmt.Fprintf(&b, "<dc:title><rdf:Alt><rdf:li xml:lang=\"x-default\">%s</rdf:li></rdf:Alt></dc:title>\n", escapeXML(meta.Title))
+	}
+	if meta.Author != "" {
+		fmt.Fprintf(&b, "<dc:creator><rdf:Seq><rdf:li>%s</rdf:li></rdf:Seq></dc:creator>\n", escapeXML(meta.Author))
+	}
+	if meta.Subject != "" {
+		fmt.Fprintf(&b, "<dc:description><rdf:Alt><rdf:li xml:lang=\"x-default\">%s</rdf:li></rdf:Alt></dc:description>\n", escapeXML(meta.Subject))
+	}
+	if len(meta.Keywords) > 0 {
+		b.WriteString("<dc:subject><rdf:Bag>")
+		for _, kw := range meta.Keywords {
+			fmt.Fprintf(&b, "<rdf:li>%s</rdf:li>", escapeXML(kw))
+		}
+		b.WriteString("</rdf:Bag></dc:subject>\n")
+		fmt.Fprintf(&b, "<pdf:Keywords>%s</pdf:Keywords>\n", escapeXML(strings.Join(meta.Keywords, ", ")))
+	}
+	if producer != "" {
+		fmt.Fprintf(&b, "<pdf:Producer>%s</pdf:Producer>\n", escapeXML(producer))
+	}
+	if meta.Creator != "" {
+		fmt.Fprintf(&b, "<xmp:CreatorTool>%s</xmp:CreatorTool>\n", escapeXML(meta.Creator))
+	}
+	date := now.Format(time.RFC3339)
+	fmt.Fprintf(&b, "<xmp:CreateDate>%s</xmp:CreateDate>\n", date)
+	fmt.Fprintf(&b, "<xmp:ModifyDate>%s</xmp:ModifyDate>\n", date)
+	fmt.Fprintf(&b, "<xmp:MetadataDate>%s</xmp:MetadataDate>\n", date)
+	if conformance != "" {
+		fmt.Fprintf(&b, "<pdfaid:part>%s</pdfaid:part>\n", conformance[:1])
+		fmt.Fprintf(&b, "<pdfaid:conformance>%s</pdfaid:conformance>\n", conformance[1:])
+	}
+
+	b.WriteString("</rdf:Description>\n</rdf:RDF>\n</x:xmpmeta>\n")
+	b.WriteString(`<?xpacket end="w"?>`)
+	return b.String()
+}
+
+func escapeXML(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
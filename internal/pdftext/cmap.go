@@ -0,0 +1,130 @@
+package pdftext
+
+import (
+	"unicode/utf16"
+)
+
+// cmap maps character codes to Unicode text, parsed from a
+// font's ToUnicode stream.
+type cmap struct {
+	// codeLen is the byte length of a character code.
+	codeLen int
+	chars   map[uint32]string
+}
+
+// parseCMap parses the bfchar and bfrange sections of a
+// ToUnicode CMap. Unsupported constructs are skipped.
+func parseCMap(data []byte) *cmap {
+	cm := &cmap{codeLen: 1, chars: make(map[uint32]string)}
+	l := newLexer(data)
+
+	for {
+		tok := l.next()
+		if tok.kind == tokEOF {
+			return cm
+		}
+		if tok.kind != tokOperator {
+			continue
+		}
+		switch string(tok.str) {
+		case "begincodespacerange":
+			cm.parseCodespace(l)
+		case "beginbfchar":
+			cm.parseBFChar(l)
+		case "beginbfrange":
+			cm.parseBFRange(l)
+		}
+	}
+}
+
+func (cm *cmap) parseCodespace(l *lexer) {
+	for {
+		tok := l.next()
+		if tok.kind == tokEOF || tok.kind == tokOperator {
+			return
+		}
+		if tok.kind == tokString && len(tok.str) > cm.codeLen {
+			cm.codeLen = len(tok.str)
+		}
+	}
+}
+
+func (cm *cmap) parseBFChar(l *lexer) {
+	for {
+		src := l.next()
+		if src.kind != tokString {
+			return
+		}
+		dst := l.next()
+		if dst.kind != tokString {
+			return
+		}
+		cm.chars[codeValue(src.str)] = decodeUTF16BE(dst.str)
+	}
+}
+
+func (cm *cmap) parseBFRange(l *lexer) {
+	for {
+		lo := l.next()
+		if lo.kind != tokString {
+			return
+		}
+		hi := l.next()
+		if hi.kind != tokString {
+			return
+		}
+		start, end := codeValue(lo.str), codeValue(hi.str)
+		if end < start || end-start > 0xFFFF {
+			return
+		}
+
+		dst := l.next()
+		switch dst.kind {
+		case tokString:
+			// Consecutive codes map to consecutive values,
+			// incrementing the last UTF-16 unit.
+			base := append([]byte(nil), dst.str...)
+			for code := start; code <= end; code++ {
+				cm.chars[code] = decodeUTF16BE(base)
+				if len(base) >= 2 {
+					v := uint16(base[len(base)-2])<<8 | uint16(base[len(base)-1])
+					v++
+					base[len(base)-2], base[len(base)-1] = byte(v>>8), byte(v)
+				}
+			}
+		case tokArrayStart:
+			code := start
+			for {
+				item := l.next()
+				if item.kind != tokString {
+					break
+				}
+				if code <= end {
+					cm.chars[code] = decodeUTF16BE(item.str)
+				}
+				code++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func codeValue(b []byte) uint32 {
+	var v uint32
+	for _, c := range b {
+		v = v<<8 | uint32(c)
+	}
+	return v
+}
+
+func decodeUTF16BE(b []byte) string {
+	if len(b)%2 != 0 {
+		return string(b)
+	}
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		units[i] = uint16(b[2*i])<<8 | uint16(b[2*i+1])
+	}
+	return string(utf16.Decode(units))
+}
@@ -0,0 +1,507 @@
+// Package pdftext extracts positioned text from PDFs on top
+// of pdfcpu's object model. It interprets page content
+// streams and ToUnicode CMaps the way a simple text parser,
+// such as an applicant tracking system, would: text comes
+// out in content stream order, and glyphs without a Unicode
+// mapping are lost.
+package pdftext
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// maxFormDepth bounds recursion into nested form XObjects.
+const maxFormDepth = 8
+
+// Run is the text shown by a single text operator.
+type Run struct {
+	// X and Y are the origin of the run in points, measured
+	// from the bottom left of the page.
+	X, Y float64
+	// EndX estimates where the run ends. Glyph widths are
+	// approximated, so it is only suitable for coarse layout.
+	EndX float64
+	// FontSize is the effective font size in points.
+	FontSize float64
+	// Font is the font's base name, without subset prefix.
+	Font string
+	// Text is the decoded text.
+	Text string
+	// Unmapped counts glyphs with no Unicode mapping, which
+	// are missing from Text.
+	Unmapped int
+}
+
+// Image is an image drawn on a page.
+type Image struct {
+	// X, Y, Width and Height are the image's bounding box in
+	// points, measured from the bottom left of the page.
+	X, Y, Width, Height float64
+}
+
+// Page holds the text and images of one page.
+type Page struct {
+	Number        int
+	Width, Height float64
+	// Runs are in content stream order.
+	Runs   []Run
+	Images []Image
+}
+
+// Line is a run of text on one baseline.
+type Line struct {
+	X, Y, EndX float64
+	// FontSize is the largest font size on the line and Font
+	// the font of its first run.
+	FontSize float64
+	Font     string
+	Text     string
+}
+
+// Lines joins the page's runs in content stream order into
+// lines, breaking when the baseline moves.
+func (p *Page) Lines() []Line {
+	var lines []Line
+	var sb strings.Builder
+	for i, r := range p.Runs {
+		if i == 0 || math.Abs(r.Y-p.Runs[i-1].Y) > 0.5*math.Max(p.Runs[i-1].FontSize, 1) {
+			if i > 0 {
+				lines[len(lines)-1].Text = sb.String()
+				sb.Reset()
+			}
+			lines = append(lines, Line{X: r.X, Y: r.Y, EndX: r.EndX, FontSize: r.FontSize, Font: r.Font})
+		} else {
+			prev := p.Runs[i-1]
+			if r.X-prev.EndX > 0.2*r.FontSize && !endsWithSpace(sb.String()) && !strings.HasPrefix(r.Text, " ") {
+				sb.WriteByte(' ')
+			}
+			line := &lines[len(lines)-1]
+			line.EndX = math.Max(line.EndX, r.EndX)
+			line.FontSize = math.Max(line.FontSize, r.FontSize)
+		}
+		sb.WriteString(r.Text)
+	}
+	if len(lines) > 0 {
+		lines[len(lines)-1].Text = sb.String()
+	}
+	return lines
+}
+
+// Text joins the page's lines with newlines.
+func (p *Page) Text() string {
+	lines := p.Lines()
+	texts := make([]string, len(lines))
+	for i, line := range lines {
+		texts[i] = line.Text
+	}
+	return strings.Join(texts, "\n")
+}
+
+func endsWithSpace(s string) bool {
+	return s == "" || strings.HasSuffix(s, " ") || strings.HasSuffix(s, "\n")
+}
+
+// ExtractFile extracts text from the PDF at path.
+func ExtractFile(path string) ([]Page, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open pdf: %w", err)
+	}
+	defer f.Close()
+	return Extract(f)
+}
+
+// Extract extracts text from every page of the PDF in rs.
+func Extract(rs io.ReadSeeker) ([]Page, error) {
+	ctx, err := api.ReadContext(rs, model.NewDefaultConfiguration())
+	if err != nil {
+		return nil, fmt.Errorf("read pdf: %w", err)
+	}
+	if err = ctx.EnsurePageCount(); err != nil {
+		return nil, fmt.Errorf("count pages: %w", err)
+	}
+
+	pages := make([]Page, 0, ctx.PageCount)
+	for nr := 1; nr <= ctx.PageCount; nr++ {
+		page, err := extractPage(ctx, nr)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", nr, err)
+		}
+		pages = append(pages, page)
+	}
+	return pages, nil
+}
+
+func extractPage(ctx *model.Context, nr int) (Page, error) {
+	d, _, attrs, err := ctx.PageDict(nr, true)
+	if err != nil {
+		return Page{}, err
+	}
+	page := Page{Number: nr}
+	if attrs != nil && attrs.MediaBox != nil {
+		page.Width = attrs.MediaBox.Width()
+		page.Height = attrs.MediaBox.Height()
+	}
+
+	content, err := ctx.PageContent(d, nr)
+	if err != nil && err != model.ErrNoContent {
+		return Page{}, err
+	}
+
+	var res types.Dict
+	if attrs != nil {
+		res = attrs.Resources
+	}
+	in := &interpreter{
+		xref:  ctx.XRefTable,
+		page:  &page,
+		fonts: make(map[string]*font),
+	}
+	in.run(content, res, identity, 0)
+	return page, nil
+}
+
+// matrix is a PDF transformation matrix [a b c d e f].
+type matrix [6]float64
+
+var identity = matrix{1, 0, 0, 1, 0, 0}
+
+// mul returns m × n.
+func (m matrix) mul(n matrix) matrix {
+	return matrix{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+func (m matrix) apply(x, y float64) (float64, float64) {
+	return m[0]*x + m[2]*y + m[4], m[1]*x + m[3]*y + m[5]
+}
+
+type graphicsState struct {
+	ctm      matrix
+	font     *font
+	fontSize float64
+	leading  float64
+}
+
+type interpreter struct {
+	xref  *model.XRefTable
+	page  *Page
+	fonts map[string]*font // keyed by font dict identity
+}
+
+// run interprets a content stream with the given resources
+// and initial transformation.
+func (in *interpreter) run(content []byte, res types.Dict, ctm matrix, depth int) {
+	gs := graphicsState{ctm: ctm}
+	var stack []graphicsState
+	var tm, tlm matrix
+	var operands []token
+
+	l := newLexer(content)
+	for {
+		tok := l.next()
+		if tok.kind == tokEOF {
+			return
+		}
+		if tok.kind == tokArrayStart {
+			operands = append(operands, token{kind: tokArrayStart, items: readArray(l)})
+			continue
+		}
+		if tok.kind != tokOperator {
+			operands = append(operands, tok)
+			continue
+		}
+
+		op := string(tok.str)
+		switch op {
+		case "q":
+			stack = append(stack, gs)
+		case "Q":
+			if len(stack) > 0 {
+				gs = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+		case "cm":
+			if m, ok := matrixOperand(operands); ok {
+				gs.ctm = m.mul(gs.ctm)
+			}
+		case "BT":
+			tm, tlm = identity, identity
+		case "Tf":
+			if len(operands) >= 2 && operands[0].kind == tokName {
+				gs.font = in.loadFont(res, string(operands[0].str))
+				gs.fontSize = operands[1].num
+			}
+		case "TL":
+			if len(operands) >= 1 {
+				gs.leading = operands[0].num
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				tx, ty := operands[0].num, operands[1].num
+				if op == "TD" {
+					gs.leading = -ty
+				}
+				tlm = matrix{1, 0, 0, 1, tx, ty}.mul(tlm)
+				tm = tlm
+			}
+		case "Tm":
+			if m, ok := matrixOperand(operands); ok {
+				tm, tlm = m, m
+			}
+		case "T*":
+			tlm = matrix{1, 0, 0, 1, 0, -gs.leading}.mul(tlm)
+			tm = tlm
+		case "Tj", "'", "\"":
+			if op != "Tj" {
+				tlm = matrix{1, 0, 0, 1, 0, -gs.leading}.mul(tlm)
+				tm = tlm
+			}
+			if len(operands) > 0 {
+				s := operands[len(operands)-1]
+				if s.kind == tokString {
+					tm = in.show(gs, tm, [][]byte{s.str}, nil)
+				}
+			}
+		case "TJ":
+			if len(operands) > 0 && operands[0].kind == tokArrayStart {
+				strs, gaps := splitTJ(operands[0].items)
+				tm = in.show(gs, tm, strs, gaps)
+			}
+		case "Do":
+			if len(operands) > 0 && operands[0].kind == tokName {
+				in.doXObject(res, string(operands[0].str), gs.ctm, depth)
+			}
+		case "BI":
+			// Skip the inline image dictionary up to ID.
+			for {
+				t := l.next()
+				if t.kind == tokEOF || (t.kind == tokOperator && string(t.str) == "ID") {
+					break
+				}
+			}
+			l.skipInlineImage()
+			in.addImage(gs.ctm)
+		}
+		operands = operands[:0]
+	}
+}
+
+// readArray reads array elements after '['. Nested arrays
+// never occur in content stream operands and are dropped.
+func readArray(l *lexer) []token {
+	var items []token
+	for {
+		tok := l.next()
+		switch tok.kind {
+		case tokEOF, tokArrayEnd:
+			return items
+		case tokArrayStart:
+			readArray(l)
+		default:
+			items = append(items, tok)
+		}
+	}
+}
+
+// splitTJ splits TJ array items into strings and the
+// adjustment preceding each one, in thousandths of an em.
+func splitTJ(items []token) ([][]byte, []float64) {
+	var strs [][]byte
+	var gaps []float64
+	gap := 0.0
+	for _, item := range items {
+		switch item.kind {
+		case tokString:
+			strs = append(strs, item.str)
+			gaps = append(gaps, gap)
+			gap = 0
+		case tokNumber:
+			gap += item.num
+		}
+	}
+	return strs, gaps
+}
+
+// wordGap is the TJ adjustment, in thousandths of an em,
+// beyond which a producer that omits space glyphs is taken
+// to have separated two words.
+const wordGap = -250
+
+// show records a run for the strings of a text showing
+// operator and returns the advanced text matrix.
+func (in *interpreter) show(gs graphicsState, tm matrix, strs [][]byte, gaps []float64) matrix {
+	if gs.font == nil || gs.fontSize == 0 {
+		return tm
+	}
+
+	trm := tm.mul(gs.ctm)
+	x, y := trm.apply(0, 0)
+	scale := math.Hypot(trm[2], trm[3])
+
+	var sb strings.Builder
+	unmapped := 0
+	advance := 0.0
+	for i, s := range strs {
+		if gaps != nil {
+			if gaps[i] <= wordGap && sb.Len() > 0 && !strings.HasSuffix(sb.String(), " ") {
+				sb.WriteByte(' ')
+			}
+			advance -= gaps[i] / 1000
+		}
+		text, missing, width := gs.font.decode(s)
+		sb.WriteString(text)
+		unmapped += missing
+		advance += width
+	}
+
+	dx := advance * gs.fontSize
+	next := matrix{1, 0, 0, 1, dx, 0}.mul(tm)
+	endX, _ := next.mul(gs.ctm).apply(0, 0)
+
+	if sb.Len() == 0 && unmapped == 0 {
+		return next
+	}
+	in.page.Runs = append(in.page.Runs, Run{
+		X:        x,
+		Y:        y,
+		EndX:     endX,
+		FontSize: gs.fontSize * scale,
+		Font:     gs.font.name,
+		Text:     sb.String(),
+		Unmapped: unmapped,
+	})
+	return next
+}
+
+func (in *interpreter) doXObject(res types.Dict, name string, ctm matrix, depth int) {
+	xobjs := in.subDict(res, "XObject")
+	if xobjs == nil {
+		return
+	}
+	obj, ok := xobjs.Find(name)
+	if !ok {
+		return
+	}
+	sd, _, err := in.xref.DereferenceStreamDict(obj)
+	if err != nil || sd == nil {
+		return
+	}
+
+	switch subtype := sd.Dict.Subtype(); {
+	case subtype != nil && *subtype == "Image":
+		in.addImage(ctm)
+	case subtype != nil && *subtype == "Form" && depth < maxFormDepth:
+		if err = sd.Decode(); err != nil {
+			return
+		}
+		formCTM := ctm
+		if m, ok := in.matrixEntry(sd.Dict, "Matrix"); ok {
+			formCTM = m.mul(ctm)
+		}
+		formRes := in.subDict(sd.Dict, "Resources")
+		if formRes == nil {
+			formRes = res
+		}
+		in.run(sd.Content, formRes, formCTM, depth+1)
+	}
+}
+
+// addImage records an image occupying the unit square
+// transformed by ctm.
+func (in *interpreter) addImage(ctm matrix) {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, c := range [][2]float64{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+		x, y := ctm.apply(c[0], c[1])
+		minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+		minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+	}
+	in.page.Images = append(in.page.Images, Image{
+		X:      minX,
+		Y:      minY,
+		Width:  maxX - minX,
+		Height: maxY - minY,
+	})
+}
+
+func (in *interpreter) subDict(d types.Dict, key string) types.Dict {
+	if d == nil {
+		return nil
+	}
+	obj, ok := d.Find(key)
+	if !ok {
+		return nil
+	}
+	sub, err := in.xref.DereferenceDict(obj)
+	if err != nil {
+		return nil
+	}
+	return sub
+}
+
+func (in *interpreter) matrixEntry(d types.Dict, key string) (matrix, bool) {
+	obj, ok := d.Find(key)
+	if !ok {
+		return matrix{}, false
+	}
+	obj, err := in.xref.Dereference(obj)
+	if err != nil {
+		return matrix{}, false
+	}
+	arr, ok := obj.(types.Array)
+	if !ok || len(arr) != 6 {
+		return matrix{}, false
+	}
+	var m matrix
+	for i, o := range arr {
+		v, ok := in.number(o)
+		if !ok {
+			return matrix{}, false
+		}
+		m[i] = v
+	}
+	return m, true
+}
+
+func (in *interpreter) number(o types.Object) (float64, bool) {
+	o, err := in.xref.Dereference(o)
+	if err != nil {
+		return 0, false
+	}
+	switch v := o.(type) {
+	case types.Integer:
+		return float64(v), true
+	case types.Float:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func matrixOperand(operands []token) (matrix, bool) {
+	if len(operands) < 6 {
+		return matrix{}, false
+	}
+	var m matrix
+	for i, t := range operands[len(operands)-6:] {
+		if t.kind != tokNumber {
+			return matrix{}, false
+		}
+		m[i] = t.num
+	}
+	return m, true
+}
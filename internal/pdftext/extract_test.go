@@ -0,0 +1,199 @@
+package pdftext
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// buildPDF assembles a PDF from numbered object bodies,
+// starting at object 1, with a valid xref table.
+func buildPDF(objects ...string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+func stream(dict, content string) string {
+	return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(content), content)
+}
+
+func TestExtract(t *testing.T) {
+	t.Parallel()
+
+	content := strings.Join([]string{
+		"BT /F1 24 Tf 72 720 Td (Jane Doe) Tj ET",
+		"BT /F2 10 Tf 1 0 0 1 72 700 Tm [<0001> -300 <00020004>] TJ ET",
+		"q 100 0 0 50 400 600 cm /Im1 Do Q",
+		"q 1 0 0 1 100 100 cm /Fm1 Do Q",
+	}, "\n")
+	cmap := strings.Join([]string{
+		"/CIDInit /ProcSet findresource begin",
+		"begincmap",
+		"1 begincodespacerange <0000> <FFFF> endcodespacerange",
+		"1 beginbfchar <0001> <0041> endbfchar",
+		"1 beginbfrange <0002> <0003> <FB01> endbfrange",
+		"endcmap",
+	}, "\n")
+
+	pdf := buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] "+
+			"/Resources << /Font << /F1 5 0 R /F2 6 0 R >> /XObject << /Im1 8 0 R /Fm1 10 0 R >> >> "+
+			"/Contents 4 0 R >>",
+		stream("", content),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		"<< /Type /Font /Subtype /Type0 /BaseFont /ABCDEF+Icons /Encoding /Identity-H "+
+			"/DescendantFonts [9 0 R] /ToUnicode 7 0 R >>",
+		stream("", cmap),
+		stream("/Type /XObject /Subtype /Image /Width 1 /Height 1 /ColorSpace /DeviceGray /BitsPerComponent 8", "\x00"),
+		"<< /Type /Font /Subtype /CIDFontType2 /BaseFont /ABCDEF+Icons "+
+			"/CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /DW 600 >>",
+		stream("/Type /XObject /Subtype /Form /BBox [0 0 100 100] /Matrix [1 0 0 1 50 50] "+
+			"/Resources << /Font << /F1 5 0 R >> >>", "BT /F1 10 Tf (In form) Tj ET"),
+	)
+
+	pages, err := Extract(bytes.NewReader(pdf))
+	if err != nil {
+		t.Fatalf("Extract() unexpected error: %v", err)
+	}
+	if len(pages) != 1 {
+		t.Fatalf("len(pages) = %d, want 1", len(pages))
+	}
+	page := pages[0]
+	if page.Width != 612 || page.Height != 792 {
+		t.Errorf("page size = %vx%v, want 612x792", page.Width, page.Height)
+	}
+	if len(page.Runs) != 3 {
+		t.Fatalf("len(Runs) = %d, want 3: %+v", len(page.Runs), page.Runs)
+	}
+
+	name := page.Runs[0]
+	if name.Text != "Jane Doe" || name.X != 72 || name.Y != 720 || name.FontSize != 24 || name.Font != "Helvetica" {
+		t.Errorf("Runs[0] = %+v, want Jane Doe at 72,720 in Helvetica 24", name)
+	}
+
+	icons := page.Runs[1]
+	if icons.Text != "A ﬁ" || icons.Unmapped != 1 || icons.Font != "Icons" {
+		t.Errorf("Runs[1] = %+v, want %q with 1 unmapped glyph in Icons", icons, "A ﬁ")
+	}
+	// Three 0.6em glyphs at 10pt plus a 0.3em adjustment.
+	if want := 72 + 21.0; math.Abs(icons.EndX-want) > 1e-9 {
+		t.Errorf("Runs[1].EndX = %v, want %v", icons.EndX, want)
+	}
+
+	form := page.Runs[2]
+	if form.Text != "In form" || form.X != 150 || form.Y != 150 {
+		t.Errorf("Runs[2] = %+v, want In form at 150,150", form)
+	}
+
+	if len(page.Images) != 1 {
+		t.Fatalf("len(Images) = %d, want 1", len(page.Images))
+	}
+	if img := page.Images[0]; img != (Image{X: 400, Y: 600, Width: 100, Height: 50}) {
+		t.Errorf("Images[0] = %+v, want 100x50 at 400,600", img)
+	}
+
+	if got, want := page.Text(), "Jane Doe\nA ﬁ\nIn form"; got != want {
+		t.Errorf("Text() = %q, want %q", got, want)
+	}
+}
+
+func TestParseCMap(t *testing.T) {
+	t.Parallel()
+
+	cm := parseCMap([]byte(strings.Join([]string{
+		"1 begincodespacerange <00> <FF> endcodespacerange",
+		"2 beginbfchar <41> <0041> <42> <D83DDE00> endbfchar",
+		"1 beginbfrange <61> <63> [<0078> <0079> <007A>] endbfrange",
+		"1 beginbfrange <30> <32> <0030> endbfrange",
+	}, "\n")))
+
+	tests := []struct {
+		code uint32
+		want string
+	}{
+		{0x41, "A"},
+		{0x42, "\U0001F600"},
+		{0x61, "x"},
+		{0x63, "z"},
+		{0x30, "0"},
+		{0x32, "2"},
+	}
+	for _, tt := range tests {
+		if got := cm.chars[tt.code]; got != tt.want {
+			t.Errorf("chars[%#x] = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+	if cm.codeLen != 1 {
+		t.Errorf("codeLen = %d, want 1", cm.codeLen)
+	}
+}
+
+func TestLexer(t *testing.T) {
+	t.Parallel()
+
+	l := newLexer([]byte(`/F1 12 Tf (a\(b\) \101) <48 69> [1 -2.5] % comment
+BI /W 1 ID xyz EI Q`))
+
+	var got []string
+	for {
+		tok := l.next()
+		if tok.kind == tokEOF {
+			break
+		}
+		switch tok.kind {
+		case tokNumber:
+			got = append(got, fmt.Sprint(tok.num))
+		case tokArrayStart:
+			got = append(got, "[")
+		case tokArrayEnd:
+			got = append(got, "]")
+		default:
+			got = append(got, string(tok.str))
+		}
+		if tok.kind == tokOperator && string(tok.str) == "ID" {
+			l.skipInlineImage()
+		}
+	}
+
+	want := []string{"F1", "12", "Tf", "a(b) A", "Hi", "[", "1", "-2.5", "]", "BI", "W", "1", "ID", "Q"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("tokens = %q, want %q", got, want)
+	}
+}
+
+func TestPageLines(t *testing.T) {
+	t.Parallel()
+
+	page := Page{Runs: []Run{
+		{X: 72, Y: 720, EndX: 150, FontSize: 24, Font: "Bold", Text: "Jane"},
+		{X: 160, Y: 720, EndX: 220, FontSize: 24, Font: "Bold", Text: "Doe"},
+		{X: 72, Y: 700, EndX: 200, FontSize: 10, Font: "Body", Text: "jane@example.com"},
+	}}
+
+	lines := page.Lines()
+	if len(lines) != 2 {
+		t.Fatalf("len(Lines()) = %d, want 2", len(lines))
+	}
+	if got := lines[0]; got.Text != "Jane Doe" || got.X != 72 || got.EndX != 220 || got.FontSize != 24 || got.Font != "Bold" {
+		t.Errorf("Lines()[0] = %+v, want Jane Doe spanning 72-220", got)
+	}
+	if got := page.Text(); got != "Jane Doe\njane@example.com" {
+		t.Errorf("Text() = %q", got)
+	}
+}
@@ -0,0 +1,210 @@
+package pdftext
+
+import (
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+const (
+	// defaultSimpleWidth is the glyph advance, in ems, used
+	// when a simple font has no width for a code.
+	defaultSimpleWidth = 0.5
+	// defaultCIDWidth is the PDF default for composite fonts
+	// without a DW entry.
+	defaultCIDWidth = 1.0
+)
+
+// font decodes strings shown with one font resource.
+type font struct {
+	name    string
+	codeLen int
+	// toUnicode is nil when the font has no ToUnicode CMap.
+	toUnicode    *cmap
+	widths       map[uint32]float64 // in ems
+	defaultWidth float64
+}
+
+// decode maps the character codes in s to text. It returns
+// the text, the number of codes with no Unicode mapping and
+// the total advance in ems.
+func (f *font) decode(s []byte) (string, int, float64) {
+	var sb strings.Builder
+	unmapped := 0
+	advance := 0.0
+	for i := 0; i+f.codeLen <= len(s); i += f.codeLen {
+		code := codeValue(s[i : i+f.codeLen])
+		if w, ok := f.widths[code]; ok {
+			advance += w
+		} else {
+			advance += f.defaultWidth
+		}
+
+		switch {
+		case f.toUnicode != nil:
+			text, ok := f.toUnicode.chars[code]
+			if !ok {
+				unmapped++
+				continue
+			}
+			sb.WriteString(text)
+		case f.codeLen == 1:
+			// Without a CMap, simple fonts are assumed to use a
+			// Latin encoding, which agrees on printable ASCII.
+			sb.WriteRune(rune(code))
+		default:
+			unmapped++
+		}
+	}
+	return sb.String(), unmapped, advance
+}
+
+// loadFont resolves the font resource name in res, caching
+// fonts by their indirect reference.
+func (in *interpreter) loadFont(res types.Dict, name string) *font {
+	fonts := in.subDict(res, "Font")
+	if fonts == nil {
+		return nil
+	}
+	obj, ok := fonts.Find(name)
+	if !ok {
+		return nil
+	}
+
+	key := ""
+	if ref, ok := obj.(types.IndirectRef); ok {
+		key = ref.String()
+		if f, ok := in.fonts[key]; ok {
+			return f
+		}
+	}
+
+	d, err := in.xref.DereferenceDict(obj)
+	if err != nil || d == nil {
+		return nil
+	}
+	f := in.parseFont(d)
+	if key != "" {
+		in.fonts[key] = f
+	}
+	return f
+}
+
+func (in *interpreter) parseFont(d types.Dict) *font {
+	f := &font{
+		codeLen:      1,
+		widths:       make(map[uint32]float64),
+		defaultWidth: defaultSimpleWidth,
+	}
+	if base := d.NameEntry("BaseFont"); base != nil {
+		f.name = stripSubsetPrefix(*base)
+	}
+
+	if subtype := d.Subtype(); subtype != nil && *subtype == "Type0" {
+		f.codeLen = 2
+		f.defaultWidth = defaultCIDWidth
+		in.parseCIDWidths(f, d)
+	} else {
+		in.parseSimpleWidths(f, d)
+	}
+
+	if obj, ok := d.Find("ToUnicode"); ok {
+		sd, _, err := in.xref.DereferenceStreamDict(obj)
+		if err == nil && sd != nil && sd.Decode() == nil {
+			f.toUnicode = parseCMap(sd.Content)
+		}
+	}
+	return f
+}
+
+func (in *interpreter) parseSimpleWidths(f *font, d types.Dict) {
+	first, ok := in.intEntry(d, "FirstChar")
+	if !ok {
+		return
+	}
+	for i, w := range in.arrayEntry(d, "Widths") {
+		if v, ok := in.number(w); ok {
+			f.widths[uint32(first+i)] = v / 1000
+		}
+	}
+}
+
+// parseCIDWidths reads DW and W from a composite font's
+// descendant. W mixes "c [w1 w2 ...]" and "cfirst clast w".
+func (in *interpreter) parseCIDWidths(f *font, d types.Dict) {
+	desc := in.arrayEntry(d, "DescendantFonts")
+	if len(desc) == 0 {
+		return
+	}
+	cid, err := in.xref.DereferenceDict(desc[0])
+	if err != nil || cid == nil {
+		return
+	}
+	if dw, ok := in.intEntry(cid, "DW"); ok {
+		f.defaultWidth = float64(dw) / 1000
+	}
+
+	w := in.arrayEntry(cid, "W")
+	for i := 0; i < len(w); {
+		first, ok := in.number(w[i])
+		if !ok || i+1 >= len(w) {
+			return
+		}
+		next, err := in.xref.Dereference(w[i+1])
+		if err != nil {
+			return
+		}
+		if arr, ok := next.(types.Array); ok {
+			for j, o := range arr {
+				if v, ok := in.number(o); ok {
+					f.widths[uint32(first)+uint32(j)] = v / 1000
+				}
+			}
+			i += 2
+			continue
+		}
+		if i+2 >= len(w) {
+			return
+		}
+		last, ok1 := in.number(w[i+1])
+		v, ok2 := in.number(w[i+2])
+		if !ok1 || !ok2 || last < first || last-first > 0xFFFF {
+			return
+		}
+		for c := uint32(first); c <= uint32(last); c++ {
+			f.widths[c] = v / 1000
+		}
+		i += 3
+	}
+}
+
+func (in *interpreter) intEntry(d types.Dict, key string) (int, bool) {
+	obj, ok := d.Find(key)
+	if !ok {
+		return 0, false
+	}
+	v, ok := in.number(obj)
+	return int(v), ok
+}
+
+func (in *interpreter) arrayEntry(d types.Dict, key string) types.Array {
+	obj, ok := d.Find(key)
+	if !ok {
+		return nil
+	}
+	obj, err := in.xref.Dereference(obj)
+	if err != nil {
+		return nil
+	}
+	arr, _ := obj.(types.Array)
+	return arr
+}
+
+// stripSubsetPrefix removes the "ABCDEF+" tag that marks an
+// embedded font subset.
+func stripSubsetPrefix(name string) string {
+	if len(name) > 7 && name[6] == '+' && strings.ToUpper(name[:6]) == name[:6] {
+		return name[7:]
+	}
+	return name
+}
@@ -0,0 +1,248 @@
+package pdftext
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// tokenKind classifies content stream tokens.
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokName
+	tokString // literal or hex string, already decoded to bytes
+	tokArrayStart
+	tokArrayEnd
+	tokDictStart
+	tokDictEnd
+	tokOperator
+)
+
+type token struct {
+	kind tokenKind
+	num  float64
+	str  []byte // name, string bytes or operator
+	// items holds array elements when the token is an
+	// array operand assembled by the interpreter.
+	items []token
+}
+
+// lexer tokenizes a PDF content stream or CMap. It is
+// lenient: malformed input yields operators that the
+// interpreter ignores rather than errors.
+type lexer struct {
+	data []byte
+	pos  int
+}
+
+func newLexer(data []byte) *lexer {
+	return &lexer{data: data}
+}
+
+func isWhitespace(c byte) bool {
+	switch c {
+	case ' ', '\t', '\r', '\n', '\f', 0:
+		return true
+	}
+	return false
+}
+
+func isDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+func (l *lexer) skipSpaceAndComments() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if isWhitespace(c) {
+			l.pos++
+			continue
+		}
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		return
+	}
+}
+
+func (l *lexer) next() token {
+	l.skipSpaceAndComments()
+	if l.pos >= len(l.data) {
+		return token{kind: tokEOF}
+	}
+
+	c := l.data[l.pos]
+	switch {
+	case c == '/':
+		l.pos++
+		start := l.pos
+		for l.pos < len(l.data) && !isWhitespace(l.data[l.pos]) && !isDelimiter(l.data[l.pos]) {
+			l.pos++
+		}
+		return token{kind: tokName, str: l.data[start:l.pos]}
+	case c == '(':
+		l.pos++
+		return token{kind: tokString, str: l.literalString()}
+	case c == '<':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '<' {
+			l.pos += 2
+			return token{kind: tokDictStart}
+		}
+		l.pos++
+		return token{kind: tokString, str: l.hexString()}
+	case c == '>':
+		l.pos++
+		if l.pos < len(l.data) && l.data[l.pos] == '>' {
+			l.pos++
+		}
+		return token{kind: tokDictEnd}
+	case c == '[':
+		l.pos++
+		return token{kind: tokArrayStart}
+	case c == ']':
+		l.pos++
+		return token{kind: tokArrayEnd}
+	case c == '{' || c == '}' || c == ')':
+		l.pos++
+		return token{kind: tokOperator, str: []byte{c}}
+	}
+
+	start := l.pos
+	for l.pos < len(l.data) && !isWhitespace(l.data[l.pos]) && !isDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	word := l.data[start:l.pos]
+	if n, err := strconv.ParseFloat(string(word), 64); err == nil {
+		return token{kind: tokNumber, num: n}
+	}
+	return token{kind: tokOperator, str: word}
+}
+
+// literalString reads a (...) string after the opening
+// parenthesis, handling nesting and escapes.
+func (l *lexer) literalString() []byte {
+	var out bytes.Buffer
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+			out.WriteByte(c)
+		case ')':
+			depth--
+			if depth == 0 {
+				return out.Bytes()
+			}
+			out.WriteByte(c)
+		case '\\':
+			if l.pos >= len(l.data) {
+				return out.Bytes()
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				out.WriteByte('\n')
+			case 'r':
+				out.WriteByte('\r')
+			case 't':
+				out.WriteByte('\t')
+			case 'b':
+				out.WriteByte('\b')
+			case 'f':
+				out.WriteByte('\f')
+			case '\r':
+				// Line continuation.
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+			case '\n':
+				// Line continuation.
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					out.WriteByte(byte(v))
+				} else {
+					out.WriteByte(e)
+				}
+			}
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.Bytes()
+}
+
+// hexString reads a <...> string after the opening bracket.
+func (l *lexer) hexString() []byte {
+	var out []byte
+	var hi byte
+	haveHi := false
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		if c == '>' {
+			break
+		}
+		v, ok := hexValue(c)
+		if !ok {
+			continue
+		}
+		if haveHi {
+			out = append(out, hi<<4|v)
+			haveHi = false
+		} else {
+			hi = v
+			haveHi = true
+		}
+	}
+	if haveHi {
+		out = append(out, hi<<4)
+	}
+	return out
+}
+
+func hexValue(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// skipInlineImage advances past inline image data, which
+// follows the ID operator and ends at an EI operator.
+func (l *lexer) skipInlineImage() {
+	if l.pos < len(l.data) && isWhitespace(l.data[l.pos]) {
+		l.pos++
+	}
+	for l.pos+2 <= len(l.data) {
+		if l.data[l.pos] == 'E' && l.data[l.pos+1] == 'I' &&
+			(l.pos == 0 || isWhitespace(l.data[l.pos-1])) &&
+			(l.pos+2 == len(l.data) || isWhitespace(l.data[l.pos+2])) {
+			l.pos += 2
+			return
+		}
+		l.pos++
+	}
+	l.pos = len(l.data)
+}
@@ -0,0 +1,198 @@
+// Package pixeldiff compares rendered pages pixel by pixel
+// and highlights what changed, so reviewers can see the
+// visual effect of a change without opening both PDFs.
+package pixeldiff
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"slices"
+)
+
+const (
+	// DefaultThreshold is the luminance difference, out of
+	// 255, below which pixels count as equal. It absorbs
+	// anti-aliasing noise between renders.
+	DefaultThreshold = 48
+	// DefaultCellSize is the side, in pixels, of the grid
+	// cells changed pixels are grouped into.
+	DefaultCellSize = 12
+)
+
+var (
+	addedColor   = color.RGBA{R: 0x1a, G: 0x7f, B: 0x37, A: 0xff}
+	removedColor = color.RGBA{R: 0xcf, G: 0x22, B: 0x2e, A: 0xff}
+	regionColor  = color.RGBA{R: 0xbf, G: 0x87, B: 0x00, A: 0xff}
+)
+
+// Options tune the comparison.
+type Options struct {
+	Threshold uint8
+	CellSize  int
+}
+
+// Result is the comparison of one page.
+type Result struct {
+	// Overlay is the new page faded, with ink that appeared
+	// in green, ink that disappeared in red, and each
+	// changed region outlined.
+	Overlay *image.RGBA
+	// Regions are the bounding boxes of changed areas, in
+	// pixels, ordered top to bottom.
+	Regions       []image.Rectangle
+	ChangedPixels int
+	TotalPixels   int
+}
+
+// Changed reports whether any pixel differs.
+func (r Result) Changed() bool {
+	return r.ChangedPixels > 0
+}
+
+// ChangedFraction is the share of pixels that differ.
+func (r Result) ChangedFraction() float64 {
+	if r.TotalPixels == 0 {
+		return 0
+	}
+	return float64(r.ChangedPixels) / float64(r.TotalPixels)
+}
+
+// Diff compares base and head. Either may be nil for a page
+// that exists on only one side; it is treated as blank.
+// Pages of different sizes are compared over the larger.
+func Diff(base, head image.Image, opts Options) Result {
+	if opts.Threshold == 0 {
+		opts.Threshold = DefaultThreshold
+	}
+	if opts.CellSize <= 0 {
+		opts.CellSize = DefaultCellSize
+	}
+
+	bounds := image.Rectangle{}
+	for _, img := range []image.Image{base, head} {
+		if img != nil {
+			b := img.Bounds()
+			bounds = bounds.Union(image.Rect(0, 0, b.Dx(), b.Dy()))
+		}
+	}
+
+	baseGray := luminance(base, bounds)
+	headGray := luminance(head, bounds)
+
+	overlay := image.NewRGBA(bounds)
+	cols := (bounds.Dx() + opts.CellSize - 1) / opts.CellSize
+	rows := (bounds.Dy() + opts.CellSize - 1) / opts.CellSize
+	cells := make([]bool, cols*rows)
+
+	var changed int
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			i := y*bounds.Dx() + x
+			b, h := baseGray[i], headGray[i]
+			switch {
+			case int(b)-int(h) > int(opts.Threshold):
+				overlay.SetRGBA(x, y, addedColor)
+			case int(h)-int(b) > int(opts.Threshold):
+				overlay.SetRGBA(x, y, removedColor)
+			default:
+				// Fade unchanged ink so changes stand out.
+				v := 255 - (255-h)/4
+				overlay.SetRGBA(x, y, color.RGBA{R: v, G: v, B: v, A: 0xff})
+				continue
+			}
+			changed++
+			cells[(y/opts.CellSize)*cols+x/opts.CellSize] = true
+		}
+	}
+
+	regions := groupCells(cells, cols, rows, opts.CellSize, bounds)
+	for _, r := range regions {
+		outline(overlay, r.Inset(-2), regionColor)
+	}
+
+	return Result{
+		Overlay:       overlay,
+		Regions:       regions,
+		ChangedPixels: changed,
+		TotalPixels:   bounds.Dx() * bounds.Dy(),
+	}
+}
+
+// luminance returns img's grey levels over bounds, padding
+// with white where img is smaller or absent.
+func luminance(img image.Image, bounds image.Rectangle) []uint8 {
+	ret := make([]uint8, bounds.Dx()*bounds.Dy())
+	for i := range ret {
+		ret[i] = 0xff
+	}
+	if img == nil {
+		return ret
+	}
+	gray := image.NewGray(img.Bounds())
+	draw.Draw(gray, gray.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(gray, gray.Bounds(), img, img.Bounds().Min, draw.Over)
+	b := img.Bounds()
+	for y := 0; y < b.Dy(); y++ {
+		copy(ret[y*bounds.Dx():], gray.Pix[y*gray.Stride:y*gray.Stride+b.Dx()])
+	}
+	return ret
+}
+
+// groupCells merges changed cells that touch, including
+// diagonally and across one empty cell, so a reworded line
+// reads as one region rather than a region per glyph.
+func groupCells(cells []bool, cols, rows, size int, bounds image.Rectangle) []image.Rectangle {
+	seen := make([]bool, len(cells))
+	var regions []image.Rectangle
+	for start, on := range cells {
+		if !on || seen[start] {
+			continue
+		}
+		var r image.Rectangle
+		stack := []int{start}
+		seen[start] = true
+		for len(stack) > 0 {
+			c := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			cx, cy := c%cols, c/cols
+			r = r.Union(image.Rect(cx*size, cy*size, (cx+1)*size, (cy+1)*size))
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					nx, ny := cx+dx, cy+dy
+					if nx < 0 || ny < 0 || nx >= cols || ny >= rows {
+						continue
+					}
+					n := ny*cols + nx
+					if cells[n] && !seen[n] {
+						seen[n] = true
+						stack = append(stack, n)
+					}
+				}
+			}
+		}
+		regions = append(regions, r.Intersect(bounds))
+	}
+	slices.SortFunc(regions, func(a, b image.Rectangle) int {
+		if a.Min.Y != b.Min.Y {
+			return a.Min.Y - b.Min.Y
+		}
+		return a.Min.X - b.Min.X
+	})
+	return regions
+}
+
+func outline(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	r = r.Intersect(img.Bounds())
+	if r.Empty() {
+		return
+	}
+	for x := r.Min.X; x < r.Max.X; x++ {
+		img.SetRGBA(x, r.Min.Y, c)
+		img.SetRGBA(x, r.Max.Y-1, c)
+	}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		img.SetRGBA(r.Min.X, y, c)
+		img.SetRGBA(r.Max.X-1, y, c)
+	}
+}
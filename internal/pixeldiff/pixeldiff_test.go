@@ -0,0 +1,96 @@
+package pixeldiff
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func page(w, h int, blocks ...image.Rectangle) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	for _, b := range blocks {
+		draw.Draw(img, b, image.Black, image.Point{}, draw.Src)
+	}
+	return img
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	line := image.Rect(10, 10, 90, 18)
+	moved := image.Rect(10, 60, 90, 68)
+
+	tests := []struct {
+		name        string
+		base, head  image.Image
+		wantRegions []image.Rectangle
+	}{
+		{
+			name: "identical",
+			base: page(100, 100, line),
+			head: page(100, 100, line),
+		},
+		{
+			name:        "line added",
+			base:        page(100, 100, line),
+			head:        page(100, 100, line, moved),
+			wantRegions: []image.Rectangle{image.Rect(0, 60, 96, 72)},
+		},
+		{
+			name:        "line moved",
+			base:        page(100, 100, line),
+			head:        page(100, 100, moved),
+			wantRegions: []image.Rectangle{image.Rect(0, 0, 96, 24), image.Rect(0, 60, 96, 72)},
+		},
+		{
+			name:        "page only in head",
+			head:        page(100, 100, line),
+			wantRegions: []image.Rectangle{image.Rect(0, 0, 96, 24)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := Diff(tt.base, tt.head, Options{})
+			if len(got.Regions) != len(tt.wantRegions) {
+				t.Fatalf("Regions = %v, want %v", got.Regions, tt.wantRegions)
+			}
+			for i, r := range got.Regions {
+				if r != tt.wantRegions[i] {
+					t.Errorf("Regions[%d] = %v, want %v", i, r, tt.wantRegions[i])
+				}
+			}
+			if got.Changed() != (len(tt.wantRegions) > 0) {
+				t.Errorf("Changed() = %v, want %v", got.Changed(), len(tt.wantRegions) > 0)
+			}
+			if got.Overlay.Bounds() != image.Rect(0, 0, 100, 100) {
+				t.Errorf("Overlay bounds = %v", got.Overlay.Bounds())
+			}
+		})
+	}
+}
+
+func TestDiffOverlayColors(t *testing.T) {
+	t.Parallel()
+
+	base := page(40, 40, image.Rect(0, 0, 10, 10))
+	head := page(40, 40, image.Rect(20, 20, 30, 30))
+	got := Diff(base, head, Options{})
+
+	if c := got.Overlay.RGBAAt(25, 25); c != addedColor {
+		t.Errorf("added pixel = %v, want %v", c, addedColor)
+	}
+	if c := got.Overlay.RGBAAt(5, 5); c != removedColor {
+		t.Errorf("removed pixel = %v, want %v", c, removedColor)
+	}
+	if c := got.Overlay.RGBAAt(35, 5); c != (color.RGBA{R: 255, G: 255, B: 255, A: 255}) {
+		t.Errorf("blank pixel = %v, want white", c)
+	}
+	if got.ChangedPixels != 200 || got.TotalPixels != 1600 {
+		t.Errorf("changed = %d/%d, want 200/1600", got.ChangedPixels, got.TotalPixels)
+	}
+}
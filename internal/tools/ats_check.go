@@ -0,0 +1,8 @@
+package tools
+
+import "github.com/ansg191/job-temporal/internal/llm"
+
+var ATSCheckToolDesc = llm.ToolDefinition{
+	Name:        "ats_check",
+	Description: "Build the final PDF and check how an applicant tracking system extracts its text. Reports reading order across columns, lost or ligature-mangled characters, icons that extract as garbage, text inside images, and whether the name, email and phone extract. Returns JSON with a summary and issues rated high, medium or low",
+}
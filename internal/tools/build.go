@@ -4,5 +4,5 @@ import "github.com/ansg191/job-temporal/internal/llm"
 
 var BuildToolDesc = llm.ToolDefinition{
 	Name:        "build",
-	Description: "Perform a compilation build, returning errors if they occur",
+	Description: "Perform a compilation build. Returns diagnostics (file, line, column, hints and a source excerpt) for each error or warning, the page count against the page limit, and how full the last page is and how far content overflows the limit",
 }
@@ -41,6 +41,17 @@ var ReadFileToolDesc = llm.ToolDefinition{
 	},
 }
 
+// patchDescription explains the patch format to the model.
+const patchDescription = `A unified diff patch in git diff format. Structure:
+1. File headers: '--- a/file' and '+++ b/file'
+2. Hunk header: '@@ -OLD_START,OLD_COUNT +NEW_START,NEW_COUNT @@' where OLD_COUNT is the exact number of lines starting with ' ' or '-', and NEW_COUNT is the exact number of lines starting with ' ' or '+'.
+3. Hunk body: Lines prefixed with ' ' (context/unchanged), '-' (removed), or '+' (added).
+
+CRITICAL RULES:
+- The counts MUST match the actual line counts in the hunk body. For a single-line change, use '@@ -N +N @@' (omit count when it equals 1).
+- Lines prefixed with '-' MUST be copied EXACTLY (character-for-character, including all whitespace) from the file. Do NOT paraphrase or retype from memory. Any mismatch will cause the patch to fail.
+- Always read_file first and copy the exact line content for '-' lines.`
+
 type editToolArgs struct {
 	File    string `json:"file"`
 	Patch   string `json:"patch"`
@@ -74,16 +85,8 @@ var EditFileToolDesc = llm.ToolDefinition{
 				"description": "The path to the file to edit",
 			},
 			"patch": map[string]string{
-				"type": "string",
-				"description": `A unified diff patch in git diff format. Structure:
-1. File headers: '--- a/file' and '+++ b/file'
-2. Hunk header: '@@ -OLD_START,OLD_COUNT +NEW_START,NEW_COUNT @@' where OLD_COUNT is the exact number of lines starting with ' ' or '-', and NEW_COUNT is the exact number of lines starting with ' ' or '+'.
-3. Hunk body: Lines prefixed with ' ' (context/unchanged), '-' (removed), or '+' (added).
-
-CRITICAL RULES:
-- The counts MUST match the actual line counts in the hunk body. For a single-line change, use '@@ -N +N @@' (omit count when it equals 1).
-- Lines prefixed with '-' MUST be copied EXACTLY (character-for-character, including all whitespace) from the file. Do NOT paraphrase or retype from memory. Any mismatch will cause the patch to fail.
-- Always read_file first and copy the exact line content for '-' lines.`,
+				"type":        "string",
+				"description": patchDescription,
 			},
 			"message": map[string]string{
 				"type":        "string",
@@ -152,3 +155,69 @@ var EditLineToolDesc = llm.ToolDefinition{
 		"additionalProperties": false,
 	},
 }
+
+var ListFilesToolDesc = llm.ToolDefinition{
+	Name:        "list_files",
+	Description: "List the files in the current directory that can be read with read_file. Files that cannot be edited are marked read-only",
+}
+
+type editFilesToolArgs struct {
+	Edits []struct {
+		File  string `json:"file"`
+		Patch string `json:"patch"`
+	} `json:"edits"`
+	Message string `json:"message"`
+}
+
+// EditFilesToolParseArgs parses the arguments for the edit_files tool into
+// the existing req EditFilesRequest struct.
+func EditFilesToolParseArgs(args string, req *activities.EditFilesRequest) error {
+	var toolArgs editFilesToolArgs
+	err := json.Unmarshal([]byte(args), &toolArgs)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal edit_files tool args: %w", err)
+	}
+
+	req.Edits = make([]activities.FileEdit, len(toolArgs.Edits))
+	for i, edit := range toolArgs.Edits {
+		req.Edits[i] = activities.FileEdit{Path: edit.File, Patch: edit.Patch}
+	}
+	req.Message = toolArgs.Message
+	return nil
+}
+
+var EditFilesToolDesc = llm.ToolDefinition{
+	Name:        "edit_files",
+	Strict:      true,
+	Description: "Edit several files at once with one patch per file. Either every patch applies and they are committed together as one commit, or nothing is changed. Prefer this over repeated edit_file calls for related changes",
+	Parameters: map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"edits": map[string]any{
+				"type":        "array",
+				"description": "The patches to apply, in order",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"file": map[string]string{
+							"type":        "string",
+							"description": "The path to the file the patch edits",
+						},
+						"patch": map[string]string{
+							"type":        "string",
+							"description": patchDescription,
+						},
+					},
+					"required":             []string{"file", "patch"},
+					"additionalProperties": false,
+				},
+			},
+			"message": map[string]string{
+				"type":        "string",
+				"description": "The commit message for all of the edits",
+			},
+		},
+		"required":             []string{"edits", "message"},
+		"additionalProperties": false,
+	},
+}
@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ansg191/job-temporal/internal/github"
+	"github.com/ansg191/job-temporal/internal/llm"
+)
+
+type suggestChangeToolArgs struct {
+	File        string `json:"file"`
+	StartLine   int    `json:"start_line"`
+	EndLine     int    `json:"end_line"`
+	Replacement string `json:"replacement"`
+	Comment     string `json:"comment"`
+}
+
+// SuggestChangeToolParseArgs parses the arguments for the
+// suggest_change tool into a review suggestion.
+func SuggestChangeToolParseArgs(args string, req *github.ReviewSuggestion) error {
+	var toolArgs suggestChangeToolArgs
+	err := json.Unmarshal([]byte(args), &toolArgs)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal suggest_change tool args: %w", err)
+	}
+	if toolArgs.StartLine < 1 || toolArgs.EndLine < toolArgs.StartLine {
+		return fmt.Errorf("invalid line range %d-%d", toolArgs.StartLine, toolArgs.EndLine)
+	}
+
+	req.Path = toolArgs.File
+	req.StartLine = toolArgs.StartLine
+	req.EndLine = toolArgs.EndLine
+	req.Replacement = toolArgs.Replacement
+	req.Comment = toolArgs.Comment
+	return nil
+}
+
+var SuggestChangeToolDesc = llm.ToolDefinition{
+	Name:   "suggest_change",
+	Strict: true,
+	Description: "Suggest replacing lines of a file instead of editing it. Suggestions are posted to the pull request " +
+		"as a review for the reviewer to apply; nothing is committed. To insert lines, replace an adjacent line " +
+		"with itself plus the new lines.",
+	Parameters: map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"file": map[string]string{
+				"type":        "string",
+				"description": "The path to the file",
+			},
+			"start_line": map[string]string{
+				"type":        "integer",
+				"description": "First line to replace (1-indexed)",
+			},
+			"end_line": map[string]string{
+				"type":        "integer",
+				"description": "Last line to replace (1-indexed, inclusive)",
+			},
+			"replacement": map[string]string{
+				"type":        "string",
+				"description": "The lines replacing start_line to end_line. Use an empty string to delete them",
+			},
+			"comment": map[string]string{
+				"type":        "string",
+				"description": "A short explanation of the suggestion for the reviewer",
+			},
+		},
+		"required":             []string{"file", "start_line", "end_line", "replacement", "comment"},
+		"additionalProperties": false,
+	},
+}
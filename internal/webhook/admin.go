@@ -0,0 +1,107 @@
+package webhook
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ansg191/job-temporal/internal/database"
+)
+
+const adminDefaultListLimit = 50
+
+// AdminHandler lists the deliveries in a Handler's inbox and
+// replays failed or skipped ones:
+//
+//   - GET /admin/webhooks/deliveries?status=failed,skipped&limit=50
+//   - POST /admin/webhooks/deliveries/{id}/replay
+//
+// Requests must authenticate with the admin token as a
+// bearer token.
+type AdminHandler struct {
+	handler *Handler
+	token   []byte
+	mux     *http.ServeMux
+}
+
+// NewAdminHandler creates an admin handler for h's inbox.
+func NewAdminHandler(h *Handler, token string) *AdminHandler {
+	a := &AdminHandler{handler: h, token: []byte(token), mux: http.NewServeMux()}
+	a.mux.HandleFunc("GET /admin/webhooks/deliveries", a.listDeliveries)
+	a.mux.HandleFunc("POST /admin/webhooks/deliveries/{id}/replay", a.replayDelivery)
+	return a
+}
+
+func (a *AdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || len(a.token) == 0 || subtle.ConstantTimeCompare([]byte(token), a.token) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if a.handler.inbox == nil {
+		http.Error(w, "webhook inbox not configured", http.StatusNotFound)
+		return
+	}
+	a.mux.ServeHTTP(w, r)
+}
+
+func (a *AdminHandler) listDeliveries(w http.ResponseWriter, r *http.Request) {
+	statuses := []database.WebhookDeliveryStatus{database.WebhookDeliveryFailed, database.WebhookDeliverySkipped}
+	if v := r.URL.Query().Get("status"); v != "" {
+		statuses = nil
+		for name := range strings.SplitSeq(v, ",") {
+			status := database.WebhookDeliveryStatus(strings.TrimSpace(name))
+			switch status {
+			case database.WebhookDeliveryPending, database.WebhookDeliveryDelivered,
+				database.WebhookDeliverySkipped, database.WebhookDeliveryFailed:
+				statuses = append(statuses, status)
+			default:
+				http.Error(w, "unknown status: "+string(status), http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	limit := adminDefaultListLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	deliveries, err := a.handler.inbox.ListWebhookDeliveries(r.Context(), statuses, limit)
+	if err != nil {
+		slog.Error("failed to list deliveries", "error", err)
+		http.Error(w, "failed to list deliveries", http.StatusInternalServerError)
+		return
+	}
+	if deliveries == nil {
+		deliveries = []database.WebhookDelivery{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(deliveries)
+}
+
+func (a *AdminHandler) replayDelivery(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	err := a.handler.inbox.ReplayWebhookDelivery(r.Context(), id)
+	if errors.Is(err, database.ErrNotFound) {
+		http.Error(w, "no failed or skipped delivery "+id, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to replay delivery", "delivery", id, "error", err)
+		http.Error(w, "failed to replay delivery", http.StatusInternalServerError)
+		return
+	}
+
+	slog.Info("replaying delivery", "delivery", id)
+	a.handler.notify()
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -9,6 +9,7 @@ import (
 	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/google/go-github/v81/github"
 	"go.temporal.io/sdk/client"
@@ -21,6 +22,15 @@ type Handler struct {
 	temporalClient client.Client
 	webhookSecret  []byte
 	resolver       WorkflowIDResolver
+
+	installations    InstallationRegistry
+	requireInstalled bool
+
+	// inbox stores deliveries for a Dispatcher to signal,
+	// which wake tells of new ones. Without an inbox, events
+	// are signalled while GitHub waits.
+	inbox Inbox
+	wake  chan struct{}
 }
 
 // NewHandler creates a new webhook handler.
@@ -31,6 +41,9 @@ func NewHandler(tc client.Client, db database.Database, webhookSecret string) *H
 		resolver: &PostgresResolver{
 			db: db,
 		},
+		installations: db,
+		inbox:         db,
+		wake:          make(chan struct{}, 1),
 	}
 }
 
@@ -61,22 +74,54 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	signal, err := h.processEvent(eventType, event)
+	if h.inbox != nil {
+		h.enqueue(w, r, eventType, payload)
+		return
+	}
+
+	skipReason, err := h.dispatchEvent(r.Context(), eventType, event)
 	if err != nil {
-		slog.Info("skipping event", "type", eventType, "reason", err)
-		w.WriteHeader(http.StatusOK)
+		slog.Error("failed to dispatch event", "type", eventType, "error", err)
+		http.Error(w, "failed to dispatch event", http.StatusInternalServerError)
 		return
 	}
+	if skipReason != "" {
+		slog.Info("skipping event", "type", eventType, "reason", skipReason)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// dispatchEvent acts on a parsed event: it updates the
+// installation registry or signals the workflow the event is
+// for. A non-empty skipReason means the event needed no
+// action; an error means it should be retried.
+func (h *Handler) dispatchEvent(ctx context.Context, eventType string, event any) (skipReason string, err error) {
+	if handled, err := h.processInstallationEvent(ctx, event); handled {
+		if err != nil {
+			return "", fmt.Errorf("failed to update installations: %w", err)
+		}
+		slog.Info("installations updated", "type", eventType)
+		return "", nil
+	}
 
-	if err := h.sendSignal(r.Context(), signal); err != nil {
+	signal, err := h.processEvent(eventType, event)
+	if err != nil {
+		return err.Error(), nil
+	}
+
+	installed, err := h.repoInstalled(ctx, signal)
+	if err != nil {
+		return "", fmt.Errorf("failed to check installation: %w", err)
+	}
+	if !installed {
+		return fmt.Sprintf("repository %s/%s not installed", signal.Owner, signal.Repo), nil
+	}
+
+	if err := h.sendSignal(ctx, signal); err != nil {
 		if errors.Is(err, database.ErrNotFound) {
-			slog.Info("skipping event", "type", eventType, "reason", err)
-			w.WriteHeader(http.StatusOK)
-			return
+			return err.Error(), nil
 		}
-		slog.Error("failed to send signal", "error", err)
-		http.Error(w, "failed to send signal", http.StatusInternalServerError)
-		return
+		return "", fmt.Errorf("failed to send signal: %w", err)
 	}
 
 	slog.Info("signal sent",
@@ -85,7 +130,7 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		"repo", signal.Repo,
 		"pr", signal.PRNumber,
 	)
-	w.WriteHeader(http.StatusOK)
+	return "", nil
 }
 
 func (h *Handler) validateAndReadPayload(r *http.Request) ([]byte, error) {
@@ -110,6 +155,10 @@ func (h *Handler) processEvent(eventType string, event any) (*WebhookSignal, err
 		return h.processPullRequest(e)
 	case *github.PushEvent:
 		return h.processPush(e)
+	case *github.CheckRunEvent:
+		return h.processCheckRun(e)
+	case *github.CheckSuiteEvent:
+		return h.processCheckSuite(e)
 	default:
 		return nil, fmt.Errorf("unsupported event type: %s", eventType)
 	}
@@ -177,6 +226,7 @@ func (h *Handler) processPullRequestReviewComment(e *github.PullRequestReviewCom
 		AuthorLogin: e.GetComment().GetUser().GetLogin(),
 		ReviewState: "",
 		Timestamp:   e.GetComment().GetCreatedAt().Time,
+		CommentID:   e.GetComment().GetID(),
 		FilePath:    e.GetComment().GetPath(),
 		Line:        e.GetComment().GetLine(),
 		StartLine:   e.GetComment().GetStartLine(),
@@ -185,7 +235,9 @@ func (h *Handler) processPullRequestReviewComment(e *github.PullRequestReviewCom
 }
 
 func (h *Handler) processPullRequest(e *github.PullRequestEvent) (*WebhookSignal, error) {
-	if e.GetAction() != "closed" {
+	switch e.GetAction() {
+	case "closed", "labeled", "unlabeled":
+	default:
 		return nil, fmt.Errorf("ignoring action: %s", e.GetAction())
 	}
 
@@ -200,7 +252,8 @@ func (h *Handler) processPullRequest(e *github.PullRequestEvent) (*WebhookSignal
 		Body:        e.GetPullRequest().GetBody(),
 		AuthorLogin: e.GetPullRequest().GetUser().GetLogin(),
 		ReviewState: "",
-		Timestamp:   e.GetPullRequest().GetClosedAt().Time,
+		Label:       e.GetLabel().GetName(),
+		Timestamp:   e.GetPullRequest().GetUpdatedAt().Time,
 		FilePath:    "",
 		Line:        0,
 		StartLine:   0,
@@ -243,6 +296,7 @@ func (h *Handler) processPush(e *github.PushEvent) (*WebhookSignal, error) {
 		Body:        "",
 		AuthorLogin: author,
 		ReviewState: "",
+		HeadSHA:     e.GetAfter(),
 		Timestamp:   e.GetRepo().GetPushedAt().Time,
 		FilePath:    "",
 		Line:        0,
@@ -251,6 +305,47 @@ func (h *Handler) processPush(e *github.PushEvent) (*WebhookSignal, error) {
 	}, nil
 }
 
+// processCheckRun turns a re-run of one of our build checks
+// into a rebuild of the checked branch.
+func (h *Handler) processCheckRun(e *github.CheckRunEvent) (*WebhookSignal, error) {
+	if e.GetAction() != "rerequested" {
+		return nil, fmt.Errorf("ignoring action: %s", e.GetAction())
+	}
+	branch := e.GetCheckRun().GetExternalID()
+	if branch == "" {
+		branch = e.GetCheckRun().GetCheckSuite().GetHeadBranch()
+	}
+	return checkRerunSignal("check_run", e.GetRepo(), branch, e.GetSender().GetLogin())
+}
+
+// processCheckSuite turns a re-run of all checks into a
+// rebuild of the suite's branch.
+func (h *Handler) processCheckSuite(e *github.CheckSuiteEvent) (*WebhookSignal, error) {
+	if e.GetAction() != "rerequested" {
+		return nil, fmt.Errorf("ignoring action: %s", e.GetAction())
+	}
+	return checkRerunSignal("check_suite", e.GetRepo(), e.GetCheckSuite().GetHeadBranch(), e.GetSender().GetLogin())
+}
+
+func checkRerunSignal(eventType string, repo *github.Repository, branch, author string) (*WebhookSignal, error) {
+	if branch == "" {
+		return nil, fmt.Errorf("ignoring %s without a branch", eventType)
+	}
+	owner, name := repo.GetOwner().GetLogin(), repo.GetName()
+	if owner == "" || name == "" {
+		return nil, fmt.Errorf("missing repository owner/repo in %s event", eventType)
+	}
+	return &WebhookSignal{
+		Type:        eventType,
+		Action:      "rerequested",
+		Owner:       owner,
+		Repo:        name,
+		PRBranch:    branch,
+		AuthorLogin: author,
+		Timestamp:   time.Now(),
+	}, nil
+}
+
 func resolvePushRepoCoordinates(e *github.PushEvent) (string, string) {
 	repo := e.GetRepo().GetName()
 	owner := e.GetRepo().GetOwner().GetLogin()
@@ -285,8 +380,22 @@ func (h *Handler) sendSignal(ctx context.Context, signal *WebhookSignal) error {
 	return h.temporalClient.SignalWorkflow(ctx, workflowID, "", signalNameForSignal(signal), signal)
 }
 
+// isRebuildSignal reports whether signal asks for the
+// branch to be rebuilt: a push, or a re-run of its checks.
+func isRebuildSignal(signal *WebhookSignal) bool {
+	if signal == nil {
+		return false
+	}
+	switch signal.Type {
+	case "push", "check_run", "check_suite":
+		return true
+	default:
+		return false
+	}
+}
+
 func signalNameForSignal(signal *WebhookSignal) string {
-	if signal != nil && signal.Type == "push" {
+	if isRebuildSignal(signal) {
 		return RebuildSignal
 	}
 	return ReviewAgentSignal
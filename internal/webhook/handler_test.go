@@ -18,9 +18,11 @@ import (
 type staticResolver struct {
 	workflowID string
 	err        error
+	calls      int
 }
 
 func (s *staticResolver) Resolve(_ context.Context, _ *WebhookSignal) (string, error) {
+	s.calls++
 	if s.err != nil {
 		return "", s.err
 	}
@@ -33,6 +35,7 @@ func TestProcessPushExtractsBranch(t *testing.T) {
 	h := &Handler{}
 	e := &github.PushEvent{
 		Ref:     github.Ptr("refs/heads/feature/rebuild"),
+		After:   github.Ptr("abc123"),
 		Deleted: github.Ptr(false),
 		Repo: &github.PushEventRepository{
 			Name:  github.Ptr("resume"),
@@ -57,6 +60,9 @@ func TestProcessPushExtractsBranch(t *testing.T) {
 	if signal.AuthorLogin != "alice" {
 		t.Fatalf("expected sender author alice, got %q", signal.AuthorLogin)
 	}
+	if signal.HeadSHA != "abc123" {
+		t.Fatalf("expected head abc123, got %q", signal.HeadSHA)
+	}
 }
 
 func TestProcessPushFallsBackToFullNameWhenOwnerLoginMissing(t *testing.T) {
@@ -113,12 +119,62 @@ func TestProcessPushIgnoresDeletedRef(t *testing.T) {
 	}
 }
 
+func TestProcessCheckRunRerequested(t *testing.T) {
+	t.Parallel()
+
+	h := &Handler{}
+	repo := &github.Repository{
+		Name:  github.Ptr("resume"),
+		Owner: &github.User{Login: github.Ptr("ansg191")},
+	}
+	e := &github.CheckRunEvent{
+		Action: github.Ptr("rerequested"),
+		CheckRun: &github.CheckRun{
+			ExternalID: github.Ptr("acme/resume"),
+			CheckSuite: &github.CheckSuite{HeadBranch: github.Ptr("other")},
+		},
+		Repo:   repo,
+		Sender: &github.User{Login: github.Ptr("alice")},
+	}
+
+	signal, err := h.processCheckRun(e)
+	if err != nil {
+		t.Fatalf("processCheckRun returned error: %v", err)
+	}
+	if signal.Type != "check_run" || signal.PRBranch != "acme/resume" {
+		t.Fatalf("unexpected signal %q for branch %q", signal.Type, signal.PRBranch)
+	}
+	if signal.Owner != "ansg191" || signal.Repo != "resume" || signal.AuthorLogin != "alice" {
+		t.Fatalf("unexpected signal coordinates: %s/%s by %s", signal.Owner, signal.Repo, signal.AuthorLogin)
+	}
+
+	e.Action = github.Ptr("completed")
+	if _, err = h.processCheckRun(e); err == nil {
+		t.Fatal("expected error for completed check run, got nil")
+	}
+
+	suite, err := h.processCheckSuite(&github.CheckSuiteEvent{
+		Action:     github.Ptr("rerequested"),
+		CheckSuite: &github.CheckSuite{HeadBranch: github.Ptr("acme/letter")},
+		Repo:       repo,
+	})
+	if err != nil {
+		t.Fatalf("processCheckSuite returned error: %v", err)
+	}
+	if suite.PRBranch != "acme/letter" {
+		t.Fatalf("expected branch acme/letter, got %q", suite.PRBranch)
+	}
+}
+
 func TestSignalNameForSignal(t *testing.T) {
 	t.Parallel()
 
 	if got := signalNameForSignal(&WebhookSignal{Type: "push"}); got != RebuildSignal {
 		t.Fatalf("expected rebuild signal, got %q", got)
 	}
+	if got := signalNameForSignal(&WebhookSignal{Type: "check_run"}); got != RebuildSignal {
+		t.Fatalf("expected rebuild signal for check re-run, got %q", got)
+	}
 	if got := signalNameForSignal(&WebhookSignal{Type: "issue_comment"}); got != ReviewAgentSignal {
 		t.Fatalf("expected review signal, got %q", got)
 	}
@@ -0,0 +1,190 @@
+package webhook
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/go-github/v81/github"
+
+	"github.com/ansg191/job-temporal/internal/database"
+)
+
+const (
+	dispatchBatchSize    = 20
+	dispatchLease        = time.Minute
+	dispatchPollInterval = 5 * time.Second
+	dispatchMaxAttempts  = 8
+	dispatchBaseBackoff  = time.Second
+	dispatchMaxBackoff   = 10 * time.Minute
+
+	// deliveryRetention is how long dispatched deliveries
+	// are kept for replay and to dedupe redeliveries.
+	deliveryRetention = 14 * 24 * time.Hour
+	pruneInterval     = time.Hour
+)
+
+// Inbox stores webhook deliveries until they have been
+// dispatched. database.Database implements it.
+type Inbox interface {
+	InsertWebhookDelivery(ctx context.Context, deliveryID, eventType string, payload []byte) (bool, error)
+	ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]database.WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, deliveryID string, status database.WebhookDeliveryStatus, lastError string, nextAttemptAt time.Time) error
+	ListWebhookDeliveries(ctx context.Context, statuses []database.WebhookDeliveryStatus, limit int) ([]database.WebhookDelivery, error)
+	ReplayWebhookDelivery(ctx context.Context, deliveryID string) error
+	PruneWebhookDeliveries(ctx context.Context, before time.Time) (int64, error)
+}
+
+// WithInbox sets the inbox deliveries are stored in. A nil
+// inbox signals events while GitHub waits instead.
+func (h *Handler) WithInbox(inbox Inbox) *Handler {
+	h.inbox = inbox
+	return h
+}
+
+// enqueue stores a validated delivery for the Dispatcher.
+// Redeliveries of a stored delivery are dropped.
+func (h *Handler) enqueue(w http.ResponseWriter, r *http.Request, eventType string, payload []byte) {
+	deliveryID := github.DeliveryID(r)
+	if deliveryID == "" {
+		http.Error(w, "missing delivery ID", http.StatusBadRequest)
+		return
+	}
+
+	inserted, err := h.inbox.InsertWebhookDelivery(r.Context(), deliveryID, eventType, payload)
+	if err != nil {
+		slog.Error("failed to store delivery", "delivery", deliveryID, "error", err)
+		http.Error(w, "failed to store delivery", http.StatusInternalServerError)
+		return
+	}
+	if !inserted {
+		slog.Info("skipping duplicate delivery", "delivery", deliveryID, "type", eventType)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	h.notify()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// notify wakes the Dispatcher for a new delivery.
+func (h *Handler) notify() {
+	select {
+	case h.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Dispatcher signals the deliveries stored in a Handler's
+// inbox, retrying with backoff while Temporal or the
+// database are unavailable.
+type Dispatcher struct {
+	handler *Handler
+	now     func() time.Time
+}
+
+// NewDispatcher creates a dispatcher for h's inbox.
+func NewDispatcher(h *Handler) *Dispatcher {
+	return &Dispatcher{handler: h, now: time.Now}
+}
+
+// Run dispatches deliveries as they arrive until ctx is
+// done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(dispatchPollInterval)
+	defer ticker.Stop()
+
+	var lastPrune time.Time
+	for {
+		if err := d.dispatchDue(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("failed to claim deliveries", "error", err)
+		}
+		if d.now().Sub(lastPrune) >= pruneInterval {
+			d.prune(ctx)
+			lastPrune = d.now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.handler.wake:
+		}
+	}
+}
+
+// dispatchDue dispatches every delivery that is due.
+func (d *Dispatcher) dispatchDue(ctx context.Context) error {
+	inbox := d.handler.inbox
+	for {
+		deliveries, err := inbox.ClaimWebhookDeliveries(ctx, dispatchBatchSize, dispatchLease)
+		if err != nil {
+			return err
+		}
+		for _, delivery := range deliveries {
+			status, lastError, next := d.attempt(ctx, delivery)
+			err = inbox.UpdateWebhookDelivery(ctx, delivery.DeliveryID, status, lastError, next)
+			if err != nil {
+				// The delivery is attempted again once its
+				// lease runs out.
+				slog.Error("failed to record delivery", "delivery", delivery.DeliveryID, "error", err)
+			}
+		}
+		if len(deliveries) < dispatchBatchSize {
+			return nil
+		}
+	}
+}
+
+// attempt dispatches a delivery and decides its status, and
+// when it is retried if it is still pending.
+func (d *Dispatcher) attempt(ctx context.Context, delivery database.WebhookDelivery) (status database.WebhookDeliveryStatus, lastError string, next time.Time) {
+	now := d.now()
+	log := slog.With("delivery", delivery.DeliveryID, "type", delivery.EventType)
+
+	event, err := github.ParseWebHook(delivery.EventType, delivery.Payload)
+	if err != nil {
+		log.Info("skipping event", "reason", err)
+		return database.WebhookDeliverySkipped, "failed to parse webhook: " + err.Error(), now
+	}
+	skipReason, err := d.handler.dispatchEvent(ctx, delivery.EventType, event)
+	switch {
+	case err == nil && skipReason == "":
+		return database.WebhookDeliveryDelivered, "", now
+	case err == nil:
+		log.Info("skipping event", "reason", skipReason)
+		return database.WebhookDeliverySkipped, skipReason, now
+	case delivery.Attempts+1 >= dispatchMaxAttempts:
+		log.Error("giving up on delivery", "attempts", delivery.Attempts+1, "error", err)
+		return database.WebhookDeliveryFailed, err.Error(), now
+	default:
+		backoff := deliveryBackoff(delivery.Attempts)
+		log.Warn("failed to dispatch delivery, retrying", "attempts", delivery.Attempts+1, "backoff", backoff, "error", err)
+		return database.WebhookDeliveryPending, err.Error(), now.Add(backoff)
+	}
+}
+
+// deliveryBackoff is the wait before retrying a delivery
+// that has failed attempts times before.
+func deliveryBackoff(attempts int) time.Duration {
+	backoff := dispatchBaseBackoff
+	for range attempts {
+		backoff *= 2
+		if backoff >= dispatchMaxBackoff {
+			return dispatchMaxBackoff
+		}
+	}
+	return backoff
+}
+
+func (d *Dispatcher) prune(ctx context.Context) {
+	n, err := d.handler.inbox.PruneWebhookDeliveries(ctx, d.now().Add(-deliveryRetention))
+	if err != nil {
+		slog.Error("failed to prune deliveries", "error", err)
+		return
+	}
+	if n > 0 {
+		slog.Info("pruned deliveries", "count", n)
+	}
+}
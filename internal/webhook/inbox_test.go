@@ -0,0 +1,281 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ansg191/job-temporal/internal/database"
+)
+
+// memoryInbox is an Inbox of deliveries by ID.
+type memoryInbox map[string]*database.WebhookDelivery
+
+func (m memoryInbox) InsertWebhookDelivery(_ context.Context, deliveryID, eventType string, payload []byte) (bool, error) {
+	if _, ok := m[deliveryID]; ok {
+		return false, nil
+	}
+	m[deliveryID] = &database.WebhookDelivery{
+		DeliveryID: deliveryID,
+		EventType:  eventType,
+		Payload:    payload,
+		Status:     database.WebhookDeliveryPending,
+	}
+	return true, nil
+}
+
+func (m memoryInbox) ClaimWebhookDeliveries(context.Context, int, time.Duration) ([]database.WebhookDelivery, error) {
+	var ret []database.WebhookDelivery
+	for _, d := range m {
+		if d.Status == database.WebhookDeliveryPending {
+			ret = append(ret, *d)
+		}
+	}
+	return ret, nil
+}
+
+func (m memoryInbox) UpdateWebhookDelivery(_ context.Context, deliveryID string, status database.WebhookDeliveryStatus, lastError string, nextAttemptAt time.Time) error {
+	d := m[deliveryID]
+	d.Status = status
+	d.LastError = lastError
+	d.NextAttemptAt = nextAttemptAt
+	d.Attempts++
+	return nil
+}
+
+func (m memoryInbox) ListWebhookDeliveries(_ context.Context, statuses []database.WebhookDeliveryStatus, _ int) ([]database.WebhookDelivery, error) {
+	var ret []database.WebhookDelivery
+	for _, d := range m {
+		if slices.Contains(statuses, d.Status) {
+			ret = append(ret, *d)
+		}
+	}
+	slices.SortFunc(ret, func(a, b database.WebhookDelivery) int {
+		return strings.Compare(a.DeliveryID, b.DeliveryID)
+	})
+	return ret, nil
+}
+
+func (m memoryInbox) ReplayWebhookDelivery(_ context.Context, deliveryID string) error {
+	d, ok := m[deliveryID]
+	if !ok || (d.Status != database.WebhookDeliveryFailed && d.Status != database.WebhookDeliverySkipped) {
+		return database.ErrNotFound
+	}
+	d.Status = database.WebhookDeliveryPending
+	d.Attempts = 0
+	return nil
+}
+
+func (m memoryInbox) PruneWebhookDeliveries(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+const testPushPayload = `{"ref":"refs/heads/feature/rebuild","deleted":false,"repository":{"name":"resume","owner":{"login":"ansg191"}},"sender":{"login":"alice"}}`
+
+func TestServeHTTPStoresDeliveriesOnce(t *testing.T) {
+	t.Parallel()
+
+	secret := "webhook-secret"
+	inbox := memoryInbox{}
+	resolver := &staticResolver{err: database.ErrNotFound}
+	h := NewHandler(nil, nil, secret).WithResolver(resolver).WithInbox(inbox)
+
+	deliver := func(deliveryID string) int {
+		payload := []byte(testPushPayload)
+		req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-GitHub-Event", "push")
+		req.Header.Set("X-Hub-Signature-256", signWebhookPayload(secret, payload))
+		if deliveryID != "" {
+			req.Header.Set("X-GitHub-Delivery", deliveryID)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	if code := deliver("d1"); code != http.StatusAccepted {
+		t.Fatalf("first delivery: expected status 202, got %d", code)
+	}
+	if code := deliver("d1"); code != http.StatusOK {
+		t.Fatalf("redelivery: expected status 200, got %d", code)
+	}
+	if code := deliver(""); code != http.StatusBadRequest {
+		t.Fatalf("delivery without ID: expected status 400, got %d", code)
+	}
+
+	if len(inbox) != 1 || inbox["d1"].EventType != "push" || string(inbox["d1"].Payload) != testPushPayload {
+		t.Fatalf("inbox = %v, want the push delivery d1", inbox)
+	}
+	if resolver.calls != 0 {
+		t.Error("signalled while GitHub waited, want the dispatcher to")
+	}
+}
+
+func TestDispatcherAttempt(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	installation := `{"action":"created","installation":{"id":7},"repositories":[{"name":"resume","full_name":"ansg191/resume"}]}`
+
+	tests := []struct {
+		name        string
+		eventType   string
+		payload     string
+		attempts    int
+		resolverErr error
+		wantStatus  database.WebhookDeliveryStatus
+		wantNext    time.Time
+	}{
+		{
+			name:       "delivered",
+			eventType:  "installation",
+			payload:    installation,
+			wantStatus: database.WebhookDeliveryDelivered,
+			wantNext:   now,
+		},
+		{
+			name:        "no workflow is skipped",
+			eventType:   "push",
+			payload:     testPushPayload,
+			resolverErr: database.ErrNotFound,
+			wantStatus:  database.WebhookDeliverySkipped,
+			wantNext:    now,
+		},
+		{
+			name:       "unparseable is skipped",
+			eventType:  "push",
+			payload:    `{`,
+			wantStatus: database.WebhookDeliverySkipped,
+			wantNext:   now,
+		},
+		{
+			name:        "error is retried with backoff",
+			eventType:   "push",
+			payload:     testPushPayload,
+			attempts:    2,
+			resolverErr: errors.New("connection refused"),
+			wantStatus:  database.WebhookDeliveryPending,
+			wantNext:    now.Add(4 * time.Second),
+		},
+		{
+			name:        "error on last attempt fails",
+			eventType:   "push",
+			payload:     testPushPayload,
+			attempts:    dispatchMaxAttempts - 1,
+			resolverErr: errors.New("connection refused"),
+			wantStatus:  database.WebhookDeliveryFailed,
+			wantNext:    now,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := NewHandler(nil, nil, "").
+				WithResolver(&staticResolver{err: tt.resolverErr}).
+				WithInstallations(memoryRegistry{})
+			d := NewDispatcher(h)
+			d.now = func() time.Time { return now }
+
+			status, lastError, next := d.attempt(context.Background(), database.WebhookDelivery{
+				DeliveryID: "d1",
+				EventType:  tt.eventType,
+				Payload:    []byte(tt.payload),
+				Attempts:   tt.attempts,
+			})
+			if status != tt.wantStatus {
+				t.Errorf("status = %q, want %q (error %q)", status, tt.wantStatus, lastError)
+			}
+			if !next.Equal(tt.wantNext) {
+				t.Errorf("next attempt = %v, want %v", next, tt.wantNext)
+			}
+			if status != database.WebhookDeliveryDelivered && lastError == "" {
+				t.Error("lastError is empty, want the reason")
+			}
+		})
+	}
+}
+
+func TestDeliveryBackoff(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 0, want: time.Second},
+		{attempts: 3, want: 8 * time.Second},
+		{attempts: 9, want: 512 * time.Second},
+		{attempts: 10, want: dispatchMaxBackoff},
+		{attempts: 100, want: dispatchMaxBackoff},
+	}
+	for _, tt := range tests {
+		if got := deliveryBackoff(tt.attempts); got != tt.want {
+			t.Errorf("deliveryBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestAdminHandler(t *testing.T) {
+	t.Parallel()
+
+	inbox := memoryInbox{
+		"failed":    {DeliveryID: "failed", EventType: "push", Status: database.WebhookDeliveryFailed},
+		"skipped":   {DeliveryID: "skipped", EventType: "issue_comment", Status: database.WebhookDeliverySkipped},
+		"delivered": {DeliveryID: "delivered", EventType: "push", Status: database.WebhookDeliveryDelivered},
+	}
+	admin := NewAdminHandler(NewHandler(nil, nil, "").WithInbox(inbox), "admin-token")
+
+	serve := func(method, target, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		admin.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := serve(http.MethodGet, "/admin/webhooks/deliveries", "wrong"); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong token: expected status 401, got %d", rr.Code)
+	}
+
+	rr := serve(http.MethodGet, "/admin/webhooks/deliveries", "admin-token")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("list: expected status 200, got %d", rr.Code)
+	}
+	var listed []database.WebhookDelivery
+	if err := json.NewDecoder(rr.Body).Decode(&listed); err != nil {
+		t.Fatalf("decode list: %v", err)
+	}
+	var ids []string
+	for _, d := range listed {
+		ids = append(ids, d.DeliveryID)
+	}
+	if want := []string{"failed", "skipped"}; !slices.Equal(ids, want) {
+		t.Errorf("listed %v, want %v", ids, want)
+	}
+
+	if rr := serve(http.MethodGet, "/admin/webhooks/deliveries?status=bogus", "admin-token"); rr.Code != http.StatusBadRequest {
+		t.Errorf("unknown status: expected status 400, got %d", rr.Code)
+	}
+
+	if rr := serve(http.MethodPost, "/admin/webhooks/deliveries/failed/replay", "admin-token"); rr.Code != http.StatusAccepted {
+		t.Fatalf("replay: expected status 202, got %d", rr.Code)
+	}
+	if got := inbox["failed"].Status; got != database.WebhookDeliveryPending {
+		t.Errorf("replayed delivery status = %q, want pending", got)
+	}
+	if rr := serve(http.MethodPost, "/admin/webhooks/deliveries/delivered/replay", "admin-token"); rr.Code != http.StatusNotFound {
+		t.Errorf("replay of delivered: expected status 404, got %d", rr.Code)
+	}
+}
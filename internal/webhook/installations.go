@@ -0,0 +1,91 @@
+package webhook
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/go-github/v81/github"
+
+	"github.com/ansg191/job-temporal/internal/database"
+)
+
+// InstallationRegistry records the repositories each GitHub
+// App installation can access. database.Database implements
+// it.
+type InstallationRegistry interface {
+	AddInstallationRepos(ctx context.Context, installationID int64, repos []database.InstallationRepo) error
+	RemoveInstallationRepos(ctx context.Context, installationID int64, repos []database.InstallationRepo) error
+	DeleteInstallation(ctx context.Context, installationID int64) error
+	IsRepoInstalled(ctx context.Context, owner, repo string) (bool, error)
+}
+
+// WithInstallations sets the registry kept up to date from
+// installation events.
+func (h *Handler) WithInstallations(registry InstallationRegistry) *Handler {
+	h.installations = registry
+	return h
+}
+
+// RequireInstalledRepos skips events from repositories the
+// installation registry does not list. The registry must
+// already hold every installation's repositories.
+func (h *Handler) RequireInstalledRepos() *Handler {
+	h.requireInstalled = true
+	return h
+}
+
+// processInstallationEvent updates the installation registry
+// from installation and installation_repositories events.
+// handled is false for any other event.
+func (h *Handler) processInstallationEvent(ctx context.Context, event any) (handled bool, err error) {
+	switch e := event.(type) {
+	case *github.InstallationEvent:
+		if h.installations == nil {
+			return true, nil
+		}
+		id := e.GetInstallation().GetID()
+		switch e.GetAction() {
+		case "created":
+			return true, h.installations.AddInstallationRepos(ctx, id, installationRepos(e.Repositories))
+		case "deleted":
+			return true, h.installations.DeleteInstallation(ctx, id)
+		default:
+			return true, nil
+		}
+	case *github.InstallationRepositoriesEvent:
+		if h.installations == nil {
+			return true, nil
+		}
+		id := e.GetInstallation().GetID()
+		if err = h.installations.AddInstallationRepos(ctx, id, installationRepos(e.RepositoriesAdded)); err != nil {
+			return true, err
+		}
+		return true, h.installations.RemoveInstallationRepos(ctx, id, installationRepos(e.RepositoriesRemoved))
+	default:
+		return false, nil
+	}
+}
+
+// installationRepos converts the repositories of an
+// installation event, which only carry their full names.
+func installationRepos(repos []*github.Repository) []database.InstallationRepo {
+	ret := make([]database.InstallationRepo, 0, len(repos))
+	for _, r := range repos {
+		owner, name, ok := strings.Cut(r.GetFullName(), "/")
+		if !ok {
+			continue
+		}
+		ret = append(ret, database.InstallationRepo{Owner: owner, Repo: name})
+	}
+	return ret
+}
+
+// repoInstalled reports whether events from signal's
+// repository are accepted: always, unless installed
+// repositories are required.
+func (h *Handler) repoInstalled(ctx context.Context, signal *WebhookSignal) (bool, error) {
+	if !h.requireInstalled || h.installations == nil {
+		return true, nil
+	}
+	return h.installations.IsRepoInstalled(ctx, signal.Owner, signal.Repo)
+}
@@ -0,0 +1,141 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/ansg191/job-temporal/internal/database"
+)
+
+// memoryRegistry is an InstallationRegistry of owner/repo
+// to installation ID.
+type memoryRegistry map[database.InstallationRepo]int64
+
+func (m memoryRegistry) AddInstallationRepos(_ context.Context, id int64, repos []database.InstallationRepo) error {
+	for _, r := range repos {
+		m[r] = id
+	}
+	return nil
+}
+
+func (m memoryRegistry) RemoveInstallationRepos(_ context.Context, id int64, repos []database.InstallationRepo) error {
+	for _, r := range repos {
+		if m[r] == id {
+			delete(m, r)
+		}
+	}
+	return nil
+}
+
+func (m memoryRegistry) DeleteInstallation(_ context.Context, id int64) error {
+	for r, installation := range m {
+		if installation == id {
+			delete(m, r)
+		}
+	}
+	return nil
+}
+
+func (m memoryRegistry) IsRepoInstalled(_ context.Context, owner, repo string) (bool, error) {
+	_, ok := m[database.InstallationRepo{Owner: owner, Repo: repo}]
+	return ok, nil
+}
+
+func (m memoryRegistry) repos() []string {
+	var ret []string
+	for r := range m {
+		ret = append(ret, r.Owner+"/"+r.Repo)
+	}
+	slices.Sort(ret)
+	return ret
+}
+
+func serveWebhook(t *testing.T, h *Handler, secret, eventType string, payload []byte) int {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Event", eventType)
+	req.Header.Set("X-Hub-Signature-256", signWebhookPayload(secret, payload))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return rr.Code
+}
+
+func TestInstallationEventsUpdateRegistry(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		eventType string
+		payload   string
+		want      []string
+	}{
+		{
+			name:      "installation created",
+			eventType: "installation",
+			payload:   `{"action":"created","installation":{"id":7},"repositories":[{"name":"resume","full_name":"ansg191/resume"},{"name":"jobs","full_name":"ansg191/jobs"}]}`,
+			want:      []string{"acme/site", "ansg191/jobs", "ansg191/resume", "other/repo"},
+		},
+		{
+			name:      "installation deleted",
+			eventType: "installation",
+			payload:   `{"action":"deleted","installation":{"id":1}}`,
+			want:      []string{"other/repo"},
+		},
+		{
+			name:      "installation suspended",
+			eventType: "installation",
+			payload:   `{"action":"suspend","installation":{"id":1}}`,
+			want:      []string{"acme/site", "other/repo"},
+		},
+		{
+			name:      "repositories added and removed",
+			eventType: "installation_repositories",
+			payload:   `{"action":"added","installation":{"id":1},"repositories_added":[{"name":"docs","full_name":"acme/docs"}],"repositories_removed":[{"name":"site","full_name":"acme/site"}]}`,
+			want:      []string{"acme/docs", "other/repo"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			registry := memoryRegistry{
+				{Owner: "acme", Repo: "site"}:  1,
+				{Owner: "other", Repo: "repo"}: 2,
+			}
+			secret := "webhook-secret"
+			h := NewHandler(nil, nil, secret).WithInstallations(registry)
+
+			if code := serveWebhook(t, h, secret, tt.eventType, []byte(tt.payload)); code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", code)
+			}
+			if got := registry.repos(); !slices.Equal(got, tt.want) {
+				t.Errorf("registry = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServeHTTPSkipsUninstalledRepos(t *testing.T) {
+	t.Parallel()
+
+	secret := "webhook-secret"
+	payload := []byte(`{"ref":"refs/heads/feature/rebuild","deleted":false,"repository":{"name":"resume","owner":{"login":"ansg191"}},"sender":{"login":"alice"}}`)
+	resolver := &staticResolver{err: database.ErrNotFound}
+	h := NewHandler(nil, nil, secret).
+		WithResolver(resolver).
+		WithInstallations(memoryRegistry{{Owner: "ansg191", Repo: "jobs"}: 1}).
+		RequireInstalledRepos()
+
+	if code := serveWebhook(t, h, secret, "push", payload); code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", code)
+	}
+	if resolver.calls != 0 {
+		t.Errorf("resolved a workflow for an uninstalled repo")
+	}
+}
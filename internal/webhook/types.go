@@ -13,8 +13,8 @@ const ReviewAgentSignal = "review-agent-signal"
 const RebuildSignal = "rebuild-signal"
 
 type WebhookSignal struct {
-	Type        string // "pull_request_review", "issue_comment", "pull_request_review_comment"
-	Action      string // "submitted", "created", etc.
+	Type        string // "pull_request_review", "issue_comment", "pull_request_review_comment", "push", "check_run", "check_suite"
+	Action      string // "submitted", "created", "rerequested", etc.
 	Owner       string
 	Repo        string
 	PRNumber    int
@@ -23,9 +23,12 @@ type WebhookSignal struct {
 	Body        string
 	AuthorLogin string
 	ReviewState string // For reviews: "approved", "changes_requested", "commented"
+	Label       string // For "labeled" and "unlabeled" PR events: the label's name
+	HeadSHA     string // For pushes: the commit the branch now points at
 	Timestamp   time.Time
 
 	// Location fields for review comments
+	CommentID int64  // ID of the review comment, to reply in its thread
 	FilePath  string // Path to the file being commented on
 	Line      int    // Line number in the file
 	StartLine int    // Start line for multi-line comments (0 if single line)
@@ -49,10 +52,8 @@ type PostgresResolver struct {
 }
 
 func (p *PostgresResolver) Resolve(ctx context.Context, signal *WebhookSignal) (string, error) {
-	switch signal.Type {
-	case "push":
+	if isRebuildSignal(signal) {
 		return p.db.GetBranchWorkflowId(ctx, signal.Owner, signal.Repo, signal.PRBranch)
-	default:
-		return p.db.GetPrWorkflowId(ctx, signal.Owner, signal.Repo, signal.PRNumber)
 	}
+	return p.db.GetPrWorkflowId(ctx, signal.Owner, signal.Repo, signal.PRNumber)
 }
@@ -0,0 +1,38 @@
+package agents
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/ansg191/job-temporal/internal/activities"
+)
+
+func runATSCheckGate(
+	ctx workflow.Context,
+	req activities.ATSCheckRequest,
+) (*activities.ATSCheckOutput, string, error) {
+	checkCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 5 * time.Minute,
+	})
+	var checkJSON string
+	err := workflow.ExecuteActivity(checkCtx, activities.ATSCheck, req).Get(ctx, &checkJSON)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var output activities.ATSCheckOutput
+	if err = json.Unmarshal([]byte(checkJSON), &output); err != nil {
+		return nil, "", fmt.Errorf("failed to parse ats check output: %w", err)
+	}
+	return &output, checkJSON, nil
+}
+
+func shouldBlockATSIssues(output *activities.ATSCheckOutput, attempt int) (bool, string) {
+	if output == nil {
+		return false, ""
+	}
+	return shouldBlockReviewBySeverity(output.Issues, attempt)
+}
@@ -33,6 +33,14 @@ type BuilderAgentRequest struct {
 	BranchName   string      `json:"branch_name"`
 	TargetBranch string      `json:"target_branch"`
 	Job          string      `json:"job"`
+	// ATSGate blocks completion until the built PDF passes
+	// the ATS text extraction check.
+	ATSGate bool `json:"ats_gate"`
+	// PDFStandard is passed to the uploaded PDF artifact.
+	PDFStandard string `json:"pdf_standard,omitempty"`
+	// Exports lists extra artifact formats to upload beside
+	// the PDF, e.g. "txt", "html" or "docx".
+	Exports []string `json:"exports,omitempty"`
 }
 
 func BuilderAgent(ctx workflow.Context, req BuilderAgentRequest) (int, error) {
@@ -41,7 +49,7 @@ func BuilderAgent(ctx workflow.Context, req BuilderAgentRequest) (int, error) {
 		return 0, fmt.Errorf("invalid build target: %d", req.BuildTarget)
 	}
 
-	agentCfg, err := loadAgentConfig(ctx, agentName)
+	agentCfg, err := loadBuilderAgentConfig(ctx, agentName, req.Builder)
 	if err != nil {
 		return 0, err
 	}
@@ -92,12 +100,22 @@ func BuilderAgent(ctx workflow.Context, req BuilderAgentRequest) (int, error) {
 	enableLayoutReview := req.BuildTarget == BuildTargetResume
 	const layoutReviewMaxRuns = 5
 
+	atsCheckRun := 0
+	const atsCheckMaxRuns = 3
+
+	lintRun := 0
+
 	letterReviewRun := 0
 	enableLetterReview := req.BuildTarget == BuildTargetCoverLetter
 	const letterReviewMaxRuns = 5
 
+	files, err := newFileTools(req.ClientOptions, req.BranchName, req.Builder, req.BuildTarget)
+	if err != nil {
+		return 0, err
+	}
 	dispatcher := &builderDispatcher{
 		aiTools:     aiTools,
+		files:       files,
 		ghOpts:      req.ClientOptions,
 		branchName:  req.BranchName,
 		builder:     req.Builder,
@@ -112,7 +130,7 @@ func BuilderAgent(ctx workflow.Context, req BuilderAgentRequest) (int, error) {
 			activities.AIRequest{
 				Model:        agentCfg.Model,
 				Input:        messages,
-				Tools:        availableBuilderTools(aiTools),
+				Tools:        availableBuilderTools(aiTools, files),
 				Temperature:  temperatureOpt(agentCfg.Temperature),
 				Conversation: conversation,
 			},
@@ -133,7 +151,7 @@ func BuilderAgent(ctx workflow.Context, req BuilderAgentRequest) (int, error) {
 
 		if enableLayoutReview && layoutReviewRun < layoutReviewMaxRuns {
 			// Layout review gate (resume only)
-			file, err := resolveBuildTargetFile(req.BuildTarget)
+			file, err := resolveBuildTargetFile(req.Builder, req.BuildTarget)
 			if err != nil {
 				return 0, err
 			}
@@ -151,14 +169,8 @@ func BuilderAgent(ctx workflow.Context, req BuilderAgentRequest) (int, error) {
 				layoutReviewReq,
 			)
 			if err != nil {
-				var appErr *temporal.ApplicationError
-				if errors.As(err, &appErr) && appErr.Type() == activities.ErrTypeBuildFailed {
-					var details []string
-					_ = appErr.Details(&details)
-					messages = []llm.Message{userMessage(fmt.Sprintf(
-						"Build failed, fix and try again:\n%s",
-						strings.Join(details, "\n"),
-					))}
+				if msg, ok := buildFailedMessage(err); ok {
+					messages = []llm.Message{msg}
 					continue
 				}
 				return 0, err
@@ -171,6 +183,59 @@ func BuilderAgent(ctx workflow.Context, req BuilderAgentRequest) (int, error) {
 			}
 		}
 
+		if lintRun < lintMaxRuns {
+			file, err := resolveBuildTargetFile(req.Builder, req.BuildTarget)
+			if err != nil {
+				return 0, err
+			}
+			lintRun++
+			lintResult, lintJSON, err := runLintGate(ctx, activities.LintRequest{
+				ClientOptions: req.ClientOptions,
+				Branch:        req.BranchName,
+				BaseBranch:    req.TargetBranch,
+				Builder:       req.Builder,
+				File:          file,
+			})
+			if err != nil {
+				if msg, ok := buildFailedMessage(err); ok {
+					messages = []llm.Message{msg}
+					continue
+				}
+				return 0, err
+			}
+			if block, reason := shouldBlockLintFindings(lintResult, lintRun); block {
+				messages = []llm.Message{userMessage(lintBlockedMessage(reason, lintJSON))}
+				continue
+			}
+		}
+
+		if req.ATSGate && atsCheckRun < atsCheckMaxRuns {
+			file, err := resolveBuildTargetFile(req.Builder, req.BuildTarget)
+			if err != nil {
+				return 0, err
+			}
+			atsCheckRun++
+			atsCheckResult, atsCheckJSON, err := runATSCheckGate(ctx, activities.ATSCheckRequest{
+				ClientOptions: req.ClientOptions,
+				Branch:        req.BranchName,
+				Builder:       req.Builder,
+				File:          file,
+			})
+			if err != nil {
+				if msg, ok := buildFailedMessage(err); ok {
+					messages = []llm.Message{msg}
+					continue
+				}
+				return 0, err
+			}
+			if block, reason := shouldBlockATSIssues(atsCheckResult, atsCheckRun); block {
+				messages = []llm.Message{userMessage(
+					"ATS check gate blocked completion (" + reason + "). Fix how the PDF text extracts and rebuild.\nCurrent findings JSON:\n" + atsCheckJSON,
+				)}
+				continue
+			}
+		}
+
 		if enableLetterReview && letterReviewRun < letterReviewMaxRuns {
 			letterReviewRun++
 			letterReviewReq := activities.ReviewLetterContentRequest{
@@ -194,6 +259,11 @@ func BuilderAgent(ctx workflow.Context, req BuilderAgentRequest) (int, error) {
 			}
 		}
 
+		var skipped extraFailures
+		if err = squashBranch(ctx, req.ClientOptions, req.BranchName, req.TargetBranch); err != nil {
+			skipped.add(ctx, "commit regrouping", err)
+		}
+
 		// Activate PR Builder workflow
 		var prNum int
 		err = workflow.ExecuteChildWorkflow(
@@ -208,6 +278,9 @@ func BuilderAgent(ctx workflow.Context, req BuilderAgentRequest) (int, error) {
 				Job:           req.Job,
 				Builder:       req.Builder,
 				BuildTarget:   req.BuildTarget,
+				PDFStandard:   req.PDFStandard,
+				Exports:       req.Exports,
+				Skipped:       skipped,
 			},
 		).Get(ctx, &prNum)
 		if err != nil {
@@ -218,8 +291,24 @@ func BuilderAgent(ctx workflow.Context, req BuilderAgentRequest) (int, error) {
 	}
 }
 
+// buildFailedMessage turns an ErrTypeBuildFailed error from
+// a gate into a message asking the agent to fix the build.
+func buildFailedMessage(err error) (llm.Message, bool) {
+	var appErr *temporal.ApplicationError
+	if !errors.As(err, &appErr) || appErr.Type() != activities.ErrTypeBuildFailed {
+		return llm.Message{}, false
+	}
+	var details []string
+	_ = appErr.Details(&details)
+	return userMessage(fmt.Sprintf(
+		"Build failed, fix and try again:\n%s",
+		strings.Join(details, "\n"),
+	)), true
+}
+
 type builderDispatcher struct {
 	aiTools     []llm.ToolDefinition
+	files       *fileTools
 	ghOpts      github.ClientOptions
 	branchName  string
 	builder     string
@@ -227,6 +316,9 @@ type builderDispatcher struct {
 }
 
 func (d *builderDispatcher) Dispatch(ctx workflow.Context, call llm.ToolCall) (workflow.Future, error) {
+	if fut, ok, err := d.files.dispatch(ctx, call); ok {
+		return fut, err
+	}
 	if slices.ContainsFunc(d.aiTools, func(param llm.ToolDefinition) bool {
 		return param.Name == call.Name
 	}) {
@@ -235,7 +327,7 @@ func (d *builderDispatcher) Dispatch(ctx workflow.Context, call llm.ToolCall) (w
 
 	switch call.Name {
 	case tools.BuildToolDesc.Name:
-		file, err := resolveBuildTargetFile(d.buildTarget)
+		file, err := resolveBuildTargetFile(d.builder, d.buildTarget)
 		if err != nil {
 			return nil, err
 		}
@@ -247,14 +339,28 @@ func (d *builderDispatcher) Dispatch(ctx workflow.Context, call llm.ToolCall) (w
 			File:          file,
 		}
 		return workflow.ExecuteActivity(ctx, activities.Build, req), nil
+	case tools.ATSCheckToolDesc.Name:
+		file, err := resolveBuildTargetFile(d.builder, d.buildTarget)
+		if err != nil {
+			return nil, err
+		}
+
+		req := activities.ATSCheckRequest{
+			ClientOptions: d.ghOpts,
+			Branch:        d.branchName,
+			Builder:       d.builder,
+			File:          file,
+		}
+		return workflow.ExecuteActivity(ctx, activities.ATSCheck, req), nil
 	default:
 		return nil, fmt.Errorf("unsupported tool: %s", call.Name)
 	}
 }
 
-func availableBuilderTools(aiTools []llm.ToolDefinition) []llm.ToolDefinition {
+func availableBuilderTools(aiTools []llm.ToolDefinition, files *fileTools) []llm.ToolDefinition {
 	ret := append([]llm.ToolDefinition{}, aiTools...)
-	ret = append(ret, tools.BuildToolDesc)
+	ret = append(ret, files.definitions()...)
+	ret = append(ret, tools.BuildToolDesc, tools.ATSCheckToolDesc)
 	return ret
 }
 
@@ -0,0 +1,62 @@
+package agents
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/ansg191/job-temporal/internal/activities"
+	"github.com/ansg191/job-temporal/internal/github"
+)
+
+const (
+	prExportsStart      = "<!-- exports:start -->"
+	prExportsEnd        = "<!-- exports:end -->"
+	prExportsLinePrefix = "Other formats:"
+)
+
+// runExports uploads the requested export formats next to
+// pdfURL, which was built with pdfStandard.
+func runExports(ctx workflow.Context, repo github.ClientOptions, branch, builderName string, target BuildTarget, formats []string, pdfURL, pdfStandard string) ([]activities.ExportArtifact, error) {
+	if len(formats) == 0 || pdfURL == "" {
+		return nil, nil
+	}
+	file, err := resolveBuildTargetFile(builderName, target)
+	if err != nil {
+		return nil, err
+	}
+
+	exportCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 5 * time.Minute,
+	})
+	var artifacts []activities.ExportArtifact
+	err = workflow.ExecuteActivity(exportCtx, activities.UploadExports, activities.UploadExportsRequest{
+		ClientOptions: repo,
+		Branch:        branch,
+		Builder:       builderName,
+		File:          file,
+		Formats:       formats,
+		PDFURL:        pdfURL,
+		PDFStandard:   pdfStandard,
+	}).Get(ctx, &artifacts)
+	return artifacts, err
+}
+
+// rewriteExports replaces the export links in a PR body,
+// placing them right after the artifact line. With no
+// artifacts the links are removed, since replacing the PDF
+// deletes the exports beside it.
+func rewriteExports(body string, artifacts []activities.ExportArtifact) string {
+	if len(artifacts) == 0 {
+		return rewritePRBlock(body, prExportsStart, prExportsEnd, nil)
+	}
+	links := make([]string, len(artifacts))
+	for i, a := range artifacts {
+		links[i] = fmt.Sprintf("[%s](%s)", a.Label, a.URL)
+	}
+	return rewritePRBlock(body, prExportsStart, prExportsEnd, []string{
+		prExportsLinePrefix + " " + strings.Join(links, " · "),
+	})
+}
@@ -0,0 +1,41 @@
+package agents
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ansg191/job-temporal/internal/activities"
+)
+
+func TestRewriteExports(t *testing.T) {
+	t.Parallel()
+
+	body := "Summary\nPDF Artifact: https://example/a/Jane-Doe-Resume.pdf\n\nDetails"
+	artifacts := []activities.ExportArtifact{
+		{Format: "txt", Label: "Plain text", URL: "https://example/a/Jane-Doe-Resume.txt"},
+		{Format: "docx", Label: "DOCX", URL: "https://example/a/Jane-Doe-Resume.docx"},
+	}
+
+	updated := rewriteExports(body, artifacts)
+	want := "Summary\nPDF Artifact: https://example/a/Jane-Doe-Resume.pdf\n\n" +
+		prExportsStart + "\n" +
+		"Other formats: [Plain text](https://example/a/Jane-Doe-Resume.txt) · [DOCX](https://example/a/Jane-Doe-Resume.docx)\n" +
+		prExportsEnd + "\n\nDetails"
+	if updated != want {
+		t.Fatalf("rewriteExports() =\n%s\nwant\n%s", updated, want)
+	}
+
+	// Exports sit between the artifact line and coverage.
+	withCoverage := rewriteExports(rewriteKeywordCoverage(updated, "table"), artifacts[:1])
+	if strings.Count(withCoverage, prExportsStart) != 1 {
+		t.Fatalf("expected a single exports block, got %q", withCoverage)
+	}
+	if strings.Index(withCoverage, prExportsStart) > strings.Index(withCoverage, prKeywordCoverageStart) {
+		t.Fatalf("expected exports before keyword coverage, got %q", withCoverage)
+	}
+
+	// A failed export drops links to the deleted files.
+	if got := rewriteExports(updated, nil); got != body {
+		t.Fatalf("rewriteExports(nil) =\n%s\nwant\n%s", got, body)
+	}
+}
@@ -0,0 +1,45 @@
+package agents
+
+import (
+	"strings"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// PR extras are what the agents add to a PR beyond the
+// build itself: regrouped history, PDF metadata, the build
+// check, keyword coverage, export links and the visual diff.
+// None of them decides a build, PR or review, so their
+// helpers return failures to the caller, which carries on
+// and lists them in the PR body instead.
+
+const (
+	prSkippedExtrasStart   = "<!-- skipped-extras:start -->"
+	prSkippedExtrasEnd     = "<!-- skipped-extras:end -->"
+	prSkippedExtrasHeading = "### Skipped extras"
+)
+
+// extraFailures lists the PR extras that failed, one
+// "<extra>: <error>" line each.
+type extraFailures []string
+
+// add logs that extra failed with err and records it.
+func (f *extraFailures) add(ctx workflow.Context, extra string, err error) {
+	workflow.GetLogger(ctx).Warn("PR extra failed", "extra", extra, "error", err)
+	msg, _, _ := strings.Cut(err.Error(), "\n")
+	*f = append(*f, extra+": "+msg)
+}
+
+// rewriteExtraFailures replaces the skipped extras block in
+// a PR body with failed, removing the block once nothing
+// fails.
+func rewriteExtraFailures(body string, failed extraFailures) string {
+	if len(failed) == 0 {
+		return rewritePRBlock(body, prSkippedExtrasStart, prSkippedExtrasEnd, nil)
+	}
+	lines := []string{prSkippedExtrasHeading, ""}
+	for _, f := range failed {
+		lines = append(lines, "- "+f)
+	}
+	return rewritePRBlock(body, prSkippedExtrasStart, prSkippedExtrasEnd, lines)
+}
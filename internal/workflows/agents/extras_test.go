@@ -0,0 +1,26 @@
+package agents
+
+import "testing"
+
+func TestRewriteExtraFailures(t *testing.T) {
+	t.Parallel()
+
+	body := "Summary\nPDF Artifact: https://example/a/Jane-Doe-Resume.pdf\n\nDetails"
+	failed := extraFailures{"keyword coverage: build failed", "visual diff: timeout"}
+
+	updated := rewriteExtraFailures(body, failed)
+	want := "Summary\nPDF Artifact: https://example/a/Jane-Doe-Resume.pdf\n\n" +
+		prSkippedExtrasStart + "\n" +
+		prSkippedExtrasHeading + "\n\n" +
+		"- keyword coverage: build failed\n" +
+		"- visual diff: timeout\n" +
+		prSkippedExtrasEnd + "\n\nDetails"
+	if updated != want {
+		t.Fatalf("rewriteExtraFailures() =\n%s\nwant\n%s", updated, want)
+	}
+
+	// Once the extras succeed the block goes away.
+	if got := rewriteExtraFailures(updated, nil); got != body {
+		t.Fatalf("rewriteExtraFailures(nil) =\n%s\nwant\n%s", got, body)
+	}
+}
@@ -0,0 +1,178 @@
+package agents
+
+import (
+	"fmt"
+
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/ansg191/job-temporal/internal/activities"
+	"github.com/ansg191/job-temporal/internal/github"
+	"github.com/ansg191/job-temporal/internal/llm"
+	"github.com/ansg191/job-temporal/internal/tools"
+)
+
+// editableFiles are the content files each build target's
+// agents may edit. The formatting template is never among
+// them.
+var editableFiles = map[BuildTarget][]string{
+	BuildTargetResume:      {"person", "jobs", "school", "projects"},
+	BuildTargetCoverLetter: {"letter"},
+}
+
+// buildTargetFiles returns the read and edit allowlists of
+// the file tools for a build target: every source file of
+// the builder may be read, only the content files edited.
+func buildTargetFiles(builderName string, buildTarget BuildTarget) (read, edit []string, err error) {
+	ext := sourceExt(builderName)
+	names, ok := editableFiles[buildTarget]
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid build target: %d", buildTarget)
+	}
+	for _, name := range names {
+		edit = append(edit, name+ext)
+	}
+	return []string{"*" + ext}, edit, nil
+}
+
+// fileTools dispatches the native file tools for one branch.
+// It tracks the branch head the tools last saw and passes it
+// as the base commit of edits, so an edit written against
+// stale content fails instead of landing in the wrong place.
+type fileTools struct {
+	ghOpts    github.ClientOptions
+	branch    string
+	readAllow []string
+	// editAllow is empty for read-only agents, which are not
+	// offered the edit tools.
+	editAllow []string
+	head      string
+	// pushed holds the commits our edits pushed, so the push
+	// events they cause can be told from others'.
+	pushed map[string]bool
+}
+
+func newFileTools(ghOpts github.ClientOptions, branch, builderName string, buildTarget BuildTarget) (*fileTools, error) {
+	read, edit, err := buildTargetFiles(builderOrDefault(builderName), buildTarget)
+	if err != nil {
+		return nil, err
+	}
+	return &fileTools{
+		ghOpts:    ghOpts,
+		branch:    branch,
+		readAllow: read,
+		editAllow: edit,
+	}, nil
+}
+
+// readOnly returns file tools for the same files without the
+// edit tools.
+func (f *fileTools) readOnly() *fileTools {
+	return &fileTools{
+		ghOpts:    f.ghOpts,
+		branch:    f.branch,
+		readAllow: f.readAllow,
+	}
+}
+
+func (f *fileTools) definitions() []llm.ToolDefinition {
+	defs := []llm.ToolDefinition{tools.ListFilesToolDesc, tools.ReadFileToolDesc}
+	if len(f.editAllow) > 0 {
+		defs = append(defs, tools.EditFileToolDesc, tools.EditFilesToolDesc, tools.EditLineToolDesc)
+	}
+	return defs
+}
+
+// dispatch runs call if it is a file tool. ok is false for
+// other tools.
+func (f *fileTools) dispatch(ctx workflow.Context, call llm.ToolCall) (fut workflow.Future, ok bool, err error) {
+	readReq := activities.ReadFileRequest{
+		ClientOptions: f.ghOpts,
+		AllowList:     f.readAllow,
+		Branch:        f.branch,
+	}
+	editReq := readReq
+	editReq.AllowList = f.editAllow
+
+	switch {
+	case call.Name == tools.ListFilesToolDesc.Name:
+		return f.track(ctx, workflow.ExecuteActivity(ctx, activities.ListFiles, activities.ListFilesRequest{
+			ClientOptions: f.ghOpts,
+			Branch:        f.branch,
+			AllowList:     f.readAllow,
+			EditAllowList: f.editAllow,
+		})), true, nil
+	case call.Name == tools.ReadFileToolDesc.Name:
+		if err = tools.ReadToolParseArgs(call.Arguments, &readReq); err != nil {
+			return nil, true, err
+		}
+		return f.track(ctx, workflow.ExecuteActivity(ctx, activities.ReadFile, readReq)), true, nil
+	case call.Name == tools.EditFileToolDesc.Name && len(f.editAllow) > 0:
+		req := activities.EditFileRequest{ReadFileRequest: editReq, BaseCommit: f.head}
+		if err = tools.EditToolParseArgs(call.Arguments, &req); err != nil {
+			return nil, true, err
+		}
+		return f.trackEdit(ctx, workflow.ExecuteActivity(ctx, activities.EditFile, req)), true, nil
+	case call.Name == tools.EditFilesToolDesc.Name && len(f.editAllow) > 0:
+		req := activities.EditFilesRequest{
+			ClientOptions: f.ghOpts,
+			AllowList:     f.editAllow,
+			Branch:        f.branch,
+			BaseCommit:    f.head,
+		}
+		if err = tools.EditFilesToolParseArgs(call.Arguments, &req); err != nil {
+			return nil, true, err
+		}
+		return f.trackEdit(ctx, workflow.ExecuteActivity(ctx, activities.EditFiles, req)), true, nil
+	case call.Name == tools.EditLineToolDesc.Name && len(f.editAllow) > 0:
+		req := activities.EditLineRequest{ReadFileRequest: editReq, BaseCommit: f.head}
+		if err = tools.EditLineToolParseArgs(call.Arguments, &req); err != nil {
+			return nil, true, err
+		}
+		return f.trackEdit(ctx, workflow.ExecuteActivity(ctx, activities.EditLine, req)), true, nil
+	default:
+		return nil, false, nil
+	}
+}
+
+// track resolves to the tool result of a file tool activity,
+// recording the head it saw.
+func (f *fileTools) track(ctx workflow.Context, activity workflow.Future) workflow.Future {
+	return f.trackHead(ctx, activity, false)
+}
+
+// trackEdit is track for edits, whose head we pushed.
+func (f *fileTools) trackEdit(ctx workflow.Context, activity workflow.Future) workflow.Future {
+	return f.trackHead(ctx, activity, true)
+}
+
+func (f *fileTools) trackHead(ctx workflow.Context, activity workflow.Future, pushed bool) workflow.Future {
+	fut, settable := workflow.NewFuture(ctx)
+	workflow.Go(ctx, func(ctx workflow.Context) {
+		var out activities.FileToolOutput
+		if err := activity.Get(ctx, &out); err != nil {
+			settable.SetError(err)
+			return
+		}
+		if out.Head != "" {
+			f.head = out.Head
+			if pushed {
+				f.recordPush(out.Head)
+			}
+		}
+		settable.SetValue(out.Result)
+	})
+	return fut
+}
+
+// recordPush records that we pushed sha.
+func (f *fileTools) recordPush(sha string) {
+	if f.pushed == nil {
+		f.pushed = make(map[string]bool)
+	}
+	f.pushed[sha] = true
+}
+
+// pushedByUs reports whether sha is a commit we pushed.
+func (f *fileTools) pushedByUs(sha string) bool {
+	return f.pushed[sha]
+}
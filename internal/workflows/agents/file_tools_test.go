@@ -0,0 +1,90 @@
+package agents
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ansg191/job-temporal/internal/github"
+	"github.com/ansg191/job-temporal/internal/tools"
+)
+
+func TestBuildTargetFiles(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		builder     string
+		target      BuildTarget
+		wantRead    []string
+		wantEdit    []string
+		errExpected bool
+	}{
+		{
+			name:     "typst resume",
+			builder:  "typst",
+			target:   BuildTargetResume,
+			wantRead: []string{"*.typ"},
+			wantEdit: []string{"person.typ", "jobs.typ", "school.typ", "projects.typ"},
+		},
+		{
+			name:     "latex cover letter",
+			builder:  "latex",
+			target:   BuildTargetCoverLetter,
+			wantRead: []string{"*.tex"},
+			wantEdit: []string{"letter.tex"},
+		},
+		{
+			name:        "invalid target",
+			builder:     "typst",
+			target:      BuildTarget(99),
+			errExpected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			read, edit, err := buildTargetFiles(tt.builder, tt.target)
+			if (err != nil) != tt.errExpected {
+				t.Fatalf("buildTargetFiles() error = %v, errExpected %v", err, tt.errExpected)
+			}
+			if !slices.Equal(read, tt.wantRead) {
+				t.Errorf("read = %q, want %q", read, tt.wantRead)
+			}
+			if !slices.Equal(edit, tt.wantEdit) {
+				t.Errorf("edit = %q, want %q", edit, tt.wantEdit)
+			}
+		})
+	}
+}
+
+func TestFileToolsDefinitions(t *testing.T) {
+	t.Parallel()
+
+	files, err := newFileTools(github.ClientOptions{Owner: "o", Repo: "r"}, "branch", "", BuildTargetResume)
+	if err != nil {
+		t.Fatalf("newFileTools() error = %v", err)
+	}
+
+	names := func(f *fileTools) []string {
+		var ret []string
+		for _, d := range f.definitions() {
+			ret = append(ret, d.Name)
+		}
+		return ret
+	}
+
+	want := []string{
+		tools.ListFilesToolDesc.Name,
+		tools.ReadFileToolDesc.Name,
+		tools.EditFileToolDesc.Name,
+		tools.EditFilesToolDesc.Name,
+		tools.EditLineToolDesc.Name,
+	}
+	if got := names(files); !slices.Equal(got, want) {
+		t.Errorf("definitions() = %q, want %q", got, want)
+	}
+	if got := names(files.readOnly()); !slices.Equal(got, want[:2]) {
+		t.Errorf("readOnly().definitions() = %q, want %q", got, want[:2])
+	}
+}
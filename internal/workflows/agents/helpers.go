@@ -3,6 +3,7 @@ package agents
 import (
 	"fmt"
 	"strings"
+	"text/template"
 	"time"
 
 	"go.temporal.io/sdk/workflow"
@@ -67,6 +68,49 @@ func loadAgentConfig(ctx workflow.Context, agentName string) (*config.AgentConfi
 	return &agentCfg, nil
 }
 
+// builderPrompt is what agent instructions say about the
+// builder's sources, filled in for {{.Ext}}, {{.Language}}
+// and {{.Bold}}.
+type builderPrompt struct {
+	Ext      string
+	Language string
+	Bold     string
+}
+
+func newBuilderPrompt(builderName string) builderPrompt {
+	if builderName == "latex" {
+		return builderPrompt{Ext: ".tex", Language: "LaTeX", Bold: `\textbf{}`}
+	}
+	return builderPrompt{Ext: ".typ", Language: "Typst", Bold: "#strong[]"}
+}
+
+// renderInstructions fills in instructions for the builder.
+func renderInstructions(instructions, builderName string) (string, error) {
+	tmpl, err := template.New("instructions").Option("missingkey=error").Parse(instructions)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse instructions: %w", err)
+	}
+	var sb strings.Builder
+	if err = tmpl.Execute(&sb, newBuilderPrompt(builderName)); err != nil {
+		return "", fmt.Errorf("failed to render instructions: %w", err)
+	}
+	return sb.String(), nil
+}
+
+// loadBuilderAgentConfig loads the config of an agent that
+// works on the builder's sources, with its instructions
+// filled in for builderName.
+func loadBuilderAgentConfig(ctx workflow.Context, agentName, builderName string) (*config.AgentConfig, error) {
+	agentCfg, err := loadAgentConfig(ctx, agentName)
+	if err != nil {
+		return nil, err
+	}
+	if agentCfg.Instructions, err = renderInstructions(agentCfg.Instructions, builderName); err != nil {
+		return nil, fmt.Errorf("agent %s: %w", agentName, err)
+	}
+	return agentCfg, nil
+}
+
 func temperatureOpt(t *float64) *float64 {
 	return t
 }
@@ -1,8 +1,14 @@
 package agents
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"gopkg.in/yaml.v3"
+
+	"github.com/ansg191/job-temporal/internal/config"
 	"github.com/ansg191/job-temporal/internal/llm"
 )
 
@@ -25,3 +31,41 @@ func TestUserMessageContinuationHasNonEmptyContent(t *testing.T) {
 		}
 	}
 }
+
+// TestRenderInstructionsNamesBuilderSources verifies the
+// agents that edit sources are only told about files of the
+// configured builder, which the file tools allow.
+func TestRenderInstructionsNamesBuilderSources(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		builder   string
+		want      string
+		forbidden string
+	}{
+		{builder: "typst", want: "jobs.typ", forbidden: ".tex"},
+		{builder: "latex", want: "jobs.tex", forbidden: ".typ"},
+	}
+	for _, agent := range []string{"builder_resume", "builder_cover_letter", "review_agent"} {
+		data, err := os.ReadFile(filepath.Join("..", "..", "..", "config", "agents", agent+".yaml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var cfg config.AgentConfig
+		if err = yaml.Unmarshal(data, &cfg); err != nil {
+			t.Fatal(err)
+		}
+		for _, tt := range tests {
+			got, err := renderInstructions(cfg.Instructions, tt.builder)
+			if err != nil {
+				t.Fatalf("%s/%s: renderInstructions: %v", agent, tt.builder, err)
+			}
+			if agent != "review_agent" && !strings.Contains(got, tt.want) {
+				t.Errorf("%s/%s: instructions do not mention %s", agent, tt.builder, tt.want)
+			}
+			if strings.Contains(got, tt.forbidden) {
+				t.Errorf("%s/%s: instructions mention %s files", agent, tt.builder, tt.forbidden)
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package agents
+
+import (
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/ansg191/job-temporal/internal/activities"
+	"github.com/ansg191/job-temporal/internal/github"
+)
+
+const (
+	prKeywordCoverageStart   = "<!-- keyword-coverage:start -->"
+	prKeywordCoverageEnd     = "<!-- keyword-coverage:end -->"
+	prKeywordCoverageHeading = "### Keyword coverage"
+)
+
+// runKeywordCoverage builds the buildTarget document from
+// branch, and the other one from its sibling PR into target,
+// and returns the job keyword coverage table.
+func runKeywordCoverage(ctx workflow.Context, repo github.ClientOptions, branch, target string, buildTarget BuildTarget, builderName, job string) (string, error) {
+	if strings.TrimSpace(job) == "" {
+		return "", nil
+	}
+	purpose, err := purposeLabelForBuildTarget(buildTarget)
+	if err != nil {
+		return "", err
+	}
+	resumeFile, err := resolveBuildTargetFile(builderName, BuildTargetResume)
+	if err != nil {
+		return "", err
+	}
+	letterFile, err := resolveBuildTargetFile(builderName, BuildTargetCoverLetter)
+	if err != nil {
+		return "", err
+	}
+
+	coverageCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 5 * time.Minute,
+	})
+	var table string
+	err = workflow.ExecuteActivity(coverageCtx, activities.KeywordCoverage, activities.KeywordCoverageRequest{
+		ClientOptions: repo,
+		Branch:        branch,
+		Target:        target,
+		Purpose:       purpose,
+		Builder:       builderName,
+		Job:           job,
+		ResumeFile:    resumeFile,
+		LetterFile:    letterFile,
+	}).Get(ctx, &table)
+	return table, err
+}
+
+// rewriteKeywordCoverage replaces the keyword coverage block
+// in a PR body with table, placing it right after the
+// artifact line. An empty table leaves the body unchanged.
+func rewriteKeywordCoverage(body, table string) string {
+	if table == "" {
+		return body
+	}
+	return rewritePRBlock(body, prKeywordCoverageStart, prKeywordCoverageEnd, []string{
+		prKeywordCoverageHeading,
+		"",
+		table,
+	})
+}
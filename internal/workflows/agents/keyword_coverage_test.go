@@ -0,0 +1,43 @@
+package agents
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRewriteKeywordCoverage(t *testing.T) {
+	t.Parallel()
+
+	table := "| Keyword | Priority | Resume | Letter | Status |"
+	body := "Summary\nPDF Artifact: https://example/file.pdf\n\nDetails"
+
+	updated := rewriteKeywordCoverage(body, table)
+	want := "Summary\nPDF Artifact: https://example/file.pdf\n\n" +
+		prKeywordCoverageStart + "\n" + prKeywordCoverageHeading + "\n\n" + table + "\n" + prKeywordCoverageEnd +
+		"\n\nDetails"
+	if updated != want {
+		t.Fatalf("rewriteKeywordCoverage() =\n%s\nwant\n%s", updated, want)
+	}
+
+	// Refreshing replaces the block instead of adding another.
+	refreshed := rewriteKeywordCoverage(updated, "new table")
+	if strings.Count(refreshed, prKeywordCoverageStart) != 1 || strings.Contains(refreshed, table) {
+		t.Fatalf("expected a single refreshed block, got %q", refreshed)
+	}
+	if !strings.Contains(refreshed, "new table\n"+prKeywordCoverageEnd+"\n\nDetails") {
+		t.Fatalf("expected block to stay next to the artifact line, got %q", refreshed)
+	}
+
+	if got := rewriteKeywordCoverage(updated, ""); got != updated {
+		t.Fatalf("expected empty table to keep body, got %q", got)
+	}
+}
+
+func TestRewriteKeywordCoverageAppendsWithoutArtifactLine(t *testing.T) {
+	t.Parallel()
+
+	updated := rewriteKeywordCoverage("Summary", "table")
+	if !strings.HasPrefix(updated, "Summary\n\n"+prKeywordCoverageStart) {
+		t.Fatalf("expected block appended, got %q", updated)
+	}
+}
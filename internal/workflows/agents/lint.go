@@ -0,0 +1,66 @@
+package agents
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/ansg191/job-temporal/internal/activities"
+)
+
+const (
+	prLintStart   = "<!-- lint:start -->"
+	prLintEnd     = "<!-- lint:end -->"
+	prLintHeading = "### Pre-flight lint"
+
+	lintMaxRuns = 3
+)
+
+func runLintGate(
+	ctx workflow.Context,
+	req activities.LintRequest,
+) (*activities.LintOutput, string, error) {
+	lintCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 5 * time.Minute,
+	})
+	var lintJSON string
+	err := workflow.ExecuteActivity(lintCtx, activities.Lint, req).Get(ctx, &lintJSON)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var output activities.LintOutput
+	if err = json.Unmarshal([]byte(lintJSON), &output); err != nil {
+		return nil, "", fmt.Errorf("failed to parse lint output: %w", err)
+	}
+	return &output, lintJSON, nil
+}
+
+func shouldBlockLintFindings(output *activities.LintOutput, attempt int) (bool, string) {
+	if output == nil {
+		return false, ""
+	}
+	return shouldBlockReviewBySeverity(output.Findings, attempt)
+}
+
+// lintBlockedMessage asks the agent to fix the findings
+// that blocked a lint gate.
+func lintBlockedMessage(reason, lintJSON string) string {
+	return "Pre-flight lint blocked completion (" + reason + "). Fix the findings and rebuild.\nCurrent findings JSON:\n" + lintJSON
+}
+
+// rewriteLint replaces the lint block in a PR body with the
+// remaining findings. A clean or missing result removes the
+// block.
+func rewriteLint(body string, output *activities.LintOutput) string {
+	if output == nil || len(output.Findings) == 0 {
+		return rewritePRBlock(body, prLintStart, prLintEnd, nil)
+	}
+	content := []string{prLintHeading, ""}
+	for _, f := range output.Findings {
+		content = append(content, fmt.Sprintf("- **%s** %s — %s", f.Severity, f.String(), f.FixHint))
+	}
+	return rewritePRBlock(body, prLintStart, prLintEnd, content)
+}
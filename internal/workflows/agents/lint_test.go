@@ -0,0 +1,44 @@
+package agents
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ansg191/job-temporal/internal/activities"
+)
+
+func TestRewriteLint(t *testing.T) {
+	t.Parallel()
+
+	body := "Summary\nPDF Artifact: https://example/a.pdf\n\nDetails"
+	output := &activities.LintOutput{Findings: []activities.LintFinding{{
+		Check:    activities.LintCheckFont,
+		Severity: "high",
+		File:     "resume.typ",
+		Line:     2,
+		Message:  `unknown font family "Inter"`,
+		FixHint:  "use an installed font family",
+	}}}
+
+	updated := rewriteLint(body, output)
+	want := "Summary\nPDF Artifact: https://example/a.pdf\n\n" +
+		prLintStart + "\n" +
+		prLintHeading + "\n\n" +
+		"- **high** resume.typ:2: unknown font family \"Inter\" — use an installed font family\n" +
+		prLintEnd + "\n\nDetails"
+	if updated != want {
+		t.Fatalf("rewriteLint() =\n%s\nwant\n%s", updated, want)
+	}
+
+	// Lint sits below blocks written after it.
+	withCoverage := rewriteKeywordCoverage(updated, "table")
+	if strings.Index(withCoverage, prKeywordCoverageStart) > strings.Index(withCoverage, prLintStart) {
+		t.Fatalf("expected keyword coverage before lint, got %q", withCoverage)
+	}
+
+	for _, clean := range []*activities.LintOutput{nil, {}} {
+		if got := rewriteLint(updated, clean); got != body {
+			t.Fatalf("rewriteLint(%v) =\n%s\nwant\n%s", clean, got, body)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package agents
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/ansg191/job-temporal/internal/activities"
+	"github.com/ansg191/job-temporal/internal/git"
+	"github.com/ansg191/job-temporal/internal/github"
+	"github.com/ansg191/job-temporal/internal/llm"
+)
+
+// mergeConflictMaxAttempts bounds how many resolutions are
+// tried before the conflict is reported.
+const mergeConflictMaxAttempts = 3
+
+type MergeConflictAgentRequest struct {
+	github.ClientOptions
+	// Branch and PR are the open pull request to bring up to
+	// date with Target.
+	Branch string `json:"branch"`
+	PR     int    `json:"pr"`
+	Target string `json:"target"`
+	// OtherPR is the pull request whose merge into Target may
+	// conflict with Branch.
+	OtherPR int    `json:"other_pr"`
+	Builder string `json:"builder"`
+	Job     string `json:"job"`
+}
+
+type mergeResolutionOutput struct {
+	Files []activities.FileResolution `json:"files"`
+}
+
+var mergeResolutionFormat = activities.GenerateTextFormat[mergeResolutionOutput]("merge_resolution")
+
+// MergeConflictAgent merges Target into Branch if they
+// conflict. The LLM resolves the conflicted files on a
+// scratch branch, which must build before Branch is
+// fast-forwarded to it. If no resolution builds, the
+// conflicting hunks are reported on both pull requests.
+func MergeConflictAgent(ctx workflow.Context, req MergeConflictAgentRequest) error {
+	req.Builder = builderOrDefault(req.Builder)
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 5 * time.Minute,
+	})
+
+	var check git.MergeCheck
+	err := workflow.ExecuteActivity(ctx, activities.CheckMerge, activities.CheckMergeRequest{
+		ClientOptions: req.ClientOptions,
+		Branch:        req.Branch,
+		Target:        req.Target,
+	}).Get(ctx, &check)
+	if err != nil {
+		return err
+	}
+	if len(check.Conflicts) == 0 {
+		return nil
+	}
+
+	scratch := "merge/" + req.Branch
+	commit, err := resolveMergeConflicts(ctx, req, &check, scratch)
+	if err != nil {
+		workflow.GetLogger(ctx).Warn("Failed to resolve merge conflicts", "branch", req.Branch, "error", err)
+		report := mergeConflictReport(req.Branch, req.Target, check.Conflicts, err)
+		deleteMergeScratch(ctx, req, scratch)
+		for _, pr := range []int{req.PR, req.OtherPR} {
+			err = workflow.ExecuteActivity(ctx, activities.CommentOnPullRequest, activities.CommentOnPullRequestRequest{
+				ClientOptions: req.ClientOptions,
+				PRNumber:      pr,
+				Body:          report,
+			}).Get(ctx, nil)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	err = workflow.ExecuteActivity(ctx, activities.ApplyMerge, activities.ApplyMergeRequest{
+		ClientOptions: req.ClientOptions,
+		Branch:        req.Branch,
+		Scratch:       scratch,
+		Commit:        commit,
+	}).Get(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	paths := make([]string, len(check.Conflicts))
+	for i, c := range check.Conflicts {
+		paths[i] = "`" + c.Path + "`"
+	}
+	return workflow.ExecuteActivity(ctx, activities.CommentOnPullRequest, activities.CommentOnPullRequestRequest{
+		ClientOptions: req.ClientOptions,
+		PRNumber:      req.PR,
+		Body: fmt.Sprintf(
+			"Merged `%s` into this branch after #%d, resolving conflicts in %s. The merge (%s) builds.",
+			req.Target, req.OtherPR, strings.Join(paths, ", "), commit,
+		),
+	}).Get(ctx, nil)
+}
+
+// deleteMergeScratch deletes the scratch branch of rejected
+// resolutions. Nothing may have been pushed to it, so a
+// failure is only logged.
+func deleteMergeScratch(ctx workflow.Context, req MergeConflictAgentRequest, scratch string) {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 5 * time.Minute,
+		RetryPolicy:         &temporal.RetryPolicy{MaximumAttempts: 3},
+	})
+	err := workflow.ExecuteActivity(ctx, activities.DeleteMergeScratch, activities.DeleteMergeScratchRequest{
+		ClientOptions: req.ClientOptions,
+		Branch:        req.Branch,
+		Scratch:       scratch,
+	}).Get(ctx, nil)
+	if err != nil {
+		workflow.GetLogger(ctx).Warn("Failed to delete scratch branch", "branch", scratch, "error", err)
+	}
+}
+
+// resolveMergeConflicts asks the LLM to resolve the
+// conflicts until a resolution builds on scratch and returns
+// its merge commit.
+func resolveMergeConflicts(ctx workflow.Context, req MergeConflictAgentRequest, check *git.MergeCheck, scratch string) (string, error) {
+	agentCfg, err := loadAgentConfig(ctx, "merge_conflict")
+	if err != nil {
+		return "", err
+	}
+	var files []string
+	for _, target := range []BuildTarget{BuildTargetResume, BuildTargetCoverLetter} {
+		file, err := resolveBuildTargetFile(req.Builder, target)
+		if err != nil {
+			return "", err
+		}
+		files = append(files, file)
+	}
+
+	messages := []llm.Message{
+		systemMessage(agentCfg.Instructions),
+		userMessage(wrapLLMXML("job_description", req.Job)),
+	}
+	for _, c := range check.Conflicts {
+		messages = append(messages, userMessage(wrapLLMXML("conflict", "path: "+c.Path+"\n\n"+c.Content)))
+	}
+	conversation, err := createConversation(ctx, agentCfg.Model, nil)
+	if err != nil {
+		return "", err
+	}
+	callAICtx := withCallAIActivityOptions(ctx)
+
+	lastErr := errors.New("no resolution attempted")
+	for attempt := 0; attempt < mergeConflictMaxAttempts; {
+		var result activities.AIResponse
+		err = workflow.ExecuteActivity(callAICtx, activities.CallAI, activities.AIRequest{
+			Model:        agentCfg.Model,
+			Input:        messages,
+			Text:         mergeResolutionFormat,
+			Temperature:  temperatureOpt(agentCfg.Temperature),
+			Conversation: conversation,
+		}).Get(ctx, &result)
+		if err != nil {
+			return "", err
+		}
+		conversation = result.Conversation
+		if aiShouldContinue(result) {
+			messages = []llm.Message{userMessage(continuationMessage)}
+			continue
+		}
+		attempt++
+
+		var out mergeResolutionOutput
+		if err = json.Unmarshal([]byte(result.OutputText), &out); err != nil {
+			lastErr = fmt.Errorf("invalid output format: %w", err)
+			messages = []llm.Message{userMessage("Invalid output format: " + err.Error())}
+			continue
+		}
+
+		var output activities.ResolveMergeOutput
+		err = workflow.ExecuteActivity(ctx, activities.ResolveMerge, activities.ResolveMergeRequest{
+			ClientOptions: req.ClientOptions,
+			Branch:        req.Branch,
+			Head:          check.Head,
+			Theirs:        check.Theirs,
+			Resolutions:   out.Files,
+			Message:       fmt.Sprintf("Merge %s into %s", req.Target, req.Branch),
+			Scratch:       scratch,
+			Builder:       req.Builder,
+			Files:         files,
+		}).Get(ctx, &output)
+		var appErr *temporal.ApplicationError
+		if errors.As(err, &appErr) && appErr.Type() == activities.ErrTypeUnresolvedConflicts {
+			lastErr = err
+			messages = []llm.Message{userMessage("Resolution rejected: " + appErr.Error())}
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if len(output.BuildErrors) > 0 {
+			lastErr = fmt.Errorf("resolution does not build")
+			messages = []llm.Message{userMessage(
+				"The resolution does not build, fix it and return every conflicted file again:\n" +
+					strings.Join(output.BuildErrors, "\n\n"),
+			)}
+			continue
+		}
+		return output.Commit, nil
+	}
+	return "", lastErr
+}
+
+// mergeConflictReport describes conflicts that could not be
+// resolved, quoting each conflicting hunk.
+func mergeConflictReport(branch, target string, conflicts []git.MergeConflict, cause error) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "`%s` conflicts with `%s` and could not be merged automatically (%v).\n", branch, target, cause)
+	sb.WriteString("Resolve these hunks by hand:\n")
+	for _, c := range conflicts {
+		fmt.Fprintf(&sb, "\n**`%s`**\n", c.Path)
+		for _, hunk := range c.Hunks {
+			sb.WriteString("\n```diff\n")
+			sb.WriteString(strings.TrimRight(hunk, "\n"))
+			sb.WriteString("\n```\n")
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,27 @@
+package agents
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ansg191/job-temporal/internal/git"
+)
+
+func TestMergeConflictReport(t *testing.T) {
+	t.Parallel()
+
+	got := mergeConflictReport("resume-acme", "final-acme", []git.MergeConflict{{
+		Path:  "person.typ",
+		Hunks: []string{"<<<<<<< HEAD\nA\n=======\nB\n>>>>>>> final-acme\n"},
+	}}, errors.New("resolution does not build"))
+	for _, want := range []string{
+		"`resume-acme` conflicts with `final-acme` and could not be merged automatically (resolution does not build).",
+		"**`person.typ`**",
+		"```diff\n<<<<<<< HEAD\nA\n=======\nB\n>>>>>>> final-acme\n```",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("report missing %q:\n%s", want, got)
+		}
+	}
+}
@@ -1,9 +1,11 @@
 package agents
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
+	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
 
 	"github.com/ansg191/job-temporal/internal/activities"
@@ -15,33 +17,81 @@ type BuildAndUploadPDFWorkflowRequest struct {
 	Branch      string      `json:"branch"`
 	Builder     string      `json:"builder"`
 	BuildTarget BuildTarget `json:"build_target"`
+	// Job is the job description, used for the document
+	// metadata and download filename.
+	Job         string `json:"job"`
+	PDFStandard string `json:"pdf_standard,omitempty"`
 }
 
-func BuildAndUploadPDFWorkflow(ctx workflow.Context, req BuildAndUploadPDFWorkflowRequest) (string, error) {
+// BuildAndUploadPDFOutput is the uploaded PDF artifact and
+// the PR extras that failed while making it.
+type BuildAndUploadPDFOutput struct {
+	URL     string        `json:"url"`
+	Skipped extraFailures `json:"skipped,omitempty"`
+}
+
+func BuildAndUploadPDFWorkflow(ctx workflow.Context, req BuildAndUploadPDFWorkflowRequest) (BuildAndUploadPDFOutput, error) {
 	ao := workflow.ActivityOptions{
 		StartToCloseTimeout: time.Minute * 3,
 	}
 	ctx = workflow.WithActivityOptions(ctx, ao)
 
-	file, err := resolveBuildTargetFile(req.BuildTarget)
+	file, err := resolveBuildTargetFile(req.Builder, req.BuildTarget)
 	if err != nil {
-		return "", err
+		return BuildAndUploadPDFOutput{}, err
 	}
 
+	var out BuildAndUploadPDFOutput
+	checkCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: time.Second * 30,
+		RetryPolicy:         &temporal.RetryPolicy{MaximumAttempts: 3},
+	})
+	check, err := startBuildCheck(checkCtx, req, file)
+	if err != nil {
+		out.Skipped.add(ctx, "build check", err)
+	}
+
+	out.URL, err = buildAndUploadPDF(ctx, req, file, &out.Skipped)
+	if checkErr := completeBuildCheck(checkCtx, req, check, file, out.URL, err); checkErr != nil {
+		out.Skipped.add(ctx, "build check", checkErr)
+	}
+	return out, err
+}
+
+// buildAndUploadPDF builds file on the branch, sets its
+// metadata and uploads it, returning the artifact URL.
+func buildAndUploadPDF(ctx workflow.Context, req BuildAndUploadPDFWorkflowRequest, file string, failed *extraFailures) (string, error) {
 	var artifactURL string
 	var pdfContent []byte
-	err = workflow.ExecuteActivity(ctx, activities.BuildFinalPDF, activities.BuildFinalPDFRequest{
+	err := workflow.ExecuteActivity(ctx, activities.BuildFinalPDF, activities.BuildFinalPDFRequest{
 		ClientOptions: req.ClientOptions,
 		Branch:        req.Branch,
 		Builder:       req.Builder,
 		File:          file,
+		PDFStandard:   req.PDFStandard,
 	}).Get(ctx, &pdfContent)
 	if err != nil {
 		return "", err
 	}
 
+	// Without metadata, upload the bare PDF.
+	var finalized activities.FinalizePDFOutput
+	err = workflow.ExecuteActivity(ctx, activities.FinalizePDF, activities.FinalizePDFRequest{
+		ClientOptions: req.ClientOptions,
+		Branch:        req.Branch,
+		Builder:       req.Builder,
+		File:          file,
+		Job:           req.Job,
+		Content:       pdfContent,
+	}).Get(ctx, &finalized)
+	if err != nil {
+		failed.add(ctx, "PDF metadata", err)
+		finalized = activities.FinalizePDFOutput{Content: pdfContent}
+	}
+
 	err = workflow.ExecuteActivity(ctx, activities.UploadPDF, activities.UploadPDFRequest{
-		Content: pdfContent,
+		Content:  finalized.Content,
+		Filename: finalized.Filename,
 	}).Get(ctx, &artifactURL)
 	if err != nil {
 		return "", err
@@ -50,13 +100,77 @@ func BuildAndUploadPDFWorkflow(ctx workflow.Context, req BuildAndUploadPDFWorkfl
 	return artifactURL, nil
 }
 
-func resolveBuildTargetFile(buildTarget BuildTarget) (string, error) {
+// startBuildCheck reports the build as a check run on the
+// branch's head commit. A zero check is never completed.
+func startBuildCheck(ctx workflow.Context, req BuildAndUploadPDFWorkflowRequest, file string) (activities.BuildCheck, error) {
+	var check activities.BuildCheck
+	err := workflow.ExecuteActivity(ctx, activities.StartBuildCheck, activities.StartBuildCheckRequest{
+		ClientOptions: req.ClientOptions,
+		Branch:        req.Branch,
+		File:          file,
+	}).Get(ctx, &check)
+	return check, err
+}
+
+// completeBuildCheck concludes the build's check run from
+// the build's result.
+func completeBuildCheck(
+	ctx workflow.Context,
+	req BuildAndUploadPDFWorkflowRequest,
+	check activities.BuildCheck,
+	file string,
+	artifactURL string,
+	buildErr error,
+) error {
+	if check.ID == 0 {
+		return nil
+	}
+	completeReq := activities.CompleteBuildCheckRequest{
+		ClientOptions: req.ClientOptions,
+		Check:         check,
+		Branch:        req.Branch,
+		File:          file,
+		ArtifactURL:   artifactURL,
+	}
+	var appErr *temporal.ApplicationError
+	switch {
+	case errors.As(buildErr, &appErr) && appErr.Type() == activities.ErrTypeBuildFailed:
+		_ = appErr.Details(&completeReq.Report, &completeReq.Diagnostics)
+		if len(completeReq.Report) == 0 {
+			completeReq.Report = []string{"build failed"}
+		}
+	case buildErr != nil:
+		completeReq.Error = buildErr.Error()
+	}
+
+	return workflow.ExecuteActivity(ctx, activities.CompleteBuildCheck, completeReq).Get(ctx, nil)
+}
+
+func resolveBuildTargetFile(builderName string, buildTarget BuildTarget) (string, error) {
+	ext := sourceExt(builderName)
 	switch buildTarget {
 	case BuildTargetResume:
-		return "resume.typ", nil
+		return "resume" + ext, nil
 	case BuildTargetCoverLetter:
-		return "cover_letter.typ", nil
+		return "cover_letter" + ext, nil
 	default:
 		return "", fmt.Errorf("invalid build target: %d", buildTarget)
 	}
 }
+
+// sourceExt is the extension of the builder's source files.
+func sourceExt(builderName string) string {
+	if builderName == "latex" {
+		return ".tex"
+	}
+	return ".typ"
+}
+
+// builderOrDefault returns name, falling back to typst for
+// requests recorded before the builder was configurable.
+func builderOrDefault(name string) string {
+	if name == "" {
+		return "typst"
+	}
+	return name
+}
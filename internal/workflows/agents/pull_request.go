@@ -36,6 +36,11 @@ type PullRequestAgentRequest struct {
 	Job         string      `json:"job"`
 	Builder     string      `json:"builder"`
 	BuildTarget BuildTarget `json:"build_target"`
+	PDFStandard string      `json:"pdf_standard,omitempty"`
+	Exports     []string    `json:"exports,omitempty"`
+	// Skipped are the extras that failed before the PR was
+	// opened, such as regrouping its history.
+	Skipped extraFailures `json:"skipped,omitempty"`
 }
 
 func PullRequestAgent(ctx workflow.Context, req PullRequestAgentRequest) (int, error) {
@@ -44,12 +49,9 @@ func PullRequestAgent(ctx workflow.Context, req PullRequestAgentRequest) (int, e
 		return 0, err
 	}
 
-	builderType := req.Builder
-	if builderType == "" {
-		builderType = "typst"
-	}
+	builderType := builderOrDefault(req.Builder)
 
-	var pdfURL string
+	var built BuildAndUploadPDFOutput
 	err = workflow.ExecuteChildWorkflow(
 		workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
 			WorkflowID: MakeChildWorkflowID(ctx, "build-upload-pdf", req.Branch, builderType),
@@ -60,11 +62,24 @@ func PullRequestAgent(ctx workflow.Context, req PullRequestAgentRequest) (int, e
 			Branch:        req.Branch,
 			Builder:       builderType,
 			BuildTarget:   req.BuildTarget,
+			Job:           req.Job,
+			PDFStandard:   req.PDFStandard,
 		},
-	).Get(ctx, &pdfURL)
+	).Get(ctx, &built)
 	if err != nil {
 		return 0, err
 	}
+	pdfURL := built.URL
+
+	failed := slices.Concat(req.Skipped, built.Skipped)
+	coverage, err := runKeywordCoverage(ctx, req.ClientOptions, req.Branch, req.Target, req.BuildTarget, builderType, req.Job)
+	if err != nil {
+		failed.add(ctx, "keyword coverage", err)
+	}
+	exports, err := runExports(ctx, req.ClientOptions, req.Branch, builderType, req.BuildTarget, req.Exports, pdfURL, req.PDFStandard)
+	if err != nil {
+		failed.add(ctx, "exports", err)
+	}
 
 	messages := []llm.Message{
 		systemMessage(agentCfg.Instructions),
@@ -90,7 +105,11 @@ func PullRequestAgent(ctx workflow.Context, req PullRequestAgentRequest) (int, e
 	}
 	callAICtx := withCallAIActivityOptions(ctx)
 
-	dispatcher := &githubDispatcher{aiTools: aiTools}
+	files, err := newFileTools(req.ClientOptions, req.Branch, builderType, req.BuildTarget)
+	if err != nil {
+		return 0, err
+	}
+	dispatcher := &githubDispatcher{aiTools: aiTools, files: files.readOnly()}
 
 	for {
 		var result activities.AIResponse
@@ -100,7 +119,7 @@ func PullRequestAgent(ctx workflow.Context, req PullRequestAgentRequest) (int, e
 			activities.AIRequest{
 				Model:        agentCfg.Model,
 				Input:        messages,
-				Tools:        aiTools,
+				Tools:        append(dispatcher.files.definitions(), aiTools...),
 				Text:         prOutputFormat,
 				Temperature:  temperatureOpt(agentCfg.Temperature),
 				Conversation: conversation,
@@ -133,6 +152,9 @@ func PullRequestAgent(ctx workflow.Context, req PullRequestAgentRequest) (int, e
 			}
 			continue
 		}
+		pr.Body = rewriteKeywordCoverage(pr.Body, coverage)
+		pr.Body = rewriteExports(pr.Body, exports)
+		pr.Body = rewriteExtraFailures(pr.Body, failed)
 
 		var prNum int
 		purposeLabel, err := purposeLabelForBuildTarget(req.BuildTarget)
@@ -181,9 +203,13 @@ func validatePRArtifactURL(body string, url string) error {
 
 type githubDispatcher struct {
 	aiTools []llm.ToolDefinition
+	files   *fileTools
 }
 
 func (d *githubDispatcher) Dispatch(ctx workflow.Context, call llm.ToolCall) (workflow.Future, error) {
+	if fut, ok, err := d.files.dispatch(ctx, call); ok {
+		return fut, err
+	}
 	if slices.ContainsFunc(d.aiTools, func(param llm.ToolDefinition) bool {
 		return param.Name == call.Name
 	}) {
@@ -191,3 +217,42 @@ func (d *githubDispatcher) Dispatch(ctx workflow.Context, call llm.ToolCall) (wo
 	}
 	return nil, fmt.Errorf("unsupported tool: %s", call.Name)
 }
+
+// rewritePRBlock replaces the block between the start and end
+// markers in a PR body with content, placing it right after
+// the artifact line, or at the end if there is none. A nil
+// content removes the block.
+func rewritePRBlock(body, start, end string, content []string) string {
+	lines := strings.Split(body, "\n")
+	next := make([]string, 0, len(lines))
+	inBlock := false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == start:
+			inBlock = true
+			// Drop the blank line that separated the block.
+			if n := len(next); n > 0 && strings.TrimSpace(next[n-1]) == "" {
+				next = next[:n-1]
+			}
+		case trimmed == end:
+			inBlock = false
+		case !inBlock:
+			next = append(next, line)
+		}
+	}
+
+	if content == nil {
+		return strings.Join(next, "\n")
+	}
+	block := append([]string{"", start}, content...)
+	block = append(block, end)
+	for i, line := range next {
+		if strings.HasPrefix(strings.TrimSpace(line), prArtifactLinePrefix) {
+			ret := append([]string{}, next[:i+1]...)
+			ret = append(ret, block...)
+			return strings.Join(append(ret, next[i+1:]...), "\n")
+		}
+	}
+	return strings.Join(append(next, block...), "\n")
+}
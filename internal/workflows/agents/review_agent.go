@@ -20,17 +20,51 @@ import (
 	"github.com/ansg191/job-temporal/internal/webhook"
 )
 
-const reviewBotLogin = "job-temporal[bot]"
+// Outcomes of an inline review comment, as reported by the
+// agent in its thread reply.
+const (
+	reviewOutcomeAddressed          = "addressed"
+	reviewOutcomePartiallyAddressed = "partially_addressed"
+	reviewOutcomeDeclined           = "declined"
+	reviewOutcomeNeedsClarification = "needs_clarification"
+)
+
+var reviewOutcomes = []string{
+	reviewOutcomeAddressed,
+	reviewOutcomePartiallyAddressed,
+	reviewOutcomeDeclined,
+	reviewOutcomeNeedsClarification,
+}
+
+// reviewThreadReply is the agent's final response to an
+// inline review comment, posted in the comment's thread.
+type reviewThreadReply struct {
+	Reply string `json:"reply"`
+	// Outcome is one of reviewOutcomes. Only addressed
+	// comments have their thread resolved.
+	Outcome string `json:"outcome"`
+}
+
+var reviewThreadReplyFormat = activities.GenerateTextFormat[reviewThreadReply]("review_thread_reply")
 
 type ReviewAgentArgs struct {
 	Repo        github.ClientOptions `json:"repo"`
 	Pr          int                  `json:"pr"`
 	BranchName  string               `json:"branch_name"`
+	Builder     string               `json:"builder"`
 	BuildTarget BuildTarget          `json:"build_target"`
+	Job         string               `json:"job"`
+	PDFStandard string               `json:"pdf_standard,omitempty"`
+	Exports     []string             `json:"exports,omitempty"`
+	// BaseBranch is the PR's base, which each rebuild is
+	// visually diffed against.
+	BaseBranch string `json:"base_branch,omitempty"`
 }
 
 func ReviewAgent(ctx workflow.Context, args ReviewAgentArgs) error {
-	agentCfg, err := loadAgentConfig(ctx, "review_agent")
+	args.Builder = builderOrDefault(args.Builder)
+
+	agentCfg, err := loadBuilderAgentConfig(ctx, "review_agent", args.Builder)
 	if err != nil {
 		return err
 	}
@@ -55,6 +89,17 @@ func ReviewAgent(ctx workflow.Context, args ReviewAgentArgs) error {
 		return err
 	}
 
+	// Suggestion mode starts on if the PR was labelled
+	// before we started.
+	var labels []string
+	err = workflow.ExecuteActivity(ctx, activities.GetPullRequestLabels, activities.GetPullRequestLabelsRequest{
+		ClientOptions: args.Repo,
+		PRNumber:      args.Pr,
+	}).Get(ctx, &labels)
+	if err != nil {
+		return err
+	}
+
 	reviewCh := workflow.GetSignalChannel(ctx, webhook.ReviewAgentSignal)
 	rebuildCh := workflow.GetSignalChannel(ctx, webhook.RebuildSignal)
 
@@ -73,15 +118,21 @@ func ReviewAgent(ctx workflow.Context, args ReviewAgentArgs) error {
 	if err != nil {
 		return err
 	}
+	files, err := newFileTools(args.Repo, args.BranchName, args.Builder, args.BuildTarget)
+	if err != nil {
+		return err
+	}
 	buildRun := 0
 	enableLayoutReview := args.BuildTarget == BuildTargetResume
 	reviewProcessor := reviewSignalProcessor{
 		args:               &args,
 		agentCfg:           agentCfg,
 		aiTools:            aiTools,
+		files:              files,
 		conversation:       conversation,
 		enableLayoutReview: enableLayoutReview,
 		buildRun:           &buildRun,
+		suggestMode:        slices.Contains(labels, suggestModeLabel),
 	}
 
 	for {
@@ -114,13 +165,14 @@ func ReviewAgent(ctx workflow.Context, args ReviewAgentArgs) error {
 		if rebuildSignal == nil {
 			continue
 		}
-		// Ignore pushes from ourselves.
-		if rebuildSignal.AuthorLogin == reviewBotLogin {
+		// Ignore pushes from ourselves: we rebuilt after
+		// them already.
+		if rebuildSignal.HeadSHA != "" && files.pushedByUs(rebuildSignal.HeadSHA) {
 			continue
 		}
 
 		buildRun++
-		pdfURL, err := runBuildAndUploadForReview(ctx, args, buildRun)
+		built, err := runBuildAndUploadForReview(ctx, args, buildRun)
 		if err != nil {
 			reason := buildFailureReason(err)
 			workflow.GetLogger(ctx).Warn("Push rebuild failed", "reason", reason)
@@ -129,7 +181,17 @@ func ReviewAgent(ctx workflow.Context, args ReviewAgentArgs) error {
 			}
 			continue
 		}
-		if err = updatePRDescriptionSuccess(ctx, args.Repo, args.Pr, pdfURL); err != nil {
+		lint, _, err := runReviewLint(ctx, args)
+		if err != nil {
+			// Nobody can act on findings for a push, so lint
+			// only annotates the PR.
+			workflow.GetLogger(ctx).Warn("Pre-flight lint failed", "error", err)
+		}
+		failed := built.Skipped
+		if err = postVisualDiff(ctx, args); err != nil {
+			failed.add(ctx, "visual diff", err)
+		}
+		if err = updatePRDescriptionSuccess(ctx, args, built.URL, lint, failed); err != nil {
 			return err
 		}
 	}
@@ -142,23 +204,36 @@ type reviewSignalProcessor struct {
 	args               *ReviewAgentArgs
 	agentCfg           *config.AgentConfig
 	aiTools            []llm.ToolDefinition
+	files              *fileTools
 	conversation       *llm.ConversationState
 	enableLayoutReview bool
 	buildRun           *int
+	// suggestMode posts edits as suggestions in a review
+	// instead of committing them.
+	suggestMode bool
 }
 
 func (p *reviewSignalProcessor) process(ctx workflow.Context, reviewSignal *webhook.WebhookSignal) (bool, error) {
 	if reviewSignal == nil {
 		return false, nil
 	}
-	// If signal is PR closed, then we are done.
-	if reviewSignal.Type == "pull_request" && reviewSignal.Action == "closed" {
-		return true, nil
+	if reviewSignal.Type == "pull_request" {
+		// If signal is PR closed, then we are done.
+		if reviewSignal.Action == "closed" {
+			return true, nil
+		}
+		p.applySuggestLabel(ctx, reviewSignal)
+		return false, nil
 	}
-	// Ignore signals from ourselves.
-	if reviewSignal.AuthorLogin == reviewBotLogin {
+	// Ignore signals from ourselves. They are told apart by
+	// their marker rather than their author, who in token mode
+	// is the reviewer too.
+	if github.IsAgentBody(reviewSignal.Body) {
 		return false, nil
 	}
+	if handled, err := p.applySuggestCommand(ctx, reviewSignal); handled || err != nil {
+		return false, err
+	}
 	// Ignore signals with empty bodies.
 	if reviewSignal.Body == "" {
 		return false, nil
@@ -175,12 +250,44 @@ func (p *reviewSignalProcessor) process(ctx workflow.Context, reviewSignal *webh
 	callAICtx := withCallAIActivityOptions(ctx)
 	dispatcher := &reviewAgentDispatcher{
 		aiTools:     p.aiTools,
+		files:       p.files,
 		ghOpts:      p.args.Repo,
 		branchName:  p.args.BranchName,
+		builder:     p.args.Builder,
 		buildTarget: p.args.BuildTarget,
 	}
+	if p.suggestMode {
+		// The GitHub MCP can commit too, so only the read-only
+		// file tools are offered.
+		dispatcher.aiTools = nil
+		dispatcher.files = p.files.readOnly()
+		dispatcher.suggestions = &suggestionRecorder{allowList: p.files.editAllow}
+		pendingInput = append(pendingInput, userMessage(suggestModeInstructions))
+	}
 
-	var pdfURL string
+	// Inline review comments are answered in their thread,
+	// with the commit that addressed them.
+	inThread := reviewSignal.Type == "pull_request_review_comment" && reviewSignal.CommentID != 0
+	var (
+		textFormat *activities.ResponseTextFormat
+		headBefore string
+	)
+	if inThread {
+		textFormat = reviewThreadReplyFormat
+		pendingInput = append(pendingInput, userMessage(reviewThreadInstructions))
+		headBefore, err = p.branchHead(ctx)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	var (
+		built       BuildAndUploadPDFOutput
+		reply       string
+		threadReply reviewThreadReply
+		lint        *activities.LintOutput
+		lintRun     int
+	)
 	for {
 		var result activities.AIResponse
 		err = workflow.ExecuteActivity(
@@ -189,8 +296,9 @@ func (p *reviewSignalProcessor) process(ctx workflow.Context, reviewSignal *webh
 			activities.AIRequest{
 				Model:        p.agentCfg.Model,
 				Input:        pendingInput,
-				Tools:        availableReviewTools(p.aiTools, p.enableLayoutReview),
+				Tools:        availableReviewTools(dispatcher.aiTools, dispatcher.files, p.enableLayoutReview, p.suggestMode),
 				Temperature:  temperatureOpt(p.agentCfg.Temperature),
+				Text:         textFormat,
 				Conversation: p.conversation,
 			},
 		).Get(ctx, &result)
@@ -207,11 +315,39 @@ func (p *reviewSignalProcessor) process(ctx workflow.Context, reviewSignal *webh
 			pendingInput = []llm.Message{userMessage(continuationMessage)}
 			continue
 		}
+		reply = result.OutputText
+		if inThread {
+			if threadReply, err = parseReviewThreadReply(reply); err != nil {
+				pendingInput = []llm.Message{userMessage("Invalid output format: " + err.Error())}
+				continue
+			}
+		}
+		if p.suggestMode {
+			// Nothing was committed, so there is nothing to
+			// lint or rebuild.
+			break
+		}
+
+		// Finished with agent loop, lint before rebuilding
+		// the PDF so blocked runs upload nothing.
+		lintRun++
+		var lintJSON string
+		lint, lintJSON, err = runReviewLint(ctx, *p.args)
+		if err != nil {
+			if msg, ok := buildFailedMessage(err); ok {
+				pendingInput = []llm.Message{msg}
+				continue
+			}
+			workflow.GetLogger(ctx).Warn("Pre-flight lint failed", "error", err)
+		} else if block, reason := shouldBlockLintFindings(lint, lintRun); block && lintRun < lintMaxRuns {
+			pendingInput = []llm.Message{userMessage(lintBlockedMessage(reason, lintJSON))}
+			continue
+		}
 
 		{
-			// Finished with agent loop, rebuild the PDF.
+			// Rebuild the PDF.
 			*p.buildRun++
-			pdfURL, err = runBuildAndUploadForReview(ctx, *p.args, *p.buildRun)
+			built, err = runBuildAndUploadForReview(ctx, *p.args, *p.buildRun)
 			if err != nil {
 				var appErr *temporal.ApplicationError
 				if errors.As(err, &appErr) && appErr.Type() == activities.ErrTypeBuildFailed {
@@ -230,22 +366,150 @@ func (p *reviewSignalProcessor) process(ctx workflow.Context, reviewSignal *webh
 		}
 	}
 
-	// Update URL in PR description.
-	if err = updatePRDescriptionSuccess(ctx, p.args.Repo, p.args.Pr, pdfURL); err != nil {
-		return false, err
+	if p.suggestMode {
+		if suggestions := dispatcher.suggestions.suggestions; len(suggestions) > 0 {
+			return false, p.postSuggestions(ctx, reviewSignal, reply, threadReply, suggestions)
+		}
+	} else {
+		// The GitHub MCP does not say what it pushed, so take
+		// the head we just rebuilt as ours.
+		if len(dispatcher.aiTools) > 0 {
+			head, err := p.branchHead(ctx)
+			if err != nil {
+				return false, err
+			}
+			p.files.recordPush(head)
+		}
+		failed := built.Skipped
+		if err = postVisualDiff(ctx, *p.args); err != nil {
+			failed.add(ctx, "visual diff", err)
+		}
+		// Update URL in PR description.
+		if err = updatePRDescriptionSuccess(ctx, *p.args, built.URL, lint, failed); err != nil {
+			return false, err
+		}
+	}
+
+	if inThread {
+		return false, p.replyInThread(ctx, reviewSignal.CommentID, threadReply, headBefore)
+	}
+
+	// Without the GitHub MCP the agent cannot comment itself,
+	// so post its final response to the reviewer.
+	if len(dispatcher.aiTools) == 0 && strings.TrimSpace(reply) != "" {
+		err = workflow.ExecuteActivity(ctx, activities.CommentOnPullRequest, activities.CommentOnPullRequestRequest{
+			ClientOptions: p.args.Repo,
+			PRNumber:      p.args.Pr,
+			Body:          reply,
+		}).Get(ctx, nil)
+		if err != nil {
+			return false, err
+		}
 	}
 
 	return false, nil
 }
 
+const reviewThreadInstructions = `This is an inline review comment. Your final response is posted as a reply in its review thread, so do not post it yourself. Respond with JSON: "reply" is your reply to the reviewer, summarizing what you changed, or explaining why you did not make a requested change; "outcome" is "addressed" if the comment is fully addressed, "partially_addressed" if only some of it is, "declined" if you chose not to make the change, or "needs_clarification" if you need the reviewer to clarify. Only fully addressed threads that you changed files for are resolved.`
+
+// parseReviewThreadReply parses and validates the agent's
+// response to an inline review comment.
+func parseReviewThreadReply(output string) (reviewThreadReply, error) {
+	var reply reviewThreadReply
+	if err := json.Unmarshal([]byte(output), &reply); err != nil {
+		return reviewThreadReply{}, err
+	}
+	reply.Reply = strings.TrimSpace(reply.Reply)
+	if reply.Reply == "" {
+		return reviewThreadReply{}, errors.New("reply must not be empty")
+	}
+	if !slices.Contains(reviewOutcomes, reply.Outcome) {
+		return reviewThreadReply{}, fmt.Errorf("outcome must be one of %s", strings.Join(reviewOutcomes, ", "))
+	}
+	return reply, nil
+}
+
+// branchHead returns the review branch's head commit.
+func (p *reviewSignalProcessor) branchHead(ctx workflow.Context) (string, error) {
+	var head string
+	err := workflow.ExecuteActivity(ctx, activities.GetBranchHeadSHA, activities.GetBranchHeadSHARequest{
+		ClientOptions: p.args.Repo,
+		Branch:        p.args.BranchName,
+	}).Get(ctx, &head)
+	return head, err
+}
+
+// replyInThread posts the agent's reply in the review
+// comment's thread, citing the commit its changes ended at,
+// and resolves the thread if the comment was addressed by a
+// change to the branch.
+func (p *reviewSignalProcessor) replyInThread(ctx workflow.Context, commentID int64, reply reviewThreadReply, headBefore string) error {
+	headAfter, err := p.branchHead(ctx)
+	if err != nil {
+		return err
+	}
+	err = workflow.ExecuteActivity(ctx, activities.ReplyToReviewComment, activities.ReplyToReviewCommentRequest{
+		ClientOptions: p.args.Repo,
+		PRNumber:      p.args.Pr,
+		CommentID:     commentID,
+		Body:          reviewThreadReplyBody(reply, headBefore, headAfter),
+	}).Get(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if !shouldResolveThread(reply, headBefore, headAfter) {
+		return nil
+	}
+	err = workflow.ExecuteActivity(ctx, activities.ResolveReviewThread, activities.ResolveReviewThreadRequest{
+		ClientOptions: p.args.Repo,
+		PRNumber:      p.args.Pr,
+		CommentID:     commentID,
+	}).Get(ctx, nil)
+	if err != nil {
+		// The reply already says what was done; an
+		// unresolved thread only costs the reviewer a click.
+		workflow.GetLogger(ctx).Warn("Failed to resolve review thread", "comment", commentID, "error", err)
+	}
+	return nil
+}
+
+// shouldResolveThread reports whether a reply resolves its
+// thread: the agent must both claim the comment is addressed
+// and have moved the branch, so a claim alone never hides a
+// comment.
+func shouldResolveThread(reply reviewThreadReply, headBefore, headAfter string) bool {
+	return reply.Outcome == reviewOutcomeAddressed && headAfter != "" && headAfter != headBefore
+}
+
+// reviewThreadReplyBody renders a thread reply, noting the
+// commit the branch moved to if the agent changed it.
+func reviewThreadReplyBody(reply reviewThreadReply, headBefore, headAfter string) string {
+	body := reply.Reply
+	if headAfter != "" && headAfter != headBefore {
+		body += "\n\nChanged in " + headAfter
+	}
+	return body
+}
+
 type reviewAgentDispatcher struct {
-	aiTools     []llm.ToolDefinition
+	aiTools []llm.ToolDefinition
+	files   *fileTools
+	// suggestions records suggest_change calls in suggestion
+	// mode, and is nil otherwise.
+	suggestions *suggestionRecorder
 	ghOpts      github.ClientOptions
 	branchName  string
+	builder     string
 	buildTarget BuildTarget
 }
 
 func (d *reviewAgentDispatcher) Dispatch(ctx workflow.Context, call llm.ToolCall) (workflow.Future, error) {
+	if fut, ok, err := d.files.dispatch(ctx, call); ok {
+		return fut, err
+	}
+	if call.Name == tools.SuggestChangeToolDesc.Name && d.suggestions != nil {
+		return d.suggestions.record(ctx, call)
+	}
 	if slices.ContainsFunc(d.aiTools, func(param llm.ToolDefinition) bool {
 		return param.Name == call.Name
 	}) {
@@ -254,7 +518,7 @@ func (d *reviewAgentDispatcher) Dispatch(ctx workflow.Context, call llm.ToolCall
 
 	switch call.Name {
 	case tools.BuildToolDesc.Name:
-		file, err := resolveBuildTargetFile(d.buildTarget)
+		file, err := resolveBuildTargetFile(d.builder, d.buildTarget)
 		if err != nil {
 			return nil, err
 		}
@@ -262,10 +526,23 @@ func (d *reviewAgentDispatcher) Dispatch(ctx workflow.Context, call llm.ToolCall
 		req := activities.BuildRequest{
 			ClientOptions: d.ghOpts,
 			Branch:        d.branchName,
-			Builder:       "typst", // TODO: remove this hardcoded builder
+			Builder:       d.builder,
 			File:          file,
 		}
 		return workflow.ExecuteActivity(ctx, activities.Build, req), nil
+	case tools.ATSCheckToolDesc.Name:
+		file, err := resolveBuildTargetFile(d.builder, d.buildTarget)
+		if err != nil {
+			return nil, err
+		}
+
+		req := activities.ATSCheckRequest{
+			ClientOptions: d.ghOpts,
+			Branch:        d.branchName,
+			Builder:       d.builder,
+			File:          file,
+		}
+		return workflow.ExecuteActivity(ctx, activities.ATSCheck, req), nil
 	case tools.OracleToolDesc.Name:
 		if d.buildTarget != BuildTargetResume {
 			return nil, fmt.Errorf("oracle is only available for resume builds")
@@ -275,7 +552,7 @@ func (d *reviewAgentDispatcher) Dispatch(ctx workflow.Context, call llm.ToolCall
 			return nil, err
 		}
 
-		file, err := resolveBuildTargetFile(d.buildTarget)
+		file, err := resolveBuildTargetFile(d.builder, d.buildTarget)
 		if err != nil {
 			return nil, err
 		}
@@ -283,7 +560,7 @@ func (d *reviewAgentDispatcher) Dispatch(ctx workflow.Context, call llm.ToolCall
 		req := OracleRequest{
 			ClientOptions: d.ghOpts,
 			Branch:        d.branchName,
-			Builder:       "typst",
+			Builder:       d.builder,
 			File:          file,
 			Label:         args.Label,
 			Questions:     args.Questions,
@@ -300,7 +577,7 @@ func (d *reviewAgentDispatcher) Dispatch(ctx workflow.Context, call llm.ToolCall
 			return nil, fmt.Errorf("list_labels is only available for resume builds")
 		}
 
-		file, err := resolveBuildTargetFile(d.buildTarget)
+		file, err := resolveBuildTargetFile(d.builder, d.buildTarget)
 		if err != nil {
 			return nil, err
 		}
@@ -308,6 +585,7 @@ func (d *reviewAgentDispatcher) Dispatch(ctx workflow.Context, call llm.ToolCall
 		req := activities.ListLabelsRequest{
 			ClientOptions: d.ghOpts,
 			Branch:        d.branchName,
+			Builder:       d.builder,
 			File:          file,
 		}
 		return workflow.ExecuteActivity(ctx, activities.ListLabels, req), nil
@@ -342,9 +620,19 @@ func (d *reviewAgentDispatcher) Dispatch(ctx workflow.Context, call llm.ToolCall
 	}
 }
 
-func availableReviewTools(aiTools []llm.ToolDefinition, enableLayoutReview bool) []llm.ToolDefinition {
-	ret := append([]llm.ToolDefinition{}, aiTools...)
-	ret = append(ret, tools.BuildToolDesc)
+// availableReviewTools lists the tools offered to the review
+// agent. Suggestion mode never offers the GitHub MCP, whose
+// tools can write to the branch.
+func availableReviewTools(aiTools []llm.ToolDefinition, files *fileTools, enableLayoutReview, suggestMode bool) []llm.ToolDefinition {
+	var ret []llm.ToolDefinition
+	if !suggestMode {
+		ret = append(ret, aiTools...)
+	}
+	ret = append(ret, files.definitions()...)
+	if suggestMode {
+		ret = append(ret, tools.SuggestChangeToolDesc)
+	}
+	ret = append(ret, tools.BuildToolDesc, tools.ATSCheckToolDesc)
 	if enableLayoutReview {
 		ret = append(ret, tools.OracleToolDesc, tools.ListLabelsToolDesc)
 	}
@@ -354,8 +642,8 @@ func availableReviewTools(aiTools []llm.ToolDefinition, enableLayoutReview bool)
 
 const prArtifactErrorLinePrefix = "PDF Artifact Error:"
 
-func runBuildAndUploadForReview(ctx workflow.Context, args ReviewAgentArgs, buildRun int) (string, error) {
-	var pdfURL string
+func runBuildAndUploadForReview(ctx workflow.Context, args ReviewAgentArgs, buildRun int) (BuildAndUploadPDFOutput, error) {
+	var built BuildAndUploadPDFOutput
 	err := workflow.ExecuteChildWorkflow(
 		workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
 			WorkflowID: MakeChildWorkflowID(
@@ -370,20 +658,49 @@ func runBuildAndUploadForReview(ctx workflow.Context, args ReviewAgentArgs, buil
 		BuildAndUploadPDFWorkflowRequest{
 			ClientOptions: args.Repo,
 			Branch:        args.BranchName,
-			Builder:       "typst", // TODO: remove this hardcoded builder
+			Builder:       args.Builder,
 			BuildTarget:   args.BuildTarget,
+			Job:           args.Job,
+			PDFStandard:   args.PDFStandard,
 		},
-	).Get(ctx, &pdfURL)
+	).Get(ctx, &built)
+	return built, err
+}
+
+// runReviewLint lints the review branch against the PR's
+// base.
+func runReviewLint(ctx workflow.Context, args ReviewAgentArgs) (*activities.LintOutput, string, error) {
+	file, err := resolveBuildTargetFile(args.Builder, args.BuildTarget)
 	if err != nil {
-		return "", err
+		return nil, "", err
 	}
-	return pdfURL, nil
+	return runLintGate(ctx, activities.LintRequest{
+		ClientOptions: args.Repo,
+		Branch:        args.BranchName,
+		BaseBranch:    args.BaseBranch,
+		Builder:       args.Builder,
+		File:          file,
+	})
 }
 
-func updatePRDescriptionSuccess(ctx workflow.Context, repo github.ClientOptions, pr int, url string) error {
+// updatePRDescriptionSuccess points the PR's artifact line at
+// url and refreshes the export links, keyword coverage table,
+// lint findings and failed extras beside it.
+func updatePRDescriptionSuccess(ctx workflow.Context, args ReviewAgentArgs, url string, lint *activities.LintOutput, failed extraFailures) error {
+	repo, pr := args.Repo, args.Pr
+	failed = slices.Clone(failed)
+	coverage, err := runKeywordCoverage(ctx, repo, args.BranchName, args.BaseBranch, args.BuildTarget, args.Builder, args.Job)
+	if err != nil {
+		failed.add(ctx, "keyword coverage", err)
+	}
+	exports, err := runExports(ctx, repo, args.BranchName, args.Builder, args.BuildTarget, args.Exports, url, args.PDFStandard)
+	if err != nil {
+		failed.add(ctx, "exports", err)
+	}
+
 	// Get existing PR description
 	var body string
-	err := workflow.ExecuteActivity(
+	err = workflow.ExecuteActivity(
 		ctx,
 		activities.GetPullRequestBody,
 		activities.GetPullRequestBodyRequest{
@@ -396,6 +713,10 @@ func updatePRDescriptionSuccess(ctx workflow.Context, repo github.ClientOptions,
 	}
 
 	body, oldURL := rewriteArtifactLinesForSuccess(body, url)
+	body = rewriteLint(body, lint)
+	body = rewriteKeywordCoverage(body, coverage)
+	body = rewriteExports(body, exports)
+	body = rewriteExtraFailures(body, failed)
 	if oldURL != "" {
 		err = workflow.ExecuteActivity(
 			ctx,
@@ -527,7 +848,10 @@ func buildFailureReason(err error) string {
 	if errors.As(err, &appErr) && appErr.Type() == activities.ErrTypeBuildFailed {
 		var details []string
 		if detailsErr := appErr.Details(&details); detailsErr == nil && len(details) > 0 {
-			return sanitizeArtifactErrorReason(details[0])
+			// Only the summary line; the rest is hints and
+			// source excerpt.
+			summary, _, _ := strings.Cut(details[0], "\n")
+			return sanitizeArtifactErrorReason(summary)
 		}
 	}
 	return sanitizeArtifactErrorReason(err.Error())
@@ -1,8 +1,12 @@
 package agents
 
 import (
+	"slices"
 	"strings"
 	"testing"
+
+	"github.com/ansg191/job-temporal/internal/llm"
+	"github.com/ansg191/job-temporal/internal/tools"
 )
 
 func TestRewriteArtifactLinesForSuccessReplacesURLAndClearsError(t *testing.T) {
@@ -58,3 +62,116 @@ func TestSanitizeArtifactErrorReasonDefaultsForEmpty(t *testing.T) {
 		t.Fatalf("expected default message, got %q", got)
 	}
 }
+
+func TestParseReviewThreadReply(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		output  string
+		want    reviewThreadReply
+		wantErr bool
+	}{
+		{
+			name:   "addressed",
+			output: `{"reply": " Shortened the bullet. ", "outcome": "addressed"}`,
+			want:   reviewThreadReply{Reply: "Shortened the bullet.", Outcome: reviewOutcomeAddressed},
+		},
+		{
+			name:   "declined",
+			output: `{"reply": "Kept it, the job asks for Go.", "outcome": "declined"}`,
+			want:   reviewThreadReply{Reply: "Kept it, the job asks for Go.", Outcome: reviewOutcomeDeclined},
+		},
+		{name: "unknown outcome", output: `{"reply": "Done", "outcome": "done"}`, wantErr: true},
+		{name: "empty reply", output: `{"reply": " ", "outcome": "addressed"}`, wantErr: true},
+		{name: "not json", output: "Done", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseReviewThreadReply(tt.output)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseReviewThreadReply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("parseReviewThreadReply() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReviewThreadReplyBodyCitesNewCommit(t *testing.T) {
+	t.Parallel()
+
+	reply := reviewThreadReply{Reply: "Done.", Outcome: reviewOutcomeAddressed}
+	if got := reviewThreadReplyBody(reply, "aaa", "bbb"); got != "Done.\n\nChanged in bbb" {
+		t.Fatalf("unexpected body for changed branch: %q", got)
+	}
+	if got := reviewThreadReplyBody(reply, "aaa", "aaa"); got != "Done." {
+		t.Fatalf("unexpected body for unchanged branch: %q", got)
+	}
+}
+
+func TestShouldResolveThread(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		outcome    string
+		headBefore string
+		headAfter  string
+		want       bool
+	}{
+		{name: "addressed with a commit", outcome: reviewOutcomeAddressed, headBefore: "aaa", headAfter: "bbb", want: true},
+		{name: "addressed without a commit", outcome: reviewOutcomeAddressed, headBefore: "aaa", headAfter: "aaa"},
+		{name: "addressed with unknown head", outcome: reviewOutcomeAddressed, headBefore: "aaa"},
+		{name: "declined with a commit", outcome: "declined", headBefore: "aaa", headAfter: "bbb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			reply := reviewThreadReply{Reply: "Done.", Outcome: tt.outcome}
+			if got := shouldResolveThread(reply, tt.headBefore, tt.headAfter); got != tt.want {
+				t.Errorf("shouldResolveThread() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAvailableReviewToolsSuggestMode(t *testing.T) {
+	t.Parallel()
+
+	mcpTool := llm.ToolDefinition{Name: "create_or_update_file"}
+	files := &fileTools{readAllow: []string{"resume.typ"}, editAllow: []string{"resume.typ"}}
+
+	tests := []struct {
+		name        string
+		files       *fileTools
+		suggestMode bool
+		wantMCP     bool
+		wantSuggest bool
+	}{
+		{name: "commit mode", files: files, wantMCP: true},
+		{name: "suggestion mode", files: files.readOnly(), suggestMode: true, wantSuggest: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			defs := availableReviewTools([]llm.ToolDefinition{mcpTool}, tt.files, false, tt.suggestMode)
+			has := func(name string) bool {
+				return slices.ContainsFunc(defs, func(d llm.ToolDefinition) bool { return d.Name == name })
+			}
+			if got := has(mcpTool.Name); got != tt.wantMCP {
+				t.Errorf("offers MCP tool = %v, want %v", got, tt.wantMCP)
+			}
+			if got := has(tools.SuggestChangeToolDesc.Name); got != tt.wantSuggest {
+				t.Errorf("offers suggest_change = %v, want %v", got, tt.wantSuggest)
+			}
+			if tt.suggestMode && has(tools.EditFileToolDesc.Name) {
+				t.Error("offers edit_file in suggestion mode")
+			}
+		})
+	}
+}
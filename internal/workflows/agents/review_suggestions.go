@@ -0,0 +1,155 @@
+package agents
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/ansg191/job-temporal/internal/activities"
+	"github.com/ansg191/job-temporal/internal/github"
+	"github.com/ansg191/job-temporal/internal/llm"
+	"github.com/ansg191/job-temporal/internal/tools"
+	"github.com/ansg191/job-temporal/internal/webhook"
+)
+
+// suggestModeLabel turns suggestion mode on for a PR while
+// it is applied.
+const suggestModeLabel = "suggestions"
+
+// Comment commands toggling suggestion mode. They must be
+// the first line of the comment.
+const (
+	suggestModeOnCommand  = "/suggestions on"
+	suggestModeOffCommand = "/suggestions off"
+)
+
+const suggestModeInstructions = `Suggestion mode is on for this pull request: do not edit files. Make every change with suggest_change instead, giving the exact replacement lines; your suggestions are posted as a review for the reviewer to apply, and your final response is the review's summary.`
+
+// applySuggestLabel switches suggestion mode when the
+// suggestions label is added to or removed from the PR.
+func (p *reviewSignalProcessor) applySuggestLabel(ctx workflow.Context, signal *webhook.WebhookSignal) {
+	if signal.Label != suggestModeLabel {
+		return
+	}
+	switch signal.Action {
+	case "labeled":
+		p.suggestMode = true
+	case "unlabeled":
+		p.suggestMode = false
+	default:
+		return
+	}
+	workflow.GetLogger(ctx).Info("Suggestion mode toggled by label", "on", p.suggestMode)
+}
+
+// applySuggestCommand switches suggestion mode if the
+// comment starts with a suggestion mode command. The rest of
+// the comment is left in signal to be handled as feedback;
+// if there is none, the switch is acknowledged and handled
+// is true.
+func (p *reviewSignalProcessor) applySuggestCommand(ctx workflow.Context, signal *webhook.WebhookSignal) (handled bool, err error) {
+	on, rest, ok := parseSuggestCommand(signal.Body)
+	if !ok {
+		return false, nil
+	}
+	p.suggestMode = on
+	signal.Body = rest
+	if rest != "" {
+		return false, nil
+	}
+
+	ack := "Suggestion mode is off: I'll commit changes to the branch again."
+	if on {
+		ack = "Suggestion mode is on: I'll post changes as suggestions in a review instead of committing them."
+	}
+	err = workflow.ExecuteActivity(ctx, activities.CommentOnPullRequest, activities.CommentOnPullRequestRequest{
+		ClientOptions: p.args.Repo,
+		PRNumber:      p.args.Pr,
+		Body:          ack,
+	}).Get(ctx, nil)
+	return true, err
+}
+
+// parseSuggestCommand reports whether body starts with a
+// suggestion mode command, which mode it selects and the
+// rest of the body.
+func parseSuggestCommand(body string) (on bool, rest string, ok bool) {
+	first, rest, _ := strings.Cut(strings.TrimSpace(body), "\n")
+	switch strings.ToLower(strings.Join(strings.Fields(first), " ")) {
+	case suggestModeOnCommand:
+		on = true
+	case suggestModeOffCommand:
+		on = false
+	default:
+		return false, "", false
+	}
+	return on, strings.TrimSpace(rest), true
+}
+
+// suggestionRecorder collects the suggest_change calls of
+// one review pass.
+type suggestionRecorder struct {
+	// allowList is the files suggestions may change: those
+	// the agent could otherwise edit.
+	allowList   []string
+	suggestions []github.ReviewSuggestion
+}
+
+func (r *suggestionRecorder) record(ctx workflow.Context, call llm.ToolCall) (workflow.Future, error) {
+	var s github.ReviewSuggestion
+	if err := tools.SuggestChangeToolParseArgs(call.Arguments, &s); err != nil {
+		return nil, err
+	}
+	s.Path = path.Clean(strings.TrimSpace(s.Path))
+	if !slices.ContainsFunc(r.allowList, func(pattern string) bool {
+		ok, err := path.Match(pattern, s.Path)
+		return err == nil && ok
+	}) {
+		return nil, fmt.Errorf("file %s not allowed to be changed", s.Path)
+	}
+	r.suggestions = append(r.suggestions, s)
+
+	fut, settable := workflow.NewFuture(ctx)
+	settable.SetValue(fmt.Sprintf("Suggestion for %s recorded", s.Location()))
+	return fut, nil
+}
+
+// postSuggestions posts the pass's suggestions as a review.
+// An inline comment is also answered in its thread, which
+// stays open until the suggestions are applied.
+func (p *reviewSignalProcessor) postSuggestions(
+	ctx workflow.Context,
+	signal *webhook.WebhookSignal,
+	reply string,
+	threadReply reviewThreadReply,
+	suggestions []github.ReviewSuggestion,
+) error {
+	inThread := signal.Type == "pull_request_review_comment" && signal.CommentID != 0
+	body := strings.TrimSpace(reply)
+	switch {
+	case inThread:
+		body = fmt.Sprintf("Suggested changes for the comment on `%s`.", signal.FilePath)
+	case body == "":
+		// GitHub requires a body on comment reviews.
+		body = "Suggested changes."
+	}
+	err := workflow.ExecuteActivity(ctx, activities.SuggestChanges, activities.SuggestChangesRequest{
+		ClientOptions: p.args.Repo,
+		PRNumber:      p.args.Pr,
+		Body:          body,
+		Suggestions:   suggestions,
+	}).Get(ctx, nil)
+	if err != nil || !inThread {
+		return err
+	}
+
+	return workflow.ExecuteActivity(ctx, activities.ReplyToReviewComment, activities.ReplyToReviewCommentRequest{
+		ClientOptions: p.args.Repo,
+		PRNumber:      p.args.Pr,
+		CommentID:     signal.CommentID,
+		Body:          threadReply.Reply + "\n\nI've suggested the changes in a review rather than committing them.",
+	}).Get(ctx, nil)
+}
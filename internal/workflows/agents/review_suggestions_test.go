@@ -0,0 +1,39 @@
+package agents
+
+import "testing"
+
+func TestParseSuggestCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		body     string
+		wantOn   bool
+		wantRest string
+		wantOK   bool
+	}{
+		{name: "on", body: "/suggestions on", wantOn: true, wantOK: true},
+		{name: "off with spacing", body: "  /Suggestions   OFF \n", wantOK: true},
+		{
+			name:     "on with feedback",
+			body:     "/suggestions on\nTighten the summary.",
+			wantOn:   true,
+			wantRest: "Tighten the summary.",
+			wantOK:   true,
+		},
+		{name: "not first line", body: "Tighten the summary.\n/suggestions on"},
+		{name: "unknown argument", body: "/suggestions maybe"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			on, rest, ok := parseSuggestCommand(tt.body)
+			if on != tt.wantOn || rest != tt.wantRest || ok != tt.wantOK {
+				t.Fatalf("parseSuggestCommand(%q) = %v, %q, %v, want %v, %q, %v",
+					tt.body, on, rest, ok, tt.wantOn, tt.wantRest, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package agents
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/ansg191/job-temporal/internal/activities"
+	"github.com/ansg191/job-temporal/internal/git"
+	"github.com/ansg191/job-temporal/internal/github"
+	"github.com/ansg191/job-temporal/internal/llm"
+)
+
+type squashOutput struct {
+	Groups []git.CommitGroup `json:"groups"`
+}
+
+var squashOutputFormat = activities.GenerateTextFormat[squashOutput]("squash_output")
+
+// squashMaxAttempts bounds how often the model may retry
+// after proposing invalid groups.
+const squashMaxAttempts = 3
+
+// squashBranch regroups the builder's commits on branch into
+// a few logical commits with generated messages before the
+// PR is opened. On failure the branch is left as it was.
+func squashBranch(ctx workflow.Context, ghOpts github.ClientOptions, branch, base string) error {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 2 * time.Minute,
+	})
+
+	var history git.BranchHistory
+	err := workflow.ExecuteActivity(ctx, activities.BranchHistory, activities.BranchHistoryRequest{
+		ClientOptions: ghOpts,
+		Branch:        branch,
+		Base:          base,
+	}).Get(ctx, &history)
+	if err != nil {
+		return err
+	}
+	if len(history.Commits) < 2 {
+		return nil
+	}
+
+	agentCfg, err := loadAgentConfig(ctx, "squash")
+	if err != nil {
+		return err
+	}
+	historyJSON, err := json.Marshal(history.Commits)
+	if err != nil {
+		return err
+	}
+	filesJSON, err := json.Marshal(history.Files)
+	if err != nil {
+		return err
+	}
+
+	messages := []llm.Message{
+		systemMessage(agentCfg.Instructions),
+		userMessage(wrapLLMXML("commits", string(historyJSON))),
+		userMessage(wrapLLMXML("changed_files", string(filesJSON))),
+	}
+	conversation, err := createConversation(ctx, agentCfg.Model, nil)
+	if err != nil {
+		return err
+	}
+	callAICtx := withCallAIActivityOptions(ctx)
+
+	for attempt := 0; attempt < squashMaxAttempts; {
+		var result activities.AIResponse
+		err = workflow.ExecuteActivity(callAICtx, activities.CallAI, activities.AIRequest{
+			Model:        agentCfg.Model,
+			Input:        messages,
+			Text:         squashOutputFormat,
+			Temperature:  temperatureOpt(agentCfg.Temperature),
+			Conversation: conversation,
+		}).Get(ctx, &result)
+		if err != nil {
+			return err
+		}
+		conversation = result.Conversation
+		if aiShouldContinue(result) {
+			messages = []llm.Message{userMessage(continuationMessage)}
+			continue
+		}
+		attempt++
+
+		var out squashOutput
+		if err = json.Unmarshal([]byte(result.OutputText), &out); err != nil {
+			messages = []llm.Message{userMessage("Invalid output format: " + err.Error())}
+			continue
+		}
+		if err = git.ValidateCommitGroups(history.Files, out.Groups); err != nil {
+			messages = []llm.Message{userMessage("Invalid groups: " + err.Error())}
+			continue
+		}
+		if len(out.Groups) >= len(history.Commits) {
+			// Nothing to squash.
+			return nil
+		}
+
+		err = workflow.ExecuteActivity(ctx, activities.RegroupCommits, activities.RegroupCommitsRequest{
+			ClientOptions: ghOpts,
+			Branch:        branch,
+			Base:          base,
+			Head:          history.Head,
+			Groups:        out.Groups,
+		}).Get(ctx, nil)
+		var appErr *temporal.ApplicationError
+		if errors.As(err, &appErr) && appErr.Type() == activities.ErrTypeInvalidCommitGroups {
+			messages = []llm.Message{userMessage("Invalid groups: " + appErr.Error())}
+			continue
+		}
+		return err
+	}
+	return fmt.Errorf("squash agent gave no valid groups in %d attempts", squashMaxAttempts)
+}
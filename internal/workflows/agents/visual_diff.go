@@ -0,0 +1,77 @@
+package agents
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/ansg191/job-temporal/internal/activities"
+)
+
+// postVisualDiff comments on the PR with a visual diff of
+// the built document against the base branch.
+func postVisualDiff(ctx workflow.Context, args ReviewAgentArgs) error {
+	if args.BaseBranch == "" {
+		return nil
+	}
+	file, err := resolveBuildTargetFile(args.Builder, args.BuildTarget)
+	if err != nil {
+		return err
+	}
+
+	diffCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 5 * time.Minute,
+	})
+	var diff activities.VisualDiffOutput
+	err = workflow.ExecuteActivity(diffCtx, activities.VisualDiff, activities.VisualDiffRequest{
+		ClientOptions: args.Repo,
+		BaseBranch:    args.BaseBranch,
+		HeadBranch:    args.BranchName,
+		Builder:       args.Builder,
+		File:          file,
+	}).Get(ctx, &diff)
+	if err != nil {
+		return err
+	}
+
+	return workflow.ExecuteActivity(ctx, activities.CommentOnPullRequest, activities.CommentOnPullRequestRequest{
+		ClientOptions: args.Repo,
+		PRNumber:      args.Pr,
+		Body:          visualDiffComment(args.BaseBranch, diff),
+	}).Get(ctx, nil)
+}
+
+func visualDiffComment(base string, diff activities.VisualDiffOutput) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### Visual diff against `%s`\n\n", base)
+	if diff.BasePages != diff.HeadPages {
+		fmt.Fprintf(&b, "Page count changed from %d to %d.\n\n", diff.BasePages, diff.HeadPages)
+	}
+	if len(diff.Pages) == 0 {
+		b.WriteString("No visual changes.")
+		return b.String()
+	}
+
+	b.WriteString("Green marks added ink and red removed ink; changed regions are outlined.\n")
+	for _, page := range diff.Pages {
+		locations := make([]string, 0, len(page.Regions))
+		for _, r := range page.Regions {
+			if r.Location != "" && !slices.Contains(locations, r.Location) {
+				locations = append(locations, r.Location)
+			}
+		}
+		regions := "region"
+		if len(page.Regions) != 1 {
+			regions = "regions"
+		}
+		fmt.Fprintf(&b, "\n**Page %d**: %.1f%% of the page changed in %d %s", page.Page, page.ChangedPercent, len(page.Regions), regions)
+		if len(locations) > 0 {
+			fmt.Fprintf(&b, " (%s)", strings.Join(locations, ", "))
+		}
+		fmt.Fprintf(&b, ".\n\n![Page %d changes](%s)\n", page.Page, page.OverlayURL)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
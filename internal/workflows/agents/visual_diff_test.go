@@ -0,0 +1,37 @@
+package agents
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ansg191/job-temporal/internal/activities"
+)
+
+func TestVisualDiffComment(t *testing.T) {
+	t.Parallel()
+
+	got := visualDiffComment("final", activities.VisualDiffOutput{BasePages: 1, HeadPages: 1})
+	if got != "### Visual diff against `final`\n\nNo visual changes." {
+		t.Errorf("unchanged comment = %q", got)
+	}
+
+	got = visualDiffComment("final", activities.VisualDiffOutput{
+		BasePages: 1,
+		HeadPages: 2,
+		Pages: []activities.VisualDiffPage{{
+			Page:           1,
+			ChangedPercent: 3.14,
+			Regions:        []activities.VisualDiffRegion{{Location: "top"}, {Location: "bottom left"}, {Location: "top"}},
+			OverlayURL:     "https://example/diff.png",
+		}},
+	})
+	for _, want := range []string{
+		"Page count changed from 1 to 2.",
+		"**Page 1**: 3.1% of the page changed in 3 regions (top, bottom left).",
+		"![Page 1 changes](https://example/diff.png)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("comment missing %q:\n%s", want, got)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"go.temporal.io/sdk/workflow"
 
+	"github.com/ansg191/job-temporal/internal/export"
 	"github.com/ansg191/job-temporal/internal/github"
 	"github.com/ansg191/job-temporal/internal/workflows/agents"
 )
@@ -15,6 +16,20 @@ type BuilderWorkflowRequest struct {
 	TargetBranch string `json:"target_branch"`
 	Purpose      string `json:"purpose"`
 	Builder      string `json:"builder"`
+	ATSGate      bool   `json:"ats_gate"`
+	PDFStandard  string `json:"pdf_standard,omitempty"`
+	// Exports lists extra formats for this target's artifact.
+	Exports []string `json:"exports,omitempty"`
+}
+
+// PullRequestOpenedSignal is sent by BuilderWorkflow to its
+// parent once its PR is open.
+const PullRequestOpenedSignal = "pull-request-opened"
+
+type PullRequestOpened struct {
+	Purpose string `json:"purpose"`
+	Branch  string `json:"branch"`
+	PR      int    `json:"pr"`
 }
 
 func BuilderWorkflow(ctx workflow.Context, req BuilderWorkflowRequest) error {
@@ -22,6 +37,9 @@ func BuilderWorkflow(ctx workflow.Context, req BuilderWorkflowRequest) error {
 	if err != nil {
 		return err
 	}
+	if _, err = export.ParseFormats(req.Exports); err != nil {
+		return err
+	}
 
 	// Create new branch for us to work with
 	var branchName string
@@ -53,12 +71,28 @@ func BuilderWorkflow(ctx workflow.Context, req BuilderWorkflowRequest) error {
 			BranchName:    branchName,
 			TargetBranch:  req.TargetBranch,
 			Job:           req.JobDesc,
+			ATSGate:       req.ATSGate,
+			PDFStandard:   req.PDFStandard,
+			Exports:       req.Exports,
 		},
 	).Get(ctx, &pr)
 	if err != nil {
 		return err
 	}
 
+	// Tell the job workflow the PR is open so it can keep it
+	// mergeable once the other PR merges.
+	if parent := workflow.GetInfo(ctx).ParentWorkflowExecution; parent != nil {
+		err = workflow.SignalExternalWorkflow(ctx, parent.ID, "", PullRequestOpenedSignal, PullRequestOpened{
+			Purpose: req.Purpose,
+			Branch:  branchName,
+			PR:      pr,
+		}).Get(ctx, nil)
+		if err != nil {
+			workflow.GetLogger(ctx).Warn("Failed to signal opened PR", "error", err)
+		}
+	}
+
 	err = workflow.ExecuteChildWorkflow(
 		workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
 			WorkflowID: agents.MakeChildWorkflowID(ctx, "review-agent", branchName, req.Purpose),
@@ -68,7 +102,12 @@ func BuilderWorkflow(ctx workflow.Context, req BuilderWorkflowRequest) error {
 			Repo:        req.ClientOptions,
 			Pr:          pr,
 			BranchName:  branchName,
+			Builder:     req.Builder,
 			BuildTarget: buildTarget,
+			Job:         req.JobDesc,
+			PDFStandard: req.PDFStandard,
+			Exports:     req.Exports,
+			BaseBranch:  req.TargetBranch,
 		},
 	).Get(ctx, &pr)
 	if err != nil {
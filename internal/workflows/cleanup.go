@@ -0,0 +1,106 @@
+package workflows
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/ansg191/job-temporal/internal/activities"
+	"github.com/ansg191/job-temporal/internal/github"
+	"github.com/ansg191/job-temporal/internal/webhook"
+)
+
+type CleanupWorkflowRequest struct {
+	Repos []github.ClientOptions `json:"repos"`
+	// Retention is how long a job's PRs and branches are kept
+	// after their last activity.
+	Retention time.Duration `json:"retention"`
+	// DryRun only reports what would be cleaned up.
+	DryRun bool `json:"dry_run"`
+}
+
+// CleanupWorkflow garbage collects the branches and PRs of
+// finished jobs: abandoned PRs are closed, merged and stale
+// feature branches deleted, and final branches archived as
+// tags. It plans every repository first and returns the
+// report; unless DryRun is set it then applies the plan. A
+// failed action is reported and skipped.
+func CleanupWorkflow(ctx workflow.Context, req CleanupWorkflowRequest) (string, error) {
+	if req.Retention <= 0 {
+		return "", temporal.NewNonRetryableApplicationError("retention must be positive", "InvalidRetention", nil)
+	}
+	logger := workflow.GetLogger(ctx)
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 2 * time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			MaximumAttempts: 3,
+		},
+	})
+	now := workflow.Now(ctx)
+
+	plans := make([]activities.CleanupPlan, 0, len(req.Repos))
+	for _, repo := range req.Repos {
+		var plan activities.CleanupPlan
+		err := workflow.ExecuteActivity(ctx, activities.PlanCleanup, activities.PlanCleanupRequest{
+			ClientOptions: repo,
+			Retention:     req.Retention,
+			Now:           now,
+		}).Get(ctx, &plan)
+		if err != nil {
+			return "", err
+		}
+		plans = append(plans, plan)
+	}
+
+	var report strings.Builder
+	if req.DryRun {
+		report.WriteString("Dry run, nothing was changed.\n")
+	}
+	for _, plan := range plans {
+		report.WriteString(plan.Report())
+		report.WriteString("\n")
+	}
+	logger.Info("Cleanup plan", "dry_run", req.DryRun, "report", report.String())
+	if req.DryRun {
+		return report.String(), nil
+	}
+
+	var failed []string
+	for _, plan := range plans {
+		for _, action := range plan.Actions {
+			if action.Kind == activities.CleanupFinishWorkflow {
+				stopReviewAgent(ctx, action.WorkflowID)
+			}
+			err := workflow.ExecuteActivity(ctx, activities.ApplyCleanupAction, activities.ApplyCleanupActionRequest{
+				ClientOptions: plan.Repo,
+				Action:        action,
+			}).Get(ctx, nil)
+			if err != nil {
+				logger.Warn("Cleanup action failed", "action", action.String(), "error", err)
+				failed = append(failed, fmt.Sprintf("%s/%s: %s: %v", plan.Repo.Owner, plan.Repo.Repo, action, err))
+			}
+		}
+	}
+	if len(failed) > 0 {
+		report.WriteString("Failed:\n  - ")
+		report.WriteString(strings.Join(failed, "\n  - "))
+		report.WriteString("\n")
+	}
+	return report.String(), nil
+}
+
+// stopReviewAgent tells a review agent its PR was closed, as
+// the webhook would, so it finishes. The agent may already
+// be gone.
+func stopReviewAgent(ctx workflow.Context, workflowID string) {
+	err := workflow.SignalExternalWorkflow(ctx, workflowID, "", webhook.ReviewAgentSignal, &webhook.WebhookSignal{
+		Type:   "pull_request",
+		Action: "closed",
+	}).Get(ctx, nil)
+	if err != nil {
+		workflow.GetLogger(ctx).Info("Review agent not signalled", "workflow_id", workflowID, "error", err)
+	}
+}
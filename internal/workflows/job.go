@@ -1,6 +1,7 @@
 package workflows
 
 import (
+	"strconv"
 	"time"
 
 	"go.temporal.io/sdk/workflow"
@@ -14,9 +15,27 @@ type JobWorkflowRequest struct {
 	github.ClientOptions
 	JobDesc   string `json:"job_desc"`
 	SourceURL string `json:"source_url"`
+	// Builder selects the document builder ("typst" or
+	// "latex"). Defaults to typst.
+	Builder string `json:"builder"`
+	// ATSGate requires the resume and cover letter to pass
+	// the ATS text extraction check before a PR is opened.
+	ATSGate bool `json:"ats_gate"`
+	// PDFStandard requests conforming final PDFs, e.g. "a-2b"
+	// for portals that only accept PDF/A. Typst only.
+	PDFStandard string `json:"pdf_standard,omitempty"`
+	// ResumeExports and CoverLetterExports list extra formats
+	// ("txt", "html", "docx") uploaded beside each PDF.
+	ResumeExports      []string `json:"resume_exports,omitempty"`
+	CoverLetterExports []string `json:"cover_letter_exports,omitempty"`
 }
 
 func JobWorkflow(ctx workflow.Context, req JobWorkflowRequest) (string, error) {
+	builderName := req.Builder
+	if builderName == "" {
+		builderName = "typst"
+	}
+
 	// Create job run record in database
 	activityCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
 		StartToCloseTimeout: time.Minute,
@@ -67,7 +86,10 @@ func JobWorkflow(ctx workflow.Context, req JobWorkflowRequest) (string, error) {
 			JobDesc:       req.JobDesc,
 			TargetBranch:  branchName,
 			Purpose:       "resume",
-			Builder:       "typst",
+			Builder:       builderName,
+			ATSGate:       req.ATSGate,
+			PDFStandard:   req.PDFStandard,
+			Exports:       req.ResumeExports,
 		},
 	)
 	coverLetterFut := workflow.ExecuteChildWorkflow(
@@ -80,16 +102,46 @@ func JobWorkflow(ctx workflow.Context, req JobWorkflowRequest) (string, error) {
 			JobDesc:       req.JobDesc,
 			TargetBranch:  branchName,
 			Purpose:       "cover_letter",
-			Builder:       "typst",
+			Builder:       builderName,
+			ATSGate:       req.ATSGate,
+			PDFStandard:   req.PDFStandard,
+			Exports:       req.CoverLetterExports,
 		},
 	)
 
-	// Wait on futures
-	if err = resumeFut.Get(ctx, nil); err != nil {
-		return "", err
+	// Wait on futures. Once one PR is closed, likely merged
+	// into the final branch, the other may conflict with it,
+	// so it is merged with the final branch.
+	merges := jobMerges{
+		req:         req,
+		builderName: builderName,
+		target:      branchName,
+		opened:      make(map[string]PullRequestOpened),
+		done:        make(map[string]bool),
 	}
-	if err = coverLetterFut.Get(ctx, nil); err != nil {
-		return "", err
+	openedCh := workflow.GetSignalChannel(ctx, PullRequestOpenedSignal)
+	selector := workflow.NewSelector(ctx)
+	for i, fut := range []workflow.ChildWorkflowFuture{resumeFut, coverLetterFut} {
+		purpose := jobPurposes[i]
+		selector.AddFuture(fut, func(f workflow.Future) {
+			err = f.Get(ctx, nil)
+			merges.done[purpose] = true
+			if err == nil {
+				merges.afterClose(ctx, purpose)
+			}
+		})
+	}
+	selector.AddReceive(openedCh, func(c workflow.ReceiveChannel, _ bool) {
+		var opened PullRequestOpened
+		c.Receive(ctx, &opened)
+		merges.opened[opened.Purpose] = opened
+		merges.afterOpen(ctx, opened.Purpose)
+	})
+	for len(merges.done) < len(jobPurposes) {
+		selector.Select(ctx)
+		if err != nil {
+			return "", err
+		}
 	}
 
 	err = workflow.ExecuteActivity(activityCtx, activities.ProtectBranch, activities.ProtectBranchRequest{
@@ -102,3 +154,64 @@ func JobWorkflow(ctx workflow.Context, req JobWorkflowRequest) (string, error) {
 
 	return branchName, nil
 }
+
+// jobPurposes are the purposes of the job's builder
+// workflows.
+var jobPurposes = []string{"resume", "cover_letter"}
+
+// jobMerges keeps each builder's PR mergeable into the final
+// branch after the other builder's PR closes.
+type jobMerges struct {
+	req         JobWorkflowRequest
+	builderName string
+	target      string
+	// opened and done are keyed by builder purpose.
+	opened map[string]PullRequestOpened
+	done   map[string]bool
+}
+
+// afterClose merges the final branch into the other PRs
+// still open once purpose's PR closed.
+func (m *jobMerges) afterClose(ctx workflow.Context, purpose string) {
+	closed, ok := m.opened[purpose]
+	if !ok {
+		return
+	}
+	for _, other := range jobPurposes {
+		if pr, ok := m.opened[other]; ok && other != purpose && !m.done[other] {
+			m.merge(ctx, pr, closed.PR)
+		}
+	}
+}
+
+// afterOpen merges the final branch into purpose's new PR if
+// another PR already closed.
+func (m *jobMerges) afterOpen(ctx workflow.Context, purpose string) {
+	for _, other := range jobPurposes {
+		if closed, ok := m.opened[other]; ok && other != purpose && m.done[other] {
+			m.merge(ctx, m.opened[purpose], closed.PR)
+		}
+	}
+}
+
+func (m *jobMerges) merge(ctx workflow.Context, pr PullRequestOpened, otherPR int) {
+	err := workflow.ExecuteChildWorkflow(
+		workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
+			WorkflowID: agents.MakeChildWorkflowID(ctx, "merge-conflict-agent", pr.Branch, strconv.Itoa(otherPR)),
+		}),
+		agents.MergeConflictAgent,
+		agents.MergeConflictAgentRequest{
+			ClientOptions: m.req.ClientOptions,
+			Branch:        pr.Branch,
+			PR:            pr.PR,
+			Target:        m.target,
+			OtherPR:       otherPR,
+			Builder:       m.builderName,
+			Job:           m.req.JobDesc,
+		},
+	).Get(ctx, nil)
+	if err != nil {
+		// The PR can still be merged by hand.
+		workflow.GetLogger(ctx).Warn("Merge conflict agent failed", "branch", pr.Branch, "error", err)
+	}
+}